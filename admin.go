@@ -0,0 +1,126 @@
+package there
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorMaintenance is served with StatusServiceUnavailable, while the router
+// is in maintenance mode
+var ErrorMaintenance = errors.New("service is under maintenance")
+
+type maintenanceMode struct {
+	enabled    atomic.Bool
+	retryAfter atomic.Int64
+}
+
+// SetMaintenance enables or disables the maintenance mode. While enabled, all
+// requests are answered with StatusServiceUnavailable and ErrorMaintenance,
+// after the global middlewares ran. A positive retryAfter is suggested to the
+// clients with the Retry-After header.
+func (router *Router) SetMaintenance(enabled bool, retryAfter time.Duration) {
+	router.maintenance.retryAfter.Store(int64(retryAfter))
+	router.maintenance.enabled.Store(enabled)
+}
+
+// Maintenance reports whether the maintenance mode is enabled
+func (router *Router) Maintenance() bool {
+	return router.maintenance.enabled.Load()
+}
+
+// serveMaintenance answers the request with ErrorMaintenance, if the
+// maintenance mode is enabled
+func (router *Router) serveMaintenance(rw http.ResponseWriter, request *http.Request) bool {
+	if !router.maintenance.enabled.Load() {
+		return false
+	}
+	retryAfter := time.Duration(router.maintenance.retryAfter.Load())
+	router.applyGlobalMiddlewares(RetryAfter(retryAfter, Error(status.ServiceUnavailable, ErrorMaintenance))).ServeHTTP(rw, request)
+	return true
+}
+
+type maintenanceBody struct {
+	Enabled bool `json:"enabled"`
+	// RetryAfter is the suggested delay in seconds
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// Admin returns a router operators manage the service at runtime with. It
+// serves the following endpoints, which all pass the auth middleware first:
+//
+//	GET /routes          the registered routes, see RoutesEndpoint
+//	GET /stats           the RouterStats and RouteStats
+//	GET /health          the health checks, see Health
+//	GET /maintenance     {"enabled":false}
+//	PUT /maintenance     {"enabled":true,"retryAfter":60} toggles the maintenance mode
+//	GET /log-level       {"level":"INFO"}
+//	PUT /log-level       {"level":"DEBUG"} changes the LogLevel of the RouterConfiguration
+//
+// RouteStats are enabled in the RouterConfiguration of the router. Serve the
+// admin router on a separate, internal port, so it stays reachable in
+// maintenance mode.
+//
+//	admin := router.Admin(adminAuth)
+//	go admin.Listen(9090)
+func (router *Router) Admin(auth Middleware) *Router {
+	router.assert(auth != nil, "the admin router needs an auth middleware")
+	router.Configuration.RouteStats = true
+
+	admin := NewRouter()
+	if auth != nil {
+		admin.Use(auth)
+	}
+	admin.Get("/routes", router.RoutesEndpoint)
+	admin.Get("/stats", func(request Request) Response {
+		return Json(status.OK, map[string]any{
+			"router": router.Stats(),
+			"routes": router.RouteStats(),
+		})
+	})
+	admin.Get("/health", router.Health)
+	admin.Get("/maintenance", func(request Request) Response {
+		return Json(status.OK, maintenanceBody{Enabled: router.Maintenance()})
+	})
+	admin.Put("/maintenance", func(request Request) Response {
+		var body maintenanceBody
+		if err := request.Body.BindJson(&body); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		if body.RetryAfter < 0 {
+			return Error(status.BadRequest, errors.New("retryAfter must not be negative"))
+		}
+		router.SetMaintenance(body.Enabled, time.Duration(body.RetryAfter)*time.Second)
+		return Json(status.OK, body)
+	})
+	admin.Get("/log-level", func(request Request) Response {
+		level := router.Configuration.LogLevel
+		if level == nil {
+			return Error(status.NotImplemented, errors.New("no LogLevel is configured"))
+		}
+		return Json(status.OK, logLevelBody{Level: level.Level().String()})
+	})
+	admin.Put("/log-level", func(request Request) Response {
+		level := router.Configuration.LogLevel
+		if level == nil {
+			return Error(status.NotImplemented, errors.New("no LogLevel is configured"))
+		}
+		var body logLevelBody
+		if err := request.Body.BindJson(&body); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		if err := level.UnmarshalText([]byte(body.Level)); err != nil {
+			return Error(status.BadRequest, fmt.Errorf("invalid level %q", body.Level))
+		}
+		return Json(status.OK, logLevelBody{Level: level.Level().String()})
+	})
+	return admin
+}
@@ -0,0 +1,95 @@
+package there
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func adminAuth(request Request, next Response) Response {
+	if request.Request.Header.Get(header.RequestAuthorization) != "Bearer admin" {
+		return Error(status.Unauthorized, errors.New("unauthorized"))
+	}
+	return next
+}
+
+func TestAdmin(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.LogLevel = &slog.LevelVar{}
+	router.Get("/users/{id}", func(request Request) Response {
+		if request.RouteParams.Get("id") == "0" {
+			return Error(status.InternalServerError, errors.New("broken"))
+		}
+		return String(status.OK, "user")
+	})
+	admin := router.Admin(adminAuth)
+
+	call := func(method, target, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		request.Header.Set(header.RequestAuthorization, "Bearer admin")
+		recorder := httptest.NewRecorder()
+		admin.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := httptest.NewRecorder()
+	admin.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/routes", nil))
+	if recorder.Code != status.Unauthorized {
+		t.Errorf("expected the admin router to be protected, got %v", recorder.Code)
+	}
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, path, nil))
+	}
+	var stats struct {
+		Routes []RouteStats `json:"routes"`
+	}
+	if err := json.Unmarshal(call(MethodGet, "/stats", "").Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Routes) != 1 || stats.Routes[0].Requests != 3 || stats.Routes[0].ServerErrors != 1 || stats.Routes[0].Pattern != "/users/{id}" {
+		t.Errorf("unexpected stats %+v", stats.Routes)
+	}
+	if body := call(MethodGet, "/routes", "").Body.String(); !strings.Contains(body, "/users/{id}") {
+		t.Errorf("expected the routes, got %v", body)
+	}
+	if code := call(MethodGet, "/health", "").Code; code != status.OK {
+		t.Errorf("expected the health checks, got %v", code)
+	}
+
+	if body := call(MethodPut, "/maintenance", `{"enabled":true,"retryAfter":60}`).Body.String(); body != `{"enabled":true,"retryAfter":60}` {
+		t.Errorf("unexpected body %v", body)
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/users/1", nil))
+	if recorder.Code != status.ServiceUnavailable || recorder.Header().Get(header.ResponseRetryAfter) != "60" {
+		t.Errorf("expected the maintenance response, got %v %v", recorder.Code, recorder.Header())
+	}
+	if body := call(MethodGet, "/maintenance", "").Body.String(); body != `{"enabled":true}` {
+		t.Errorf("unexpected body %v", body)
+	}
+	call(MethodPut, "/maintenance", `{"enabled":false}`)
+	if router.Maintenance() {
+		t.Errorf("expected the maintenance mode to be disabled")
+	}
+
+	if body := call(MethodPut, "/log-level", `{"level":"debug"}`).Body.String(); body != `{"level":"DEBUG"}` {
+		t.Errorf("unexpected body %v", body)
+	}
+	if router.Configuration.LogLevel.Level() != slog.LevelDebug {
+		t.Errorf("expected the level to be changed")
+	}
+	if code := call(MethodPut, "/log-level", `{"level":"loud"}`).Code; code != status.BadRequest {
+		t.Errorf("expected invalid levels to be rejected, got %v", code)
+	}
+	router.Configuration.LogLevel = nil
+	if code := call(MethodGet, "/log-level", "").Code; code != status.NotImplemented {
+		t.Errorf("expected %v without a LogLevel, got %v", status.NotImplemented, code)
+	}
+}
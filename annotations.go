@@ -0,0 +1,73 @@
+package there
+
+// RouteDoc holds the documentation annotations of a route. Keeping them next to
+// the registration lets tooling like the OpenAPI generator or the route listing
+// describe the route without a separate source of truth.
+//
+//	router.Post("/user", CreateUser).
+//		Doc("Create a user", "Creates a new user and returns it").
+//		Tags("users").
+//		Request(CreateUserInput{}).
+//		Response(status.Created, UserOutput{})
+type RouteDoc struct {
+	Summary     string
+	Description string
+	// Tags group the route with others, like in the OpenAPI document
+	Tags []string
+	// Request is a sample value of the expected request body
+	Request any
+	// Responses maps a status code to a sample value of the response body
+	Responses map[int]any
+	// Examples are complete example responses, see Example
+	Examples []RouteExample
+}
+
+// Doc sets the summary and description of the route
+func (group *RouteRouteGroupBuilder) Doc(summary, description string) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		doc := endpoint.documentation()
+		doc.Summary = summary
+		doc.Description = description
+	}
+	return group
+}
+
+// Tags adds tags to the route, which group it with others in the OpenAPI document
+func (group *RouteRouteGroupBuilder) Tags(tags ...string) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		doc := endpoint.documentation()
+		doc.Tags = append(doc.Tags, tags...)
+	}
+	return group
+}
+
+// Request documents the body the route expects. The value is only used to
+// describe the type, it is never bound to.
+func (group *RouteRouteGroupBuilder) Request(body any) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		endpoint.documentation().Request = body
+	}
+	return group
+}
+
+// Response documents the body the route returns with the given status code.
+// Can be called multiple times to document different status codes.
+func (group *RouteRouteGroupBuilder) Response(code int, body any) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		doc := endpoint.documentation()
+		if doc.Responses == nil {
+			doc.Responses = map[int]any{}
+		}
+		doc.Responses[code] = body
+	}
+	return group
+}
+
+// documentation returns the RouteDoc of the endpoint and creates it, if it
+// does not exist yet
+func (h *muxHandlerEndpoint) documentation() *RouteDoc {
+	if h.doc == nil {
+		h.doc = &RouteDoc{}
+	}
+	return h.doc
+}
@@ -0,0 +1,94 @@
+package there
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// AssetPrefix is the path below which the assets registered with Router.Asset are served
+const AssetPrefix = "/_assets"
+
+// asset is a file registered with Router.Asset
+type asset struct {
+	data        []byte
+	contentType string
+	etag        string
+}
+
+type assetStore struct {
+	once   sync.Once
+	assets sync.Map // map[string]asset, by the last segment of their path
+}
+
+// Asset registers the content, like CSS or JavaScript generated at runtime,
+// and returns its path, like "/_assets/app.1f2e3d4c5b6a7980.css". The path
+// contains the hash of the content, so it changes with the content, and is
+// served with an immutable Cache-Control header for a year. Registering the
+// same content again returns the same path, also on other instances.
+//
+// The Content-Type is guessed by the extension of the name. Use
+// Request.ExternalPath for links, if the router has a BasePath.
+//
+//	stylesheet := router.Asset("app.css", compileStyles())
+//	// <link rel="stylesheet" href="{{.Stylesheet}}">
+func (router *Router) Asset(name string, data []byte) string {
+	router.assets.once.Do(func() {
+		policy := CachePolicy{MaxAge: 365 * 24 * time.Hour, Immutable: true}
+		router.Handle(AssetPrefix+"/{asset}", router.serveAsset, MethodGet, MethodHead).WithCachePolicy(policy)
+	})
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	extension := path.Ext(name)
+	base := strings.TrimSuffix(path.Base(name), extension)
+	if base == "" || base == "." || base == "/" {
+		base = "asset"
+	}
+
+	contentType := ContentTypeApplicationOctetDashStream
+	if extension != "" {
+		if guessed := ContentType(extension[1:]); guessed != "" {
+			contentType = guessed
+		}
+	}
+	key := base + "." + hash + extension
+	router.assets.assets.Store(key, asset{
+		data:        bytes.Clone(data),
+		contentType: contentType,
+		etag:        `"` + hash + `"`,
+	})
+	return AssetPrefix + "/" + key
+}
+
+// RemoveAsset stops serving the asset at the path returned by Asset, so
+// replaced assets do not stay in memory
+func (router *Router) RemoveAsset(assetPath string) {
+	router.assets.assets.Delete(strings.TrimPrefix(assetPath, AssetPrefix+"/"))
+}
+
+func (router *Router) serveAsset(request Request) Response {
+	stored, ok := router.assets.assets.Load(request.RouteParams.Get("asset"))
+	if !ok {
+		return Error(status.NotFound, errors.New("asset not found"))
+	}
+	return assetResponse(stored.(asset))
+}
+
+type assetResponse asset
+
+func (a assetResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set(header.ContentType, a.contentType)
+	rw.Header().Set(header.ResponseEtag, a.etag)
+	// http.ServeContent handles Range and the conditional headers
+	http.ServeContent(rw, r, "", time.Time{}, bytes.NewReader(a.data))
+}
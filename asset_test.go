@@ -0,0 +1,50 @@
+package there
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestAsset(t *testing.T) {
+	router := NewRouter()
+	url := router.Asset("app.css", []byte("body{margin:0}"))
+	if !regexp.MustCompile(`^/_assets/app\.[0-9a-f]{16}\.css$`).MatchString(url) {
+		t.Fatalf("unexpected url %v", url)
+	}
+	if again := router.Asset("app.css", []byte("body{margin:0}")); again != url {
+		t.Errorf("expected the same url for the same content, got %v", again)
+	}
+	if changed := router.Asset("app.css", []byte("body{margin:1px}")); changed == url {
+		t.Errorf("expected a new url for changed content")
+	}
+
+	serve := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, url, nil)
+		if ifNoneMatch != "" {
+			request.Header.Set(header.RequestIfNoneMatch, ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve(url, "")
+	if recorder.Body.String() != "body{margin:0}" || recorder.Header().Get(header.ContentType) != ContentTypeTextCss {
+		t.Errorf("unexpected response %v %v", recorder.Header(), recorder.Body.String())
+	}
+	if cacheControl := recorder.Header().Get(header.CacheControl); cacheControl != "public, max-age=31536000, immutable" {
+		t.Errorf("unexpected Cache-Control %v", cacheControl)
+	}
+	if recorder := serve(url, recorder.Header().Get(header.ResponseEtag)); recorder.Code != status.NotModified {
+		t.Errorf("expected 304, got %v", recorder.Code)
+	}
+
+	router.RemoveAsset(url)
+	if recorder := serve(url, ""); recorder.Code != status.NotFound || recorder.Header().Get(header.CacheControl) != "" {
+		t.Errorf("expected an uncached 404, got %v %v", recorder.Code, recorder.Header())
+	}
+}
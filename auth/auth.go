@@ -0,0 +1,382 @@
+// Package auth implements the OAuth2 authorization code flow with PKCE, and
+// OpenID Connect discovery on top of it, so web apps can add "Sign in with X".
+// Tokens are kept in the session of the client, so the session.Middleware must
+// be registered.
+//
+//	provider, err := auth.Discover(ctx, "https://accounts.example.com")
+//	provider.ClientID = "..."
+//	provider.ClientSecret = "..."
+//	provider.RedirectURL = "https://app.example.com/auth/callback"
+//	provider.Scopes = []string{"openid", "email"}
+//
+//	authenticator := auth.New(auth.Configuration{Provider: provider})
+//	router.Use(session.Middleware(session.Configuration{Key: key}))
+//	authenticator.Register(router.RouteGroup)
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/session"
+	"github.com/gebes/there/v2/status"
+)
+
+var (
+	// ErrorNotLoggedIn is returned by Token, if the session holds no token
+	ErrorNotLoggedIn = errors.New("not logged in")
+	// ErrorInvalidState is returned by the callback, if the state does not match the login
+	ErrorInvalidState = errors.New("invalid oauth2 state")
+)
+
+// Provider describes the endpoints of an OAuth2 provider and the registered client
+type Provider struct {
+	ClientID     string
+	ClientSecret string
+	// AuthURL is the authorization endpoint, the user is redirected to
+	AuthURL string
+	// TokenURL is the endpoint, codes and refresh tokens are exchanged at
+	TokenURL string
+	// LogoutURL is the optional end session endpoint of the provider
+	LogoutURL string
+	// RedirectURL must point to the callback route and be registered at the provider
+	RedirectURL string
+	Scopes      []string
+}
+
+// Discover loads the endpoints of an OpenID Connect provider from its
+// /.well-known/openid-configuration document
+func Discover(ctx context.Context, issuer string) (Provider, error) {
+	endpoint := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Provider{}, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return Provider{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != status.OK {
+		return Provider{}, fmt.Errorf("auth: discovery failed with status %d", response.StatusCode)
+	}
+	var document struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		EndSessionEndpoint    string `json:"end_session_endpoint"`
+	}
+	if err = json.NewDecoder(response.Body).Decode(&document); err != nil {
+		return Provider{}, err
+	}
+	return Provider{
+		AuthURL:   document.AuthorizationEndpoint,
+		TokenURL:  document.TokenEndpoint,
+		LogoutURL: document.EndSessionEndpoint,
+	}, nil
+}
+
+// Token is the result of a successful login
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// expired reports whether the token expires within the next ten seconds
+func (t Token) expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(10*time.Second).After(t.Expiry)
+}
+
+type Configuration struct {
+	Provider Provider
+	// LoginPath defaults to "/auth/login". A relative ?return= parameter is
+	// redirected to after the login.
+	LoginPath string
+	// CallbackPath defaults to "/auth/callback"
+	CallbackPath string
+	// LogoutPath defaults to "/auth/logout"
+	LogoutPath string
+	// AfterLogin is redirected to after the login. Defaults to "/".
+	AfterLogin string
+	// AfterLogout is redirected to after the logout, unless the Provider has
+	// a LogoutURL. Defaults to "/".
+	AfterLogout string
+	// Client is used for the token requests. Defaults to http.DefaultClient.
+	Client *http.Client
+	// KeepIDToken stores the id_token in the session, so the logout passes it
+	// to the provider as id_token_hint. It is dropped otherwise, as it is large
+	// and the session cookie is limited to 4096 bytes.
+	KeepIDToken bool
+}
+
+// Authenticator serves the login, callback and logout routes
+type Authenticator struct {
+	config Configuration
+}
+
+// New creates an Authenticator and fills in the defaults of the Configuration
+func New(configuration Configuration) *Authenticator {
+	defaults := map[*string]string{
+		&configuration.LoginPath:    "/auth/login",
+		&configuration.CallbackPath: "/auth/callback",
+		&configuration.LogoutPath:   "/auth/logout",
+		&configuration.AfterLogin:   "/",
+		&configuration.AfterLogout:  "/",
+	}
+	for field, value := range defaults {
+		if *field == "" {
+			*field = value
+		}
+	}
+	if configuration.Client == nil {
+		configuration.Client = http.DefaultClient
+	}
+	return &Authenticator{config: configuration}
+}
+
+const (
+	sessionState    = "auth_state"
+	sessionVerifier = "auth_verifier"
+	sessionReturn   = "auth_return"
+	sessionToken    = "auth_token"
+)
+
+// Register adds the login, callback and logout routes to the group
+func (a *Authenticator) Register(group *there.RouteGroup) {
+	group.Get(a.config.LoginPath, a.login)
+	group.Get(a.config.CallbackPath, a.callback)
+	group.Get(a.config.LogoutPath, a.logout)
+}
+
+func (a *Authenticator) login(request there.Request) there.Response {
+	state, err := randomString()
+	if err != nil {
+		return there.Error(status.InternalServerError, err)
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return there.Error(status.InternalServerError, err)
+	}
+
+	s := session.Of(request)
+	s.Set(sessionState, state)
+	s.Set(sessionVerifier, verifier)
+	s.Delete(sessionReturn)
+	if target, ok := request.Params.Get("return"); ok && isLocalPath(target) {
+		s.Set(sessionReturn, target)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.config.Provider.ClientID},
+		"redirect_uri":          {a.config.Provider.RedirectURL},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if len(a.config.Provider.Scopes) > 0 {
+		query.Set("scope", strings.Join(a.config.Provider.Scopes, " "))
+	}
+	return there.Redirect(status.Found, withQuery(a.config.Provider.AuthURL, query))
+}
+
+func (a *Authenticator) callback(request there.Request) there.Response {
+	if reason, ok := request.Params.Get("error"); ok {
+		return there.Error(status.Unauthorized, fmt.Errorf("login failed: %v", reason))
+	}
+
+	s := session.Of(request)
+	expected := s.Get(sessionState)
+	state, _ := request.Params.Get("state")
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		return there.Error(status.BadRequest, ErrorInvalidState)
+	}
+	code, ok := request.Params.Get("code")
+	if !ok {
+		return there.Error(status.BadRequest, errors.New("missing code"))
+	}
+
+	token, err := a.exchange(request.Context(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.config.Provider.RedirectURL},
+		"code_verifier": {s.Get(sessionVerifier)},
+	})
+	if err != nil {
+		return there.Error(status.BadGateway, err)
+	}
+	// the client is logged in now, so a session planted before must not stay valid
+	s.Renew()
+	if err = a.storeToken(s, token); err != nil {
+		return there.Error(status.InternalServerError, err)
+	}
+
+	target := s.Get(sessionReturn)
+	if target == "" {
+		target = a.config.AfterLogin
+	}
+	s.Delete(sessionState)
+	s.Delete(sessionVerifier)
+	s.Delete(sessionReturn)
+	return there.Redirect(status.Found, target)
+}
+
+func (a *Authenticator) logout(request there.Request) there.Response {
+	s := session.Of(request)
+	token, _ := loadToken(s)
+	s.Clear()
+
+	if a.config.Provider.LogoutURL == "" {
+		return there.Redirect(status.Found, a.config.AfterLogout)
+	}
+	query := url.Values{"client_id": {a.config.Provider.ClientID}}
+	if token != nil && token.IDToken != "" {
+		query.Set("id_token_hint", token.IDToken)
+	}
+	return there.Redirect(status.Found, withQuery(a.config.Provider.LogoutURL, query))
+}
+
+// Token returns the token of the logged-in client. An expired token is
+// refreshed and stored again, if the provider issued a refresh token.
+func (a *Authenticator) Token(request there.Request) (*Token, error) {
+	s := session.Of(request)
+	token, err := loadToken(s)
+	if err != nil {
+		return nil, err
+	}
+	if !token.expired() || token.RefreshToken == "" {
+		return token, nil
+	}
+
+	refreshed, err := a.exchange(request.Context(), url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	if refreshed.IDToken == "" {
+		refreshed.IDToken = token.IDToken
+	}
+	return refreshed, a.storeToken(s, refreshed)
+}
+
+// RequireLogin redirects clients without a token to the login route. The
+// check runs, when the response is served, after the session was loaded.
+func (a *Authenticator) RequireLogin(request there.Request, next there.Response) there.Response {
+	return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, err := a.Token(request); err != nil {
+			login := a.config.LoginPath + "?" + url.Values{"return": {r.URL.RequestURI()}}.Encode()
+			there.Redirect(status.Found, login).ServeHTTP(rw, r)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// exchange requests a token at the TokenURL of the provider
+func (a *Authenticator) exchange(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", a.config.Provider.ClientID)
+	if a.config.Provider.ClientSecret != "" {
+		form.Set("client_secret", a.config.Provider.ClientSecret)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.Provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := a.config.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != status.OK {
+		return nil, fmt.Errorf("auth: token request failed with status %d: %s", response.StatusCode, body)
+	}
+
+	var result struct {
+		Token
+		ExpiresIn int64 `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("auth: token response without access_token")
+	}
+	token := result.Token
+	if result.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return &token, nil
+}
+
+func (a *Authenticator) storeToken(s *session.Session, token *Token) error {
+	stored := *token
+	if !a.config.KeepIDToken {
+		stored.IDToken = ""
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	s.Set(sessionToken, string(data))
+	return nil
+}
+
+func loadToken(s *session.Session) (*Token, error) {
+	data := s.Get(sessionToken)
+	if data == "" {
+		return nil, ErrorNotLoggedIn
+	}
+	var token Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// isLocalPath prevents open redirects through the return parameter
+func isLocalPath(target string) bool {
+	return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, "/\\")
+}
+
+func withQuery(endpoint string, query url.Values) string {
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + query.Encode()
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/session"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	var challenge string
+	provider := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if r.Form.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			rw.WriteHeader(status.BadRequest)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		// the id_token is dropped, so the session cookie stays below the limit of browsers
+		_, _ = rw.Write([]byte(`{"access_token":"access","token_type":"Bearer","expires_in":3600,"id_token":"` + strings.Repeat("a", 4000) + `"}`))
+	}))
+	defer provider.Close()
+
+	authenticator := New(Configuration{Provider: Provider{
+		ClientID:    "client",
+		AuthURL:     "https://provider.example/authorize",
+		TokenURL:    provider.URL,
+		RedirectURL: "https://app.example/auth/callback",
+	}})
+	router := there.NewRouter()
+	router.Use(session.Middleware(session.Configuration{Key: make([]byte, 32)}))
+	authenticator.Register(router.RouteGroup)
+	router.Get("/me", func(request there.Request) there.Response {
+		token, err := authenticator.Token(request)
+		if err != nil {
+			return there.Error(status.InternalServerError, err)
+		}
+		return there.String(status.OK, token.AccessToken+token.IDToken)
+	}).With(authenticator.RequireLogin)
+
+	var cookies []*http.Cookie
+	serve := func(target string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodGet, target, nil)
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if set := recorder.Result().Cookies(); len(set) > 0 {
+			cookies = set
+		}
+		return recorder
+	}
+
+	recorder := serve("/me")
+	if recorder.Code != status.Found || recorder.Header().Get("Location") != "/auth/login?return=%2Fme" {
+		t.Fatalf("expected a redirect to the login, got %v %v", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	recorder = serve("/auth/login?return=/me")
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil || location.Host != "provider.example" {
+		t.Fatalf("expected a redirect to the provider, got %v", recorder.Header().Get("Location"))
+	}
+	challenge = location.Query().Get("code_challenge")
+
+	if recorder = serve("/auth/callback?code=code&state=wrong"); recorder.Code != status.BadRequest {
+		t.Errorf("expected a rejected state, got %v", recorder.Code)
+	}
+
+	recorder = serve("/auth/callback?code=code&state=" + location.Query().Get("state"))
+	if recorder.Code != status.Found || recorder.Header().Get("Location") != "/me" {
+		t.Fatalf("expected a redirect back, got %v %v %v", recorder.Code, recorder.Header().Get("Location"), recorder.Body.String())
+	}
+
+	if recorder = serve("/me"); recorder.Body.String() != "access" {
+		t.Errorf("expected the access token, got %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	serve("/auth/logout")
+	if recorder = serve("/me"); recorder.Code != status.Found {
+		t.Errorf("expected to be logged out, got %v", recorder.Code)
+	}
+}
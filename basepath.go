@@ -0,0 +1,58 @@
+package there
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// cleanBasePath brings the BasePath into the form "/prefix", without a trailing slash
+func cleanBasePath(basePath string) string {
+	basePath = strings.TrimRight(basePath, "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return basePath
+}
+
+// stripBasePath returns a shallow copy of the request without the BasePath in
+// its URL. The second return value is false, if the path is outside the BasePath.
+func stripBasePath(request *http.Request, basePath string) (*http.Request, bool) {
+	basePath = cleanBasePath(basePath)
+	p, ok := trimBasePath(request.URL.Path, basePath)
+	if !ok {
+		return nil, false
+	}
+	rawPath := request.URL.RawPath
+	if rawPath != "" {
+		rawPath, ok = trimBasePath(rawPath, basePath)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	stripped := new(http.Request)
+	*stripped = *request
+	stripped.URL = new(url.URL)
+	*stripped.URL = *request.URL
+	stripped.URL.Path = p
+	stripped.URL.RawPath = rawPath
+	return stripped, true
+}
+
+func trimBasePath(p, basePath string) (string, bool) {
+	if basePath == "" {
+		return p, true
+	}
+	if !strings.HasPrefix(p, basePath) {
+		return "", false
+	}
+	p = p[len(basePath):]
+	if p == "" {
+		return "/", true
+	}
+	if p[0] != '/' {
+		return "", false
+	}
+	return p, true
+}
@@ -0,0 +1,43 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestBasePath(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.BasePath = "service/"
+	router.Get("/", func(request Request) Response {
+		return String(status.OK, "root")
+	})
+	router.Get("/user", func(request Request) Response {
+		return String(status.OK, request.Request.URL.Path)
+	})
+	router.Get("/old", func(request Request) Response {
+		return Redirect(status.MovedPermanently, "/user")
+	})
+
+	assertBodyResponse(t, router, MethodGet, "/service", "root")
+	assertBodyResponse(t, router, MethodGet, "/service/user", "/user")
+
+	for _, route := range []string{"/user", "/serviceuser", "/other/user"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		if recorder.Code != status.NotFound {
+			t.Errorf("%v: expected %v, got %v", route, status.NotFound, recorder.Code)
+		}
+	}
+
+	router.Configuration.TrustForwardedPrefix = true
+	request := httptest.NewRequest(MethodGet, "/service/old", nil)
+	request.Header.Set(header.RequestXForwardedPrefix, "/edge")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if location := recorder.Header().Get(header.ResponseLocation); location != "/edge/service/user" {
+		t.Errorf("unexpected location %v", location)
+	}
+}
@@ -0,0 +1,177 @@
+// Package bench provides benchmarks for the warm paths of a Router: routing,
+// binding, rendering and middleware chains. Run them against the own route
+// table and compare the results across upgrades, like with benchstat, to catch
+// performance regressions.
+//
+//	func BenchmarkRoutes(b *testing.B) {
+//		bench.Routes(b, api.NewRouter())
+//	}
+//
+//	func BenchmarkBindOrder(b *testing.B) {
+//		bench.Binding[api.Order](b, nil, there.ContentTypeApplicationJson, orderJson)
+//	}
+package bench
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// Routes runs a sub-benchmark for every route of the router, named after its
+// method and pattern. Route parameters are filled with SamplePath, routes the
+// sample path does not reach, like ones with parameter constraints, are skipped.
+func Routes(b *testing.B, router *there.Router) {
+	for _, route := range router.Snapshot().Routes {
+		request := httptest.NewRequest(route.Method, SamplePath(route.Pattern), nil)
+		b.Run(route.Method+" "+route.Pattern, func(b *testing.B) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code == status.NotFound || recorder.Code == status.MethodNotAllowed {
+				b.Skipf("bench: %v does not reach the route", request.URL.Path)
+			}
+			Request(b, router, request)
+		})
+	}
+}
+
+// Request serves the request b.N times. Bodies of requests are replayed for
+// every iteration.
+func Request(b *testing.B, handler http.Handler, request *http.Request) {
+	var body []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(request.Body)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	reader := bytes.NewReader(body)
+	writer := &discardWriter{header: http.Header{}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(body)
+		request.Body = io.NopCloser(reader)
+		writer.reset()
+		handler.ServeHTTP(writer, request)
+	}
+}
+
+// Binding benchmarks BodyReader.Bind with the body of the content type into a
+// new T. Serializers registered on the router are used, it may be nil.
+func Binding[T any](b *testing.B, router *there.Router, contentType string, body []byte) {
+	if router == nil {
+		router = there.NewRouter()
+	}
+	// capture a request, that carries the router, so its serializers are found
+	var captured *http.Request
+	capture := there.NewRouter()
+	capture.Configuration = router.Configuration
+	capture.Post("/", func(request there.Request) there.Response {
+		captured = request.Request
+		return there.Status(status.NoContent)
+	})
+	request := httptest.NewRequest(there.MethodPost, "/", nil)
+	request.Header.Set(header.ContentType, contentType)
+	capture.ServeHTTP(httptest.NewRecorder(), request)
+	if captured == nil {
+		b.Fatal("bench: could not capture the binding request")
+	}
+
+	reader := bytes.NewReader(body)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(body)
+		captured.Body = io.NopCloser(reader)
+		var dest T
+		if err := there.NewHttpRequest(nil, captured).Body.Bind(&dest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Rendering benchmarks serving the response, like Json or Html, b.N times
+func Rendering(b *testing.B, response there.Response) {
+	request := httptest.NewRequest(there.MethodGet, "/", nil)
+	writer := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writer.reset()
+		response.ServeHTTP(writer, request)
+	}
+}
+
+// Middlewares benchmarks a route behind the middlewares, that responds with
+// StatusNoContent, so the result is the cost of the chain
+func Middlewares(b *testing.B, middlewares ...there.Middleware) {
+	router := there.NewRouter()
+	route := router.Get("/", func(request there.Request) there.Response {
+		return there.Status(status.NoContent)
+	})
+	for _, middleware := range middlewares {
+		route.With(middleware)
+	}
+	Request(b, router, httptest.NewRequest(there.MethodGet, "/", nil))
+}
+
+// SamplePath turns a pattern into a path, that matches it, by filling every
+// route parameter with "1", like "/users/{id}" into "/users/1"
+func SamplePath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(pattern[:start])
+		if pattern[start:start+end+1] != "{$}" {
+			b.WriteString("1")
+		}
+		pattern = pattern[start+end+1:]
+	}
+	b.WriteString(pattern)
+	return b.String()
+}
+
+// discardWriter is a http.ResponseWriter, that discards everything, so the
+// benchmarks measure the router instead of a recorder
+type discardWriter struct {
+	header http.Header
+	code   int
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *discardWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *discardWriter) reset() {
+	clear(w.header)
+	w.code = 0
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+type user struct {
+	Id    int      `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func newRouter() *there.Router {
+	router := there.NewRouter()
+	router.Get("/users", func(request there.Request) there.Response {
+		return there.Json(status.OK, []user{{Id: 1, Name: "a"}})
+	})
+	router.Get("/users/{id}", func(request there.Request) there.Response {
+		return there.Json(status.OK, user{Id: 1, Name: request.RouteParams.Get("id")})
+	})
+	router.Get("/files/{path...}", func(request there.Request) there.Response {
+		return there.String(status.OK, request.RouteParams.Get("path"))
+	})
+	return router
+}
+
+func TestSamplePath(t *testing.T) {
+	tests := map[string]string{
+		"/users":                   "/users",
+		"/users/{id}":              "/users/1",
+		"/users/{id}/cars/{car}":   "/users/1/cars/1",
+		"/files/{path...}":         "/files/1",
+		"/{$}":                     "/",
+		"example.com/users/{id}":   "example.com/users/1",
+		"GET /users/{id}/settings": "/users/1/settings",
+	}
+	for pattern, expected := range tests {
+		if path := SamplePath(pattern); path != expected {
+			t.Errorf("%v: expected %v, got %v", pattern, expected, path)
+		}
+	}
+}
+
+func BenchmarkRoutes(b *testing.B) {
+	Routes(b, newRouter())
+}
+
+func BenchmarkBinding(b *testing.B) {
+	Binding[user](b, nil, there.ContentTypeApplicationJson, []byte(`{"id":1,"name":"a","roles":["admin","user"]}`))
+}
+
+func BenchmarkRendering(b *testing.B) {
+	Rendering(b, there.Json(status.OK, user{Id: 1, Name: "a", Roles: []string{"admin"}}))
+}
+
+func BenchmarkMiddlewares(b *testing.B) {
+	noop := func(request there.Request, next there.Response) there.Response {
+		return next
+	}
+	Middlewares(b, noop, noop, noop)
+}
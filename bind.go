@@ -0,0 +1,96 @@
+package there
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BindingError is returned by the bind methods of the BodyReader, when the body
+// could not be unmarshalled into the destination. Its message is safe to be shown
+// to clients and, as it is marshallable, it can also be rendered as it is:
+//
+//	var user User
+//	err := request.Body.BindJson(&user)
+//	if err != nil {
+//		return there.Json(status.BadRequest, err)
+//	}
+//
+// Which results in
+//
+//	{"message":"field \"age\" must be of type int, got string","field":"age","expected":"int","actual":"string","offset":24,"line":3,"column":10}
+type BindingError struct {
+	Message string `json:"message" xml:"Message"`
+	// Field is the dot separated path of the offending field. Empty for syntax errors.
+	Field string `json:"field,omitempty" xml:"Field,omitempty"`
+	// Expected is the Go type the field requires
+	Expected string `json:"expected,omitempty" xml:"Expected,omitempty"`
+	// Actual describes the value that was sent instead
+	Actual string `json:"actual,omitempty" xml:"Actual,omitempty"`
+	// Offset is the byte offset in the body, after which the error occurred
+	Offset int64 `json:"offset,omitempty" xml:"Offset,omitempty"`
+	// Line and Column are the one-based position of the error in the body
+	Line   int `json:"line,omitempty" xml:"Line,omitempty"`
+	Column int `json:"column,omitempty" xml:"Column,omitempty"`
+
+	err error
+}
+
+func (e *BindingError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error of the underlying unmarshaller
+func (e *BindingError) Unwrap() error {
+	return e.err
+}
+
+// bindingErrorConverters fill in the BindingError for the errors of optional
+// formats, like xml. They report whether they handled the error.
+var bindingErrorConverters []func(bindingError *BindingError, err error) bool
+
+// newBindingError converts the error of an unmarshaller into a BindingError.
+// Errors that carry no position or type information keep their message.
+func newBindingError(body []byte, err error) error {
+	bindingError := &BindingError{Message: err.Error(), err: err}
+
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		bindingError.Offset = syntaxError.Offset
+		bindingError.Message = "invalid json: " + syntaxError.Error()
+	case errors.As(err, &typeError):
+		bindingError.Offset = typeError.Offset
+		bindingError.Field = typeError.Field
+		bindingError.Expected = typeError.Type.String()
+		bindingError.Actual = typeError.Value
+		if typeError.Field == "" {
+			bindingError.Message = fmt.Sprintf("body must be of type %v, got %v", bindingError.Expected, bindingError.Actual)
+		} else {
+			bindingError.Message = fmt.Sprintf("field %q must be of type %v, got %v", bindingError.Field, bindingError.Expected, bindingError.Actual)
+		}
+	default:
+		for _, convert := range bindingErrorConverters {
+			if convert(bindingError, err) {
+				break
+			}
+		}
+		return bindingError
+	}
+
+	bindingError.Line, bindingError.Column = position(body, bindingError.Offset)
+	return bindingError
+}
+
+// position returns the one-based line and column of the byte offset in data
+func position(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte{'\n'}) + 1
+	column = int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, column
+}
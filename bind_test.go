@@ -0,0 +1,69 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestBindingError(t *testing.T) {
+	type address struct {
+		Zip int `json:"zip" xml:"zip"`
+	}
+	type input struct {
+		Name    string  `json:"name" xml:"name"`
+		Address address `json:"address" xml:"address"`
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, err *BindingError)
+	}{
+		{
+			name: "json type",
+			body: "{\n  \"name\": \"John\",\n  \"address\": {\"zip\": \"abc\"}\n}",
+			check: func(t *testing.T, err *BindingError) {
+				if err.Field != "address.zip" || err.Expected != "int" || err.Actual != "string" {
+					t.Errorf("unexpected field information: %+v", err)
+				}
+				if err.Line != 3 || err.Column != 27 {
+					t.Errorf("unexpected position %v:%v", err.Line, err.Column)
+				}
+				if strings.Contains(err.Error(), "json: cannot") {
+					t.Errorf("raw error message was exposed: %v", err.Error())
+				}
+			},
+		},
+		{
+			name: "json syntax",
+			body: "{\n\"name\": }",
+			check: func(t *testing.T, err *BindingError) {
+				if err.Line != 2 || err.Offset != 11 {
+					t.Errorf("unexpected position %+v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bindErr error
+			router := NewRouter()
+			router.Post("/", func(request Request) Response {
+				var dest input
+				bindErr = request.Body.BindJson(&dest)
+				return Status(status.OK)
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodPost, "/", strings.NewReader(tt.body)))
+
+			var bindingError *BindingError
+			if !errors.As(bindErr, &bindingError) {
+				t.Fatalf("expected a BindingError, got %T %v", bindErr, bindErr)
+			}
+			tt.check(t, bindingError)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package there
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bindSource provides the values of the struct fields with its tag
+type bindSource struct {
+	// tag is the struct tag, like "query"
+	tag string
+	// name describes the source in errors, like "query parameter"
+	name   string
+	values func(key string) []string
+	// files provides the uploaded files for fields of type *UploadedFile or
+	// []*UploadedFile, if not nil
+	files func(key string) []*multipart.FileHeader
+}
+
+// Bind fills the struct dest points to with the query parameters, route
+// parameters and headers of the request, as declared by the struct tags
+// `query:"page"`, `param:"id"` and `header:"X-Api-Key"`. Appending ",required"
+// to the name fails the binding, if the value is missing.
+//
+//	type ListUsers struct {
+//		TenantId string        `param:"tenant,required"`
+//		Page     int           `query:"page"`
+//		Tags     []string      `query:"tag"`
+//		Timeout  time.Duration `query:"timeout"`
+//		ApiKey   string        `header:"X-Api-Key,required"`
+//	}
+//
+//	var params ListUsers
+//	if err := request.Bind(&params); err != nil {
+//		return there.Json(status.BadRequest, err)
+//	}
+//
+// Fields can be strings, booleans, numbers, time.Duration, types implementing
+// ParamUnmarshaler or encoding.TextUnmarshaler, like time.Time, as well as
+// pointers and slices of them. Slices take all values of a query parameter or
+// header. Invalid and missing values result in a *BindingError, or in a
+// *ParamError, if a ParamUnmarshaler failed.
+func (r *Request) Bind(dest any) error {
+	return bindStruct(dest, []bindSource{
+		{tag: "query", name: "query parameter", values: func(key string) []string {
+			values, _ := r.Params.GetSlice(key)
+			return values
+		}},
+		{tag: "param", name: "route parameter", values: func(key string) []string {
+			if value := r.RouteParams.Get(key); value != "" {
+				return []string{value}
+			}
+			return nil
+		}},
+		{tag: "header", name: "header", values: func(key string) []string {
+			return r.Request.Header.Values(key)
+		}},
+	})
+}
+
+func bindStruct(dest any, sources []bindSource) error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.New("bind: dest must be a non-nil pointer to a struct")
+	}
+	return bindFields(value.Elem(), sources)
+}
+
+func bindFields(v reflect.Value, sources []bindSource) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		source, tag, ok := bindSourceOf(field, sources)
+		if !ok {
+			// the exported fields of embedded structs are bound as well
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindFields(v.Field(i), sources); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if source.files != nil && (field.Type == uploadedFileType || field.Type == uploadedFilesType) {
+			files := source.files(name)
+			if len(files) == 0 && strings.Contains(","+options+",", ",required,") {
+				return &BindingError{
+					Message:  fmt.Sprintf("%v %q is required", source.name, name),
+					Field:    name,
+					Expected: "file",
+				}
+			}
+			setBindFiles(v.Field(i), files)
+			continue
+		}
+
+		values := source.values(name)
+		if len(values) == 0 {
+			if strings.Contains(","+options+",", ",required,") {
+				return &BindingError{
+					Message:  fmt.Sprintf("%v %q is required", source.name, name),
+					Field:    name,
+					Expected: field.Type.String(),
+				}
+			}
+			continue
+		}
+
+		if err := setBindValue(v.Field(i), name, values); err != nil {
+			var paramError *ParamError
+			if errors.As(err, &paramError) {
+				return paramError
+			}
+			return &BindingError{
+				Message:  fmt.Sprintf("%v %q must be of type %v, got %q", source.name, name, field.Type, strings.Join(values, ",")),
+				Field:    name,
+				Expected: field.Type.String(),
+				Actual:   strings.Join(values, ","),
+				err:      err,
+			}
+		}
+	}
+	return nil
+}
+
+func bindSourceOf(field reflect.StructField, sources []bindSource) (bindSource, string, bool) {
+	for _, source := range sources {
+		if tag, ok := field.Tag.Lookup(source.tag); ok {
+			return source, tag, true
+		}
+	}
+	return bindSource{}, "", false
+}
+
+var (
+	paramUnmarshalerType = reflect.TypeOf((*ParamUnmarshaler)(nil)).Elem()
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	uploadedFileType     = reflect.TypeOf((*UploadedFile)(nil))
+	uploadedFilesType    = reflect.TypeOf([]*UploadedFile(nil))
+)
+
+func setBindFiles(field reflect.Value, headers []*multipart.FileHeader) {
+	if len(headers) == 0 {
+		return
+	}
+	if field.Type() == uploadedFileType {
+		field.Set(reflect.ValueOf(newUploadedFile(headers[0])))
+		return
+	}
+	files := make([]*UploadedFile, len(headers))
+	for i, header := range headers {
+		files[i] = newUploadedFile(header)
+	}
+	field.Set(reflect.ValueOf(files))
+}
+
+// setBindValue parses the values into the field
+func setBindValue(field reflect.Value, name string, values []string) error {
+	pointer := reflect.PointerTo(field.Type())
+	if field.Kind() == reflect.Slice && !pointer.Implements(paramUnmarshalerType) && !pointer.Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setBindValue(slice.Index(i), name, []string{value}); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setBindValue(elem.Elem(), name, values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	value := values[0]
+	if pointer.Implements(paramUnmarshalerType) {
+		return unmarshalParam(name, value, field.Addr().Interface().(ParamUnmarshaler))
+	}
+	if pointer.Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		field.SetInt(int64(d))
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("bind: unsupported type %v", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package there
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+type bindOrderStatus string
+
+func (s *bindOrderStatus) UnmarshalParam(value string) error {
+	parsed, err := ParseEnum(value, bindOrderStatus("open"), bindOrderStatus("closed"))
+	*s = parsed
+	return err
+}
+
+type bindPaging struct {
+	Page  int  `query:"page"`
+	Limit *int `query:"limit"`
+}
+
+type bindListOrders struct {
+	bindPaging
+	Tenant  string          `param:"tenant,required"`
+	Tags    []string        `query:"tag"`
+	Timeout time.Duration   `query:"timeout"`
+	Since   time.Time       `query:"since"`
+	Status  bindOrderStatus `query:"status"`
+	ApiKey  string          `header:"X-Api-Key,required"`
+	ignored string          `query:"ignored"`
+}
+
+func TestRequestBind(t *testing.T) {
+	router := NewRouter()
+	router.Get("/tenants/{tenant}/orders", func(request Request) Response {
+		var params bindListOrders
+		if err := request.Bind(&params); err != nil {
+			return Json(status.BadRequest, err)
+		}
+		return Json(status.OK, params)
+	})
+
+	serve := func(target, apiKey string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, target, nil)
+		if apiKey != "" {
+			request.Header.Set("X-Api-Key", apiKey)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/tenants/acme/orders?page=2&limit=10&tag=a&tag=b&timeout=1m&since=2024-01-02T03:04:05Z&status=open&ignored=x", "secret")
+	if recorder.Code != status.OK {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	var params bindListOrders
+	if err := json.Unmarshal(recorder.Body.Bytes(), &params); err != nil {
+		t.Fatal(err)
+	}
+	limit := 10
+	expected := bindListOrders{
+		bindPaging: bindPaging{Page: 2, Limit: &limit},
+		Tenant:     "acme",
+		Tags:       []string{"a", "b"},
+		Timeout:    time.Minute,
+		Since:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Status:     "open",
+		ApiKey:     "secret",
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("expected %+v, got %+v", expected, params)
+	}
+
+	tests := []struct {
+		target, apiKey, message string
+	}{
+		{"/tenants/acme/orders", "", `header "X-Api-Key" is required`},
+		{"/tenants/acme/orders?page=two", "secret", `query parameter "page" must be of type int, got "two"`},
+		{"/tenants/acme/orders?status=lost", "secret", `invalid value "lost" for parameter "status", valid values are open, closed`},
+	}
+	for _, test := range tests {
+		recorder = serve(test.target, test.apiKey)
+		var body map[string]any
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if recorder.Code != status.BadRequest || body["message"] != test.message {
+			t.Errorf("%v: unexpected response %v %v", test.target, recorder.Code, body)
+		}
+	}
+
+	request := Request{}
+	if err := request.Bind(bindListOrders{}); err == nil || errors.As(err, new(*BindingError)) {
+		t.Errorf("expected an error for a non-pointer destination, got %v", err)
+	}
+}
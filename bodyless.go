@@ -0,0 +1,96 @@
+package there
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// bodylessWriter discards the bodies of responses, that must not have one: all
+// responses to HEAD requests and the ones with StatusNoContent or StatusNotModified.
+// Responses can therefore always write their body, regardless of the request method
+// and status code.
+//
+// For HEAD requests, the headers are held back until the response is complete,
+// so the Content-Length matches the one of the respective GET request, unless
+// the response set it itself or flushed early, like streams.
+type bodylessWriter struct {
+	http.ResponseWriter
+	head bool
+	// code is the final status code, once it was written
+	code int
+	// discarded counts the body bytes of HEAD responses
+	discarded int
+	// sent is set, once the headers were passed to the underlying http.ResponseWriter
+	sent bool
+}
+
+func newBodylessWriter(rw http.ResponseWriter, r *http.Request) *bodylessWriter {
+	return &bodylessWriter{ResponseWriter: rw, head: r.Method == MethodHead}
+}
+
+func (w *bodylessWriter) suppressed() bool {
+	return w.head || w.code == status.NoContent || w.code == status.NotModified
+}
+
+func (w *bodylessWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		// informational responses, like early hints, are no final status code
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code != 0 {
+		return
+	}
+	w.code = code
+	if code == status.NoContent {
+		w.Header().Del(header.ContentLength)
+	}
+	if !w.head {
+		w.send()
+	}
+}
+
+func (w *bodylessWriter) send() {
+	if w.sent {
+		return
+	}
+	w.sent = true
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+func (w *bodylessWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(status.OK)
+	}
+	if !w.suppressed() {
+		return w.ResponseWriter.Write(b)
+	}
+	w.discarded += len(b)
+	return len(b), nil
+}
+
+func (w *bodylessWriter) Flush() {
+	if w.code != 0 {
+		w.send()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *bodylessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish sends the held back headers of HEAD responses
+func (w *bodylessWriter) finish() {
+	if w.code == 0 || w.sent {
+		return
+	}
+	if w.code != status.NoContent && w.code != status.NotModified && w.Header().Get(header.ContentLength) == "" {
+		w.Header().Set(header.ContentLength, strconv.Itoa(w.discarded))
+	}
+	w.send()
+}
@@ -0,0 +1,46 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestBodySuppression(t *testing.T) {
+	router := NewRouter()
+	user := map[string]string{"name": "there"}
+	router.Handle("/user", func(request Request) Response {
+		return Json(status.OK, user)
+	}, MethodGet, MethodHead)
+	router.Get("/empty", func(request Request) Response {
+		return String(status.NoContent, "ignored")
+	})
+	router.Get("/cached", func(request Request) Response {
+		return Headers(map[string]string{header.ContentLength: "15"}, String(status.NotModified, "ignored"))
+	})
+
+	serve := func(method, route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, route, nil))
+		return recorder
+	}
+
+	get := serve(MethodGet, "/user")
+	head := serve(MethodHead, "/user")
+	if head.Code != status.OK || head.Body.Len() != 0 {
+		t.Errorf("expected an empty %v, got %v %v", status.OK, head.Code, head.Body.String())
+	}
+	if length := head.Header().Get(header.ContentLength); length != strconv.Itoa(get.Body.Len()) {
+		t.Errorf("expected the content length of the GET request, got %v", length)
+	}
+	if recorder := serve(MethodGet, "/empty"); recorder.Code != status.NoContent || recorder.Body.Len() != 0 || recorder.Header().Get(header.ContentLength) != "" {
+		t.Errorf("expected an empty %v, got %v %v", status.NoContent, recorder.Code, recorder.Body.String())
+	}
+	recorder := serve(MethodGet, "/cached")
+	if recorder.Code != status.NotModified || recorder.Body.Len() != 0 || recorder.Header().Get(header.ContentLength) != "15" {
+		t.Errorf("expected an empty %v, got %v %v", status.NotModified, recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,139 @@
+package there
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// CachePolicy describes how long and by whom a response may be cached
+type CachePolicy struct {
+	// MaxAge is the time a response is fresh
+	MaxAge time.Duration
+	// Vary lists the request headers, which select different representations
+	// of the response, like Accept-Language
+	Vary []string
+	// Private restricts caching to the client, so shared caches, like proxies
+	// or the response cache of middlewares.Cache, do not store the response
+	Private bool
+	// Immutable tells clients, that the response never changes while it is
+	// fresh, so they do not revalidate it, like on reloads. Only use it for
+	// URLs, that change with their content.
+	Immutable bool
+}
+
+// CacheControl returns the value of the Cache-Control header for the policy
+func (p CachePolicy) CacheControl() string {
+	visibility := "public"
+	if p.Private {
+		visibility = "private"
+	}
+	value := visibility + ", max-age=" + strconv.FormatInt(int64(p.MaxAge/time.Second), 10)
+	if p.Immutable {
+		value += ", immutable"
+	}
+	return value
+}
+
+// cacheableStatus reports whether responses with the code may be cached with the policy
+func cacheableStatus(code int) bool {
+	return (code >= 200 && code < 300) || code == status.MovedPermanently || code == status.PermanentRedirect
+}
+
+// CacheControl wraps around your current Response and sets the Cache-Control
+// and Vary headers according to the policy. Only successful responses and
+// permanent redirects are marked as cacheable, and a Cache-Control header set
+// by the wrapped Response is preserved.
+//
+//	return there.CacheControl(there.CachePolicy{MaxAge: time.Minute}, there.Json(status.OK, products))
+func CacheControl(policy CachePolicy, response Response) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writer := &cacheControlWriter{ResponseWriter: rw, policy: policy}
+		response.ServeHTTP(writer, r)
+		writer.apply(status.OK)
+	})
+}
+
+// cacheControlWriter sets the headers of the CachePolicy, right before they are sent
+type cacheControlWriter struct {
+	http.ResponseWriter
+	policy  CachePolicy
+	applied bool
+}
+
+func (w *cacheControlWriter) apply(code int) {
+	if w.applied {
+		return
+	}
+	w.applied = true
+	headers := w.ResponseWriter.Header()
+	for _, name := range w.policy.Vary {
+		headers.Add(header.ResponseVary, name)
+	}
+	if cacheableStatus(code) && headers.Get(header.CacheControl) == "" {
+		headers.Set(header.CacheControl, w.policy.CacheControl())
+	}
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.apply(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	w.apply(status.OK)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) Flush() {
+	w.apply(status.OK)
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Cacheable declares the cache policy of the route in one place. Responses to
+// GET and HEAD requests get the Cache-Control and Vary headers, like with the
+// CacheControl helper, and the response cache of middlewares.Cache stores them
+// for maxAge. The policy can be read with Request.CachePolicy.
+//
+//	router.Get("/products", GetProducts).Cacheable(time.Minute, header.RequestAcceptLanguage)
+func (group *RouteRouteGroupBuilder) Cacheable(maxAge time.Duration, varyOn ...string) *RouteRouteGroupBuilder {
+	return group.WithCachePolicy(CachePolicy{MaxAge: maxAge, Vary: varyOn})
+}
+
+// WithCachePolicy is like Cacheable, but takes the complete CachePolicy
+func (group *RouteRouteGroupBuilder) WithCachePolicy(policy CachePolicy) *RouteRouteGroupBuilder {
+	group.assert(policy.MaxAge >= 0, "max age of the cache policy must not be negative")
+	for _, endpoint := range group.endpoints() {
+		endpoint.cachePolicy = &policy
+	}
+	return group
+}
+
+type cachePolicyKey struct{}
+
+// CachePolicy returns the policy declared for the route with Cacheable
+func (r *Request) CachePolicy() (CachePolicy, bool) {
+	policy, ok := r.Request.Context().Value(cachePolicyKey{}).(*CachePolicy)
+	if !ok {
+		return CachePolicy{}, false
+	}
+	return *policy, true
+}
+
+// withCachePolicy stores the policy of the route in the request
+func withCachePolicy(request *Request, policy *CachePolicy) {
+	if policy != nil {
+		request.WithContext(context.WithValue(request.Context(), cachePolicyKey{}, policy))
+	}
+}
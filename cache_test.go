@@ -0,0 +1,49 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestCacheable(t *testing.T) {
+	router := NewRouter()
+	var policy CachePolicy
+	router.Get("/products", func(request Request) Response {
+		policy, _ = request.CachePolicy()
+		if request.Params.Has("fail") {
+			return Error(status.InternalServerError, errors.New("failed"))
+		}
+		return String(status.OK, "products")
+	}).Cacheable(time.Minute, header.RequestAcceptLanguage)
+	router.Get("/private", func(request Request) Response {
+		return CacheControl(CachePolicy{MaxAge: 10 * time.Second, Private: true}, String(status.OK, "private"))
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	recorder := serve("/products")
+	if value := recorder.Header().Get(header.CacheControl); value != "public, max-age=60" {
+		t.Errorf("unexpected cache control %v", value)
+	}
+	if value := recorder.Header().Get(header.ResponseVary); value != header.RequestAcceptLanguage {
+		t.Errorf("unexpected vary %v", value)
+	}
+	if policy.MaxAge != time.Minute {
+		t.Errorf("expected the policy to be readable, got %v", policy)
+	}
+	if value := serve("/products?fail").Header().Get(header.CacheControl); value != "" {
+		t.Errorf("errors must not be cacheable, got %v", value)
+	}
+	if value := serve("/private").Header().Get(header.CacheControl); value != "private, max-age=10" {
+		t.Errorf("unexpected cache control %v", value)
+	}
+}
@@ -0,0 +1,109 @@
+package there
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+)
+
+// Variant is one of the Endpoints a Canary route chooses from
+type Variant struct {
+	// Name identifies the variant in the cookie and for the Selector
+	Name string
+	// Weight is the share of traffic relative to the other variants, like 95 and 5
+	Weight   int
+	Endpoint Endpoint
+}
+
+// CanaryConfiguration defines how a Canary route assigns the variants to requests
+type CanaryConfiguration struct {
+	// Selector picks a variant by its name based on request attributes, like an
+	// internal user. If it returns an unknown name or nothing, the variant is
+	// chosen by weight.
+	Selector func(request Request) string
+	// Cookie pins clients to the variant they were assigned first. Clients of
+	// variants with no Weight, like a rolled back canary, are assigned again.
+	// Disabled, if empty.
+	Cookie string
+	// Header assigns variants deterministically by hashing its value, like a user
+	// or tenant id, so the same value always gets the same variant. Disabled, if empty.
+	Header string
+}
+
+// Canary returns an Endpoint, that serves one of the variants per request, to
+// roll out a new implementation gradually inside one binary.
+//
+//	router.Get("/checkout", there.Canary(there.CanaryConfiguration{Cookie: "checkout_variant"},
+//		there.Variant{Name: "stable", Weight: 95, Endpoint: Checkout},
+//		there.Variant{Name: "canary", Weight: 5, Endpoint: CheckoutV2},
+//	))
+func Canary(configuration CanaryConfiguration, variants ...Variant) Endpoint {
+	total := 0
+	byName := make(map[string]Variant, len(variants))
+	for _, variant := range variants {
+		if variant.Weight > 0 {
+			total += variant.Weight
+		}
+		byName[variant.Name] = variant
+	}
+
+	pick := func(n int) Variant {
+		for _, variant := range variants {
+			if variant.Weight <= 0 {
+				continue
+			}
+			if n < variant.Weight {
+				return variant
+			}
+			n -= variant.Weight
+		}
+		return variants[0]
+	}
+
+	return func(request Request) Response {
+		if len(variants) == 0 {
+			return notImplementedEndpoint(request)
+		}
+
+		if configuration.Selector != nil {
+			if variant, ok := byName[configuration.Selector(request)]; ok {
+				return variant.Endpoint(request)
+			}
+		}
+		if configuration.Cookie != "" {
+			if cookie, err := request.Request.Cookie(configuration.Cookie); err == nil {
+				if variant, ok := byName[cookie.Value]; ok && variant.Weight > 0 {
+					return variant.Endpoint(request)
+				}
+			}
+		}
+
+		var variant Variant
+		if value := request.Request.Header.Get(configuration.Header); configuration.Header != "" && value != "" && total > 0 {
+			h := fnv.New32a()
+			h.Write([]byte(value))
+			variant = pick(int(h.Sum32() % uint32(total)))
+		} else if total > 0 {
+			variant = pick(rand.IntN(total))
+		} else {
+			variant = variants[0]
+		}
+
+		response := variant.Endpoint(request)
+		if configuration.Cookie == "" {
+			return response
+		}
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			http.SetCookie(rw, &http.Cookie{
+				Name:     configuration.Cookie,
+				Value:    variant.Name,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			if response != nil {
+				response.ServeHTTP(rw, r)
+			}
+		})
+	}
+}
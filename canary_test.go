@@ -0,0 +1,68 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestCanary(t *testing.T) {
+	variant := func(name string) Endpoint {
+		return func(request Request) Response {
+			return String(status.OK, name)
+		}
+	}
+	router := NewRouter()
+	router.Get("/weighted", Canary(CanaryConfiguration{Cookie: "variant"},
+		Variant{Name: "stable", Weight: 1, Endpoint: variant("stable")},
+		Variant{Name: "canary", Weight: 0, Endpoint: variant("canary")},
+	))
+	router.Get("/selected", Canary(CanaryConfiguration{
+		Selector: func(request Request) string {
+			if beta, _ := request.Headers.Get("Beta"); beta == "true" {
+				return "canary"
+			}
+			return ""
+		},
+		Header: "User",
+	},
+		Variant{Name: "stable", Weight: 50, Endpoint: variant("stable")},
+		Variant{Name: "canary", Weight: 50, Endpoint: variant("canary")},
+	))
+
+	serve := func(route string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/weighted", nil)
+	cookies := recorder.Result().Cookies()
+	if recorder.Body.String() != "stable" || len(cookies) != 1 || cookies[0].Value != "stable" {
+		t.Errorf("expected the stable variant to be assigned, got %v %v", recorder.Body.String(), cookies)
+	}
+	if recorder = serve("/weighted", map[string]string{"Cookie": "variant=stable"}); recorder.Body.String() != "stable" || len(recorder.Result().Cookies()) != 0 {
+		t.Errorf("expected the pinned variant, got %v", recorder.Body.String())
+	}
+	// the canary was rolled back, so its clients are reassigned
+	recorder = serve("/weighted", map[string]string{"Cookie": "variant=canary"})
+	cookies = recorder.Result().Cookies()
+	if recorder.Body.String() != "stable" || len(cookies) != 1 || cookies[0].Value != "stable" {
+		t.Errorf("expected the variant without weight to be replaced, got %v %v", recorder.Body.String(), cookies)
+	}
+
+	if recorder = serve("/selected", map[string]string{"Beta": "true"}); recorder.Body.String() != "canary" {
+		t.Errorf("expected the selected variant, got %v", recorder.Body.String())
+	}
+	first := serve("/selected", map[string]string{"User": "42"}).Body.String()
+	for i := 0; i < 10; i++ {
+		if next := serve("/selected", map[string]string{"User": "42"}).Body.String(); next != first {
+			t.Errorf("expected a sticky assignment by header, got %v and %v", first, next)
+		}
+	}
+}
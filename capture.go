@@ -0,0 +1,110 @@
+package there
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// CaptureWriter records the status code and the amount of bytes written to
+// the wrapped http.ResponseWriter, so they can be read after the response was
+// served. Use AfterResponse in middlewares, unless the writer is needed.
+type CaptureWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewCaptureWriter wraps the http.ResponseWriter
+func NewCaptureWriter(rw http.ResponseWriter) *CaptureWriter {
+	return &CaptureWriter{ResponseWriter: rw}
+}
+
+// Status returns the sent status code. Responses, that did not write a status
+// code, are sent with StatusOK by the http server.
+func (w *CaptureWriter) Status() int {
+	if w.status == 0 {
+		return status.OK
+	}
+	return w.status
+}
+
+// BytesWritten returns the size of the sent body, before any compression of
+// middlewares further out
+func (w *CaptureWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+// Written reports whether the status code or any data was sent
+func (w *CaptureWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *CaptureWriter) WriteHeader(code int) {
+	// informational responses are followed by the final one
+	if w.status == 0 && (code >= 200 || code == status.SwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *CaptureWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = status.OK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *CaptureWriter) Flush() {
+	if w.status == 0 {
+		w.status = status.OK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *CaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ResponseInfo describes a served response
+type ResponseInfo struct {
+	// Status is the sent status code
+	Status int
+	// Bytes is the size of the sent body
+	Bytes int64
+	// Start is the time the response started to be served
+	Start time.Time
+	// Duration is the time it took to serve the response
+	Duration time.Duration
+}
+
+// AfterResponse serves the response and calls the hook with the status code
+// and size of what was sent. Middlewares for logging, metrics or auditing use
+// it to learn the outcome of the Endpoint and the middlewares after them.
+//
+//	func Audit(request there.Request, next there.Response) there.Response {
+//		return there.AfterResponse(next, func(info there.ResponseInfo) {
+//			if info.Status >= 400 {
+//				audit.Failed(request.Pattern(), info.Status)
+//			}
+//		})
+//	}
+//
+// The hook is not called, if serving the response panics.
+func AfterResponse(response Response, hook func(info ResponseInfo)) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writer := NewCaptureWriter(rw)
+		start := time.Now()
+		response.ServeHTTP(writer, r)
+		hook(ResponseInfo{
+			Status:   writer.Status(),
+			Bytes:    writer.BytesWritten(),
+			Start:    start,
+			Duration: time.Since(start),
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package there
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestAfterResponse(t *testing.T) {
+	var infos []ResponseInfo
+	router := NewRouter()
+	router.Use(func(request Request, next Response) Response {
+		return AfterResponse(next, func(info ResponseInfo) {
+			infos = append(infos, info)
+		})
+	})
+	router.Get("/created", func(request Request) Response {
+		return String(status.Created, "created")
+	})
+	router.Get("/empty", func(request Request) Response {
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {})
+	})
+	router.Get("/accepted", func(request Request) Response {
+		return Headers(map[string]string{"X-Job": "1"}, String(status.Accepted, "accepted"))
+	})
+
+	for _, route := range []string{"/created", "/empty", "/accepted", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, route, nil))
+	}
+
+	expected := []ResponseInfo{
+		{Status: status.Created, Bytes: 7},
+		{Status: status.OK, Bytes: 0},
+		{Status: status.Accepted, Bytes: 8},
+	}
+	if len(infos) != 4 {
+		t.Fatalf("expected 4 responses, got %d", len(infos))
+	}
+	for i, e := range expected {
+		if info := infos[i]; info.Status != e.Status || info.Bytes != e.Bytes || info.Start.IsZero() {
+			t.Errorf("%d: expected %+v, got %+v", i, e, info)
+		}
+	}
+	if infos[3].Status != status.NotFound || infos[3].Bytes == 0 {
+		t.Errorf("unexpected not found response %+v", infos[3])
+	}
+}
+
+func TestCaptureWriter(t *testing.T) {
+	writer := NewCaptureWriter(httptest.NewRecorder())
+	if writer.Written() || writer.Status() != status.OK {
+		t.Errorf("unexpected unwritten state %v", writer.Status())
+	}
+	writer.WriteHeader(status.EarlyHints)
+	if writer.Written() {
+		t.Error("expected informational responses not to count as written")
+	}
+	writer.WriteHeader(status.Accepted)
+	if !writer.Written() || writer.Status() != status.Accepted {
+		t.Errorf("expected the final status code, got %v", writer.Status())
+	}
+
+	writer = NewCaptureWriter(httptest.NewRecorder())
+	_ = http.NewResponseController(writer).Flush()
+	if !writer.Written() {
+		t.Error("expected a flushed response to be written")
+	}
+}
@@ -0,0 +1,159 @@
+// Package cbor encodes and decodes CBOR (RFC 8949), the binary data format of
+// IoT protocols, COSE and WebAuthn, without dependencies. Register its Codec,
+// so BodyReader.Bind reads and Auto answers with application/cbor:
+//
+//	router.RegisterCodec(cbor.Codec())
+//
+// Go values are mapped like by encoding/json. Struct fields are named by their
+// "cbor" tag, or their "json" tag, if there is none, and support omitempty.
+// Byte slices are byte strings, maps are encoded with their keys sorted by
+// their encoding, so equal values have equal encodings, and time.Time is a
+// RFC 3339 string with tag 0. Floats are encoded as float32, if that is
+// lossless.
+package cbor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gebes/there/v2"
+)
+
+// ContentType is the content type of CBOR
+const ContentType = there.ContentTypeApplicationCbor
+
+// maxDepth limits how deep values are nested, so malicious input and cyclic
+// values terminate
+const maxDepth = 256
+
+var (
+	// ErrorMalformed is returned by Unmarshal for data, that is no valid CBOR
+	ErrorMalformed = errors.New("cbor: malformed data")
+	// ErrorUnsupportedType is returned by Marshal for values, that can not be
+	// encoded, like channels and funcs
+	ErrorUnsupportedType = errors.New("cbor: unsupported type")
+)
+
+// Codec returns the there.Codec of CBOR
+func Codec() there.Codec {
+	return there.NewCodec(ContentType, there.SerializerFuncs{
+		MarshalFunc:   Marshal,
+		UnmarshalFunc: Unmarshal,
+	})
+}
+
+// UnmarshalTypeError describes a CBOR value, that does not fit the Go value
+// it is decoded into
+type UnmarshalTypeError struct {
+	// Value describes the CBOR value, like "negative integer"
+	Value string
+	Type  reflect.Type
+	// Offset is the position of the value in the data
+	Offset int
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("cbor: cannot unmarshal %v at offset %d into Go value of type %v", e.Value, e.Offset, e.Type)
+}
+
+// major types of the initial byte
+const (
+	majorUnsigned byte = iota << 5
+	majorNegative
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+// additional information of the initial byte
+const (
+	infoUint8      = 24
+	infoUint16     = 25
+	infoUint32     = 26
+	infoUint64     = 27
+	infoIndefinite = 31
+)
+
+// simple values and floats of the major type 7
+const (
+	simpleFalse     = majorSimple | 20
+	simpleTrue      = majorSimple | 21
+	simpleNull      = majorSimple | 22
+	simpleUndefined = majorSimple | 23
+	floatHalf       = majorSimple | infoUint16
+	floatSingle     = majorSimple | infoUint32
+	floatDouble     = majorSimple | infoUint64
+	breakCode       = majorSimple | infoIndefinite
+)
+
+// tags of times
+const (
+	tagDateTime = 0
+	tagEpoch    = 1
+)
+
+// field is an encoded field of a struct
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// fieldsOf returns the encoded fields of the struct type. Fields of embedded
+// structs without name are promoted, unless the struct has a field of the
+// same name itself.
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+	var fields []field
+	seen := map[string]bool{}
+	var embedded []field
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		tag, ok := structField.Tag.Lookup("cbor")
+		if !ok {
+			tag = structField.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := structField.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if structField.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for _, promoted := range fieldsOf(fieldType) {
+				promoted.index = append([]int{i}, promoted.index...)
+				embedded = append(embedded, promoted)
+			}
+			continue
+		}
+		if !structField.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = structField.Name
+		}
+		seen[name] = true
+		fields = append(fields, field{name: name, index: []int{i}, omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty")})
+	}
+	for _, promoted := range embedded {
+		if !seen[promoted.name] {
+			seen[promoted.name] = true
+			fields = append(fields, promoted)
+		}
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,231 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestMarshal encodes the examples of RFC 8949, appendix A
+func TestMarshal(t *testing.T) {
+	for _, test := range []struct {
+		value    any
+		expected string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{int64(math.MinInt64), "3b7fffffffffffffff"},
+		{1.5, "fa3fc00000"},
+		{1.1, "fb3ff199999999999a"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]any{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[int]int{3: 4, 1: 2}, "a201020304"},
+		{map[string]any{"b": []int{2, 3}, "a": 1}, "a26161016162820203"},
+		{time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "c074323031332d30332d32315432303a30343a30305a"},
+	} {
+		data, err := Marshal(test.value)
+		if err != nil {
+			t.Errorf("%#v: %v", test.value, err)
+			continue
+		}
+		if encoded := hex.EncodeToString(data); encoded != test.expected {
+			t.Errorf("%#v: expected %v, got %v", test.value, test.expected, encoded)
+		}
+	}
+
+	if _, err := Marshal(make(chan int)); !errors.Is(err, ErrorUnsupportedType) {
+		t.Errorf("expected ErrorUnsupportedType, got %v", err)
+	}
+}
+
+// TestUnmarshalAny decodes the examples of RFC 8949, appendix A, including
+// the ones encoders of this package do not produce
+func TestUnmarshalAny(t *testing.T) {
+	for _, test := range []struct {
+		data     string
+		expected any
+	}{
+		{"1bffffffffffffffff", uint64(18446744073709551615)},
+		{"3903e7", int64(-1000)},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f9c400", -4.0},
+		{"f97c00", math.Inf(1)},
+		{"fb7e37e43c8800759c", 1.0e+300},
+		{"f7", nil},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"c11a514b67b0", time.Unix(1363896240, 0)},
+		{"d82076687474703a2f2f7777772e6578616d706c652e636f6d", "http://www.example.com"},
+	} {
+		var value any
+		if err := Unmarshal(decodeHex(t, test.data), &value); err != nil {
+			t.Errorf("%v: %v", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("%v: expected %#v, got %#v", test.data, test.expected, value)
+		}
+	}
+}
+
+type Location struct {
+	City string `json:"city"`
+}
+
+type device struct {
+	*Location
+	Id       string            `cbor:"id"`
+	Serial   [4]byte           `json:"serial"`
+	Readings []float64         `json:"readings,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Battery  *int              `json:"battery"`
+	Seen     time.Time         `json:"seen"`
+	Ignored  string            `json:"-"`
+	internal int
+}
+
+func TestRoundTrip(t *testing.T) {
+	battery := 87
+	original := device{
+		Location: &Location{City: "Vienna"},
+		Id:       "sensor-1",
+		Serial:   [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Readings: []float64{21.5, -3.25, 0.1},
+		Battery:  &battery,
+		Seen:     time.Date(2024, 5, 1, 12, 30, 0, 500, time.UTC),
+		Ignored:  "ignored",
+		internal: 1,
+	}
+	data, err := Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded device
+	if err := Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	original.Ignored, original.internal = "", 0
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("expected %+v, got %+v", original, decoded)
+	}
+
+	var fields map[string]any
+	if err := Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 6 || fields["city"] != "Vienna" || fields["id"] != "sensor-1" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var typeError *UnmarshalTypeError
+	var small struct {
+		Value int8 `json:"value"`
+	}
+	if err := Unmarshal(decodeHex(t, "a16576616c75651903e8"), &small); !errors.As(err, &typeError) || typeError.Offset != 7 {
+		t.Errorf("expected an overflow to be a type error, got %v", err)
+	}
+	var text string
+	if err := Unmarshal(decodeHex(t, "01"), &text); !errors.As(err, &typeError) {
+		t.Errorf("expected a type error, got %v", err)
+	}
+
+	for _, data := range []string{
+		"",                   // empty
+		"1903",               // truncated argument
+		"5b00000000ffffffff", // length beyond the data
+		"9bffffffffffffffff", // array length beyond the data
+		"1c",                 // reserved additional information
+		"62c328",             // invalid utf-8
+		"ff",                 // unexpected break
+		"0101",               // extra data
+		"5f6161ff",           // text chunk in a byte string
+		"1f",                 // indefinite integer
+	} {
+		var value any
+		if err := Unmarshal(decodeHex(t, data), &value); !errors.Is(err, ErrorMalformed) {
+			t.Errorf("%q: expected ErrorMalformed, got %v", data, err)
+		}
+	}
+
+	nested := bytes.Repeat([]byte{0x81}, maxDepth+2)
+	var value any
+	if err := Unmarshal(append(nested, 0x01), &value); !errors.Is(err, ErrorMalformed) {
+		t.Errorf("expected deep nesting to be rejected, got %v", err)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	type reading struct {
+		Sensor string  `json:"sensor"`
+		Value  float64 `json:"value"`
+	}
+	router := there.NewRouter()
+	router.RegisterCodec(Codec())
+	router.Post("/readings", func(request there.Request) there.Response {
+		var r reading
+		if err := request.Body.Bind(&r); err != nil {
+			return there.Error(status.BadRequest, err)
+		}
+		r.Value *= 2
+		return there.Auto(status.Created, r)
+	})
+
+	body, err := Marshal(reading{Sensor: "a", Value: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := httptest.NewRequest(there.MethodPost, "/readings", bytes.NewReader(body))
+	request.Header.Set(header.ContentType, ContentType)
+	request.Header.Set(header.RequestAccept, ContentType)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.Created || recorder.Header().Get(header.ContentType) != ContentType {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Header())
+	}
+	var response reading
+	if err := Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response != (reading{Sensor: "a", Value: 3}) {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
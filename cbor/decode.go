@@ -0,0 +1,657 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Unmarshal decodes the CBOR data into the value v points to. Into an empty
+// interface, integers are decoded as int64, or uint64 if they exceed it,
+// floats as float64, byte strings as []byte, arrays as []any, maps as
+// map[string]any, or map[any]any if not all keys are strings, and times as
+// time.Time. Other tags are dropped in favor of their content.
+func Unmarshal(data []byte, v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("cbor: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	d := &decoder{data: data}
+	if err := d.decode(value.Elem(), 0); err != nil {
+		return err
+	}
+	if d.offset != len(d.data) {
+		return d.malformed("%d bytes of extra data", len(d.data)-d.offset)
+	}
+	return nil
+}
+
+type decoder struct {
+	data   []byte
+	offset int
+}
+
+func (d *decoder) malformed(format string, args ...any) error {
+	return fmt.Errorf("%w: %v at offset %d", ErrorMalformed, fmt.Sprintf(format, args...), d.offset)
+}
+
+// item is the initial byte and argument of a data item
+type item struct {
+	offset   int
+	major    byte
+	info     byte
+	argument uint64
+}
+
+func (i item) indefinite() bool {
+	return i.info == infoIndefinite
+}
+
+func (i item) describe() string {
+	switch i.major {
+	case majorUnsigned:
+		return "unsigned integer"
+	case majorNegative:
+		return "negative integer"
+	case majorBytes:
+		return "byte string"
+	case majorText:
+		return "text string"
+	case majorArray:
+		return "array"
+	case majorMap:
+		return "map"
+	case majorTag:
+		return "tag"
+	}
+	switch i.major | i.info {
+	case simpleFalse, simpleTrue:
+		return "bool"
+	case floatHalf, floatSingle, floatDouble:
+		return "float"
+	}
+	return "simple value"
+}
+
+// head reads the initial byte and argument of the next data item
+func (d *decoder) head() (item, error) {
+	if d.offset >= len(d.data) {
+		return item{}, d.malformed("unexpected end of data")
+	}
+	b := d.data[d.offset]
+	i := item{offset: d.offset, major: b & 0xe0, info: b & 0x1f}
+	d.offset++
+	size := 0
+	switch {
+	case i.info < infoUint8:
+		i.argument = uint64(i.info)
+	case i.info <= infoUint64:
+		size = 1 << (i.info - infoUint8)
+	case i.info == infoIndefinite:
+		if i.major == majorUnsigned || i.major == majorNegative || i.major == majorTag {
+			return item{}, d.malformed("indefinite length of a %v", i.describe())
+		}
+	default:
+		return item{}, d.malformed("reserved additional information %d", i.info)
+	}
+	if len(d.data)-d.offset < size {
+		return item{}, d.malformed("unexpected end of data")
+	}
+	switch size {
+	case 1:
+		i.argument = uint64(d.data[d.offset])
+	case 2:
+		i.argument = uint64(binary.BigEndian.Uint16(d.data[d.offset:]))
+	case 4:
+		i.argument = uint64(binary.BigEndian.Uint32(d.data[d.offset:]))
+	case 8:
+		i.argument = binary.BigEndian.Uint64(d.data[d.offset:])
+	}
+	d.offset += size
+	return i, nil
+}
+
+// breaks reports whether the next byte ends an indefinite length item, and
+// consumes it if so
+func (d *decoder) breaks() (bool, error) {
+	if d.offset >= len(d.data) {
+		return false, d.malformed("unexpected end of data")
+	}
+	if d.data[d.offset] == breakCode {
+		d.offset++
+		return true, nil
+	}
+	return false, nil
+}
+
+// length checks the definite length of an array or map, whose items take at
+// least one byte each, against the remaining data
+func (d *decoder) length(i item, perItem int) (int, error) {
+	if i.argument > uint64(len(d.data)-d.offset)/uint64(perItem) {
+		return 0, d.malformed("length %d exceeds the data", i.argument)
+	}
+	return int(i.argument), nil
+}
+
+// str reads the content of a byte or text string
+func (d *decoder) str(i item) ([]byte, error) {
+	if !i.indefinite() {
+		if i.argument > uint64(len(d.data)-d.offset) {
+			return nil, d.malformed("length %d exceeds the data", i.argument)
+		}
+		content := d.data[d.offset : d.offset+int(i.argument)]
+		d.offset += int(i.argument)
+		return content, nil
+	}
+	var content []byte
+	for {
+		done, err := d.breaks()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return content, nil
+		}
+		chunk, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunk.major != i.major || chunk.indefinite() {
+			return nil, d.malformed("invalid chunk of an indefinite length %v", i.describe())
+		}
+		data, err := d.str(chunk)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, data...)
+	}
+}
+
+func (d *decoder) text(i item) (string, error) {
+	content, err := d.str(i)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(content) {
+		return "", d.malformed("invalid utf-8 in a text string")
+	}
+	return string(content), nil
+}
+
+// float returns the value of a float item
+func float(i item) float64 {
+	switch i.major | i.info {
+	case floatHalf:
+		return halfToFloat(uint16(i.argument))
+	case floatSingle:
+		return float64(math.Float32frombits(uint32(i.argument)))
+	default:
+		return math.Float64frombits(i.argument)
+	}
+}
+
+// halfToFloat converts an IEEE 754 half precision float
+func halfToFloat(h uint16) float64 {
+	exponent := int(h>>10) & 0x1f
+	mantissa := float64(h & 0x3ff)
+	var f float64
+	switch exponent {
+	case 0:
+		f = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+func (d *decoder) typeError(i item, t reflect.Type) error {
+	return &UnmarshalTypeError{Value: i.describe(), Type: t, Offset: i.offset}
+}
+
+func (d *decoder) decode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return d.malformed("values nested deeper than %d", maxDepth)
+	}
+	start := d.offset
+	i, err := d.head()
+	if err != nil {
+		return err
+	}
+	if i.major|i.info == simpleNull || i.major|i.info == simpleUndefined {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		d.offset = start
+		return d.decode(v.Elem(), depth+1)
+	case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+		d.offset = start
+		value, err := d.decodeAny(depth)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	case v.Type() == timeType:
+		d.offset = start
+		t, err := d.decodeTime(depth)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch i.major {
+	case majorUnsigned:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if i.argument > math.MaxInt64 || v.OverflowInt(int64(i.argument)) {
+				return d.typeError(i, v.Type())
+			}
+			v.SetInt(int64(i.argument))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if v.OverflowUint(i.argument) {
+				return d.typeError(i, v.Type())
+			}
+			v.SetUint(i.argument)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(i.argument))
+		default:
+			return d.typeError(i, v.Type())
+		}
+	case majorNegative:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if i.argument > math.MaxInt64 || v.OverflowInt(-1-int64(i.argument)) {
+				return d.typeError(i, v.Type())
+			}
+			v.SetInt(-1 - int64(i.argument))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(-1 - float64(i.argument))
+		default:
+			return d.typeError(i, v.Type())
+		}
+	case majorBytes:
+		content, err := d.str(i)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(append([]byte{}, content...))
+		case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+			for j := 0; j < v.Len(); j++ {
+				if j < len(content) {
+					v.Index(j).SetUint(uint64(content[j]))
+				} else {
+					v.Index(j).SetZero()
+				}
+			}
+		default:
+			return d.typeError(i, v.Type())
+		}
+	case majorText:
+		s, err := d.text(i)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.String {
+			return d.typeError(i, v.Type())
+		}
+		v.SetString(s)
+	case majorArray:
+		return d.decodeArray(i, v, depth)
+	case majorMap:
+		return d.decodeMap(i, v, depth)
+	case majorTag:
+		// the tag is dropped in favor of its content
+		return d.decode(v, depth+1)
+	default:
+		switch i.major | i.info {
+		case simpleFalse, simpleTrue:
+			if v.Kind() != reflect.Bool {
+				return d.typeError(i, v.Type())
+			}
+			v.SetBool(i.major|i.info == simpleTrue)
+		case floatHalf, floatSingle, floatDouble:
+			if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+				return d.typeError(i, v.Type())
+			}
+			v.SetFloat(float(i))
+		case breakCode:
+			return d.malformed("unexpected break")
+		default:
+			return d.typeError(i, v.Type())
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeArray(i item, v reflect.Value, depth int) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		if !i.indefinite() {
+			n, err := d.length(i, 1)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for j := 0; j < n; j++ {
+				if err := d.decode(v.Index(j), depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+		for j := 0; ; j++ {
+			done, err := d.breaks()
+			if err != nil || done {
+				return err
+			}
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+			if err := d.decode(v.Index(j), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		j := 0
+		err := d.items(i, func() error {
+			defer func() { j++ }()
+			if j < v.Len() {
+				return d.decode(v.Index(j), depth+1)
+			}
+			_, err := d.decodeAny(depth + 1)
+			return err
+		})
+		for ; j < v.Len(); j++ {
+			v.Index(j).SetZero()
+		}
+		return err
+	default:
+		return d.typeError(i, v.Type())
+	}
+}
+
+// items calls decode for every item of the array
+func (d *decoder) items(i item, decode func() error) error {
+	if i.indefinite() {
+		for {
+			done, err := d.breaks()
+			if err != nil || done {
+				return err
+			}
+			if err := decode(); err != nil {
+				return err
+			}
+		}
+	}
+	n, err := d.length(i, 1)
+	if err != nil {
+		return err
+	}
+	for j := 0; j < n; j++ {
+		if err := decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pairs calls decode for every key value pair of the map
+func (d *decoder) pairs(i item, decode func() error) error {
+	if i.indefinite() {
+		return d.items(i, decode)
+	}
+	n, err := d.length(i, 2)
+	if err != nil {
+		return err
+	}
+	for j := 0; j < n; j++ {
+		if err := decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeMap(i item, v reflect.Value, depth int) error {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		return d.pairs(i, func() error {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key, depth+1); err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(value, depth+1); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+			return nil
+		})
+	case reflect.Struct:
+		fields := fieldsOf(v.Type())
+		return d.pairs(i, func() error {
+			var name string
+			if err := d.decode(reflect.ValueOf(&name).Elem(), depth+1); err != nil {
+				return err
+			}
+			f, ok := fieldByName(fields, name)
+			if !ok {
+				_, err := d.decodeAny(depth + 1)
+				return err
+			}
+			value, ok := fieldByIndex(v, f.index)
+			if !ok {
+				_, err := d.decodeAny(depth + 1)
+				return err
+			}
+			return d.decode(value, depth+1)
+		})
+	default:
+		return d.typeError(i, v.Type())
+	}
+}
+
+// fieldByName finds the field by its exact name, or case-insensitively
+func fieldByName(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// fieldByIndex returns the field and allocates nil embedded pointers on the
+// way. Reports false, if a pointer to an unexported struct had to be allocated.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for j, x := range index {
+		if j > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func (d *decoder) decodeTime(depth int) (time.Time, error) {
+	i, err := d.head()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if i.major == majorTag {
+		if i.argument != tagDateTime && i.argument != tagEpoch {
+			return time.Time{}, d.typeError(i, timeType)
+		}
+		i, err = d.head()
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	switch i.major {
+	case majorText:
+		s, err := d.text(i)
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cbor: %w", err)
+		}
+		return t, nil
+	case majorUnsigned:
+		if i.argument > math.MaxInt64 {
+			return time.Time{}, d.typeError(i, timeType)
+		}
+		return time.Unix(int64(i.argument), 0), nil
+	case majorNegative:
+		if i.argument > math.MaxInt64 {
+			return time.Time{}, d.typeError(i, timeType)
+		}
+		return time.Unix(-1-int64(i.argument), 0), nil
+	}
+	switch i.major | i.info {
+	case floatHalf, floatSingle, floatDouble:
+		seconds, fraction := math.Modf(float(i))
+		return time.Unix(int64(seconds), int64(fraction*1e9)), nil
+	}
+	return time.Time{}, d.typeError(i, timeType)
+}
+
+// decodeAny decodes the next item into the natural Go type
+func (d *decoder) decodeAny(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, d.malformed("values nested deeper than %d", maxDepth)
+	}
+	start := d.offset
+	i, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch i.major {
+	case majorUnsigned:
+		if i.argument > math.MaxInt64 {
+			return i.argument, nil
+		}
+		return int64(i.argument), nil
+	case majorNegative:
+		if i.argument > math.MaxInt64 {
+			return nil, d.typeError(i, reflect.TypeOf(int64(0)))
+		}
+		return -1 - int64(i.argument), nil
+	case majorBytes:
+		content, err := d.str(i)
+		return append([]byte{}, content...), err
+	case majorText:
+		return d.text(i)
+	case majorArray:
+		array := []any{}
+		err := d.items(i, func() error {
+			value, err := d.decodeAny(depth + 1)
+			array = append(array, value)
+			return err
+		})
+		return array, err
+	case majorMap:
+		return d.decodeAnyMap(i, depth)
+	case majorTag:
+		if i.argument == tagDateTime || i.argument == tagEpoch {
+			d.offset = start
+			return d.decodeTime(depth)
+		}
+		return d.decodeAny(depth + 1)
+	}
+	switch i.major | i.info {
+	case simpleFalse:
+		return false, nil
+	case simpleTrue:
+		return true, nil
+	case simpleNull, simpleUndefined:
+		return nil, nil
+	case floatHalf, floatSingle, floatDouble:
+		return float(i), nil
+	case breakCode:
+		return nil, d.malformed("unexpected break")
+	}
+	return nil, d.typeError(i, reflect.TypeOf((*any)(nil)).Elem())
+}
+
+// decodeAnyMap decodes a map into map[string]any, or map[any]any, if not all
+// keys are strings
+func (d *decoder) decodeAnyMap(i item, depth int) (any, error) {
+	var keys, values []any
+	allStrings := true
+	err := d.pairs(i, func() error {
+		offset := d.offset
+		key, err := d.decodeAny(depth + 1)
+		if err != nil {
+			return err
+		}
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			d.offset = offset
+			return d.malformed("map key of the type %T", key)
+		}
+		_, isString := key.(string)
+		allStrings = allStrings && isString
+		value, err := d.decodeAny(depth + 1)
+		keys, values = append(keys, key), append(values, value)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if allStrings {
+		m := make(map[string]any, len(keys))
+		for j, key := range keys {
+			m[key.(string)] = values[j]
+		}
+		return m, nil
+	}
+	m := make(map[any]any, len(keys))
+	for j, key := range keys {
+		m[key] = values[j]
+	}
+	return m, nil
+}
@@ -0,0 +1,207 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Marshal returns the CBOR encoding of v
+func Marshal(v any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+	return e.data, nil
+}
+
+type encoder struct {
+	data []byte
+}
+
+// head writes the initial byte of the major type with its argument in the
+// shortest form
+func (e *encoder) head(major byte, argument uint64) {
+	switch {
+	case argument < infoUint8:
+		e.data = append(e.data, major|byte(argument))
+	case argument <= math.MaxUint8:
+		e.data = append(e.data, major|infoUint8, byte(argument))
+	case argument <= math.MaxUint16:
+		e.data = binary.BigEndian.AppendUint16(append(e.data, major|infoUint16), uint16(argument))
+	case argument <= math.MaxUint32:
+		e.data = binary.BigEndian.AppendUint32(append(e.data, major|infoUint32), uint32(argument))
+	default:
+		e.data = binary.BigEndian.AppendUint64(append(e.data, major|infoUint64), argument)
+	}
+}
+
+func (e *encoder) text(s string) {
+	e.head(majorText, uint64(len(s)))
+	e.data = append(e.data, s...)
+}
+
+func (e *encoder) encode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%w: values nested deeper than %d", ErrorUnsupportedType, maxDepth)
+	}
+	if !v.IsValid() {
+		e.data = append(e.data, simpleNull)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.head(majorTag, tagDateTime)
+		e.text(v.Interface().(time.Time).Format(time.RFC3339Nano))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.data = append(e.data, simpleNull)
+			return nil
+		}
+		return e.encode(v.Elem(), depth+1)
+	case reflect.Bool:
+		if v.Bool() {
+			e.data = append(e.data, simpleTrue)
+		} else {
+			e.data = append(e.data, simpleFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); i >= 0 {
+			e.head(majorUnsigned, uint64(i))
+		} else {
+			e.head(majorNegative, uint64(-1-i))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.head(majorUnsigned, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if single := float32(f); float64(single) == f || math.IsNaN(f) {
+			e.data = binary.BigEndian.AppendUint32(append(e.data, floatSingle), math.Float32bits(single))
+		} else {
+			e.data = binary.BigEndian.AppendUint64(append(e.data, floatDouble), math.Float64bits(f))
+		}
+	case reflect.String:
+		e.text(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.data = append(e.data, simpleNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.head(majorBytes, uint64(v.Len()))
+			e.data = append(e.data, v.Bytes()...)
+			return nil
+		}
+		return e.array(v, depth)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.head(majorBytes, uint64(v.Len()))
+			for i := 0; i < v.Len(); i++ {
+				e.data = append(e.data, byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		return e.array(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			e.data = append(e.data, simpleNull)
+			return nil
+		}
+		return e.mapping(v, depth)
+	case reflect.Struct:
+		return e.structure(v, depth)
+	default:
+		return fmt.Errorf("%w: %v", ErrorUnsupportedType, v.Type())
+	}
+	return nil
+}
+
+func (e *encoder) array(v reflect.Value, depth int) error {
+	e.head(majorArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapping encodes the map with its keys sorted by their encoding
+func (e *encoder) mapping(v reflect.Value, depth int) error {
+	type pair struct {
+		key   []byte
+		value reflect.Value
+	}
+	pairs := make([]pair, 0, v.Len())
+	iterator := v.MapRange()
+	for iterator.Next() {
+		key := &encoder{}
+		if err := key.encode(iterator.Key(), depth+1); err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{key: key.data, value: iterator.Value()})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].key, pairs[j].key) < 0
+	})
+	e.head(majorMap, uint64(len(pairs)))
+	for _, pair := range pairs {
+		e.data = append(e.data, pair.key...)
+		if err := e.encode(pair.value, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structure encodes the struct as map of its field names
+func (e *encoder) structure(v reflect.Value, depth int) error {
+	type encodedField struct {
+		name  string
+		value reflect.Value
+	}
+	var fields []encodedField
+	for _, f := range fieldsOf(v.Type()) {
+		value, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// promoted from a nil embedded pointer
+			continue
+		}
+		if f.omitEmpty && empty(value) {
+			continue
+		}
+		fields = append(fields, encodedField{name: f.name, value: value})
+	}
+	e.head(majorMap, uint64(len(fields)))
+	for _, f := range fields {
+		e.text(f.name)
+		if err := e.encode(f.value, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// empty reports whether omitempty leaves the value out, like encoding/json
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package there
+
+import "net/http"
+
+// Codec is a Serializer, that knows its content type. Registered with
+// RegisterCodec, it is used for both directions: BodyReader.Bind decodes
+// request bodies of the content type with it, and Auto offers it in the
+// content negotiation. Adding a format, like CBOR or Avro, is one registration:
+//
+//	router.RegisterCodec(there.NewCodec("application/cbor", cborSerializer))
+type Codec interface {
+	ContentType() string
+	Serializer
+}
+
+// NewCodec creates a Codec of the content type from a Serializer
+func NewCodec(contentType string, serializer Serializer) Codec {
+	return codec{contentType: contentType, Serializer: serializer}
+}
+
+type codec struct {
+	contentType string
+	Serializer
+}
+
+func (c codec) ContentType() string {
+	return c.contentType
+}
+
+// RegisterCodec registers the codecs in the Serializers of the
+// RouterConfiguration. A codec replaces the one registered for the same
+// content type before.
+func (router *Router) RegisterCodec(codecs ...Codec) *Router {
+	for _, codec := range codecs {
+		router.Configuration.RegisterSerializer(codec.ContentType(), codec)
+	}
+	return router
+}
+
+// builtinCodecs decode the content types, that BodyReader.Bind supports
+// without registration, besides json
+var builtinCodecs = map[string]Codec{}
+
+// codecOf returns the serializer the Router serving the request registered for
+// the content type, or the builtin one. Nil, if there is none.
+func codecOf(r *http.Request, contentType string) Serializer {
+	if serializer := serializerOf(r, contentType); serializer != nil {
+		return serializer
+	}
+	if codec, ok := builtinCodecs[contentType]; ok {
+		return codec
+	}
+	return nil
+}
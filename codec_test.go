@@ -0,0 +1,76 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestCodec(t *testing.T) {
+	const contentType = "text/x-key-value"
+	router := NewRouter()
+	router.RegisterCodec(NewCodec(contentType, keyValue))
+	router.Post("/echo", func(request Request) Response {
+		var body map[string]string
+		if err := request.Body.Bind(&body); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		return Auto(status.OK, body)
+	})
+
+	serve := func(contentType, accept, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodPost, "/echo", strings.NewReader(body))
+		request.Header.Set(header.ContentType, contentType)
+		request.Header.Set(header.RequestAccept, accept)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve(contentType, contentType, "name=there\n")
+	if recorder.Code != status.OK || recorder.Body.String() != "name=there\n" ||
+		recorder.Header().Get(header.ContentType) != contentType {
+		t.Errorf("unexpected response %v %v %q", recorder.Code, recorder.Header(), recorder.Body.String())
+	}
+	recorder = serve(contentType, ContentTypeApplicationJson, "name=there\n")
+	if recorder.Body.String() != `{"name":"there"}` {
+		t.Errorf("expected the codec body to be answered with json, got %q", recorder.Body.String())
+	}
+	recorder = serve(contentType, "image/png", "name=there\n")
+	if recorder.Header().Get(header.ContentType) != ContentTypeApplicationJson {
+		t.Errorf("expected the fallback for unacceptable types, got %v", recorder.Header())
+	}
+	if recorder = serve(contentType, contentType, "broken"); recorder.Code != status.BadRequest {
+		t.Errorf("expected invalid bodies to be rejected, got %v", recorder.Code)
+	}
+	if recorder = serve("application/unknown", contentType, "name=there"); recorder.Code != status.BadRequest {
+		t.Errorf("expected unregistered content types to be rejected, got %v", recorder.Code)
+	}
+}
+
+func TestBuiltinCodecs(t *testing.T) {
+	if _, ok := builtinCodecs[ContentTypeApplicationXml]; !ok {
+		t.Skip("built without xml")
+	}
+	type user struct {
+		Name string `xml:"name"`
+	}
+	router := NewRouter()
+	var bound user
+	router.Post("/", func(request Request) Response {
+		if err := request.Body.Bind(&bound); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		return Status(status.NoContent)
+	})
+	request := httptest.NewRequest(MethodPost, "/", strings.NewReader("<user><name>there</name></user>"))
+	request.Header.Set(header.ContentType, ContentTypeApplicationXml)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.NoContent || bound.Name != "there" {
+		t.Errorf("expected xml to be bound without registration, got %v %+v", recorder.Code, bound)
+	}
+}
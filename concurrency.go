@@ -0,0 +1,88 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorConcurrencyLimit is served, if a route has no free slot and its queue is full or the wait timed out
+var ErrorConcurrencyLimit = errors.New("too many concurrent requests")
+
+// concurrencyLimiter allows a fixed amount of requests to be served at once,
+// while a limited amount of further requests waits for a free slot
+type concurrencyLimiter struct {
+	slots   chan struct{}
+	queue   int64
+	waiting atomic.Int64
+	timeout time.Duration
+}
+
+// acquire reserves a slot and reports, whether it succeeded
+func (l *concurrencyLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.waiting.Add(1) > l.queue {
+		l.waiting.Add(-1)
+		return false
+	}
+	defer l.waiting.Add(-1)
+
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// serve runs the response in a slot, or rejects it with StatusServiceUnavailable
+func (l *concurrencyLimiter) serve(rw http.ResponseWriter, r *http.Request, response Response) {
+	if !l.acquire(r.Context()) {
+		setRetryAfter(rw, r, l.timeout)
+		Error(status.ServiceUnavailable, ErrorConcurrencyLimit).ServeHTTP(rw, r)
+		return
+	}
+	defer l.release()
+	response.ServeHTTP(rw, r)
+}
+
+// WithConcurrency limits the amount of requests the route serves at once, so an
+// expensive endpoint cannot starve the rest of the service. If all slots are
+// taken, up to queue requests wait for at most timeout. Further requests, and
+// the ones that waited too long, are rejected with StatusServiceUnavailable.
+// A zero timeout waits until the request is cancelled.
+//
+// The limit is shared by all methods of the route and only covers the Endpoint,
+// so requests rejected by middlewares never take a slot.
+//
+//	router.Get("/report", GenerateReport).WithConcurrency(2, 10, 5*time.Second)
+func (group *RouteRouteGroupBuilder) WithConcurrency(limit, queue int, timeout time.Duration) *RouteRouteGroupBuilder {
+	group.assert(limit > 0, "concurrency limit must be positive")
+	limiter := &concurrencyLimiter{
+		slots:   make(chan struct{}, limit),
+		queue:   int64(queue),
+		timeout: timeout,
+	}
+	for _, endpoint := range group.endpoints() {
+		endpoint.concurrency = limiter
+	}
+	return group
+}
@@ -0,0 +1,61 @@
+package there
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestWithConcurrency(t *testing.T) {
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	router := NewRouter()
+	router.Get("/report", func(request Request) Response {
+		started <- struct{}{}
+		<-release
+		return Status(status.OK)
+	}).WithConcurrency(1, 1, 50*time.Millisecond)
+	router.Get("/other", func(request Request) Response {
+		return Status(status.OK)
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if recorder := serve("/report"); recorder.Code != status.OK {
+			t.Errorf("expected %v, got %v", status.OK, recorder.Code)
+		}
+	}()
+	<-started
+
+	if recorder := serve("/other"); recorder.Code != status.OK {
+		t.Errorf("other routes must not be limited, got %v", recorder.Code)
+	}
+	recorder := serve("/report")
+	if recorder.Code != status.ServiceUnavailable || recorder.Header().Get(header.ResponseRetryAfter) != "1" {
+		t.Errorf("expected %v after the queue timeout, got %v", status.ServiceUnavailable, recorder.Code)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serve("/report")
+	}()
+	time.Sleep(10 * time.Millisecond) // let the request above enter the queue
+	if recorder = serve("/report"); recorder.Code != status.ServiceUnavailable {
+		t.Errorf("expected %v with a full queue, got %v", status.ServiceUnavailable, recorder.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package there
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+)
+
+// ConnInfo describes the connection a request was received on
+type ConnInfo struct {
+	// Protocol is the HTTP version of the request, like "HTTP/1.1" or "HTTP/2.0"
+	Protocol   string
+	ProtoMajor int
+	ProtoMinor int
+	// TLS is the state of the TLS connection, or nil for plain connections
+	TLS *tls.ConnectionState
+	// LocalAddr is the address of the server, that accepted the connection.
+	// Nil, if the request was not received by a http.Server.
+	LocalAddr net.Addr
+	// RemoteAddr is the address of the client, or of the last proxy, as host:port
+	RemoteAddr string
+}
+
+// Conn returns the metadata of the connection the request was received on
+//
+//	router.Get("/whoami", func(request there.Request) there.Response {
+//		conn := request.Conn()
+//		if certificate := conn.ClientCertificate(); certificate != nil {
+//			return there.String(status.OK, certificate.Subject.CommonName)
+//		}
+//		return there.Error(status.Unauthorized, errors.New("client certificate required"))
+//	})
+func (r *Request) Conn() ConnInfo {
+	localAddr, _ := r.Request.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ConnInfo{
+		Protocol:   r.Request.Proto,
+		ProtoMajor: r.Request.ProtoMajor,
+		ProtoMinor: r.Request.ProtoMinor,
+		TLS:        r.Request.TLS,
+		LocalAddr:  localAddr,
+		RemoteAddr: r.Request.RemoteAddr,
+	}
+}
+
+// Secure reports whether the connection is encrypted with TLS
+func (c ConnInfo) Secure() bool {
+	return c.TLS != nil
+}
+
+// HTTP2 reports whether the request was sent with HTTP/2 or newer
+func (c ConnInfo) HTTP2() bool {
+	return c.ProtoMajor >= 2
+}
+
+// NegotiatedProtocol returns the protocol negotiated with ALPN, like "h2".
+// Empty for plain connections or clients without ALPN.
+func (c ConnInfo) NegotiatedProtocol() string {
+	if c.TLS == nil {
+		return ""
+	}
+	return c.TLS.NegotiatedProtocol
+}
+
+// ServerName returns the server name the client requested with SNI
+func (c ConnInfo) ServerName() string {
+	if c.TLS == nil {
+		return ""
+	}
+	return c.TLS.ServerName
+}
+
+// TLSVersion returns the name of the TLS version, like "TLS 1.3"
+func (c ConnInfo) TLSVersion() string {
+	if c.TLS == nil {
+		return ""
+	}
+	return tls.VersionName(c.TLS.Version)
+}
+
+// CipherSuite returns the name of the negotiated cipher suite
+func (c ConnInfo) CipherSuite() string {
+	if c.TLS == nil {
+		return ""
+	}
+	return tls.CipherSuiteName(c.TLS.CipherSuite)
+}
+
+// ClientCertificate returns the certificate the client authenticated with, or
+// nil. Whether it was verified depends on the ClientAuth of the tls.Config.
+func (c ConnInfo) ClientCertificate() *x509.Certificate {
+	if c.TLS == nil || len(c.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return c.TLS.PeerCertificates[0]
+}
+
+// RemoteHost returns the host of the RemoteAddr without its port
+func (c ConnInfo) RemoteHost() string {
+	host, _, err := net.SplitHostPort(c.RemoteAddr)
+	if err != nil {
+		return c.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,67 @@
+package there
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestConn(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		conn := request.Conn()
+		return String(status.OK, fmt.Sprintf("%v %v %v %v %v %v",
+			conn.Protocol, conn.HTTP2(), conn.Secure(), conn.NegotiatedProtocol(), conn.LocalAddr != nil, conn.RemoteHost()))
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "HTTP/1.1 false false  true 127.0.0.1" {
+		t.Errorf("unexpected plain connection %q", body)
+	}
+
+	server = httptest.NewUnstartedServer(router)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	response, err = server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "HTTP/2.0 true true h2 true 127.0.0.1" {
+		t.Errorf("unexpected tls connection %q", body)
+	}
+}
+
+func TestConnInfo(t *testing.T) {
+	var conn ConnInfo
+	if conn.ClientCertificate() != nil || conn.ServerName() != "" || conn.TLSVersion() != "" || conn.CipherSuite() != "" {
+		t.Error("expected no tls details for plain connections")
+	}
+
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}}
+	conn = ConnInfo{TLS: &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		ServerName:       "example.com",
+		PeerCertificates: []*x509.Certificate{certificate},
+	}, RemoteAddr: "[::1]:4000"}
+	if conn.ClientCertificate() != certificate || conn.ServerName() != "example.com" ||
+		conn.TLSVersion() != "TLS 1.3" || conn.RemoteHost() != "::1" {
+		t.Errorf("unexpected tls details %v %v %v", conn.ServerName(), conn.TLSVersion(), conn.RemoteHost())
+	}
+}
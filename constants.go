@@ -34,8 +34,13 @@ const (
 	ContentTypeApplicationXhtmlPlusXml                   = "application/xhtml+xml"
 	ContentTypeApplicationXDashShockwaveDashFlash        = "application/x-shockwave-flash"
 	ContentTypeApplicationJson                           = "application/json"
+	ContentTypeApplicationGrpc                           = "application/grpc"
+	ContentTypeApplicationGrpcDashWeb                    = "application/grpc-web"
+	ContentTypeApplicationGrpcDashWebDashText            = "application/grpc-web-text"
 	ContentTypeApplicationMsgpack                        = "application/x-msgpack"
+	ContentTypeApplicationCbor                           = "application/cbor"
 	ContentTypeApplicationLdPlusJson                     = "application/ld+json"
+	ContentTypeApplicationProblemPlusJson                = "application/problem+json"
 	ContentTypeApplicationXml                            = "application/xml"
 	ContentTypeApplicationZip                            = "application/zip"
 	ContentTypeApplicationXDashWwwDashFormDashUrlencoded = "application/x-www-form-urlencoded"
@@ -57,6 +62,7 @@ const (
 	ContentTypeMultipartFormDashData                     = "multipart/form-data"
 	ContentTypeTextCss                                   = "text/css"
 	ContentTypeTextCsv                                   = "text/csv"
+	ContentTypeTextEventDashStream                       = "text/event-stream"
 	ContentTypeTextHtml                                  = "text/html"
 	ContentTypeTextJavascript                            = "text/javascript"
 	ContentTypeTextPlain                                 = "text/plain"
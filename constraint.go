@@ -0,0 +1,104 @@
+package there
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParamConstraint reports whether the value of a route parameter is valid.
+// Requests with invalid values are not found, so endpoints do not need to
+// validate the parameters themselves.
+type ParamConstraint func(value string) bool
+
+var (
+	// Int accepts decimal integers, that fit into an int64, like "-42"
+	Int ParamConstraint = func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	}
+	// Uint accepts unsigned decimal integers, that fit into an uint64, like "42"
+	Uint ParamConstraint = func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	}
+	// UUID accepts UUIDs in their canonical form, like "01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47"
+	UUID = Regex(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	// Slug accepts lowercase letters, digits and single dashes between them, like "hello-there"
+	Slug = Regex(`[a-z0-9]+(-[a-z0-9]+)*`)
+)
+
+// Regex accepts values matching the regular expression completely. It panics,
+// if the expression does not compile.
+func Regex(expr string) ParamConstraint {
+	re := regexp.MustCompile(`^(?:` + expr + `)$`)
+	return re.MatchString
+}
+
+// OneOf accepts the given values
+func OneOf(values ...string) ParamConstraint {
+	return func(value string) bool {
+		return slices.Contains(values, value)
+	}
+}
+
+// Where constrains the route parameter. Constraints can also be written
+// inline as regular expression, like "/users/{id:[0-9]+}".
+//
+//	router.Get("/users/{id}", GetUser).Where("id", there.Int)
+//	router.Get("/reports/{period}", GetReport).Where("period", there.OneOf("day", "week"))
+func (group *RouteRouteGroupBuilder) Where(name string, constraint ParamConstraint) *RouteRouteGroupBuilder {
+	group.assert(slices.Contains(routeParameters(group.muxHandler.pattern), name) || slices.Contains(group.hostParams, name),
+		"route \""+group.muxHandler.pattern+"\" has no parameter \""+name+"\" to constrain")
+	for _, endpoint := range group.endpoints() {
+		endpoint.constrain(name, constraint)
+	}
+	return group
+}
+
+func (h *muxHandlerEndpoint) constrain(name string, constraint ParamConstraint) {
+	if h.constraints == nil {
+		h.constraints = map[string][]ParamConstraint{}
+	}
+	h.constraints[name] = append(h.constraints[name], constraint)
+}
+
+// paramsValid reports whether the route parameters of the request satisfy the constraints
+func (h *muxHandlerEndpoint) paramsValid(value func(name string) string) bool {
+	for name, constraints := range h.constraints {
+		for _, constraint := range constraints {
+			if !constraint(value(name)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inlineConstraints removes the regular expressions of the wildcards in the
+// pattern, like in "/users/{id:[0-9]+}", and returns them as constraints
+func (group *RouteGroup) inlineConstraints(pattern string) (string, map[string]ParamConstraint) {
+	var constraints map[string]ParamConstraint
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name, expr, ok := strings.Cut(segment[1:len(segment)-1], ":")
+		if !ok {
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		re, err := regexp.Compile(`^(?:` + expr + `)$`)
+		group.assert(err == nil, "constraint of parameter \""+name+"\" in \""+pattern+"\" is no valid regular expression")
+		if err != nil {
+			continue
+		}
+		if constraints == nil {
+			constraints = map[string]ParamConstraint{}
+		}
+		constraints[name] = re.MatchString
+	}
+	return strings.Join(segments, "/"), constraints
+}
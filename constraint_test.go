@@ -0,0 +1,58 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestParamConstraints(t *testing.T) {
+	router := NewRouter()
+	echo := func(name string) Endpoint {
+		return func(request Request) Response {
+			return String(status.OK, name+" "+request.RouteParams.Get("id"))
+		}
+	}
+	router.Get("/users/{id}", echo("user")).Where("id", Int)
+	router.Get("/users/me", echo("me"))
+	router.Get("/orders/{id:[A-Z]{2}-[0-9]+}", echo("order"))
+	router.Group("/tenants/{tenant:[a-z]+}").Get("/items/{id}", echo("item")).Where("id", UUID)
+	router.Get("/reports/{id}", echo("report")).Where("id", OneOf("day", "week"))
+
+	for target, expected := range map[string]string{
+		"/users/42":    "user 42",
+		"/users/-7":    "user -7",
+		"/users/me":    "me ",
+		"/orders/AT-1": "order AT-1",
+		"/tenants/acme/items/01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47": "item 01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47",
+		"/reports/week": "report week",
+	} {
+		assertBodyResponse(t, router, MethodGet, target, expected)
+	}
+
+	for _, target := range []string{
+		"/users/abc", "/users/1.5", "/orders/at-1", "/orders/AT-1x",
+		"/tenants/ACME/items/01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47", "/tenants/acme/items/1", "/reports/month",
+	} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, target, nil))
+		if recorder.Code != status.NotFound {
+			t.Errorf("%v: expected %v, got %v", target, status.NotFound, recorder.Code)
+		}
+		if _, ok := router.Match(MethodGet, target); ok {
+			t.Errorf("%v: expected no match", target)
+		}
+	}
+	if match, ok := router.Match(MethodGet, "/orders/AT-1"); !ok || match.Pattern != "/orders/{id}" {
+		t.Errorf("expected the inline constraint to be removed from the pattern, got %v", match.Pattern)
+	}
+
+	router.Get("/broken/{id:[}", nil)
+	router.Get("/posts/{id}", nil).Where("slug", Slug)
+	for i := 0; i < 2; i++ {
+		if router.HasError() == nil {
+			t.Errorf("expected an assertion error")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package there
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// routeDeprecation announces the deprecation of a route and counts its usage per consumer
+type routeDeprecation struct {
+	pattern string
+	methods []string
+	since   time.Time
+	sunset  time.Time
+	link    string
+
+	mutex sync.Mutex
+	usage map[string]uint64
+}
+
+func (d *routeDeprecation) middleware(request Request, next Response) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		consumer := ""
+		if router := routerOf(r); router != nil && router.Configuration.Consumer != nil {
+			consumer = router.Configuration.Consumer(request)
+		}
+		d.mutex.Lock()
+		d.usage[consumer]++
+		d.mutex.Unlock()
+
+		headers := rw.Header()
+		headers.Set(header.ResponseDeprecation, "@"+strconv.FormatInt(d.since.Unix(), 10))
+		message := "route is deprecated"
+		if !d.sunset.IsZero() {
+			headers.Set(header.ResponseSunset, d.sunset.UTC().Format(http.TimeFormat))
+			message += " and will be removed on " + d.sunset.UTC().Format(time.DateOnly)
+		}
+		if d.link != "" {
+			headers.Add(header.ResponseLink, "<"+d.link+">; rel=\"deprecation\"")
+			message += ", see " + d.link
+		}
+		request.AddIssue("deprecation", message)
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// DeprecatedRoute describes a route marked with Deprecated and how often it was used since
+type DeprecatedRoute struct {
+	Pattern string    `json:"pattern"`
+	Methods []string  `json:"methods"`
+	Sunset  time.Time `json:"sunset"`
+	Link    string    `json:"link,omitempty"`
+	// Usage counts the requests per consumer, as identified by the Consumer of the
+	// RouterConfiguration. Requests without a consumer are counted with an empty key.
+	Usage map[string]uint64 `json:"usage"`
+}
+
+// Deprecated marks the route as deprecated. Its responses announce the
+// deprecation with the Deprecation header, as of the time the route was marked,
+// the sunset date with the Sunset header and the link to the migration guide
+// with a Link header. A zero sunset or an empty link are omitted. The
+// deprecation is recorded as Issue of the request as well.
+//
+// The requests to the route are counted per consumer, so API owners can reach
+// out to the remaining consumers before the route is removed, see DeprecatedRoutes.
+//
+//	router.Get("/v1/users", GetUsersV1).Deprecated(sunset, "https://example.com/migrate-to-v2")
+func (group *RouteRouteGroupBuilder) Deprecated(sunset time.Time, link string) *RouteRouteGroupBuilder {
+	deprecation := &routeDeprecation{
+		pattern: group.muxHandler.pattern,
+		since:   time.Now(),
+		sunset:  sunset,
+		link:    link,
+		usage:   map[string]uint64{},
+	}
+	for _, method := range group.methods {
+		deprecation.methods = append(deprecation.methods, methodToString(method))
+	}
+	group.Router.mutex.Lock()
+	group.Router.deprecations = append(group.Router.deprecations, deprecation)
+	group.Router.mutex.Unlock()
+	return group.With(deprecation.middleware)
+}
+
+// DeprecatedRoutes returns the routes marked with Deprecated and their usage, ordered by pattern
+func (router *Router) DeprecatedRoutes() []DeprecatedRoute {
+	router.mutex.Lock()
+	deprecations := append([]*routeDeprecation(nil), router.deprecations...)
+	router.mutex.Unlock()
+
+	routes := make([]DeprecatedRoute, 0, len(deprecations))
+	for _, deprecation := range deprecations {
+		deprecation.mutex.Lock()
+		usage := make(map[string]uint64, len(deprecation.usage))
+		for consumer, count := range deprecation.usage {
+			usage[consumer] = count
+		}
+		deprecation.mutex.Unlock()
+		routes = append(routes, DeprecatedRoute{
+			Pattern: deprecation.pattern,
+			Methods: deprecation.methods,
+			Sunset:  deprecation.sunset,
+			Link:    deprecation.link,
+			Usage:   usage,
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Pattern < routes[j].Pattern
+	})
+	return routes
+}
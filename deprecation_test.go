@@ -0,0 +1,61 @@
+package there
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestDeprecated(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.Consumer = func(request Request) string {
+		return request.Headers.GetDefault("X-Api-Key", "")
+	}
+	sunset := time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC)
+	router.Get("/v1/users", func(request Request) Response {
+		return Status(status.OK)
+	}).Deprecated(sunset, "https://example.com/migrate")
+	router.Get("/v2/users", func(request Request) Response {
+		return Status(status.OK)
+	})
+
+	serve := func(route, key string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(MethodGet, route, nil)
+		if key != "" {
+			request.Header.Set("X-Api-Key", key)
+		}
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/v1/users", "a")
+	if value := recorder.Header().Get(header.ResponseSunset); value != "Sun, 30 Jun 2030 00:00:00 GMT" {
+		t.Errorf("unexpected sunset %v", value)
+	}
+	if value := recorder.Header().Get(header.ResponseLink); value != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("unexpected link %v", value)
+	}
+	if value := recorder.Header().Get(header.ResponseDeprecation); len(value) < 2 || value[0] != '@' {
+		t.Errorf("unexpected deprecation %v", value)
+	}
+	if value := serve("/v2/users", "a").Header().Get(header.ResponseDeprecation); value != "" {
+		t.Errorf("only deprecated routes must be announced, got %v", value)
+	}
+	serve("/v1/users", "a")
+	serve("/v1/users", "b")
+	serve("/v1/users", "")
+
+	routes := router.DeprecatedRoutes()
+	if len(routes) != 1 || routes[0].Pattern != "/v1/users" || !reflect.DeepEqual(routes[0].Methods, []string{MethodGet}) {
+		t.Fatalf("unexpected routes %v", routes)
+	}
+	expected := map[string]uint64{"a": 2, "b": 1, "": 1}
+	if !reflect.DeepEqual(routes[0].Usage, expected) {
+		t.Errorf("expected %v, got %v", expected, routes[0].Usage)
+	}
+}
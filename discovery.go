@@ -0,0 +1,115 @@
+package there
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// routeDescription is the body returned for OPTIONS requests, when the
+// OptionsDiscovery of the RouterConfiguration is enabled
+type routeDescription struct {
+	Path       string                         `json:"path"`
+	Methods    []string                       `json:"methods"`
+	Parameters []string                       `json:"parameters,omitempty"`
+	Endpoints  map[string]endpointDescription `json:"endpoints,omitempty"`
+}
+
+type endpointDescription struct {
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Request     map[string]any            `json:"request,omitempty"`
+	Responses   map[string]map[string]any `json:"responses,omitempty"`
+}
+
+// discoveryEndpoint describes the route of the muxHandler with its allowed
+// methods, its route parameters and everything documented with RouteDoc
+func (h *muxHandler) discoveryEndpoint(request Request) Response {
+	methods := h.allowedMethods()
+	description := routeDescription{
+		Path:       h.pattern,
+		Methods:    methods,
+		Parameters: routeParameters(h.pattern),
+		Endpoints:  map[string]endpointDescription{},
+	}
+	for m, endpoint := range h.methods {
+		if endpoint.doc == nil {
+			continue
+		}
+		endpointDescription := endpointDescription{
+			Summary:     endpoint.doc.Summary,
+			Description: endpoint.doc.Description,
+			Request:     schemaOf(endpoint.doc.Request),
+		}
+		for code, body := range endpoint.doc.Responses {
+			if endpointDescription.Responses == nil {
+				endpointDescription.Responses = map[string]map[string]any{}
+			}
+			endpointDescription.Responses[strconv.Itoa(code)] = schemaOf(body)
+		}
+		description.Endpoints[methodToString(m)] = endpointDescription
+	}
+	return Headers(map[string]string{
+		header.ResponseAllow: strings.Join(methods, ", "),
+	}, Json(status.OK, description))
+}
+
+// optionsEndpoint answers OPTIONS requests with the allowed methods of the route
+func (h *muxHandler) optionsEndpoint(request Request) Response {
+	return Headers(map[string]string{
+		header.ResponseAllow: strings.Join(h.allowedMethods(), ", "),
+	}, Status(status.NoContent))
+}
+
+// AllowedMethods returns the methods the route of the request can be requested
+// with, like for the Allow header. Nil, if no route matches the path.
+func (r *Request) AllowedMethods() []string {
+	router := routerOf(r.Request)
+	if router == nil {
+		return nil
+	}
+	handler, _ := router.serveMux.Handler(r.Request)
+	if h, ok := handler.(*muxHandler); ok {
+		return h.allowedMethods()
+	}
+	return nil
+}
+
+// allowedMethods returns the methods registered on the muxHandler in the order of
+// AllMethods. HEAD is allowed as well, if AutoHead answers it with the GET
+// endpoint, and OPTIONS, if the OptionsDiscovery or AutoOptions answers it.
+func (h *muxHandler) allowedMethods() []string {
+	configuration := h.router.Configuration
+	_, get := h.methods[methodGet]
+	var allowed []string
+	for m := method(0); m < methods; m++ {
+		if _, ok := h.methods[m]; ok ||
+			(m == methodHead && get && configuration.AutoHead) ||
+			(m == methodOptions && (configuration.OptionsDiscovery || configuration.AutoOptions)) {
+			allowed = append(allowed, methodToString(m))
+		}
+	}
+	return allowed
+}
+
+// routeParameters returns the names of the wildcards in a ServeMux pattern
+func routeParameters(pattern string) []string {
+	var parameters []string
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return parameters
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return parameters
+		}
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		if name != "$" {
+			parameters = append(parameters, name)
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
@@ -0,0 +1,130 @@
+package there
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestOptionsDiscovery(t *testing.T) {
+	type output struct {
+		Id      int       `json:"id"`
+		Tags    []string  `json:"tags,omitempty"`
+		Created time.Time `json:"created"`
+		Ignored string    `json:"-"`
+	}
+
+	router := NewRouter()
+	router.Get("/user/{id}", handler).
+		Doc("Get a user", "").
+		Response(status.OK, output{})
+	router.Delete("/user/{id}", handler)
+
+	request := httptest.NewRequest(MethodOptions, "/user/5", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.MethodNotAllowed {
+		t.Fatalf("discovery should be opt-in, got %v", recorder.Code)
+	}
+
+	router.Configuration.OptionsDiscovery = true
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if allow := recorder.Header().Get(header.ResponseAllow); allow != "GET, DELETE, OPTIONS" {
+		t.Errorf("unexpected allow header %v", allow)
+	}
+
+	var description map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &description); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(description["parameters"], []any{"id"}) {
+		t.Errorf("unexpected parameters %v", description["parameters"])
+	}
+	response := description["endpoints"].(map[string]any)["GET"].(map[string]any)["responses"].(map[string]any)["200"]
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":      map[string]any{"type": "integer"},
+			"tags":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"created": map[string]any{"type": "string", "format": "date-time"},
+		},
+		"required": []any{"id", "created"},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("unexpected schema %v", response)
+	}
+}
+
+func TestAutoHeadAndOptions(t *testing.T) {
+	router := NewRouter()
+	router.Get("/user/{id}", func(request Request) Response {
+		return String(status.OK, "user "+request.RouteParams.Get("id"))
+	})
+	router.Post("/user/{id}", handler)
+	router.Get("/custom", handler)
+	router.Options("/custom", func(request Request) Response {
+		return Status(status.OK)
+	})
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	for _, method := range []string{MethodHead, MethodOptions} {
+		if recorder := serve(method, "/user/5"); recorder.Code != status.MethodNotAllowed {
+			t.Errorf("%v should be opt-in, got %v", method, recorder.Code)
+		}
+	}
+
+	router.Configuration.AutoHead = true
+	router.Configuration.AutoOptions = true
+	recorder := serve(MethodHead, "/user/5")
+	if recorder.Code != status.OK || recorder.Body.Len() != 0 || recorder.Header().Get(header.ContentLength) != "6" {
+		t.Errorf("expected the GET endpoint without body, got %v %q %v", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+	recorder = serve(MethodOptions, "/user/5")
+	if recorder.Code != status.NoContent || recorder.Header().Get(header.ResponseAllow) != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("unexpected options response %v %v", recorder.Code, recorder.Header())
+	}
+	if recorder = serve(MethodOptions, "/custom"); recorder.Code != status.OK {
+		t.Errorf("explicit OPTIONS handlers take precedence, got %v", recorder.Code)
+	}
+	recorder = serve(MethodDelete, "/user/5")
+	if recorder.Code != status.MethodNotAllowed || recorder.Header().Get(header.ResponseAllow) != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("unexpected method not allowed response %v %v", recorder.Code, recorder.Header())
+	}
+	if _, ok := router.Match(MethodHead, "/user/5"); !ok {
+		t.Errorf("expected HEAD to match the GET route")
+	}
+}
+
+func TestSchemaEmbeddedStructs(t *testing.T) {
+	type base struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	type node struct {
+		*node
+		base
+		Name string `json:"name,omitempty"`
+	}
+
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":   map[string]any{"type": "integer"},
+			"name": map[string]any{"type": "string"},
+		},
+		"required": []string{"id"},
+	}
+	if schema := schemaOf(node{}); !reflect.DeepEqual(schema, want) {
+		t.Errorf("unexpected schema %v", schema)
+	}
+}
@@ -0,0 +1,131 @@
+package there
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// DownloadOptions configure a Download response
+type DownloadOptions struct {
+	// Name is the file name suggested to the client. Defaults to the base of the path.
+	Name string
+	// ContentType defaults to the type guessed by the file extension or
+	// application/octet-stream
+	ContentType string
+	// Digest computes the SHA-256 digest of the file, so clients can verify the
+	// download. It is sent as Repr-Digest and, for complete responses, as
+	// Content-Digest (RFC 9530), and doubles as strong ETag, which makes resuming
+	// with If-Range reliable. The digest is cached until the file changes.
+	Digest bool
+}
+
+// Download serves the file at the path as an attachment. Range requests are
+// supported, so clients can resume interrupted downloads of large artifacts,
+// and conditional requests are answered with the Last-Modified time.
+//
+//	router.Get("/releases/{version}", func(request there.Request) there.Response {
+//		version, _ := request.RouteParams.Get("version")
+//		return there.Download("./releases/app-"+filepath.Base(version)+".tar.gz", there.DownloadOptions{Digest: true})
+//	})
+//
+// If the file does not exist, then an Error with StatusNotFound is returned.
+func Download(path string, options ...DownloadOptions) Response {
+	var config DownloadOptions
+	if len(options) >= 1 {
+		config = options[0]
+	}
+	if config.Name == "" {
+		config.Name = filepath.Base(path)
+	}
+	if config.ContentType == "" {
+		extension := filepath.Ext(path)
+		if extension != "" {
+			config.ContentType = ContentType(extension[1:])
+		}
+		if config.ContentType == "" {
+			config.ContentType = ContentTypeApplicationOctetDashStream
+		}
+	}
+	return downloadResponse{path: path, options: config}
+}
+
+type downloadResponse struct {
+	path    string
+	options DownloadOptions
+}
+
+func (d downloadResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	file, err := os.Open(d.path)
+	if err != nil {
+		Error(status.NotFound, err).ServeHTTP(rw, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		Error(status.NotFound, os.ErrNotExist).ServeHTTP(rw, r)
+		return
+	}
+
+	headers := rw.Header()
+	headers.Set(header.ContentType, d.options.ContentType)
+	headers.Set(header.ResponseContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": d.options.Name}))
+	headers.Set(header.ResponseAcceptRanges, "bytes")
+
+	if d.options.Digest {
+		sum, err := fileDigestOf(d.path, file, info)
+		if err != nil {
+			Error(status.InternalServerError, err).ServeHTTP(rw, r)
+			return
+		}
+		digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum) + ":"
+		headers.Set(header.ResponseReprDigest, digest)
+		if r.Header.Get(header.RequestRange) == "" {
+			headers.Set(header.ResponseContentDigest, digest)
+		}
+		headers.Set(header.ResponseEtag, "\""+hex.EncodeToString(sum)+"\"")
+	}
+
+	// http.ServeContent handles Range, If-Range and the conditional headers
+	http.ServeContent(rw, r, "", info.ModTime(), file)
+}
+
+type fileDigest struct {
+	modified time.Time
+	size     int64
+	sum      []byte
+}
+
+// fileDigestCache holds the digests of downloaded files, so large files are
+// only hashed again after they changed
+var fileDigestCache sync.Map // map[string]fileDigest
+
+func fileDigestOf(path string, file *os.File, info os.FileInfo) ([]byte, error) {
+	if cached, ok := fileDigestCache.Load(path); ok {
+		digest := cached.(fileDigest)
+		if digest.modified.Equal(info.ModTime()) && digest.size == info.Size() {
+			return digest.sum, nil
+		}
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	sum := h.Sum(nil)
+	fileDigestCache.Store(path, fileDigest{modified: info.ModTime(), size: info.Size(), sum: sum})
+	return sum, nil
+}
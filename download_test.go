@@ -0,0 +1,69 @@
+package there
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestDownload(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
+	router := NewRouter()
+	router.Get("/download", func(request Request) Response {
+		return Download(path, DownloadOptions{Name: "app v1.bin", Digest: true})
+	})
+	router.Get("/missing", func(request Request) Response {
+		return Download(filepath.Join(t.TempDir(), "missing"))
+	})
+
+	serve := func(route string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/download", nil)
+	if recorder.Code != status.OK || recorder.Body.String() != string(content) {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get(header.ResponseContentDigest) != digest || recorder.Header().Get(header.ResponseReprDigest) != digest {
+		t.Errorf("unexpected digests %v", recorder.Header())
+	}
+	if recorder.Header().Get(header.ResponseAcceptRanges) != "bytes" ||
+		recorder.Header().Get(header.ResponseContentDisposition) != `attachment; filename="app v1.bin"` {
+		t.Errorf("unexpected headers %v", recorder.Header())
+	}
+
+	etag := recorder.Header().Get(header.ResponseEtag)
+	recorder = serve("/download", map[string]string{header.RequestRange: "bytes=10-", header.RequestIfRange: etag})
+	if recorder.Code != status.PartialContent || recorder.Body.String() != "abcdefghij" {
+		t.Errorf("expected the resumed part, got %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get(header.ResponseContentDigest) != "" || recorder.Header().Get(header.ResponseReprDigest) != digest {
+		t.Errorf("partial responses must only carry the digest of the representation: %v", recorder.Header())
+	}
+
+	if recorder = serve("/download", map[string]string{header.RequestRange: "bytes=10-", header.RequestIfRange: `"outdated"`}); recorder.Code != status.OK {
+		t.Errorf("expected the full file for an outdated If-Range, got %v", recorder.Code)
+	}
+	if recorder = serve("/missing", nil); recorder.Code != status.NotFound {
+		t.Errorf("expected %v, got %v", status.NotFound, recorder.Code)
+	}
+}
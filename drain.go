@@ -0,0 +1,32 @@
+package there
+
+import (
+	"io"
+	"net/http"
+)
+
+// defaultBodyDrainLimit matches the amount net/http discards itself, before it closes the connection
+const defaultBodyDrainLimit = 256 << 10
+
+// drainBody discards the unread rest of the request body up to the limit, so the
+// keep-alive connection can be reused, even if a middleware responded without
+// reading the body, like after a failed authentication
+func (router *Router) drainBody(request *http.Request) {
+	limit := router.Configuration.BodyDrainLimit
+	if limit < 0 || request.Body == nil || request.Body == http.NoBody {
+		return
+	}
+	if limit == 0 {
+		limit = defaultBodyDrainLimit
+	}
+	drained, err := io.CopyN(io.Discard, request.Body, limit)
+	if drained == 0 {
+		return
+	}
+	router.stats.drainedRequests.Add(1)
+	router.stats.drainedBytes.Add(uint64(drained))
+	if err == nil {
+		// the limit was reached, net/http closes the connection, if the body is still not consumed
+		router.stats.drainLimitExceeded.Add(1)
+	}
+}
@@ -0,0 +1,49 @@
+package there
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestBodyDrain(t *testing.T) {
+	router := NewRouter()
+	router.Post("/reject", func(request Request) Response {
+		return Status(status.Unauthorized)
+	})
+	router.Post("/read", func(request Request) Response {
+		_, _ = io.ReadAll(request.Request.Body)
+		return Status(status.OK)
+	})
+
+	serve := func(route, body string) *strings.Reader {
+		reader := strings.NewReader(body)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodPost, route, reader))
+		return reader
+	}
+
+	if reader := serve("/reject", "0123456789"); reader.Len() != 0 {
+		t.Errorf("expected the body to be drained, %v bytes left", reader.Len())
+	}
+	serve("/read", "0123456789")
+	stats := router.Stats()
+	if stats.DrainedRequests != 1 || stats.DrainedBytes != 10 || stats.DrainLimitExceeded != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	router.Configuration.BodyDrainLimit = 4
+	if reader := serve("/reject", "0123456789"); reader.Len() != 6 {
+		t.Errorf("expected 4 bytes to be drained, %v bytes left", reader.Len())
+	}
+	if stats = router.Stats(); stats.DrainLimitExceeded != 1 || stats.DrainedBytes != 14 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	router.Configuration.BodyDrainLimit = -1
+	if reader := serve("/reject", "0123456789"); reader.Len() != 10 {
+		t.Errorf("draining must be disabled, %v bytes left", reader.Len())
+	}
+}
@@ -0,0 +1,718 @@
+package there
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeFormat defines how time.Time values are encoded in Json responses and
+// parsed by BindJson
+type TimeFormat uint8
+
+const (
+	// TimeFormatRFC3339 encodes times like "2006-01-02T15:04:05.999999999Z07:00", as encoding/json does
+	TimeFormatRFC3339 TimeFormat = iota
+	// TimeFormatUnix encodes times as seconds since the unix epoch
+	TimeFormatUnix
+	// TimeFormatUnixMilli encodes times as milliseconds since the unix epoch
+	TimeFormatUnixMilli
+)
+
+// DurationFormat defines how time.Duration values are encoded in Json responses
+// and parsed by BindJson
+type DurationFormat uint8
+
+const (
+	// DurationFormatNanoseconds encodes durations as integer nanoseconds, as encoding/json does
+	DurationFormatNanoseconds DurationFormat = iota
+	// DurationFormatMilliseconds encodes durations as integer milliseconds
+	DurationFormatMilliseconds
+	// DurationFormatSeconds encodes durations as fractional seconds
+	DurationFormatSeconds
+	// DurationFormatString encodes durations like "1h2m3.5s"
+	DurationFormatString
+)
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonOptions collects the settings of the RouterConfiguration, that affect how
+// json is encoded and decoded
+type jsonOptions struct {
+	timeFormat     TimeFormat
+	durationFormat DurationFormat
+	// canonical sorts all object keys and disables the escaping of HTML characters
+	canonical bool
+}
+
+// jsonOptionsOf returns the jsonOptions of the Router serving the request
+func jsonOptionsOf(r *http.Request) jsonOptions {
+	router := routerOf(r)
+	if router == nil {
+		return jsonOptions{}
+	}
+	return jsonOptions{
+		timeFormat:     router.Configuration.JsonTimeFormat,
+		durationFormat: router.Configuration.JsonDurationFormat,
+	}
+}
+
+// isDefault reports whether encoding/json can be used as it is
+func (o jsonOptions) isDefault() bool {
+	return o == jsonOptions{}
+}
+
+// marshal encodes v to json. Unless the options are the default, v is first
+// converted into a generic tree, in which times and durations are formatted.
+func (o jsonOptions) marshal(v any) ([]byte, error) {
+	if o.isDefault() {
+		return json.Marshal(v)
+	}
+	tree, err := o.tree(reflect.ValueOf(v), 0)
+	if err != nil {
+		return nil, err
+	}
+	return encodeJson(tree, !o.canonical)
+}
+
+// encodeJson marshals v like json.Marshal, but allows to disable the escaping of
+// HTML characters
+func encodeJson(v any, escapeHTML bool) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(escapeHTML)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// unmarshal decodes data into dest. Unless the options are the default, the
+// times and durations in data are first rewritten into the format encoding/json
+// expects, guided by the type of dest. Only these values are replaced, so the
+// offsets of errors still point into data.
+func (o jsonOptions) unmarshal(data []byte, dest any) error {
+	destination := reflect.TypeOf(dest)
+	if o.isDefault() || destination == nil || destination.Kind() != reflect.Pointer {
+		return json.Unmarshal(data, dest)
+	}
+	rewriter := &jsonRewriter{options: o, data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	rewriter.decoder.UseNumber()
+	if err := rewriter.value(destination.Elem(), 0); err != nil {
+		if !json.Valid(data) {
+			// reports the syntax error like encoding/json
+			return json.Unmarshal(data, dest)
+		}
+		return err
+	}
+	if len(rewriter.edits) == 0 {
+		return json.Unmarshal(data, dest)
+	}
+	err := json.Unmarshal(rewriter.rewritten(), dest)
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		syntaxError.Offset = rewriter.originalOffset(syntaxError.Offset)
+	case errors.As(err, &typeError):
+		typeError.Offset = rewriter.originalOffset(typeError.Offset)
+	}
+	return err
+}
+
+// jsonMaxNesting is the depth of arrays and objects encoding/json accepts
+const jsonMaxNesting = 10000
+
+// jsonRewriter replaces the times and durations of json data, that are not in
+// the format encoding/json expects for the type of their destination
+type jsonRewriter struct {
+	options jsonOptions
+	data    []byte
+	decoder *json.Decoder
+	edits   []jsonEdit
+}
+
+// jsonEdit replaces the bytes of the data from start to end
+type jsonEdit struct {
+	start, end  int64
+	replacement []byte
+}
+
+// value reads the next value, which is decoded into the type t. Values without
+// destination, like unknown fields, have no type.
+func (r *jsonRewriter) value(t reflect.Type, depth int) error {
+	if depth > jsonMaxNesting {
+		return errors.New("json: exceeded max depth")
+	}
+	start := r.valueStart()
+	token, err := r.decoder.Token()
+	if err != nil {
+		return err
+	}
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && t != timeType && t != durationType &&
+		(reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		// custom formats are left to the type
+		t = nil
+	}
+	if delim, ok := token.(json.Delim); ok {
+		return r.composite(delim, t, depth)
+	}
+
+	var converted any
+	switch t {
+	case timeType:
+		converted, err = r.options.parseTime(token)
+	case durationType:
+		converted, err = r.options.parseDuration(token)
+	default:
+		return nil
+	}
+	if err != nil || converted == token {
+		return err
+	}
+	replacement, err := json.Marshal(converted)
+	if err != nil {
+		return err
+	}
+	r.edits = append(r.edits, jsonEdit{start: start, end: r.decoder.InputOffset(), replacement: replacement})
+	return nil
+}
+
+// composite reads the members of the object or array, whose opening delimiter was read
+func (r *jsonRewriter) composite(delim json.Delim, t reflect.Type, depth int) error {
+	switch delim {
+	case '{':
+		for r.decoder.More() {
+			key, err := r.decoder.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := key.(string)
+			if err = r.value(jsonMemberType(t, name), depth+1); err != nil {
+				return fmt.Errorf("%v: %w", name, err)
+			}
+		}
+	case '[':
+		var element reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			element = t.Elem()
+		}
+		for i := 0; r.decoder.More(); i++ {
+			if err := r.value(element, depth+1); err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+		}
+	}
+	// the closing delimiter
+	_, err := r.decoder.Token()
+	return err
+}
+
+// valueStart returns the offset of the next value, which follows the current
+// offset of the decoder after whitespace and separators
+func (r *jsonRewriter) valueStart() int64 {
+	offset := r.decoder.InputOffset()
+	for offset < int64(len(r.data)) {
+		switch r.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// rewritten returns the data with the edits applied
+func (r *jsonRewriter) rewritten() []byte {
+	var b bytes.Buffer
+	previous := int64(0)
+	for _, edit := range r.edits {
+		b.Write(r.data[previous:edit.start])
+		b.Write(edit.replacement)
+		previous = edit.end
+	}
+	b.Write(r.data[previous:])
+	return b.Bytes()
+}
+
+// originalOffset maps an offset in the rewritten data back onto the data.
+// Offsets within a replaced value point to the end of the original value.
+func (r *jsonRewriter) originalOffset(offset int64) int64 {
+	shift := int64(0)
+	for _, edit := range r.edits {
+		start := edit.start + shift
+		if offset <= start {
+			break
+		}
+		if offset <= start+int64(len(edit.replacement)) {
+			return edit.end
+		}
+		shift += int64(len(edit.replacement)) - (edit.end - edit.start)
+	}
+	return offset - shift
+}
+
+// jsonMemberType returns the type a member of a json object is decoded into,
+// if the object is decoded into the type t. Like encoding/json, names of
+// struct fields are preferably matched exactly, otherwise case-insensitively.
+func jsonMemberType(t reflect.Type, name string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		fields := jsonStructFields(t)
+		for _, field := range fields {
+			if field.name == name {
+				return field.typ
+			}
+		}
+		for _, field := range fields {
+			if strings.EqualFold(field.name, name) {
+				return field.typ
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTreeDepth limits the depth of converted values, so cyclic data terminates
+const jsonTreeDepth = 1000
+
+// jsonField is a single member of a jsonObject
+type jsonField struct {
+	name  string
+	value any
+}
+
+// jsonObject is an encoded struct, which keeps the order of its fields.
+// HTML characters are not escaped, as encoding/json escapes the output of
+// a json.Marshaler itself, if required.
+type jsonObject []jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := encodeJson(field.name, false)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		value, err := encodeJson(field.value, false)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// tree converts v into values encoding/json marshals the same way, except for
+// times and durations, which are formatted according to the options
+func (o jsonOptions) tree(v reflect.Value, depth int) (any, error) {
+	if depth > jsonTreeDepth {
+		return nil, errors.New("json: value is nested too deeply or cyclic")
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		return o.formatTime(v.Interface().(time.Time)), nil
+	case durationType:
+		return o.formatDuration(time.Duration(v.Int())), nil
+	}
+
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface && v.CanAddr() &&
+		(v.Addr().Type().Implements(jsonMarshalerType) || v.Addr().Type().Implements(textMarshalerType)) {
+		v = v.Addr()
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() != reflect.Pointer || v.Elem().Type() != timeType {
+			if o.canonical && v.Type().Implements(jsonMarshalerType) {
+				return canonicalMarshaler(v.Interface().(json.Marshaler))
+			}
+			return v.Interface(), nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return o.tree(v.Elem(), depth+1)
+	case reflect.Struct:
+		return o.structTree(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]any, v.Len())
+		iterator := v.MapRange()
+		for iterator.Next() {
+			key, err := mapKey(iterator.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := o.tree(iterator.Value(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			value, err := o.tree(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+func (o jsonOptions) structTree(v reflect.Value, depth int) (any, error) {
+	object := jsonObject{}
+	for _, field := range jsonStructFields(v.Type()) {
+		value, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// promoted through a nil embedded pointer
+			continue
+		}
+		if field.omitEmpty && isEmptyJsonValue(value) {
+			continue
+		}
+		converted, err := o.tree(value, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if field.quoted {
+			converted = quoteJsonValue(value, converted)
+		}
+		object = append(object, jsonField{name: field.name, value: converted})
+	}
+	if o.canonical {
+		sort.Slice(object, func(i, j int) bool {
+			return object[i].name < object[j].name
+		})
+	}
+	return object, nil
+}
+
+// canonicalMarshaler decodes the output of a json.Marshaler into a generic tree,
+// so its keys get sorted too. Numbers are kept exactly as they were marshalled.
+func canonicalMarshaler(marshaler json.Marshaler) (any, error) {
+	data, err := marshaler.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree any
+	err = decoder.Decode(&tree)
+	return tree, err
+}
+
+// jsonStructField is a field of a struct, as encoding/json encodes it
+type jsonStructField struct {
+	name string
+	// index is the path of the field through embedded structs, see reflect.Value.FieldByIndex
+	index     []int
+	typ       reflect.Type
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+// jsonStructFieldCache caches the fields per struct type
+var jsonStructFieldCache sync.Map // map[reflect.Type][]jsonStructField
+
+// jsonStructFields returns the fields of the struct type encoding/json encodes,
+// in its order. It follows the rules of encoding/json for tags and embedded
+// structs: of the fields with the same name, the shallowest one wins, a tagged
+// one among equally shallow ones, and otherwise all of them are omitted.
+func jsonStructFields(t reflect.Type) []jsonStructField {
+	if cached, ok := jsonStructFieldCache.Load(t); ok {
+		return cached.([]jsonStructField)
+	}
+
+	var fields []jsonStructField
+	current, next := []jsonStructField{}, []jsonStructField{{typ: t}}
+	// count and nextCount are the amount of embedded structs of a type at the current and next depth
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, parent := range current {
+			if visited[parent.typ] {
+				continue
+			}
+			visited[parent.typ] = true
+			for i := 0; i < parent.typ.NumField(); i++ {
+				field := parent.typ.Field(i)
+				if field.Anonymous {
+					fieldType := field.Type
+					if fieldType.Kind() == reflect.Pointer {
+						fieldType = fieldType.Elem()
+					}
+					if !field.IsExported() && fieldType.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !field.IsExported() {
+					continue
+				}
+				tag := field.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), parent.index...), i)
+
+				fieldType := field.Type
+				if fieldType.Name() == "" && fieldType.Kind() == reflect.Pointer {
+					fieldType = fieldType.Elem()
+				}
+				if name != "" || !field.Anonymous || fieldType.Kind() != reflect.Struct {
+					jsonField := jsonStructField{
+						name:      name,
+						index:     index,
+						typ:       field.Type,
+						tagged:    name != "",
+						omitEmpty: strings.Contains(options, "omitempty"),
+						quoted:    strings.Contains(options, "string"),
+					}
+					if name == "" {
+						jsonField.name = field.Name
+					}
+					fields = append(fields, jsonField)
+					if count[parent.typ] > 1 {
+						// the struct was embedded several times at this depth, so its
+						// fields are ambiguous, which the duplicate reveals below
+						fields = append(fields, jsonField)
+					}
+					continue
+				}
+				nextCount[fieldType]++
+				if nextCount[fieldType] == 1 {
+					next = append(next, jsonStructField{name: fieldType.Name(), index: index, typ: fieldType})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+	dominant := fields[:0:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if field, ok := dominantJsonField(fields[i:j]); ok {
+			dominant = append(dominant, field)
+		}
+		i = j
+	}
+	sort.Slice(dominant, func(i, j int) bool {
+		return slices.Compare(dominant[i].index, dominant[j].index) < 0
+	})
+
+	jsonStructFieldCache.Store(t, dominant)
+	return dominant
+}
+
+// dominantJsonField returns the field, that wins among the fields with the
+// same name, which are sorted by depth and tags
+func dominantJsonField(fields []jsonStructField) (jsonStructField, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return jsonStructField{}, false
+	}
+	return fields[0], true
+}
+
+// quoteJsonValue applies the string option of a json tag, which only affects
+// strings, numbers and booleans
+func quoteJsonValue(v reflect.Value, converted any) any {
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		data, err := json.Marshal(converted)
+		if err != nil {
+			return converted
+		}
+		return string(data)
+	}
+	return converted
+}
+
+func isEmptyJsonValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("json: unsupported map key type %v", key.Type())
+}
+
+func (o jsonOptions) formatTime(t time.Time) any {
+	switch o.timeFormat {
+	case TimeFormatUnix:
+		return t.Unix()
+	case TimeFormatUnixMilli:
+		return t.UnixMilli()
+	default:
+		return t
+	}
+}
+
+func (o jsonOptions) formatDuration(d time.Duration) any {
+	switch o.durationFormat {
+	case DurationFormatMilliseconds:
+		return d.Milliseconds()
+	case DurationFormatSeconds:
+		return d.Seconds()
+	case DurationFormatString:
+		return d.String()
+	default:
+		return int64(d)
+	}
+}
+
+func (o jsonOptions) parseTime(value any) (any, error) {
+	number, ok := value.(json.Number)
+	if o.timeFormat == TimeFormatRFC3339 || !ok {
+		return value, nil
+	}
+	var t time.Time
+	switch o.timeFormat {
+	case TimeFormatUnix:
+		seconds, err := number.Float64()
+		if err != nil {
+			return nil, err
+		}
+		whole := int64(seconds)
+		t = time.Unix(whole, int64((seconds-float64(whole))*float64(time.Second)))
+	case TimeFormatUnixMilli:
+		milliseconds, err := number.Int64()
+		if err != nil {
+			return nil, err
+		}
+		t = time.UnixMilli(milliseconds)
+	}
+	return t.UTC().Format(time.RFC3339Nano), nil
+}
+
+func (o jsonOptions) parseDuration(value any) (any, error) {
+	var d time.Duration
+	switch v := value.(type) {
+	case string:
+		if o.durationFormat != DurationFormatString {
+			return value, nil
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		d = parsed
+	case json.Number:
+		switch o.durationFormat {
+		case DurationFormatMilliseconds:
+			milliseconds, err := v.Int64()
+			if err != nil {
+				return nil, err
+			}
+			d = time.Duration(milliseconds) * time.Millisecond
+		case DurationFormatSeconds:
+			seconds, err := v.Float64()
+			if err != nil {
+				return nil, err
+			}
+			d = time.Duration(seconds * float64(time.Second))
+		default:
+			return value, nil
+		}
+	default:
+		return value, nil
+	}
+	return int64(d), nil
+}
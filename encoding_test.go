@@ -0,0 +1,143 @@
+package there
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+type encodingEvent struct {
+	Name     string        `json:"name"`
+	At       time.Time     `json:"at"`
+	Timeout  time.Duration `json:"timeout"`
+	Optional *time.Time    `json:"optional,omitempty"`
+	Tags     map[string]time.Duration
+}
+
+func TestJsonEncodingFormats(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := encodingEvent{Name: "deploy", At: at, Timeout: 1500 * time.Millisecond, Tags: map[string]time.Duration{"a": time.Second}}
+
+	tests := []struct {
+		time     TimeFormat
+		duration DurationFormat
+		expected string
+	}{
+		{TimeFormatRFC3339, DurationFormatNanoseconds, `{"name":"deploy","at":"2024-01-02T03:04:05Z","timeout":1500000000,"Tags":{"a":1000000000}}`},
+		{TimeFormatUnix, DurationFormatSeconds, `{"name":"deploy","at":1704164645,"timeout":1.5,"Tags":{"a":1}}`},
+		{TimeFormatUnixMilli, DurationFormatMilliseconds, `{"name":"deploy","at":1704164645000,"timeout":1500,"Tags":{"a":1000}}`},
+		{TimeFormatRFC3339, DurationFormatString, `{"name":"deploy","at":"2024-01-02T03:04:05Z","timeout":"1.5s","Tags":{"a":"1s"}}`},
+	}
+
+	for _, test := range tests {
+		router := NewRouter()
+		router.Configuration.JsonTimeFormat = test.time
+		router.Configuration.JsonDurationFormat = test.duration
+		router.Get("/", func(request Request) Response {
+			return Json(status.OK, event)
+		})
+		router.Post("/", func(request Request) Response {
+			var parsed encodingEvent
+			if err := request.Body.BindJson(&parsed); err != nil {
+				return Error(status.BadRequest, err)
+			}
+			return Json(status.OK, parsed)
+		})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/", nil))
+		if recorder.Body.String() != test.expected {
+			t.Errorf("expected %v, got %v", test.expected, recorder.Body.String())
+		}
+
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodPost, "/", strings.NewReader(test.expected)))
+		if recorder.Code != status.OK || recorder.Body.String() != test.expected {
+			t.Errorf("round trip failed with %v: %v", recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestJsonEncodingFormatsBindingErrors(t *testing.T) {
+	options := jsonOptions{timeFormat: TimeFormatUnixMilli, durationFormat: DurationFormatString}
+	body := "{\n  \"name\": \"deploy\",\n  \"at\": 1704164645000,\n  \"timeout\": \"1.5s\",\n  \"Tags\": {\"a\": true}\n}"
+
+	var event encodingEvent
+	err := newBindingError([]byte(body), options.unmarshal([]byte(body), &event))
+	var bindingError *BindingError
+	if !errors.As(err, &bindingError) || bindingError.Field != "Tags.a" || bindingError.Line != 5 || bindingError.Column != 21 {
+		t.Errorf("expected the position of the invalid field in the body, got %+v", err)
+	}
+
+	for _, body := range []string{`{"at":1} garbage`, `{"at":1}{}`} {
+		if err := options.unmarshal([]byte(body), &event); err == nil {
+			t.Errorf("%v: expected trailing data to be rejected", body)
+		}
+	}
+	if err := options.unmarshal([]byte(`{"at":1,`), &event); err == nil || !errors.As(err, new(*json.SyntaxError)) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+}
+
+func TestJsonEncodingEmbeddedFields(t *testing.T) {
+	type A struct {
+		Name string
+		Id   int `json:"id"`
+	}
+	type B struct {
+		Name string
+		Id   int
+	}
+	type C struct {
+		Title string `json:"name"`
+	}
+	data := struct {
+		A
+		B
+		When time.Duration `json:"when"`
+	}{A{"a", 1}, B{"b", 2}, time.Second}
+	tagged := struct {
+		B
+		C
+	}{B{"b", 2}, C{"c"}}
+
+	options := jsonOptions{durationFormat: DurationFormatString}
+	for _, v := range []any{data, tagged} {
+		expected, _ := json.Marshal(v)
+		expected = []byte(strings.Replace(string(expected), `"when":1000000000`, `"when":"1s"`, 1))
+		actual, err := options.marshal(v)
+		if err != nil || string(actual) != string(expected) {
+			t.Errorf("expected %s like encoding/json, got %s %v", expected, actual, err)
+		}
+	}
+}
+
+func TestCanonicalJson(t *testing.T) {
+	type inner struct {
+		Zeta  string  `json:"zeta"`
+		Alpha float64 `json:"alpha"`
+	}
+	data := struct {
+		Name  string         `json:"name"`
+		Inner inner          `json:"inner"`
+		Map   map[string]int `json:"map"`
+		Html  string         `json:"html"`
+	}{"x", inner{"<z>", 0.1}, map[string]int{"b": 2, "a": 1}, "a&b"}
+
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		return CanonicalJson(status.OK, data)
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/", nil))
+
+	expected := `{"html":"a&b","inner":{"alpha":0.1,"zeta":"<z>"},"map":{"a":1,"b":2},"name":"x"}`
+	if recorder.Body.String() != expected {
+		t.Errorf("expected %v, got %v", expected, recorder.Body.String())
+	}
+}
@@ -0,0 +1,54 @@
+package there
+
+import (
+	"context"
+)
+
+// Envelope is the shape json responses are wrapped in, if envelopes are
+// enabled with the Envelope of the RouterConfiguration or WithEnvelope of a
+// group. Json and CanonicalJson responses become the Data, Paginated adds the
+// pagination to the Meta and Error responses become the Errors:
+//
+//	{"data":{"name":"John"}}
+//	{"data":[...],"meta":{"pagination":{"page":2,"limit":20,"total":93,"pages":5}}}
+//	{"data":null,"errors":[{"message":"user not found"}]}
+type Envelope struct {
+	Data   any             `json:"data"`
+	Meta   map[string]any  `json:"meta,omitempty"`
+	Errors []EnvelopeError `json:"errors,omitempty"`
+}
+
+// EnvelopeError is an error of an Envelope
+type EnvelopeError struct {
+	Message string `json:"message"`
+}
+
+// WithEnvelope enables or disables envelopes for the routes registered on the
+// group afterwards, and on the groups created from it, regardless of the
+// Envelope of the RouterConfiguration.
+//
+//	api := router.Group("/api").WithEnvelope(true)
+//	api.Get("/users", GetUsers)
+func (group *RouteGroup) WithEnvelope(enabled bool) *RouteGroup {
+	group.envelope = &enabled
+	return group
+}
+
+type envelopeKey struct{}
+
+// withEnvelope marks the request, if its responses are wrapped in envelopes
+func withEnvelope(request *Request, router *Router, envelope *bool) {
+	enabled := router.Configuration.Envelope
+	if envelope != nil {
+		enabled = *envelope
+	}
+	if enabled {
+		request.WithContext(context.WithValue(request.Context(), envelopeKey{}, true))
+	}
+}
+
+// enveloped reports whether the responses to the request are wrapped in envelopes
+func enveloped(ctx context.Context) bool {
+	enabled, _ := ctx.Value(envelopeKey{}).(bool)
+	return enabled
+}
@@ -0,0 +1,57 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestEnvelope(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.Envelope = true
+	users := []string{"a", "b", "c", "d", "e"}
+	router.Get("/users", func(request Request) Response {
+		pagination := request.Pagination(2, 10)
+		end := min(pagination.Offset()+pagination.Limit, len(users))
+		return Paginated(status.OK, users[pagination.Offset():end], pagination, len(users))
+	})
+	router.Get("/users/{id}", func(request Request) Response {
+		if request.RouteParams.Get("id") != "a" {
+			return Error(status.NotFound, errors.New("user not found"))
+		}
+		return Json(status.OK, map[string]string{"name": "a"})
+	})
+	router.Group("/raw").WithEnvelope(false).Get("/user", func(request Request) Response {
+		return Json(status.OK, map[string]string{"name": "a"})
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	tests := map[string]string{
+		"/users/a":               `{"data":{"name":"a"}}`,
+		"/users/b":               `{"data":null,"errors":[{"message":"user not found"}]}`,
+		"/users?page=2":          `{"data":["c","d"],"meta":{"pagination":{"page":2,"limit":2,"total":5,"pages":3}}}`,
+		"/users?page=0&limit=50": `{"data":["a","b","c","d","e"],"meta":{"pagination":{"page":1,"limit":10,"total":5,"pages":1}}}`,
+		"/raw/user":              `{"name":"a"}`,
+	}
+	for route, expected := range tests {
+		if body := serve(route).Body.String(); body != expected {
+			t.Errorf("%v: expected %v, got %v", route, expected, body)
+		}
+	}
+	if total := serve("/users").Header().Get(header.ResponseXTotalCount); total != "5" {
+		t.Errorf("expected a total of 5, got %v", total)
+	}
+
+	router.Configuration.Envelope = false
+	if body := serve("/users?page=3").Body.String(); body != `["e"]` {
+		t.Errorf("expected no envelope, got %v", body)
+	}
+}
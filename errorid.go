@@ -0,0 +1,68 @@
+package there
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// TraceIdentifier is implemented by Spans, that know the id of their trace.
+// Its id is included in 5xx Error responses, unless Request.SetRequestId set
+// another one.
+type TraceIdentifier interface {
+	TraceId() string
+}
+
+type requestIdKey struct{}
+
+// SetRequestId stores the id of the request, which 5xx Error responses include,
+// so clients can quote it to support. The RequestId middleware of the
+// middlewares package sets it.
+func (r *Request) SetRequestId(id string) {
+	r.WithContext(context.WithValue(r.Context(), requestIdKey{}, id))
+}
+
+// RequestId returns the id set with SetRequestId, or else the trace id of the
+// Span, if it is a TraceIdentifier. Empty, if neither is available.
+func (r *Request) RequestId() string {
+	return requestIdOf(r.Request)
+}
+
+func requestIdOf(request *http.Request) string {
+	if id, ok := request.Context().Value(requestIdKey{}).(string); ok && id != "" {
+		return id
+	}
+	if identifier, ok := spanOf(request).(TraceIdentifier); ok {
+		return identifier.TraceId()
+	}
+	return ""
+}
+
+// errorBody is the json body of an Error response with a request id
+type errorBody struct {
+	Error     string `json:"error"`
+	RequestId string `json:"requestId,omitempty"`
+}
+
+// internal adds the request id to a 5xx error and hides its message, if the
+// RouterConfiguration does not expose internal errors
+func (e errorResponse) internal(r *http.Request) errorResponse {
+	if e.code < status.InternalServerError {
+		return e
+	}
+	changed := false
+	if router := routerOf(r); router != nil && router.Configuration.HideInternalErrors {
+		e.message = status.Text(e.code)
+		changed = true
+	}
+	if e.requestId = requestIdOf(r); e.requestId != "" {
+		changed = true
+	}
+	if changed {
+		if data, err := encodeJson(errorBody{Error: e.message, RequestId: e.requestId}, false); err == nil {
+			e.data = data
+		}
+	}
+	return e
+}
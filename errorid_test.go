@@ -0,0 +1,95 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+type identifiedSpan struct {
+	recordedSpan
+}
+
+func (s *identifiedSpan) TraceId() string {
+	return "4bf92f3577b34da6a3ce929d0e0e4736"
+}
+
+type identifiedTracer struct{}
+
+func (identifiedTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, &identifiedSpan{recordedSpan{attributes: map[string]any{}}}
+}
+
+func TestErrorRequestId(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(request Request, next Response) Response {
+		if id := request.Request.Header.Get(header.XRequestId); id != "" {
+			request.SetRequestId(id)
+		}
+		return next
+	})
+	router.Get("/internal", func(request Request) Response {
+		return Error(status.InternalServerError, errors.New("database: connection refused"))
+	})
+	router.Get("/missing", func(request Request) Response {
+		return Error(status.NotFound, errors.New("user not found"))
+	})
+
+	serve := func(path, accept string) string {
+		request := httptest.NewRequest(MethodGet, path, nil)
+		request.Header.Set(header.XRequestId, "42")
+		request.Header.Set(header.RequestAccept, accept)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	if body := serve("/internal", ""); body != `{"error":"database: connection refused","requestId":"42"}` {
+		t.Errorf("unexpected body %v", body)
+	}
+	if body := serve("/missing", ""); body != `{"error":"user not found"}` {
+		t.Errorf("expected no request id for 4xx errors, got %v", body)
+	}
+
+	router.Configuration.HideInternalErrors = true
+	if body := serve("/internal", ""); body != `{"error":"Internal Server Error","requestId":"42"}` {
+		t.Errorf("expected the internal error to be hidden, got %v", body)
+	}
+	if body := serve("/missing", ""); body != `{"error":"user not found"}` {
+		t.Errorf("expected 4xx errors to be exposed, got %v", body)
+	}
+
+	router.Configuration.ErrorFormats = ErrorFormats{ContentTypes: []string{
+		ContentTypeApplicationProblemPlusJson, ContentTypeTextHtml, ContentTypeTextPlain,
+	}}
+	for accept, expected := range map[string]string{
+		ContentTypeApplicationProblemPlusJson: `"requestId":"42"`,
+		ContentTypeTextHtml:                   "Request id: <code>42</code>",
+		ContentTypeTextPlain:                  "Internal Server Error\nRequest id: 42",
+	} {
+		if body := serve("/internal", accept); !strings.Contains(body, expected) || strings.Contains(body, "database") {
+			t.Errorf("%v: expected %q, got %v", accept, expected, body)
+		}
+	}
+	router.Configuration.ErrorFormats = ErrorFormats{}
+
+	router.Configuration.Envelope = true
+	if body := serve("/internal", ""); body != `{"data":null,"meta":{"requestId":"42"},"errors":[{"message":"Internal Server Error"}]}` {
+		t.Errorf("unexpected envelope %v", body)
+	}
+}
+
+func TestErrorTraceId(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.Tracer = identifiedTracer{}
+	router.Get("/", func(request Request) Response {
+		return Error(status.BadGateway, errors.New("upstream failed"))
+	})
+
+	assertBodyResponse(t, router, MethodGet, "/", `{"error":"upstream failed","requestId":"4bf92f3577b34da6a3ce929d0e0e4736"}`)
+}
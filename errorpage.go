@@ -0,0 +1,145 @@
+package there
+
+import (
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorFormats configures the content types, which Error responses are
+// negotiated between, according to the Accept header of the request, so the
+// same errors can be shown to browsers and API clients.
+//
+//	router.Configuration.ErrorFormats = there.ErrorFormats{
+//		ContentTypes: []string{there.ContentTypeApplicationProblemPlusJson, there.ContentTypeTextHtml},
+//		HtmlTemplate: "templates/error.html",
+//	}
+type ErrorFormats struct {
+	// ContentTypes are the formats errors are rendered in, besides json. Supported
+	// are ContentTypeApplicationProblemPlusJson (RFC 9457), ContentTypeApplicationXml,
+	// ContentTypeTextHtml and ContentTypeTextPlain. Empty disables the negotiation,
+	// so errors are always json.
+	ContentTypes []string
+	// Default is the format for clients, that accept none of the formats.
+	// Defaults to json.
+	Default string
+	// HtmlTemplate is the file of the html error page, which is rendered with
+	// an ErrorPage. If empty, a minimal page is rendered.
+	HtmlTemplate string
+}
+
+// ErrorPage is the data the HtmlTemplate of the ErrorFormats is rendered with
+type ErrorPage struct {
+	Status  int
+	Title   string
+	Message string
+	Method  string
+	Path    string
+	// RequestId identifies the request for support, if it is a 5xx error. See Request.RequestId.
+	RequestId string
+}
+
+// problem is the body of an application/problem+json response
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// RequestId is an extension member
+	RequestId string `json:"requestId,omitempty"`
+}
+
+// xmlError is the body of an xml error
+type xmlError struct {
+	XMLName   struct{} `xml:"Error"`
+	Message   string   `xml:"Message"`
+	RequestId string   `xml:"RequestId,omitempty"`
+}
+
+// negotiateError renders the error in the format the client prefers. It
+// reports false, if json should be served.
+func negotiateError(rw http.ResponseWriter, r *http.Request, e errorResponse) bool {
+	router := routerOf(r)
+	if router == nil || len(router.Configuration.ErrorFormats.ContentTypes) == 0 {
+		return false
+	}
+	formats := router.Configuration.ErrorFormats
+
+	offers := []string{ContentTypeApplicationJson}
+	for _, contentType := range formats.ContentTypes {
+		if contentType != ContentTypeApplicationJson {
+			offers = append(offers, contentType)
+		}
+	}
+	fallback := formats.Default
+	if fallback == "" {
+		fallback = ContentTypeApplicationJson
+	}
+	rw.Header().Add(header.ResponseVary, header.RequestAccept)
+	code, message := e.code, e.message
+	contentType := NegotiateContentType(r.Header[header.RequestAccept], offers, fallback)
+
+	switch contentType {
+	case ContentTypeApplicationProblemPlusJson:
+		data, err := encodeJson(problem{
+			Type:      "about:blank",
+			Title:     status.Text(code),
+			Status:    code,
+			Detail:    message,
+			Instance:  r.URL.Path,
+			RequestId: e.requestId,
+		}, false)
+		if err != nil {
+			return false
+		}
+		rw.Header().Set(header.ContentType, ContentTypeApplicationProblemPlusJson)
+		jsonResponse{code: code, data: data}.ServeHTTP(rw, r)
+	case ContentTypeApplicationXml:
+		handler, ok := AutoHandlers[ContentTypeApplicationXml]
+		if !ok {
+			return false
+		}
+		handler(code, xmlError{Message: message, RequestId: e.requestId}).ServeHTTP(rw, r)
+	case ContentTypeTextHtml:
+		page := ErrorPage{Status: code, Title: status.Text(code), Message: message, Method: r.Method, Path: r.URL.Path, RequestId: e.requestId}
+		data := defaultErrorPage(page)
+		if formats.HtmlTemplate != "" {
+			// the template is not rendered with Html, as its errors would end up here again
+			content, err := parseTemplate(formats.HtmlTemplate, page, template.FuncMap{})
+			if err != nil {
+				log.Printf("errorResponse: rendering the error page failed: %v", err)
+			} else {
+				data = []byte(*content)
+			}
+		}
+		htmlResponse{code: code, data: data}.ServeHTTP(rw, r)
+	case ContentTypeTextPlain:
+		rw.Header().Set(header.ContentType, ContentTypeTextPlain)
+		rw.WriteHeader(code)
+		if e.requestId != "" {
+			message += "\nRequest id: " + e.requestId
+		}
+		if _, err := rw.Write([]byte(message)); err != nil {
+			log.Printf("errorResponse: ServeHttp write failed: %v", err)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func defaultErrorPage(page ErrorPage) []byte {
+	title := html.EscapeString(strconv.Itoa(page.Status) + " " + page.Title)
+	requestId := ""
+	if page.RequestId != "" {
+		requestId = "<p>Request id: <code>" + html.EscapeString(page.RequestId) + "</code></p>"
+	}
+	return []byte("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>" + title + "</title></head>" +
+		"<body><h1>" + title + "</h1><p>" + html.EscapeString(page.Message) + "</p>" + requestId + "</body></html>")
+}
@@ -0,0 +1,68 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestErrorFormats(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.ErrorFormats = ErrorFormats{
+		ContentTypes: []string{ContentTypeApplicationProblemPlusJson, ContentTypeApplicationXml, ContentTypeTextHtml, ContentTypeTextPlain},
+	}
+	router.Get("/users/{id}", func(request Request) Response {
+		return Error(status.NotFound, errors.New("user <1> not found"))
+	})
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, "/users/1", nil)
+		if accept != "" {
+			request.Header.Set(header.RequestAccept, accept)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", ContentTypeApplicationJson, `{"error":"user <1> not found"}`},
+		{ContentTypeApplicationProblemPlusJson, ContentTypeApplicationProblemPlusJson,
+			`{"type":"about:blank","title":"Not Found","status":404,"detail":"user <1> not found","instance":"/users/1"}`},
+		{"text/plain", ContentTypeTextPlain, `user <1> not found`},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", ContentTypeTextHtml, `<h1>404 Not Found</h1><p>user &lt;1&gt; not found</p>`},
+		{"image/png", ContentTypeApplicationJson, `{"error":"user <1> not found"}`},
+	}
+	for _, test := range tests {
+		recorder := serve(test.accept)
+		if recorder.Code != status.NotFound {
+			t.Errorf("%v: expected 404, got %v", test.accept, recorder.Code)
+		}
+		if contentType := recorder.Header().Get(header.ContentType); !strings.HasPrefix(contentType, test.contentType) {
+			t.Errorf("%v: expected %v, got %v", test.accept, test.contentType, contentType)
+		}
+		if !strings.Contains(recorder.Body.String(), test.body) {
+			t.Errorf("%v: unexpected body %v", test.accept, recorder.Body.String())
+		}
+	}
+
+	template := filepath.Join(t.TempDir(), "error.html")
+	if err := os.WriteFile(template, []byte(`<p>{{.Status}} at {{.Path}}: {{.Message}}</p>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router.Configuration.ErrorFormats.HtmlTemplate = template
+	router.Configuration.ErrorFormats.Default = ContentTypeTextHtml
+	if body := serve("image/png").Body.String(); body != "<p>404 at /users/1: user &lt;1&gt; not found</p>" {
+		t.Errorf("unexpected error page %v", body)
+	}
+}
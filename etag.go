@@ -0,0 +1,132 @@
+package there
+
+import (
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ETagConfiguration controls how ETag generates entity tags
+type ETagConfiguration struct {
+	// Weak marks the entity tags as weak (W/"..."), meaning that the responses
+	// are semantically equivalent but not necessarily byte-for-byte identical
+	Weak bool
+	// Hash creates the hash the response body is digested with. Defaults to the
+	// 64-bit FNV-1a hash, which is fast but not collision resistant.
+	Hash func() hash.Hash
+}
+
+// ETag wraps around your current Response, generates an entity tag from its body
+// and answers with StatusNotModified, if the tag matches the If-None-Match header
+// of the request. Only successful responses to GET and HEAD requests are tagged.
+//
+// If the wrapped Response already set an ETag header, for example with Versioned,
+// the body is not hashed and the given tag is used instead.
+//
+//	func ExampleETagGet(request there.Request) there.Response {
+//		return there.ETag(there.Json(status.OK, user), there.ETagConfiguration{Weak: true})
+//	}
+func ETag(response Response, configuration ...ETagConfiguration) Response {
+	config := ETagConfiguration{}
+	if len(configuration) >= 1 {
+		config = configuration[0]
+	}
+	if config.Hash == nil {
+		config.Hash = func() hash.Hash {
+			return fnv.New64a()
+		}
+	}
+	return &etagResponse{config: config, response: response}
+}
+
+type etagResponse struct {
+	config   ETagConfiguration
+	response Response
+}
+
+func (e etagResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != MethodGet && r.Method != MethodHead {
+		e.response.ServeHTTP(rw, r)
+		return
+	}
+
+	writer := newBufferedResponseWriter(rw, r)
+	e.response.ServeHTTP(writer, r)
+
+	code := writer.statusCode()
+	if code >= 200 && code < 300 {
+		tag := rw.Header().Get(header.ResponseEtag)
+		if tag == "" {
+			h := e.config.Hash()
+			h.Write(writer.body.Bytes())
+			tag = "\"" + hex.EncodeToString(h.Sum(nil)) + "\""
+		}
+		if e.config.Weak && !strings.HasPrefix(tag, "W/") {
+			tag = "W/" + tag
+		}
+		rw.Header().Set(header.ResponseEtag, tag)
+
+		if etagMatches(r.Header.Get(header.RequestIfNoneMatch), tag) {
+			rw.WriteHeader(status.NotModified)
+			return
+		}
+	}
+
+	err := writer.flush()
+	if err != nil && !errors.Is(err, ErrorClientDisconnected) {
+		log.Printf("etagResponse: ServeHttp write failed: %v", err)
+	}
+}
+
+// Versioned wraps around your current Response and uses the given version as
+// its entity tag, instead of hashing the body. Use it, when the version of the
+// resource is already known, like the time a database row was updated at.
+//
+// If the version matches the If-None-Match header of the request, then the
+// wrapped Response is not served at all and StatusNotModified is returned.
+//
+//	func ExampleVersionedGet(request there.Request) there.Response {
+//		user := loadUser(request)
+//		return there.Versioned(user.UpdatedAt.Format(time.RFC3339Nano), there.Json(status.OK, user))
+//	}
+func Versioned(version string, response Response) Response {
+	return &versionedResponse{tag: "\"" + version + "\"", response: response}
+}
+
+type versionedResponse struct {
+	tag      string
+	response Response
+}
+
+func (v versionedResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set(header.ResponseEtag, v.tag)
+	if (r.Method == MethodGet || r.Method == MethodHead) &&
+		etagMatches(r.Header.Get(header.RequestIfNoneMatch), v.tag) {
+		rw.WriteHeader(status.NotModified)
+		return
+	}
+	v.response.ServeHTTP(rw, r)
+}
+
+// etagMatches reports whether the If-None-Match header value contains the tag.
+// As required for If-None-Match, the weak comparison is used.
+func etagMatches(ifNoneMatch, tag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
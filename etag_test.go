@@ -0,0 +1,66 @@
+package there
+
+import (
+	"crypto/sha256"
+	"hash"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func serveConditional(router *Router, route, ifNoneMatch string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(MethodGet, route, nil)
+	if ifNoneMatch != "" {
+		request.Header.Set(header.RequestIfNoneMatch, ifNoneMatch)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestETag(t *testing.T) {
+	router := NewRouter()
+	router.Get("/strong", func(request Request) Response {
+		return ETag(String(status.OK, "Hello there"))
+	})
+	router.Get("/weak", func(request Request) Response {
+		return ETag(String(status.OK, "Hello there"), ETagConfiguration{
+			Weak: true,
+			Hash: func() hash.Hash { return sha256.New() },
+		})
+	})
+	router.Get("/versioned", func(request Request) Response {
+		return ETag(Versioned("v1", String(status.OK, "Hello there")))
+	})
+
+	strong := serveConditional(router, "/strong", "").Header().Get(header.ResponseEtag)
+	if !strings.HasPrefix(strong, "\"") {
+		t.Fatalf("expected a strong etag, got %v", strong)
+	}
+	if code := serveConditional(router, "/strong", strong).Code; code != status.NotModified {
+		t.Errorf("expected %v, got %v", status.NotModified, code)
+	}
+
+	weak := serveConditional(router, "/weak", "").Header().Get(header.ResponseEtag)
+	if !strings.HasPrefix(weak, "W/\"") || len(weak) != 2+64+2 {
+		t.Fatalf("expected a weak sha256 etag, got %v", weak)
+	}
+	if code := serveConditional(router, "/weak", strong).Code; code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, code)
+	}
+
+	recorder := serveConditional(router, "/versioned", "")
+	if tag := recorder.Header().Get(header.ResponseEtag); tag != "\"v1\"" {
+		t.Errorf("expected the version as etag, got %v", tag)
+	}
+	if recorder.Body.String() != "Hello there" {
+		t.Errorf("unexpected body %v", recorder.Body.String())
+	}
+	recorder = serveConditional(router, "/versioned", "W/\"v0\", \"v1\"")
+	if recorder.Code != status.NotModified || recorder.Body.Len() != 0 {
+		t.Errorf("expected an empty %v, got %v %v", status.NotModified, recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,126 @@
+package there
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// SSEEvent is a single event of an EventStream
+type SSEEvent struct {
+	// ID is sent back by reconnecting clients in the Last-Event-ID header
+	ID string
+	// Event is the type of the event. Clients receive events without a type as "message".
+	Event string
+	// Data is the payload. Multiple lines are sent as multiple data fields.
+	Data string
+	// Retry tells the client how long to wait before reconnecting, if not zero
+	Retry time.Duration
+}
+
+// SSEWriter sends events to the client of an EventStream. Every event is
+// flushed immediately.
+type SSEWriter struct {
+	stream  *StreamWriter
+	request *http.Request
+}
+
+// Send writes the event and flushes it. The returned errors are the ones of
+// the StreamWriter, like ErrorClientDisconnected.
+func (w *SSEWriter) Send(event SSEEvent) error {
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + sseField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + sseField(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(event.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return w.write(b.String())
+}
+
+// Data sends an event without type, containing only the data
+func (w *SSEWriter) Data(data string) error {
+	return w.Send(SSEEvent{Data: data})
+}
+
+// Json sends an event of the given type with the data marshalled as json
+func (w *SSEWriter) Json(event string, data any) error {
+	encoded, err := jsonOptionsOf(w.request).marshal(data)
+	if err != nil {
+		return err
+	}
+	return w.Send(SSEEvent{Event: event, Data: string(encoded)})
+}
+
+// Comment sends a comment, which clients ignore. Use it as keep-alive, so
+// proxies do not close idle streams.
+func (w *SSEWriter) Comment(text string) error {
+	return w.write(": " + sseField(text) + "\n\n")
+}
+
+// LastEventID returns the ID of the last event, a reconnecting client received
+func (w *SSEWriter) LastEventID() string {
+	return w.request.Header.Get(header.RequestLastEventId)
+}
+
+// Done returns a channel, that is closed as soon as the client disconnects
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.stream.Done()
+}
+
+func (w *SSEWriter) write(data string) error {
+	if _, err := w.stream.Write([]byte(data)); err != nil {
+		return err
+	}
+	return w.stream.Flush()
+}
+
+// sseField removes line breaks, which would end the field early
+func sseField(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// EventStream streams Server-Sent Events to the client. The producer runs until
+// it returns, and should stop, once the client disconnects, which it can notice
+// with SSEWriter.Done or the errors of SSEWriter.Send.
+//
+//	router.Get("/time", func(request there.Request) there.Response {
+//		return there.EventStream(status.OK, func(events *there.SSEWriter) {
+//			ticker := time.NewTicker(time.Second)
+//			defer ticker.Stop()
+//			for {
+//				select {
+//				case <-events.Done():
+//					return
+//				case now := <-ticker.C:
+//					if events.Data(now.Format(time.RFC3339)) != nil {
+//						return
+//					}
+//				}
+//			}
+//		})
+//	})
+func EventStream(code int, producer func(events *SSEWriter)) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		stream := Flusher(rw, r)
+		stream.Header().Set(header.ContentType, ContentTypeTextEventDashStream)
+		stream.Header().Set(header.CacheControl, "no-cache")
+		// disables the buffering of reverse proxies, like nginx
+		stream.Header().Set("X-Accel-Buffering", "no")
+		stream.WriteHeader(code)
+		if stream.Flush() != nil {
+			return
+		}
+		producer(&SSEWriter{stream: stream, request: r})
+	})
+}
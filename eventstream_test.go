@@ -0,0 +1,79 @@
+package there
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestEventStream(t *testing.T) {
+	router := NewRouter()
+	router.Get("/events", func(request Request) Response {
+		return EventStream(status.OK, func(events *SSEWriter) {
+			_ = events.Send(SSEEvent{ID: events.LastEventID() + "1", Event: "greeting", Data: "hello\nworld", Retry: time.Second})
+			_ = events.Json("user", map[string]string{"name": "there"})
+			_ = events.Comment("keep-alive")
+		})
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(MethodGet, "/events", nil)
+	request.Header.Set(header.RequestLastEventId, "4")
+	router.ServeHTTP(recorder, request)
+
+	expected := "id: 41\nevent: greeting\nretry: 1000\ndata: hello\ndata: world\n\n" +
+		"event: user\ndata: {\"name\":\"there\"}\n\n" +
+		": keep-alive\n\n"
+	if recorder.Body.String() != expected {
+		t.Errorf("expected %q, got %q", expected, recorder.Body.String())
+	}
+	if value := recorder.Header().Get(header.ContentType); value != ContentTypeTextEventDashStream {
+		t.Errorf("unexpected content type %v", value)
+	}
+	if !recorder.Flushed {
+		t.Errorf("events must be flushed")
+	}
+}
+
+func TestEventStreamDisconnect(t *testing.T) {
+	stopped := make(chan struct{})
+	router := NewRouter()
+	router.Get("/events", func(request Request) Response {
+		return EventStream(status.OK, func(events *SSEWriter) {
+			defer close(stopped)
+			for {
+				select {
+				case <-events.Done():
+					return
+				case <-time.After(5 * time.Millisecond):
+					if events.Data("tick") != nil {
+						return
+					}
+				}
+			}
+		})
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	if err != nil || line != "data: tick\n" {
+		t.Fatalf("unexpected line %q %v", line, err)
+	}
+	response.Body.Close()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Errorf("the producer must stop after the client disconnected")
+	}
+}
@@ -10,14 +10,15 @@ import (
 func main() {
 	router := there.NewRouter()
 
-	router.Group("/example").
+	example := router.Group("/example")
+	example.
 		Get("/json", ExampleJsonGet).
 		Get("/jsonerror", ExampleJsonGet).
-		Get("/xml", ExampleXmlGet).
 		Get("/error", ExampleErrorGet).
 		Get("/message", ExampleMessageGet).
 		Get("/status", ExampleStatusGet).
 		Get("/string", ExampleStringGet)
+	registerXmlExamples(example)
 
 	err := router.Listen(8080)
 	if err != nil {
@@ -45,25 +46,6 @@ func ExampleJsonErrorGet(request there.Request) there.Response {
 	return resp
 }
 
-type User struct {
-	Firstname string `xml:"firstname"`
-	Surname   string `xml:"surname"`
-}
-
-func ExampleXmlErrorGet(request there.Request) there.Response {
-	user := User{"John", "Smith"}
-	resp, err := there.XmlError(status.OK, user)
-	if err != nil {
-		return there.Error(status.InternalServerError, fmt.Errorf("something went wrong: %v", err))
-	}
-	return resp
-}
-
-func ExampleXmlGet(request there.Request) there.Response {
-	user := User{"John", "Smith"}
-	return there.Xml(status.OK, user)
-}
-
 func ExampleErrorGet(request there.Request) there.Response {
 	if 1 != 2 {
 		return there.Error(status.InternalServerError, errors.New("something went wrong"))
@@ -0,0 +1,8 @@
+//go:build there_noxml
+
+package main
+
+import "github.com/gebes/there/v2"
+
+// registerXmlExamples registers nothing, as there is no xml support with the there_noxml tag
+func registerXmlExamples(group *there.RouteGroup) {}
@@ -0,0 +1,32 @@
+//go:build !there_noxml
+
+package main
+
+import (
+	"fmt"
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func registerXmlExamples(group *there.RouteGroup) {
+	group.Get("/xml", ExampleXmlGet)
+}
+
+type User struct {
+	Firstname string `xml:"firstname"`
+	Surname   string `xml:"surname"`
+}
+
+func ExampleXmlErrorGet(request there.Request) there.Response {
+	user := User{"John", "Smith"}
+	resp, err := there.XmlError(status.OK, user)
+	if err != nil {
+		return there.Error(status.InternalServerError, fmt.Errorf("something went wrong: %v", err))
+	}
+	return resp
+}
+
+func ExampleXmlGet(request there.Request) there.Response {
+	user := User{"John", "Smith"}
+	return there.Xml(status.OK, user)
+}
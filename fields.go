@@ -0,0 +1,240 @@
+package there
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorUnknownField is returned, if the fields query parameter selects a
+// field, that is not allowed for the route
+var ErrorUnknownField = errors.New("unknown field")
+
+// FieldsParameter is the query parameter clients select the fields with
+const FieldsParameter = "fields"
+
+// SparseFields lets clients select the fields of successful Json responses
+// with the fields query parameter, so endpoints do not need to build partial
+// DTOs. Fields are named like in the json output, which is by the json struct
+// tags, and nested fields are separated by dots. For arrays, the fields of
+// every element are selected, and with envelopes, the fields of the data.
+//
+// If allowed fields are given, selecting other fields fails with
+// StatusBadRequest. Allowing a field allows its nested fields as well. Without
+// the query parameter, the complete response is served.
+//
+//	router.Get("/users", GetUsers).SparseFields("id", "name", "address.city")
+//	// GET /users?fields=id,address.city
+//	// [{"id":1,"address":{"city":"Vienna"}}]
+func (group *RouteRouteGroupBuilder) SparseFields(allowed ...string) *RouteRouteGroupBuilder {
+	for _, field := range allowed {
+		group.assert(validField(field), "sparse field \""+field+"\" is malformed")
+	}
+	for _, endpoint := range group.endpoints() {
+		endpoint.sparseFields = &sparseFields{allowed: allowed}
+	}
+	return group
+}
+
+// sparseFields are the fields a route allows to be selected
+type sparseFields struct {
+	allowed []string
+}
+
+func (s *sparseFields) allows(field string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	for _, allowed := range s.allowed {
+		if field == allowed || strings.HasPrefix(field, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parse returns the fields selected with the query parameter
+func (s *sparseFields) parse(parameter string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(parameter, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !validField(field) || !s.allows(field) {
+			return nil, fmt.Errorf("%w %q", ErrorUnknownField, field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func validField(field string) bool {
+	for _, segment := range strings.Split(field, ".") {
+		if segment == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldSet is the tree of the selected fields. A nil subtree selects the
+// complete value of the field.
+type fieldSet map[string]fieldSet
+
+func newFieldSet(fields []string) fieldSet {
+	set := fieldSet{}
+	for _, field := range fields {
+		node := set
+		segments := strings.Split(field, ".")
+		for i, segment := range segments {
+			child, ok := node[segment]
+			if i == len(segments)-1 {
+				node[segment] = nil
+				break
+			}
+			if ok && child == nil {
+				// the parent is selected completely
+				break
+			}
+			if child == nil {
+				child = fieldSet{}
+				node[segment] = child
+			}
+			node = child
+		}
+	}
+	return set
+}
+
+// filter removes the fields, that are not selected, from the json document,
+// while keeping the order of the remaining ones
+func (set fieldSet) filter(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out bytes.Buffer
+	out.Grow(len(data))
+	if err := set.filterValue(decoder, &out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (set fieldSet) filterValue(decoder *json.Decoder, out *bytes.Buffer) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	switch token {
+	case json.Delim('{'):
+		out.WriteByte('{')
+		first := true
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			child, selected := set[key.(string)]
+			if !selected {
+				var skipped json.RawMessage
+				if err := decoder.Decode(&skipped); err != nil {
+					return err
+				}
+				continue
+			}
+			if !first {
+				out.WriteByte(',')
+			}
+			first = false
+			if err := writeJsonToken(out, key); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if child == nil {
+				var value json.RawMessage
+				if err := decoder.Decode(&value); err != nil {
+					return err
+				}
+				out.Write(value)
+			} else if err := child.filterValue(decoder, out); err != nil {
+				return err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		out.WriteByte('}')
+	case json.Delim('['):
+		out.WriteByte('[')
+		for first := true; decoder.More(); first = false {
+			if !first {
+				out.WriteByte(',')
+			}
+			if err := set.filterValue(decoder, out); err != nil {
+				return err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		out.WriteByte(']')
+	default:
+		return writeJsonToken(out, token)
+	}
+	return nil
+}
+
+func writeJsonToken(out *bytes.Buffer, token json.Token) error {
+	data, err := encodeJson(token, false)
+	if err != nil {
+		return err
+	}
+	out.Write(data)
+	return nil
+}
+
+type fieldsKey struct{}
+
+// selectedFields are the fields a request selected
+type selectedFields struct {
+	fields []string
+	set    fieldSet
+}
+
+// Fields returns the fields the client selected with the fields query
+// parameter on a route with SparseFields, like to load only the needed
+// columns. Empty, if the complete response is served.
+func (r *Request) Fields() []string {
+	if selected := selectedFieldsOf(r.Request.Context()); selected != nil {
+		return selected.fields
+	}
+	return nil
+}
+
+func selectedFieldsOf(ctx context.Context) *selectedFields {
+	selected, _ := ctx.Value(fieldsKey{}).(*selectedFields)
+	return selected
+}
+
+// withSparseFields stores the fields selected with the query parameter in the
+// request. It returns an Endpoint rejecting the request, if they are not allowed.
+func withSparseFields(request *Request, sparse *sparseFields) Endpoint {
+	if sparse == nil {
+		return nil
+	}
+	fields, err := sparse.parse(request.Request.URL.Query().Get(FieldsParameter))
+	if err != nil {
+		return func(request Request) Response {
+			return Error(status.BadRequest, err)
+		}
+	}
+	if len(fields) > 0 {
+		request.WithContext(context.WithValue(request.Context(), fieldsKey{}, &selectedFields{fields: fields, set: newFieldSet(fields)}))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+type fieldsAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
+
+type fieldsUser struct {
+	Id      int           `json:"id"`
+	Name    string        `json:"name"`
+	Email   string        `json:"email"`
+	Address fieldsAddress `json:"address"`
+}
+
+func TestSparseFields(t *testing.T) {
+	users := []fieldsUser{
+		{Id: 1, Name: "Hannes", Email: "hannes@example.com", Address: fieldsAddress{City: "Vienna", Street: "Ring"}},
+		{Id: 2, Name: "Anna", Email: "anna@example.com", Address: fieldsAddress{City: "Graz", Street: "Platz"}},
+	}
+	var selected []string
+	router := NewRouter()
+	router.Get("/users", func(request Request) Response {
+		selected = request.Fields()
+		return Json(status.OK, users)
+	}).SparseFields("id", "name", "address")
+	router.Get("/users/{id}", func(request Request) Response {
+		return Json(status.OK, users[0])
+	}).SparseFields()
+	router.Get("/missing", func(request Request) Response {
+		return Json(status.NotFound, users[0])
+	}).SparseFields()
+	router.Group("/enveloped").WithEnvelope(true).Get("/", func(request Request) Response {
+		return Paginated(status.OK, users[:1], Pagination{Page: 1, Limit: 10}, 1)
+	}).SparseFields()
+
+	for target, expected := range map[string]string{
+		"/users":                              `[{"id":1,"name":"Hannes","email":"hannes@example.com","address":{"city":"Vienna","street":"Ring"}},{"id":2,"name":"Anna","email":"anna@example.com","address":{"city":"Graz","street":"Platz"}}]`,
+		"/users?fields=name,id":               `[{"id":1,"name":"Hannes"},{"id":2,"name":"Anna"}]`,
+		"/users?fields=address.city":          `[{"address":{"city":"Vienna"}},{"address":{"city":"Graz"}}]`,
+		"/users?fields=address,address.city":  `[{"address":{"city":"Vienna","street":"Ring"}},{"address":{"city":"Graz","street":"Platz"}}]`,
+		"/users/1?fields=email,+address.city": `{"email":"hannes@example.com","address":{"city":"Vienna"}}`,
+		"/users/1?fields=":                    `{"id":1,"name":"Hannes","email":"hannes@example.com","address":{"city":"Vienna","street":"Ring"}}`,
+		"/missing?fields=id":                  `{"id":1,"name":"Hannes","email":"hannes@example.com","address":{"city":"Vienna","street":"Ring"}}`,
+		"/enveloped?fields=name":              `{"data":[{"name":"Hannes"}],"meta":{"pagination":{"page":1,"limit":10,"total":1,"pages":1}}}`,
+	} {
+		assertBodyResponse(t, router, MethodGet, target, expected)
+	}
+
+	assertBodyResponse(t, router, MethodGet, "/users?fields=id,address.city", `[{"id":1,"address":{"city":"Vienna"}},{"id":2,"address":{"city":"Graz"}}]`)
+	if len(selected) != 2 || selected[0] != "id" || selected[1] != "address.city" {
+		t.Errorf("unexpected selected fields %v", selected)
+	}
+
+	for _, target := range []string{"/users?fields=email", "/users?fields=id..name"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, target, nil))
+		if recorder.Code != status.BadRequest {
+			t.Errorf("%v: expected %v, got %v", target, status.BadRequest, recorder.Code)
+		}
+	}
+
+	if _, err := (&sparseFields{allowed: []string{"id"}}).parse("name"); !errors.Is(err, ErrorUnknownField) {
+		t.Errorf("expected %v, got %v", ErrorUnknownField, err)
+	}
+}
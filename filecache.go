@@ -0,0 +1,100 @@
+package there
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// fileValidators are the cache validators of a file on disk
+type fileValidators struct {
+	etag     string
+	modified time.Time
+	size     int64
+}
+
+// notModified reports whether the conditional headers of the request match the
+// validators. As defined in RFC 9110, If-Modified-Since is ignored, if the
+// request contains If-None-Match.
+func (v fileValidators) notModified(r *http.Request) bool {
+	if r.Method != MethodGet && r.Method != MethodHead {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get(header.RequestIfNoneMatch); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, v.etag)
+	}
+	since, err := http.ParseTime(r.Header.Get(header.RequestIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !v.modified.Truncate(time.Second).After(since)
+}
+
+// fileValidatorLimit is the amount of files a validatorCache holds the validators of
+const fileValidatorLimit = 4096
+
+// validatorCache holds the validators of served files, so their content is
+// only hashed again after they changed. Once it is full, an arbitrary entry
+// makes room for the next one, as the paths may depend on the requests.
+type validatorCache struct {
+	mutex   sync.Mutex
+	entries map[string]fileValidators
+}
+
+func (c *validatorCache) load(name string) (fileValidators, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	validators, ok := c.entries[name]
+	return validators, ok
+}
+
+func (c *validatorCache) store(name string, validators fileValidators) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]fileValidators{}
+	}
+	if _, ok := c.entries[name]; !ok && len(c.entries) >= fileValidatorLimit {
+		for evicted := range c.entries {
+			delete(c.entries, evicted)
+			break
+		}
+	}
+	c.entries[name] = validators
+}
+
+// fileValidatorCache holds the validators of the files served with File
+var fileValidatorCache validatorCache
+
+// fileValidatorsOf returns the validators of the file. If they had to be
+// computed, the content of the file is returned as well, so it is not read twice.
+func fileValidatorsOf(path string) (fileValidators, []byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileValidators{}, nil, err
+	}
+	if validators, ok := fileValidatorCache.load(path); ok {
+		if validators.modified.Equal(info.ModTime()) && validators.size == info.Size() {
+			return validators, nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileValidators{}, nil, err
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	validators := fileValidators{
+		etag:     "\"" + hex.EncodeToString(h.Sum(nil)) + "\"",
+		modified: info.ModTime(),
+		size:     info.Size(),
+	}
+	fileValidatorCache.store(path, validators)
+	return validators, data, nil
+}
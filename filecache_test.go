@@ -0,0 +1,77 @@
+package there
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestFileValidators(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.html")
+	if err := os.WriteFile(path, []byte("<h1>hello</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		return File(path)
+	})
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, "/", nil)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve(nil)
+	etag, modified := recorder.Header().Get(header.ResponseEtag), recorder.Header().Get(header.ResponseLastModified)
+	if recorder.Code != status.OK || recorder.Body.String() != "<h1>hello</h1>" || etag == "" || modified == "" {
+		t.Fatalf("unexpected response %v %v %v", recorder.Code, recorder.Header(), recorder.Body.String())
+	}
+	if recorder.Header().Get(header.ContentType) != ContentTypeTextHtml {
+		t.Errorf("unexpected content type %v", recorder.Header().Get(header.ContentType))
+	}
+
+	if recorder = serve(map[string]string{header.RequestIfNoneMatch: etag}); recorder.Code != status.NotModified || recorder.Body.Len() != 0 {
+		t.Errorf("expected %v, got %v", status.NotModified, recorder.Code)
+	}
+	if recorder = serve(map[string]string{header.RequestIfModifiedSince: modified}); recorder.Code != status.NotModified {
+		t.Errorf("expected %v, got %v", status.NotModified, recorder.Code)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.WriteFile(path, []byte("<h1>changed</h1>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(path, later, later)
+	recorder = serve(map[string]string{header.RequestIfNoneMatch: etag})
+	if recorder.Code != status.OK || recorder.Header().Get(header.ResponseEtag) == etag {
+		t.Errorf("expected the changed file, got %v %v", recorder.Code, recorder.Header())
+	}
+	if recorder = serve(map[string]string{header.RequestIfModifiedSince: later.Add(-time.Minute).UTC().Format(http.TimeFormat)}); recorder.Code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, recorder.Code)
+	}
+}
+
+func TestValidatorCacheLimit(t *testing.T) {
+	var cache validatorCache
+	for i := 0; i <= fileValidatorLimit; i++ {
+		cache.store(filepath.Join("files", strconv.Itoa(i)), fileValidators{size: int64(i)})
+	}
+	if len(cache.entries) != fileValidatorLimit {
+		t.Errorf("expected %v entries, got %v", fileValidatorLimit, len(cache.entries))
+	}
+	if validators, ok := cache.load(filepath.Join("files", strconv.Itoa(fileValidatorLimit))); !ok || validators.size != fileValidatorLimit {
+		t.Errorf("expected the latest entry to be kept")
+	}
+}
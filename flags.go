@@ -0,0 +1,132 @@
+package there
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FlagAttributes describe the caller, feature flags are evaluated for
+type FlagAttributes struct {
+	User   string
+	Tenant string
+	// Custom holds further attributes, like the country or plan of the caller
+	Custom map[string]string
+}
+
+// FlagProvider decides whether a feature flag is enabled. Implement it to plug
+// in a remote flag service.
+type FlagProvider interface {
+	Enabled(flag string, attributes FlagAttributes) bool
+}
+
+// FlagProviderFunc allows a plain function to be used as FlagProvider
+type FlagProviderFunc func(flag string, attributes FlagAttributes) bool
+
+func (f FlagProviderFunc) Enabled(flag string, attributes FlagAttributes) bool {
+	return f(flag, attributes)
+}
+
+// StaticFlags is a FlagProvider with fixed values. Unknown flags are disabled.
+type StaticFlags map[string]bool
+
+func (s StaticFlags) Enabled(flag string, _ FlagAttributes) bool {
+	return s[flag]
+}
+
+// EnvFlags is a FlagProvider reading the flags from environment variables. The
+// flag "new-checkout" with the prefix "FLAG_" is read from FLAG_NEW_CHECKOUT.
+// Values are parsed with strconv.ParseBool, anything else is disabled.
+func EnvFlags(prefix string) FlagProvider {
+	return FlagProviderFunc(func(flag string, _ FlagAttributes) bool {
+		name := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(flag))
+		enabled, _ := strconv.ParseBool(os.Getenv(name))
+		return enabled
+	})
+}
+
+// FlagSet evaluates the feature flags of a single request. Every flag is
+// evaluated at most once per request, so it stays consistent throughout it.
+type FlagSet struct {
+	provider   FlagProvider
+	attributes FlagAttributes
+	mutex      sync.Mutex
+	evaluated  map[string]bool
+}
+
+// Enabled reports whether the flag is enabled for the request. Without a
+// FlagProvider in the RouterConfiguration, all flags are disabled.
+func (f *FlagSet) Enabled(flag string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if enabled, ok := f.evaluated[flag]; ok {
+		return enabled
+	}
+	enabled := f.provider != nil && f.provider.Enabled(flag, f.attributes)
+	f.evaluated[flag] = enabled
+	return enabled
+}
+
+// Evaluated returns a copy of all flags evaluated so far
+func (f *FlagSet) Evaluated() map[string]bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	evaluated := make(map[string]bool, len(f.evaluated))
+	for flag, enabled := range f.evaluated {
+		evaluated[flag] = enabled
+	}
+	return evaluated
+}
+
+type flagSetKey struct{}
+
+// Flags returns the FlagSet of the request, which evaluates flags with the
+// FlagProvider and FlagAttributes of the RouterConfiguration.
+//
+//	if there.Flags(request).Enabled("new-checkout") {
+//		return CheckoutV2(request)
+//	}
+func Flags(request Request) *FlagSet {
+	return flagsOf(request.Request)
+}
+
+func flagsOf(r *http.Request) *FlagSet {
+	if flags, ok := r.Context().Value(flagSetKey{}).(*FlagSet); ok {
+		return flags
+	}
+	flags := &FlagSet{evaluated: map[string]bool{}}
+	if router := routerOf(r); router != nil {
+		flags.provider = router.Configuration.FlagProvider
+		if router.Configuration.FlagAttributes != nil {
+			flags.attributes = router.Configuration.FlagAttributes(NewHttpRequest(nil, r))
+		}
+	}
+	*r = *r.WithContext(context.WithValue(r.Context(), flagSetKey{}, flags))
+	return flags
+}
+
+// ExposeFlags evaluates the flags up front, so all of them are included in the
+// "flags" function of Html templates, for example to pass them to scripts:
+//
+//	router.Use(there.ExposeFlags("new-checkout", "dark-mode"))
+//
+//	<script>window.flags = {{ flags }}</script>
+//	{{ if flag "new-checkout" }}<a href="/checkout/v2">Checkout</a>{{ end }}
+//
+// The "flag" function is available in templates without this middleware too.
+func ExposeFlags(flags ...string) Middleware {
+	return func(request Request, next Response) Response {
+		// evaluated when served, so middlewares registered before, like the
+		// authentication, have already provided the attributes
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			set := flagsOf(r)
+			for _, flag := range flags {
+				set.Enabled(flag)
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package there
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestFlags(t *testing.T) {
+	evaluations := 0
+	router := NewRouter()
+	router.Configuration.FlagProvider = FlagProviderFunc(func(flag string, attributes FlagAttributes) bool {
+		evaluations++
+		return flag == "new-checkout" && attributes.Tenant == "beta"
+	})
+	router.Configuration.FlagAttributes = func(request Request) FlagAttributes {
+		tenant, _ := request.Headers.Get("Tenant")
+		return FlagAttributes{Tenant: tenant}
+	}
+
+	router.Get("/checkout", func(request Request) Response {
+		flags := Flags(request)
+		if flags.Enabled("new-checkout") && flags.Enabled("new-checkout") {
+			return String(status.OK, "v2")
+		}
+		return String(status.OK, "v1")
+	})
+
+	template := filepath.Join(t.TempDir(), "page.html")
+	_ = os.WriteFile(template, []byte(`{{ if flag "new-checkout" }}new{{ else }}old{{ end }} {{ len flags }}`), 0o644)
+	router.Get("/page", func(request Request) Response {
+		return Html(status.OK, template, nil)
+	}).With(ExposeFlags("dark-mode"))
+
+	serve := func(route, tenant string) string {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		request.Header.Set("Tenant", tenant)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	if body := serve("/checkout", "beta"); body != "v2" || evaluations != 1 {
+		t.Errorf("expected v2 with a single evaluation, got %v after %v evaluations", body, evaluations)
+	}
+	if body := serve("/checkout", "other"); body != "v1" {
+		t.Errorf("expected v1, got %v", body)
+	}
+	if body := serve("/page", "beta"); body != "new 2" {
+		t.Errorf("expected the flags in the template, got %v", body)
+	}
+
+	t.Setenv("FLAG_DARK_MODE", "true")
+	if !EnvFlags("FLAG_").Enabled("dark-mode", FlagAttributes{}) {
+		t.Errorf("expected the flag from the environment")
+	}
+}
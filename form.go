@@ -0,0 +1,171 @@
+package there
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// ErrorFileTooLarge is returned by FormFile, if the uploaded file exceeds the size limit
+var ErrorFileTooLarge = errors.New("uploaded file too large")
+
+// defaultFormMemory is the part of a multipart form kept in memory, the rest
+// of the files is stored in temporary files
+const defaultFormMemory = 32 << 20
+
+// UploadedFile is a file of a multipart/form-data body. Files larger than the
+// memory limit of the form are buffered on disk, see ParseForm. To handle large
+// uploads without buffering them at all, use MultipartStream instead.
+type UploadedFile struct {
+	// Name is the file name, that was sent by the client. Never use it as path
+	// without sanitizing it.
+	Name string
+	Size int64
+	// ContentType is the type the client declared
+	ContentType string
+	Header      *multipart.FileHeader
+}
+
+func newUploadedFile(fileHeader *multipart.FileHeader) *UploadedFile {
+	return &UploadedFile{
+		Name:        fileHeader.Filename,
+		Size:        fileHeader.Size,
+		ContentType: fileHeader.Header.Get(header.ContentType),
+		Header:      fileHeader,
+	}
+}
+
+// Open opens the content of the file
+func (f *UploadedFile) Open() (multipart.File, error) {
+	return f.Header.Open()
+}
+
+// SniffedType detects the type from the first 512 bytes of the content, with
+// the algorithm of http.DetectContentType. Do not trust ContentType alone, when
+// storing uploads.
+func (f *UploadedFile) SniffedType() (string, error) {
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(sniff[:n]), nil
+}
+
+// SaveTo copies the content of the file to the path. An existing file is overwritten.
+func (f *UploadedFile) SaveTo(path string) error {
+	file, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	destination, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(destination, file); err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+// ParseForm parses an application/x-www-form-urlencoded or multipart/form-data
+// body once. Of multipart bodies, up to maxMemory bytes are kept in memory,
+// and the remaining files are stored in temporary files, which are removed
+// after the request. The maxMemory defaults to 32 MiB. The size of the whole
+// body is limited by the MaxBodySize of the RouterConfiguration.
+func (r *Request) ParseForm(maxMemory ...int64) error {
+	if r.Request.PostForm != nil {
+		return nil
+	}
+	memory := int64(defaultFormMemory)
+	if len(maxMemory) >= 1 && maxMemory[0] > 0 {
+		memory = maxMemory[0]
+	}
+
+	var err error
+	if strings.HasPrefix(r.Request.Header.Get(header.ContentType), ContentTypeMultipartFormDashData) {
+		err = r.Request.ParseMultipartForm(memory)
+	} else {
+		err = r.Request.ParseForm()
+	}
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return bodyTooLargeError(maxBytesError.Limit)
+		}
+		return &BindingError{Message: "invalid form: " + err.Error(), err: err}
+	}
+	return nil
+}
+
+// FormFile returns the first file uploaded with the name. If maxSize is given
+// and the file is larger, then ErrorFileTooLarge is returned. If there is no
+// such file, then http.ErrMissingFile is returned.
+//
+//	avatar, err := request.FormFile("avatar", 5<<20)
+//	if err != nil {
+//		return there.Error(status.BadRequest, err)
+//	}
+//	err = avatar.SaveTo(filepath.Join(uploads, userId))
+func (r *Request) FormFile(name string, maxSize ...int64) (*UploadedFile, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	if r.Request.MultipartForm == nil || len(r.Request.MultipartForm.File[name]) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	file := newUploadedFile(r.Request.MultipartForm.File[name][0])
+	if len(maxSize) >= 1 && maxSize[0] > 0 && file.Size > maxSize[0] {
+		return nil, fmt.Errorf("%w: %q has %d bytes, the limit is %d bytes", ErrorFileTooLarge, file.Name, file.Size, maxSize[0])
+	}
+	return file, nil
+}
+
+// BindForm fills the struct dest points to with the fields of an
+// application/x-www-form-urlencoded or multipart/form-data body, as declared by
+// the struct tag `form:"name"`. The fields support the same types and the
+// ",required" option as Request.Bind, and uploaded files are bound to fields
+// of type *UploadedFile or []*UploadedFile.
+//
+//	type Signup struct {
+//		Email  string              `form:"email,required"`
+//		Age    int                 `form:"age"`
+//		Avatar *there.UploadedFile `form:"avatar"`
+//	}
+//
+//	var signup Signup
+//	if err := request.Body.BindForm(&signup); err != nil {
+//		return there.Json(status.BadRequest, err)
+//	}
+func (read BodyReader) BindForm(dest any) error {
+	request := Request{Request: read.request}
+	if err := request.ParseForm(); err != nil {
+		return err
+	}
+	return bindStruct(dest, []bindSource{{
+		tag:  "form",
+		name: "form field",
+		values: func(key string) []string {
+			return read.request.PostForm[key]
+		},
+		files: func(key string) []*multipart.FileHeader {
+			if read.request.MultipartForm == nil {
+				return nil
+			}
+			return read.request.MultipartForm.File[key]
+		},
+	}})
+}
@@ -0,0 +1,103 @@
+package there
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+type signupForm struct {
+	Email  string          `form:"email,required"`
+	Age    int             `form:"age"`
+	Avatar *UploadedFile   `form:"avatar"`
+	Photos []*UploadedFile `form:"photo"`
+}
+
+func TestBindFormUrlencoded(t *testing.T) {
+	router := NewRouter()
+	router.Post("/signup", func(request Request) Response {
+		var form signupForm
+		if err := request.Body.BindForm(&form); err != nil {
+			return Json(status.BadRequest, err)
+		}
+		return Json(status.OK, map[string]any{"email": form.Email, "age": form.Age, "avatar": form.Avatar != nil})
+	})
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodPost, "/signup", strings.NewReader(body))
+		request.Header.Set(header.ContentType, ContentTypeApplicationXDashWwwDashFormDashUrlencoded)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := serve("email=a%40b.c&age=30"); recorder.Body.String() != `{"age":30,"avatar":false,"email":"a@b.c"}` {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve("age=30"); recorder.Code != status.BadRequest || !strings.Contains(recorder.Body.String(), `form field \"email\" is required`) {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve("email=a&age=old"); recorder.Code != status.BadRequest {
+		t.Errorf("expected %v, got %v", status.BadRequest, recorder.Code)
+	}
+}
+
+func TestBindFormMultipart(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("email", "a@b.c")
+	avatar, _ := writer.CreateFormFile("avatar", "me.png")
+	_, _ = avatar.Write([]byte("\x89PNG\r\n\x1a\nrest of the image"))
+	for _, name := range []string{"1.txt", "2.txt"} {
+		photo, _ := writer.CreateFormFile("photo", name)
+		_, _ = photo.Write([]byte("photo " + name))
+	}
+	_ = writer.Close()
+
+	dir := t.TempDir()
+	router := NewRouter()
+	router.Post("/signup", func(request Request) Response {
+		var form signupForm
+		if err := request.Body.BindForm(&form); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		if form.Email != "a@b.c" || form.Avatar == nil || len(form.Photos) != 2 {
+			return Error(status.BadRequest, errors.New("form was not bound"))
+		}
+		sniffed, err := form.Avatar.SniffedType()
+		if err != nil || sniffed != "image/png" {
+			return Error(status.BadRequest, errors.New("unexpected type "+sniffed))
+		}
+		if err := form.Photos[1].SaveTo(filepath.Join(dir, "photo")); err != nil {
+			return Error(status.InternalServerError, err)
+		}
+		if _, err := request.FormFile("avatar", 8); !errors.Is(err, ErrorFileTooLarge) {
+			return Error(status.BadRequest, errors.New("size limit was not enforced"))
+		}
+		if _, err := request.FormFile("missing"); !errors.Is(err, http.ErrMissingFile) {
+			return Error(status.BadRequest, errors.New("missing file was found"))
+		}
+		return Status(status.OK)
+	})
+
+	request := httptest.NewRequest(MethodPost, "/signup", &body)
+	request.Header.Set(header.ContentType, writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.OK {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	saved, err := os.ReadFile(filepath.Join(dir, "photo"))
+	if err != nil || string(saved) != "photo 2.txt" {
+		t.Errorf("unexpected saved file %q %v", saved, err)
+	}
+}
@@ -0,0 +1,54 @@
+package there
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// ExternalPath returns the given absolute path as the client has to request it.
+// Use it for links and Location headers, so they stay correct when the router
+// runs behind a reverse proxy, that strips a path prefix, or is mounted under a
+// BasePath. The forwarded prefix is only added, if TrustForwardedPrefix is
+// enabled in the RouterConfiguration.
+//
+//	func CreateUser(request there.Request) there.Response {
+//		user := createUser(request)
+//		return there.Headers(map[string]string{
+//			header.ResponseLocation: request.ExternalPath("/user/" + user.Id),
+//		}, there.Json(status.Created, user))
+//	}
+func (r *Request) ExternalPath(p string) string {
+	return externalPrefix(r.Request) + p
+}
+
+// externalPrefix returns the prefix, that needs to be added to absolute paths
+// generated for the client. It consists of the forwarded prefix and the
+// BasePath of the router and never ends with a slash.
+func externalPrefix(request *http.Request) string {
+	router := routerOf(request)
+	if router == nil {
+		return ""
+	}
+	prefix := cleanBasePath(router.Configuration.BasePath)
+	if router.Configuration.TrustForwardedPrefix {
+		prefix = forwardedPrefix(request.Header.Get(header.RequestXForwardedPrefix)) + prefix
+	}
+	return prefix
+}
+
+// forwardedPrefix sanitizes the value of a X-Forwarded-Prefix header. Values
+// that are no absolute path are ignored, which also prevents generating
+// protocol relative URLs like //example.com.
+func forwardedPrefix(value string) string {
+	if !strings.HasPrefix(value, "/") {
+		return ""
+	}
+	value = path.Clean(value)
+	if value == "/" {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,60 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestForwardedPrefix(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "/", want: ""},
+		{value: "/service", want: "/service"},
+		{value: "/service/", want: "/service"},
+		{value: "//evil.com", want: "/evil.com"},
+		{value: "https://evil.com", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := forwardedPrefix(tt.value); got != tt.want {
+				t.Errorf("forwardedPrefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectWithForwardedPrefix(t *testing.T) {
+	router := NewRouter()
+	router.Get("/old", func(request Request) Response {
+		return Redirect(status.MovedPermanently, "/new")
+	})
+	router.Get("/link", func(request Request) Response {
+		return String(status.OK, request.ExternalPath("/new"))
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		request.Header.Set(header.RequestXForwardedPrefix, "/service")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if location := serve("/old").Header().Get(header.ResponseLocation); location != "/new" {
+		t.Errorf("prefix must not be trusted by default, got %v", location)
+	}
+
+	router.Configuration.TrustForwardedPrefix = true
+	if location := serve("/old").Header().Get(header.ResponseLocation); location != "/service/new" {
+		t.Errorf("unexpected location %v", location)
+	}
+	if link := serve("/link").Body.String(); link != "/service/new" {
+		t.Errorf("unexpected link %v", link)
+	}
+}
@@ -0,0 +1,405 @@
+package there
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// GrpcCode is the status code of a gRPC call
+type GrpcCode uint32
+
+const (
+	GrpcCodeOK GrpcCode = iota
+	GrpcCodeCanceled
+	GrpcCodeUnknown
+	GrpcCodeInvalidArgument
+	GrpcCodeDeadlineExceeded
+	GrpcCodeNotFound
+	GrpcCodeAlreadyExists
+	GrpcCodePermissionDenied
+	GrpcCodeResourceExhausted
+	GrpcCodeFailedPrecondition
+	GrpcCodeAborted
+	GrpcCodeOutOfRange
+	GrpcCodeUnimplemented
+	GrpcCodeInternal
+	GrpcCodeUnavailable
+	GrpcCodeDataLoss
+	GrpcCodeUnauthenticated
+)
+
+// GrpcError is an error with a gRPC status code. Methods of GrpcWeb return it
+// to choose the code the client receives, other errors are sent as
+// GrpcCodeUnknown.
+//
+//	return nil, &there.GrpcError{Code: there.GrpcCodeNotFound, Message: "user not found"}
+type GrpcError struct {
+	Code    GrpcCode
+	Message string
+}
+
+func (e *GrpcError) Error() string {
+	return fmt.Sprintf("grpc: code %d: %v", e.Code, e.Message)
+}
+
+// grpcErrorOf converts err into a GrpcError
+func grpcErrorOf(err error) *GrpcError {
+	var grpcError *GrpcError
+	switch {
+	case errors.As(err, &grpcError):
+		return grpcError
+	case errors.Is(err, context.DeadlineExceeded):
+		return &GrpcError{Code: GrpcCodeDeadlineExceeded, Message: err.Error()}
+	case errors.Is(err, context.Canceled):
+		return &GrpcError{Code: GrpcCodeCanceled, Message: err.Error()}
+	default:
+		return &GrpcError{Code: GrpcCodeUnknown, Message: err.Error()}
+	}
+}
+
+const (
+	grpcFrameData    byte = 0x00
+	grpcFrameTrailer byte = 0x80
+	// grpcFrameCompressed flags frames, whose payload is compressed
+	grpcFrameCompressed byte = 0x01
+)
+
+// GrpcWeb is an Endpoint serving a unary gRPC method to gRPC-web clients, like
+// browsers using grpc-web or connect-web. The messages are unmarshalled and
+// marshalled with the serializer, like one wrapping proto.Unmarshal and
+// proto.Marshal. The route is the full method name.
+//
+//	protobuf := there.SerializerFuncs{
+//		MarshalFunc:   func(v any) ([]byte, error) { return proto.Marshal(v.(proto.Message)) },
+//		UnmarshalFunc: func(data []byte, v any) error { return proto.Unmarshal(data, v.(proto.Message)) },
+//	}
+//	router.Post("/helloworld.Greeter/SayHello", there.GrpcWeb(protobuf, SayHello))
+//
+// Both the binary and the base64 text mode are supported. The status is sent
+// in the trailers, which browsers only read, if Grpc-Status and Grpc-Message
+// are exposed by the CORS configuration. Requests, that are no gRPC-web
+// requests, get a StatusUnsupportedMediaType response.
+func GrpcWeb[Req, Res any](serializer Serializer, method func(request Request, in *Req) (*Res, error)) Endpoint {
+	return func(request Request) Response {
+		contentType, text, ok := parseGrpcWebContentType(request.Request.Header.Get(header.ContentType))
+		if !ok {
+			return Error(status.UnsupportedMediaType, errors.New("grpc-web: expected a content type of "+ContentTypeApplicationGrpcDashWeb))
+		}
+		response := grpcWebResponse{contentType: contentType, text: text}
+
+		body, err := request.Body.ToBytes()
+		if err == nil && text {
+			body, err = io.ReadAll(&grpcWebTextReader{source: bytes.NewReader(body)})
+		}
+		if err != nil {
+			response.err = &GrpcError{Code: GrpcCodeInternal, Message: "reading the request failed: " + err.Error()}
+			return response
+		}
+		flags, payload, err := readGrpcFrame(bytes.NewReader(body))
+		if err != nil {
+			response.err = &GrpcError{Code: GrpcCodeInternal, Message: err.Error()}
+			return response
+		}
+		if flags&grpcFrameCompressed != 0 {
+			response.err = &GrpcError{Code: GrpcCodeUnimplemented, Message: "compressed messages are not supported"}
+			return response
+		}
+
+		in := new(Req)
+		if err := serializer.Unmarshal(payload, in); err != nil {
+			response.err = &GrpcError{Code: GrpcCodeInternal, Message: "unmarshalling the request failed: " + err.Error()}
+			return response
+		}
+		out, err := method(request, in)
+		if err != nil {
+			response.err = grpcErrorOf(err)
+			return response
+		}
+		if out == nil {
+			out = new(Res)
+		}
+		response.message, err = serializer.Marshal(out)
+		if err != nil {
+			response.err = &GrpcError{Code: GrpcCodeInternal, Message: "marshalling the response failed: " + err.Error()}
+		}
+		return response
+	}
+}
+
+type grpcWebResponse struct {
+	contentType string
+	text        bool
+	message     []byte
+	err         *GrpcError
+}
+
+func (g grpcWebResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set(header.ContentType, g.contentType)
+	rw.WriteHeader(status.OK)
+	trailers := http.Header{}
+	if g.err != nil {
+		trailers.Set(header.ResponseGrpcStatus, strconv.Itoa(int(g.err.Code)))
+		trailers.Set(header.ResponseGrpcMessage, encodeGrpcMessage(g.err.Message))
+	} else {
+		writeGrpcWebFrame(rw, g.text, grpcFrameData, g.message)
+		trailers.Set(header.ResponseGrpcStatus, "0")
+	}
+	writeGrpcWebFrame(rw, g.text, grpcFrameTrailer, encodeGrpcTrailers(trailers))
+}
+
+// GrpcWebServer is an Endpoint translating gRPC-web requests for the server,
+// like a *grpc.Server, which serves gRPC over HTTP/2 with its ServeHTTP method.
+// The requests are passed on as gRPC requests, and the trailers of the
+// responses are framed into their bodies, so browsers can call the existing
+// services. Register it for the services, like with a wildcard route.
+//
+//	router.Post("/helloworld.Greeter/{method}", there.GrpcWebServer(grpcServer))
+//
+// Like with GrpcWeb, Grpc-Status and Grpc-Message have to be exposed by the
+// CORS configuration.
+func GrpcWebServer(server http.Handler) Endpoint {
+	return func(request Request) Response {
+		return grpcWebBridge{server: server}
+	}
+}
+
+type grpcWebBridge struct {
+	server http.Handler
+}
+
+func (g grpcWebBridge) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	contentType, text, ok := parseGrpcWebContentType(r.Header.Get(header.ContentType))
+	if !ok {
+		Error(status.UnsupportedMediaType, errors.New("grpc-web: expected a content type of "+ContentTypeApplicationGrpcDashWeb)).ServeHTTP(rw, r)
+		return
+	}
+	subtype := strings.TrimPrefix(strings.TrimPrefix(contentType, ContentTypeApplicationGrpcDashWebDashText), ContentTypeApplicationGrpcDashWeb)
+
+	grpcRequest := r.Clone(r.Context())
+	grpcRequest.Proto, grpcRequest.ProtoMajor, grpcRequest.ProtoMinor = "HTTP/2.0", 2, 0
+	grpcRequest.Header.Set(header.ContentType, ContentTypeApplicationGrpc+subtype)
+	grpcRequest.Header.Set("Te", "trailers")
+	grpcRequest.Header.Del(header.ContentLength)
+	grpcRequest.ContentLength = -1
+	if text {
+		grpcRequest.Body = struct {
+			io.Reader
+			io.Closer
+		}{&grpcWebTextReader{source: r.Body}, r.Body}
+	}
+
+	writer := &grpcWebWriter{ResponseWriter: rw, header: http.Header{}, contentType: contentType, text: text}
+	g.server.ServeHTTP(writer, grpcRequest)
+	writer.finish()
+}
+
+// grpcWebWriter frames the body of a gRPC response for gRPC-web and collects
+// the trailers, so they are written as trailer frame
+type grpcWebWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	contentType string
+	text        bool
+
+	wroteHeader bool
+	// declared are the trailers announced in the Trailer header
+	declared []string
+}
+
+func (w *grpcWebWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *grpcWebWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	for _, value := range w.header.Values(header.Trailer) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				w.declared = append(w.declared, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	destination := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		if key == header.Trailer || strings.HasPrefix(key, http.TrailerPrefix) || slices.Contains(w.declared, key) {
+			continue
+		}
+		destination[key] = values
+	}
+	destination.Set(header.ContentType, w.contentType)
+	destination.Del(header.ContentLength)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *grpcWebWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(status.OK)
+	}
+	if !w.text {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write([]byte(base64.StdEncoding.EncodeToString(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *grpcWebWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(status.OK)
+	}
+	if err := http.NewResponseController(w.ResponseWriter).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("grpcWebWriter: flush failed: %v", err)
+	}
+}
+
+func (w *grpcWebWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the trailer frame. If the server responded without a body, the
+// status may be in the headers instead.
+func (w *grpcWebWriter) finish() {
+	headersOnly := !w.wroteHeader
+	if headersOnly {
+		w.WriteHeader(status.OK)
+	}
+	trailers := http.Header{}
+	for key, values := range w.header {
+		if strings.HasPrefix(key, http.TrailerPrefix) {
+			trailers[http.CanonicalHeaderKey(strings.TrimPrefix(key, http.TrailerPrefix))] = values
+		}
+	}
+	for _, key := range w.declared {
+		if values, ok := w.header[key]; ok {
+			trailers[key] = values
+		}
+	}
+	if headersOnly && trailers.Get(header.ResponseGrpcStatus) == "" {
+		for _, key := range []string{header.ResponseGrpcStatus, header.ResponseGrpcMessage} {
+			if values, ok := w.header[key]; ok {
+				trailers[key] = values
+			}
+		}
+	}
+	writeGrpcWebFrame(w.ResponseWriter, w.text, grpcFrameTrailer, encodeGrpcTrailers(trailers))
+}
+
+// parseGrpcWebContentType returns the media type of a gRPC-web request, like
+// "application/grpc-web+proto", and whether its body is base64 encoded
+func parseGrpcWebContentType(value string) (contentType string, text bool, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return "", false, false
+	}
+	for _, candidate := range []string{ContentTypeApplicationGrpcDashWebDashText, ContentTypeApplicationGrpcDashWeb} {
+		if subtype, found := strings.CutPrefix(mediaType, candidate); found && (subtype == "" || subtype[0] == '+') {
+			return mediaType, candidate == ContentTypeApplicationGrpcDashWebDashText, true
+		}
+	}
+	return "", false, false
+}
+
+// readGrpcFrame reads a length-prefixed message
+func readGrpcFrame(r io.Reader) (flags byte, payload []byte, err error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return 0, nil, fmt.Errorf("reading the frame header failed: %v", err)
+	}
+	payload = make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("reading the frame failed: %v", err)
+	}
+	return prefix[0], payload, nil
+}
+
+func writeGrpcWebFrame(w io.Writer, text bool, flags byte, payload []byte) {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+	if text {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+	if _, err := w.Write(frame); err != nil {
+		log.Printf("grpc-web: writing the frame failed: %v", err)
+	}
+}
+
+// encodeGrpcTrailers encodes the trailers like HTTP/1 headers with lowercase
+// names, as the gRPC-web protocol requires
+func encodeGrpcTrailers(trailers http.Header) []byte {
+	keys := make([]string, 0, len(trailers))
+	for key := range trailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b bytes.Buffer
+	for _, key := range keys {
+		for _, value := range trailers[key] {
+			b.WriteString(strings.ToLower(key) + ":" + value + "\r\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// encodeGrpcMessage percent-encodes the message for the Grpc-Message trailer
+func encodeGrpcMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// grpcWebTextReader decodes a base64 body of the text mode. As every message
+// may be encoded and padded on its own, the body is decoded in groups of four
+// characters.
+type grpcWebTextReader struct {
+	source  io.Reader
+	quad    [4]byte
+	buffer  [3]byte
+	decoded []byte
+}
+
+func (t *grpcWebTextReader) Read(p []byte) (int, error) {
+	for len(t.decoded) == 0 {
+		if _, err := io.ReadFull(t.source, t.quad[:]); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return 0, errors.New("grpc-web: truncated base64 body")
+			}
+			return 0, err
+		}
+		n, err := base64.StdEncoding.Decode(t.buffer[:], t.quad[:])
+		if err != nil {
+			return 0, fmt.Errorf("grpc-web: %v", err)
+		}
+		t.decoded = t.buffer[:n]
+	}
+	n := copy(p, t.decoded)
+	t.decoded = t.decoded[n:]
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package there
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+type greetReply struct {
+	Message string `json:"message"`
+}
+
+var jsonSerializer = SerializerFuncs{MarshalFunc: json.Marshal, UnmarshalFunc: json.Unmarshal}
+
+func grpcWebFrame(flags byte, payload []byte) []byte {
+	var b bytes.Buffer
+	writeGrpcWebFrame(&b, false, flags, payload)
+	return b.Bytes()
+}
+
+// readGrpcWebFrames returns the message and the trailers of a gRPC-web response
+func readGrpcWebFrames(t *testing.T, body []byte) (message, trailers string) {
+	t.Helper()
+	reader := bytes.NewReader(body)
+	for reader.Len() > 0 {
+		flags, payload, err := readGrpcFrame(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flags&grpcFrameTrailer != 0 {
+			trailers = string(payload)
+		} else {
+			message = string(payload)
+		}
+	}
+	return message, trailers
+}
+
+func TestGrpcWeb(t *testing.T) {
+	router := NewRouter()
+	router.Post("/greeter.Greeter/Greet", GrpcWeb(jsonSerializer, func(request Request, in *greetRequest) (*greetReply, error) {
+		if in.Name == "" {
+			return nil, &GrpcError{Code: GrpcCodeInvalidArgument, Message: "name is required"}
+		}
+		return &greetReply{Message: "Hello " + in.Name}, nil
+	}))
+
+	serve := func(contentType string, body []byte) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodPost, "/greeter.Greeter/Greet", bytes.NewReader(body))
+		request.Header.Set(header.ContentType, contentType)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("application/grpc-web+json", grpcWebFrame(grpcFrameData, []byte(`{"name":"there"}`)))
+	if recorder.Code != status.OK || recorder.Header().Get(header.ContentType) != "application/grpc-web+json" {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Header())
+	}
+	message, trailers := readGrpcWebFrames(t, recorder.Body.Bytes())
+	if message != `{"message":"Hello there"}` || trailers != "grpc-status:0\r\n" {
+		t.Errorf("unexpected message %q and trailers %q", message, trailers)
+	}
+
+	// the text mode encodes the frames with base64
+	body := base64.StdEncoding.EncodeToString(grpcWebFrame(grpcFrameData, []byte(`{"name":""}`)))
+	recorder = serve(ContentTypeApplicationGrpcDashWebDashText, []byte(body))
+	decoded, err := io.ReadAll(&grpcWebTextReader{source: recorder.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, trailers = readGrpcWebFrames(t, decoded)
+	if message != "" || trailers != "grpc-message:name is required\r\ngrpc-status:3\r\n" {
+		t.Errorf("unexpected message %q and trailers %q", message, trailers)
+	}
+
+	if recorder := serve(ContentTypeApplicationJson, []byte(`{}`)); recorder.Code != status.UnsupportedMediaType {
+		t.Errorf("expected 415, got %v", recorder.Code)
+	}
+}
+
+func TestGrpcWebServer(t *testing.T) {
+	// server behaves like a gRPC server, which only accepts HTTP/2 requests
+	server := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Header.Get(header.ContentType) != "application/grpc+json" {
+			t.Errorf("unexpected request %v %v", r.Proto, r.Header)
+		}
+		_, payload, err := readGrpcFrame(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		rw.Header().Set(header.ContentType, "application/grpc+json")
+		rw.Header().Set(header.Trailer, "Grpc-Status")
+		rw.WriteHeader(status.OK)
+		rw.Write(grpcWebFrame(grpcFrameData, payload))
+		rw.Header().Set("Grpc-Status", "0")
+		rw.Header().Set(http.TrailerPrefix+"Grpc-Message", "echo")
+	})
+
+	router := NewRouter()
+	router.Post("/echo.Echo/{method}", GrpcWebServer(server))
+
+	body := base64.StdEncoding.EncodeToString(grpcWebFrame(grpcFrameData, []byte(`"ping"`)))
+	request := httptest.NewRequest(MethodPost, "/echo.Echo/Echo", strings.NewReader(body))
+	request.Header.Set(header.ContentType, "application/grpc-web-text+json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Header().Get(header.ContentType) != "application/grpc-web-text+json" || recorder.Header().Get(header.Trailer) != "" {
+		t.Errorf("unexpected headers %v", recorder.Header())
+	}
+	decoded, err := io.ReadAll(&grpcWebTextReader{source: recorder.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, trailers := readGrpcWebFrames(t, decoded)
+	if message != `"ping"` || trailers != "grpc-message:echo\r\ngrpc-status:0\r\n" {
+		t.Errorf("unexpected message %q and trailers %q", message, trailers)
+	}
+}
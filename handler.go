@@ -1,41 +1,149 @@
 package there
 
 import (
+	"context"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
 	"net/http"
 	"path"
+	"strings"
 )
 
 func (router *Router) ServeHTTP(rw http.ResponseWriter, request *http.Request) {
-	_, pattern := router.serveMux.Handler(request)
+	request = request.WithContext(context.WithValue(request.Context(), routerKey{}, router))
+	if tracer := router.Configuration.Tracer; tracer != nil {
+		var end func()
+		rw, request, end = startSpan(tracer, rw, request)
+		defer end()
+	}
+	defer router.drainBody(request)
+	bodyless := newBodylessWriter(rw, request)
+	defer bodyless.finish()
+	rw = bodyless
+	if !router.Configuration.RequestHeaders.empty() {
+		router.Configuration.RequestHeaders.apply(request)
+	}
+	if router.rejectDuplicateHeaders(rw, request) {
+		return
+	}
+	if policy := router.Configuration.ResponseHeaderPolicy; !policy.empty() {
+		writer := &headerPolicyWriter{ResponseWriter: rw, request: request, policy: policy}
+		defer writer.finish()
+		rw = writer
+	}
+	if reporting := router.Configuration.Issues; reporting.enabled() {
+		writer := &issueWriter{ResponseWriter: rw, request: request, reporting: reporting, issues: issuesOf(request)}
+		defer writer.finish()
+		rw = writer
+	}
+	defer router.recoverPanic(rw, request, bodyless)
+	if router.Configuration.RequestTimeoutFromHeaders {
+		timeout, ok := requestTimeout(request.Header, router.Configuration.MaxRequestTimeout)
+		if ok {
+			ctx, cancel := context.WithTimeout(request.Context(), timeout)
+			defer cancel()
+			request = request.WithContext(ctx)
+		}
+	}
+
+	if router.Configuration.BasePath != "" {
+		stripped, ok := stripBasePath(request, router.Configuration.BasePath)
+		if !ok {
+			router.serveNotFound(rw, request)
+			return
+		}
+		request = stripped
+	}
+	if router.Configuration.MatrixParams {
+		request = stripMatrixParams(request)
+	}
+
+	if router.serveMaintenance(rw, request) {
+		return
+	}
+	routed, pattern := router.routeHost(request)
+	if router.serveVariant(rw, request, pattern) {
+		return
+	}
 	if len(pattern) == 0 { // no handler was found
-		// not found with global middlewares applied
-		wrappedNotFoundHandler := router.applyGlobalMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			router.Configuration.RouteNotFoundHandler(NewHttpRequest(rw, req)).ServeHTTP(rw, req)
-		}))
-		wrappedNotFoundHandler.ServeHTTP(rw, request)
+		router.serveNotFound(rw, request)
 	} else {
-		router.serveMux.ServeHTTP(rw, request)
+		router.serveMux.ServeHTTP(rw, routed)
 	}
 }
 
+// serveNotFound serves the RouteNotFoundHandler with global middlewares applied
+func (router *Router) serveNotFound(rw http.ResponseWriter, request *http.Request) {
+	wrappedNotFoundHandler := router.applyGlobalMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		router.Configuration.RouteNotFoundHandler(NewHttpRequest(rw, req)).ServeHTTP(rw, req)
+	}))
+	wrappedNotFoundHandler.ServeHTTP(rw, request)
+}
+
+type routerKey struct{}
+
+// routerOf returns the Router serving the request or nil, if the request
+// was not served by a Router
+func routerOf(request *http.Request) *Router {
+	router, _ := request.Context().Value(routerKey{}).(*Router)
+	return router
+}
+
 // muxHandler defines a struct that encapsulates a handler and its middleware.
 type (
 	muxHandler struct {
 		router  *Router
+		pattern string
 		methods map[method]*muxHandlerEndpoint
+		// discovery answers OPTIONS requests, if OptionsDiscovery is enabled
+		discovery *muxHandlerEndpoint
+		// options answers OPTIONS requests, if AutoOptions is enabled
+		options *muxHandlerEndpoint
 	}
 	muxHandlerEndpoint struct {
 		endpoint    Endpoint
 		middlewares []Middleware
+		doc         *RouteDoc
+		// maxBodySize overrides the MaxBodySize of the RouterConfiguration, if not zero
+		maxBodySize int64
+		// headerLimits overrides the ResponseHeaderLimits of the RouterConfiguration, if not nil
+		headerLimits *HeaderLimits
+		// responseSizeLimit overrides the ResponseSizeLimit of the RouterConfiguration, if not nil
+		responseSizeLimit *ResponseSizeLimit
+		meta              RouteMeta
+		// locale of the path, if the route was registered with HandleLocalized
+		locale string
+		// concurrency limits the requests served at once, if not nil
+		concurrency *concurrencyLimiter
+		// toggle disables the route at runtime or outside of its schedule, if not nil
+		toggle *routeToggle
+		// cachePolicy is declared with Cacheable, if not nil
+		cachePolicy *CachePolicy
+		// envelope overrides the Envelope of the RouterConfiguration, if not nil
+		envelope *bool
+		// requiredChecks have to be healthy to serve the endpoint
+		requiredChecks []string
+		// undocumented excludes the endpoint from the OpenAPI document
+		undocumented bool
+		// sparseFields lets clients select the fields of Json responses, if not nil
+		sparseFields *sparseFields
+		// stats are collected, if RouteStats is enabled in the RouterConfiguration
+		stats *endpointStats
+		// constraints the route parameters have to satisfy, see Where
+		constraints map[string][]ParamConstraint
 	}
 )
 
 // newMuxHandler initializes and returns a new muxHandler.
-func newMuxHandler(router *Router, endpoint Endpoint) *muxHandler {
-	return &muxHandler{
+func newMuxHandler(router *Router, pattern string) *muxHandler {
+	h := &muxHandler{
 		router:  router,
+		pattern: pattern,
 		methods: map[method]*muxHandlerEndpoint{},
 	}
+	h.discovery = &muxHandlerEndpoint{endpoint: h.discoveryEndpoint}
+	h.options = &muxHandlerEndpoint{endpoint: h.optionsEndpoint}
+	return h
 }
 
 // AddMiddleware adds a new middleware to the handler's stack.
@@ -45,39 +153,179 @@ func (h *muxHandlerEndpoint) AddMiddleware(m Middleware) {
 
 // ServeHTTP implements the http.Handler interface for muxHandler.
 func (h *muxHandler) ServeHTTP(rw http.ResponseWriter, request *http.Request) {
+	restoreHost(request)
 	httpRequest := NewHttpRequest(rw, request)
+	httpRequest.pattern = h.pattern
 	method := methodToInt(request.Method)
+	if traced(request) {
+		nameSpan(spanOf(request), request.Method, h.pattern)
+	}
 
 	sanitizedPath := request.URL.Path
 	if h.router.Configuration.SanitizePaths {
 		sanitizedPath = path.Clean(sanitizedPath)
 	}
 
+	// preflight is the endpoint a CORS preflight request asks for
+	var preflight *muxHandlerEndpoint
 	muxHandlerEndpoint, ok := h.methods[method]
+	if !ok && method == methodHead && h.router.Configuration.AutoHead {
+		// the bodylessWriter discards the body of the GET endpoint
+		muxHandlerEndpoint, ok = h.methods[methodGet]
+	}
+	if !ok && method == methodOptions {
+		if requested := request.Header.Get(header.RequestAccessControlRequestMethod); requested != "" {
+			preflight = h.methods[methodToInt(requested)]
+		}
+	}
+	if !ok && preflight == nil && method == methodOptions && h.router.Configuration.OptionsDiscovery {
+		muxHandlerEndpoint, ok = h.discovery, true
+	}
+	if !ok && preflight == nil && method == methodOptions && h.router.Configuration.AutoOptions {
+		muxHandlerEndpoint, ok = h.options, true
+	}
 	if !ok {
-		// not found with global middlewares applied
+		// method not allowed with global middlewares applied
+		handler := h.router.Configuration.MethodNotAllowedHandler
+		if handler == nil {
+			handler = h.router.Configuration.RouteNotFoundHandler
+		} else {
+			rw.Header().Set(header.ResponseAllow, strings.Join(h.allowedMethods(), ", "))
+		}
+		var next Response = ResponseFunc(func(rw http.ResponseWriter, req *http.Request) {
+			handler(httpRequest).ServeHTTP(rw, req)
+		})
+		if preflight != nil {
+			// preflight requests pass the middlewares of the requested route, so
+			// a CORS middleware of the route can answer them
+			for i := len(preflight.middlewares) - 1; i >= 0; i-- {
+				next = applyMiddleware(httpRequest, preflight.middlewares[i], next)
+			}
+		}
+		h.router.applyGlobalMiddlewares(next).ServeHTTP(rw, request)
+		return
+	}
+	if !muxHandlerEndpoint.paramsValid(request.PathValue) {
+		h.router.serveNotFound(rw, request)
+		return
+	}
+	if toggle := muxHandlerEndpoint.toggle; toggle != nil && !toggle.enabled() {
+		// disabled routes skip their own middlewares, so they cannot be told apart from unknown routes
 		h.router.applyGlobalMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			h.router.Configuration.RouteNotFoundHandler(httpRequest).ServeHTTP(rw, req)
+			toggle.endpoint(h.router)(httpRequest).ServeHTTP(rw, req)
 		})).ServeHTTP(rw, request)
 		return
 	}
 	endpoint, middlewares := muxHandlerEndpoint.endpoint, muxHandlerEndpoint.middlewares
+	withRouteMeta(&httpRequest, muxHandlerEndpoint.meta)
+	withLocale(&httpRequest, muxHandlerEndpoint.locale)
+	withCachePolicy(&httpRequest, muxHandlerEndpoint.cachePolicy)
+	withEnvelope(&httpRequest, h.router, muxHandlerEndpoint.envelope)
+	if doc := muxHandlerEndpoint.doc; h.router.Configuration.StubMode && doc != nil && len(doc.Examples) > 0 {
+		endpoint = stubEndpoint(doc.Examples)
+	}
+	if endpoint == nil {
+		if h.router.Configuration.MockMode {
+			endpoint = mockEndpoint(muxHandlerEndpoint.doc)
+		} else {
+			endpoint = notImplementedEndpoint
+		}
+	}
+	if rejected := withSparseFields(&httpRequest, muxHandlerEndpoint.sparseFields); rejected != nil {
+		endpoint = rejected
+	}
+
+	maxBodySize := h.router.Configuration.MaxBodySize
+	if muxHandlerEndpoint.maxBodySize != 0 {
+		maxBodySize = muxHandlerEndpoint.maxBodySize
+	}
+	if maxBodySize > 0 && request.Body != nil {
+		if request.ContentLength > maxBodySize {
+			endpoint = bodyTooLargeEndpoint(maxBodySize)
+		} else {
+			request.Body = http.MaxBytesReader(rw, request.Body, maxBodySize)
+		}
+	}
 
 	var next Response = ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
-		endpoint(httpRequest).ServeHTTP(rw, r)
+		// a nil Response is allowed, if the Endpoint wrote to the connection itself, like after a Hijack
+		if response := endpoint(httpRequest); response != nil {
+			traceResponse(r, response)
+			response.ServeHTTP(rw, r)
+		}
 	})
+	if policy := muxHandlerEndpoint.cachePolicy; policy != nil && (method == methodGet || method == methodHead) {
+		next = CacheControl(*policy, next)
+	}
+	if limiter := muxHandlerEndpoint.concurrency; limiter != nil {
+		limited := next
+		next = ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			limiter.serve(rw, r, limited)
+		})
+	}
+	if checks := muxHandlerEndpoint.requiredChecks; len(checks) > 0 {
+		checked := next
+		next = ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			h.router.serveRequiringChecks(rw, r, checks, checked)
+		})
+	}
 
 	// Apply endpoint-specific middleware in reverse order.
 	for i := len(middlewares) - 1; i >= 0; i-- {
-		next = middlewares[i](httpRequest, next)
+		next = applyMiddleware(httpRequest, middlewares[i], next)
 	}
 
 	// Apply global middlewares in reverse order.
 	for i := len(h.router.globalMiddlewares) - 1; i >= 0; i-- {
-		next = h.router.globalMiddlewares[i](httpRequest, next)
+		next = applyMiddleware(httpRequest, h.router.globalMiddlewares[i], next)
+	}
+
+	if stats := muxHandlerEndpoint.stats; stats != nil && h.router.Configuration.RouteStats {
+		counted := next
+		next = ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			stats.serve(rw, r, counted)
+		})
+	}
+
+	responseSizeLimit := h.router.Configuration.ResponseSizeLimit
+	if muxHandlerEndpoint.responseSizeLimit != nil {
+		responseSizeLimit = *muxHandlerEndpoint.responseSizeLimit
+	}
+	var sizeWriter *responseSizeWriter
+	if responseSizeLimit.enabled() {
+		sizeWriter = &responseSizeWriter{ResponseWriter: rw, request: request, limit: responseSizeLimit}
+		rw = sizeWriter
+	}
+
+	headerLimits := h.router.Configuration.ResponseHeaderLimits
+	if muxHandlerEndpoint.headerLimits != nil {
+		headerLimits = *muxHandlerEndpoint.headerLimits
+	}
+	if headerLimits.enabled() {
+		rw = &headerLimitWriter{ResponseWriter: rw, request: request, limits: headerLimits}
 	}
 
 	next.ServeHTTP(rw, request)
+	if sizeWriter != nil {
+		sizeWriter.finish()
+	}
+
+	if httpRequest.Disconnected() {
+		h.router.stats.clientDisconnects.Add(1)
+	}
+}
+
+// notImplementedEndpoint is served for routes that were registered without an Endpoint
+func notImplementedEndpoint(request Request) Response {
+	return Status(status.NotImplemented)
+}
+
+// bodyTooLargeEndpoint is served instead of the actual Endpoint, if the request
+// announced a body larger than the limit of the route
+func bodyTooLargeEndpoint(limit int64) Endpoint {
+	return func(request Request) Response {
+		return Error(status.RequestEntityTooLarge, bodyTooLargeError(limit))
+	}
 }
 
 // applyGlobalMiddlewares wraps the given http.Handler with the router's global middlewares.
@@ -90,7 +338,7 @@ func (router *Router) applyGlobalMiddlewares(handler http.Handler) http.Handler
 
 		// Apply global middlewares in reverse order.
 		for i := len(router.globalMiddlewares) - 1; i >= 0; i-- {
-			next = router.globalMiddlewares[i](httpRequest, next)
+			next = applyMiddleware(httpRequest, router.globalMiddlewares[i], next)
 		}
 
 		next.ServeHTTP(rw, request)
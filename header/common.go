@@ -81,4 +81,10 @@ const (
 	//
 	//	Warning: 199 Miscellaneous warning
 	Warning = "Warning"
+
+	// XRequestId
+	// Non-standard. Correlates a request across services and log entries.
+	//
+	//	X-Request-Id: 01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47
+	XRequestId = "X-Request-Id"
 )
@@ -124,6 +124,12 @@ const (
 	//	If-Unmodified-Since: Sat, 29 Oct 1994 19:43:31 GMT
 	RequestIfUnmodifiedSince = "If-Unmodified-Since"
 
+	// RequestLastEventId
+	// The ID of the last Server-Sent Event, a reconnecting client received.
+	//
+	//	Last-Event-ID: 42
+	RequestLastEventId = "Last-Event-ID"
+
 	// RequestMaxForwards
 	// Limit the number of times the message can be forwarded through proxies or gateways.
 	//
@@ -160,6 +166,24 @@ const (
 	//	Referer: http://en.wikipedia.org/wiki/Main_Page
 	RequestReferer = "Referer"
 
+	// RequestSecWebSocketKey
+	// A random nonce of the client, which the server proves the WebSocket handshake with, see RFC 6455.
+	//
+	//	Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==
+	RequestSecWebSocketKey = "Sec-WebSocket-Key"
+
+	// RequestSecWebSocketProtocol
+	// The subprotocols the client wants to speak over the WebSocket, in the order of preference.
+	//
+	//	Sec-WebSocket-Protocol: chat, superchat
+	RequestSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+
+	// RequestSecWebSocketVersion
+	// The version of the WebSocket protocol, the client wants to speak.
+	//
+	//	Sec-WebSocket-Version: 13
+	RequestSecWebSocketVersion = "Sec-WebSocket-Version"
+
 	// RequestTe
 	// The transfer encodings the user agent is willing to accept: the same values as for the response header field Transfer-Encoding can be used, plus the "trailers" value (related to the "chunked" transfer method) to notify the server it expects to receive additional fields in the trailer after the last, zero-sized, chunk. Only trailers is supported in HTTP/2.
 	//
@@ -171,4 +195,28 @@ const (
 	//
 	//	User-Agent: Mozilla/5.0 (X11; Linux x86_64; rv:12.0) Gecko/20100101 Firefox/12.0
 	RequestUserAgent = "User-Agent"
+
+	// RequestXRequestTimeout
+	// Non-standard. The remaining time budget of the caller, either as duration or in seconds.
+	//
+	//	X-Request-Timeout: 1.5s
+	RequestXRequestTimeout = "X-Request-Timeout"
+
+	// RequestGrpcTimeout
+	// The remaining time budget of a gRPC caller, as up to eight digits followed by a unit (H, M, S, m, u, n).
+	//
+	//	Grpc-Timeout: 500m
+	RequestGrpcTimeout = "Grpc-Timeout"
+
+	// RequestXForwardedPrefix
+	// Non-standard. The path prefix a reverse proxy stripped before forwarding the request.
+	//
+	//	X-Forwarded-Prefix: /service-name
+	RequestXForwardedPrefix = "X-Forwarded-Prefix"
+
+	// RequestXForwardedProto
+	// Non-standard. The protocol the client used to connect to the reverse proxy.
+	//
+	//	X-Forwarded-Proto: https
+	RequestXForwardedProto = "X-Forwarded-Proto"
 )
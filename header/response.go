@@ -77,6 +77,12 @@ const (
 	//	Content-Disposition: attachment; filename="fname.ext"
 	ResponseContentDisposition = "Content-Disposition"
 
+	// ResponseContentDigest
+	// The digest of the content of the message, as defined in RFC 9530
+	//
+	//	Content-Digest: sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+	ResponseContentDigest = "Content-Digest"
+
 	// ResponseContentLanguage
 	// The natural language or languages of the intended audience for the enclosed content
 	//
@@ -101,6 +107,12 @@ const (
 	//	Delta-Base: "abc"
 	ResponseDeltaBase = "Delta-Base"
 
+	// ResponseDeprecation
+	// Announces, that the resource is or will be deprecated as of the given date, see RFC 9745.
+	//
+	//	Deprecation: @1688169599
+	ResponseDeprecation = "Deprecation"
+
 	// ResponseEtag
 	// An identifier for a specific version of a resource, often a message digest
 	//
@@ -113,6 +125,18 @@ const (
 	//	Expires: Thu, 01 Dec 1994 16:00:00 GMT
 	ResponseExpires = "Expires"
 
+	// ResponseGrpcStatus
+	// The status code of a gRPC call, sent as trailer. Zero means OK.
+	//
+	//	Grpc-Status: 5
+	ResponseGrpcStatus = "Grpc-Status"
+
+	// ResponseGrpcMessage
+	// The percent-encoded error message of a failed gRPC call, sent as trailer.
+	//
+	//	Grpc-Message: user%20not%20found
+	ResponseGrpcMessage = "Grpc-Message"
+
 	// ResponseIm
 	// Instance-manipulations applied to the response.
 	//
@@ -171,6 +195,30 @@ const (
 	//	Retry-After: Fri, 07 Nov 2014 23:59:59 GMT
 	ResponseRetryAfter = "Retry-After"
 
+	// ResponseRateLimit
+	// The remaining quota of the client and the seconds until it is reset, see draft-ietf-httpapi-ratelimit-headers.
+	//
+	//	RateLimit: "default";r=50;t=30
+	ResponseRateLimit = "RateLimit"
+
+	// ResponseRateLimitPolicy
+	// The quota policies of the server, with their quota and window in seconds, see draft-ietf-httpapi-ratelimit-headers.
+	//
+	//	RateLimit-Policy: "default";q=100;w=60
+	ResponseRateLimitPolicy = "RateLimit-Policy"
+
+	// ResponseSecWebSocketAccept
+	// Proves, that the server accepted the WebSocket handshake of the client, see RFC 6455.
+	//
+	//	Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=
+	ResponseSecWebSocketAccept = "Sec-WebSocket-Accept"
+
+	// ResponseSecWebSocketProtocol
+	// The subprotocol, the server selected for the WebSocket.
+	//
+	//	Sec-WebSocket-Protocol: chat
+	ResponseSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+
 	// ResponseServer
 	// A name for the server
 	//
@@ -183,6 +231,18 @@ const (
 	//	Set-Cookie: UserID=JohnDoe; Max-Age=3600; Version=1
 	ResponseSetCookie = "Set-Cookie"
 
+	// ResponseSignature
+	// The signatures of the message, whose covered components are described in Signature-Input, see RFC 9421.
+	//
+	//	Signature: sig1=:P0wLUszWQjoi54udOtydf9IWTfNhy+r53jGFj9XZuP4=:
+	ResponseSignature = "Signature"
+
+	// ResponseSignatureInput
+	// The covered components and parameters of the signatures in the Signature header, see RFC 9421.
+	//
+	//	Signature-Input: sig1=("@status" "content-digest");created=1618884473;keyid="test-key"
+	ResponseSignatureInput = "Signature-Input"
+
 	// ResponseStrictTransportSecurity
 	// A HSTS Policy informing the HTTP client how long to cache the HTTPS only policy and whether this applies to subdomains.
 	//
@@ -195,6 +255,18 @@ const (
 	//	Tk: ?
 	ResponseTk = "Tk"
 
+	// ResponseReprDigest
+	// The digest of the whole selected representation, even for partial responses, as defined in RFC 9530
+	//
+	//	Repr-Digest: sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+	ResponseReprDigest = "Repr-Digest"
+
+	// ResponseSunset
+	// The date, after which the resource is expected to become unavailable, see RFC 8594.
+	//
+	//	Sunset: Sat, 31 Dec 2018 23:59:59 GMT
+	ResponseSunset = "Sunset"
+
 	// ResponseVary
 	// Tells downstream proxies how to match future request headers to decide whether the cached response can be used rather than requesting a fresh one from the origin server.
 	// Example 1:
@@ -202,6 +274,12 @@ const (
 	//	Vary: Accept-Language
 	ResponseVary = "Vary"
 
+	// ResponseWarning
+	// A general warning about possible problems with the entity body.
+	//
+	//	Warning: 199 - "Miscellaneous warning"
+	ResponseWarning = "Warning"
+
 	// ResponseWwwAuthenticate
 	// Indicates the authentication scheme that should be used to access the requested entity.
 	//
@@ -213,4 +291,10 @@ const (
 	//
 	//	X-Frame-Options: deny
 	ResponseXFrameOptions = "X-Frame-Options"
+
+	// ResponseXTotalCount
+	// Non-standard. The total amount of items of a paginated collection.
+	//
+	//	X-Total-Count: 93
+	ResponseXTotalCount = "X-Total-Count"
 )
@@ -0,0 +1,155 @@
+package there
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// HeaderLimits guards against runaway header emission, for example by a buggy
+// middleware adding a header on every call. Zero values mean no limit.
+type HeaderLimits struct {
+	// MaxCount is the maximum amount of header values in a response
+	MaxCount int
+	// MaxBytes is the maximum size of all header names and values in a response
+	MaxBytes int
+	// Truncate removes the largest headers until the response is within the limits.
+	// Otherwise, the response is replaced with a StatusInternalServerError.
+	// Either way, a log entry is written.
+	Truncate bool
+}
+
+func (l HeaderLimits) enabled() bool {
+	return l.MaxCount > 0 || l.MaxBytes > 0
+}
+
+func (l HeaderLimits) exceeded(headers http.Header) bool {
+	count, size := headerSize(headers)
+	return (l.MaxCount > 0 && count > l.MaxCount) || (l.MaxBytes > 0 && size > l.MaxBytes)
+}
+
+// headerSize returns the amount of values and the size of all headers, as they
+// are sent in HTTP/1.1, without the status line
+func headerSize(headers http.Header) (count, size int) {
+	for name, values := range headers {
+		for _, value := range values {
+			count++
+			size += len(name) + len(value) + len(": \r\n")
+		}
+	}
+	return count, size
+}
+
+// protectedHeaders are never removed when truncating, as the body can not be
+// interpreted without them
+var protectedHeaders = map[string]bool{
+	header.ContentType:      true,
+	header.ContentLength:    true,
+	header.ContentEncoding:  true,
+	header.TransferEncoding: true,
+	header.ResponseLocation: true,
+}
+
+// headerLimitWriter checks the headers against the HeaderLimits, right before
+// they are sent
+type headerLimitWriter struct {
+	http.ResponseWriter
+	request *http.Request
+	limits  HeaderLimits
+	checked bool
+	failed  bool
+}
+
+func (w *headerLimitWriter) WriteHeader(code int) {
+	if w.check() {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *headerLimitWriter) Write(b []byte) (int, error) {
+	if !w.check() {
+		// the response was replaced, discard the original body
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerLimitWriter) Flush() {
+	if w.check() {
+		_ = http.NewResponseController(w.ResponseWriter).Flush()
+	}
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *headerLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// check enforces the limits once and reports, whether the original response
+// may still be written
+func (w *headerLimitWriter) check() bool {
+	if w.checked {
+		return !w.failed
+	}
+	w.checked = true
+
+	headers := w.ResponseWriter.Header()
+	if !w.limits.exceeded(headers) {
+		return true
+	}
+	count, size := headerSize(headers)
+	log.Printf("headerLimits: %v %v exceeded the header limits with %d headers and %d bytes",
+		w.request.Method, w.request.URL.Path, count, size)
+
+	if w.limits.Truncate {
+		truncateHeaders(headers, w.limits)
+		return true
+	}
+
+	w.failed = true
+	for name := range headers {
+		delete(headers, name)
+	}
+	Error(status.InternalServerError, errors.New("response headers exceeded the limits")).
+		ServeHTTP(w.ResponseWriter, w.request)
+	return false
+}
+
+// truncateHeaders removes the largest headers until the limits are met
+func truncateHeaders(headers http.Header, limits HeaderLimits) {
+	names := make([]string, 0, len(headers))
+	sizes := map[string]int{}
+	for name, values := range headers {
+		if protectedHeaders[name] {
+			continue
+		}
+		names = append(names, name)
+		for _, value := range values {
+			sizes[name] += len(name) + len(value)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if sizes[names[i]] == sizes[names[j]] {
+			return names[i] < names[j]
+		}
+		return sizes[names[i]] > sizes[names[j]]
+	})
+	for _, name := range names {
+		if !limits.exceeded(headers) {
+			return
+		}
+		delete(headers, name)
+	}
+}
+
+// WithHeaderLimits overrides the ResponseHeaderLimits of the RouterConfiguration for the route
+func (group *RouteRouteGroupBuilder) WithHeaderLimits(limits HeaderLimits) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		endpoint.headerLimits = &limits
+	}
+	return group
+}
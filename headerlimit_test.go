@@ -0,0 +1,53 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHeaderLimits(t *testing.T) {
+	runaway := func(request Request) Response {
+		headers := map[string]string{"X-Large": strings.Repeat("a", 100)}
+		for i := 0; i < 10; i++ {
+			headers["X-Header-"+strconv.Itoa(i)] = "value"
+		}
+		return Headers(headers, String(status.OK, "body"))
+	}
+
+	router := NewRouter()
+	router.Configuration.ResponseHeaderLimits = HeaderLimits{MaxCount: 5}
+	router.Get("/error", runaway)
+	router.Get("/truncate", runaway).WithHeaderLimits(HeaderLimits{MaxBytes: 200, Truncate: true})
+	router.Get("/unlimited", runaway).WithHeaderLimits(HeaderLimits{})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	recorder := serve("/error")
+	if recorder.Code != status.InternalServerError || recorder.Header().Get("X-Header-0") != "" {
+		t.Errorf("expected a clean %v, got %v %v", status.InternalServerError, recorder.Code, recorder.Header())
+	}
+
+	recorder = serve("/truncate")
+	if recorder.Code != status.OK || recorder.Body.String() != "body" {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Large") != "" || recorder.Header().Get(header.ContentType) == "" {
+		t.Errorf("largest header was not truncated or content type was removed: %v", recorder.Header())
+	}
+	if _, size := headerSize(recorder.Header()); size > 200 {
+		t.Errorf("headers still exceed the limit with %v bytes", size)
+	}
+
+	if recorder = serve("/unlimited"); len(recorder.Header()) != 12 {
+		t.Errorf("route override was not applied: %v", recorder.Header())
+	}
+}
@@ -0,0 +1,140 @@
+package there
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorDuplicateHeader is the error of requests rejected by HeaderReject
+var ErrorDuplicateHeader = errors.New("duplicate header")
+
+// HeaderMergePolicy defines how the Headers reader handles headers, that were
+// sent several times or in different case variants, like "X_Tenant" and
+// "x_tenant". Case variants are merged under the canonical name in all policies.
+type HeaderMergePolicy int
+
+const (
+	// HeaderFirstWins reads the first value of a header. Use GetSlice for all values.
+	HeaderFirstWins HeaderMergePolicy = iota
+	// HeaderJoin joins the values of a header with ", ", or "; " for cookies
+	HeaderJoin
+	// HeaderReject answers requests, that send a header more than once, with
+	// StatusBadRequest before they are routed. Note, that proxies may append
+	// values to headers like X-Forwarded-For.
+	HeaderReject
+)
+
+// Header returns the value of a request header by its name in any case,
+// according to the HeaderMergePolicy of the RouterConfiguration. Host and
+// Content-Length are the values the server actually used, so security checks
+// see the same values as the routing and the BodyReader.
+//
+//	tenant, ok := request.Header("x-tenant")
+func (r *Request) Header(name string) (string, bool) {
+	name = canonicalHeaderName(name)
+	switch name {
+	case header.RequestHost:
+		return r.Request.Host, r.Request.Host != ""
+	case header.ContentLength:
+		if r.Request.ContentLength < 0 {
+			return "", false
+		}
+		return strconv.FormatInt(r.Request.ContentLength, 10), true
+	}
+	return r.Headers.Get(name)
+}
+
+// newHeaderReader reads the headers of the request with the HeaderMergePolicy
+// of its router
+func newHeaderReader(request *http.Request) MapReader {
+	policy := HeaderFirstWins
+	if router := routerOf(request); router != nil {
+		policy = router.Configuration.HeaderMergePolicy
+	}
+	merged := mergeHeaders(request.Header)
+	if policy != HeaderJoin {
+		return MapReader(merged)
+	}
+	joined := make(MapReader, len(merged))
+	for name, values := range merged {
+		separator := ", "
+		if name == header.RequestCookie {
+			separator = "; "
+		}
+		joined[name] = []string{strings.Join(values, separator)}
+	}
+	return joined
+}
+
+// mergeHeaders merges the case variants of the headers under their canonical
+// name. The headers are returned as they are, if they have no case variants.
+func mergeHeaders(headers http.Header) http.Header {
+	canonical := true
+	for name := range headers {
+		if canonicalHeaderName(name) != name {
+			canonical = false
+			break
+		}
+	}
+	if canonical {
+		return headers
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	merged := make(http.Header, len(headers))
+	for _, name := range names {
+		canonicalName := canonicalHeaderName(name)
+		merged[canonicalName] = append(merged[canonicalName], headers[name]...)
+	}
+	return merged
+}
+
+// duplicateHeader returns the first header, that was sent more than once
+func duplicateHeader(headers http.Header) (string, bool) {
+	for name, values := range mergeHeaders(headers) {
+		if len(values) > 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// rejectDuplicateHeaders answers the request, if it violates HeaderReject
+func (router *Router) rejectDuplicateHeaders(rw http.ResponseWriter, request *http.Request) bool {
+	if router.Configuration.HeaderMergePolicy != HeaderReject {
+		return false
+	}
+	name, duplicate := duplicateHeader(request.Header)
+	if !duplicate {
+		return false
+	}
+	Error(status.BadRequest, fmt.Errorf("%w: %v", ErrorDuplicateHeader, name)).ServeHTTP(rw, request)
+	return true
+}
+
+// canonicalHeaderName is like http.CanonicalHeaderKey, but also canonicalizes
+// names with characters, that are not valid in header names, like "x_tenant"
+func canonicalHeaderName(name string) string {
+	b := []byte(name)
+	upper := true
+	for i, c := range b {
+		if upper && 'a' <= c && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		} else if !upper && 'A' <= c && c <= 'Z' {
+			b[i] = c - 'A' + 'a'
+		}
+		upper = c == '-'
+	}
+	return string(b)
+}
@@ -0,0 +1,59 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHeaderMergePolicy(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		tenant, _ := request.Header("x_tenant")
+		accept, _ := request.Headers.Get("Accept")
+		host, _ := request.Header("HOST")
+		return String(status.OK, tenant+"|"+accept+"|"+host)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, "http://example.com/", nil)
+		request.Header["X_tenant"] = []string{"a"}
+		request.Header["x_tenant"] = []string{"b"}
+		request.Header.Add("Accept", "text/plain")
+		request.Header.Add("Accept", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if body := serve().Body.String(); body != "a|text/plain|example.com" {
+		t.Errorf("unexpected first-wins headers %v", body)
+	}
+
+	router.Configuration.HeaderMergePolicy = HeaderJoin
+	if body := serve().Body.String(); body != "a, b|text/plain, application/json|example.com" {
+		t.Errorf("unexpected joined headers %v", body)
+	}
+
+	router.Configuration.HeaderMergePolicy = HeaderReject
+	recorder := serve()
+	if recorder.Code != status.BadRequest || !strings.Contains(recorder.Body.String(), ErrorDuplicateHeader.Error()) {
+		t.Errorf("expected the request to be rejected, got %v %v", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestCanonicalHeaderName(t *testing.T) {
+	tests := map[string]string{
+		"content-type": "Content-Type",
+		"X-API-KEY":    "X-Api-Key",
+		"x_tenant":     "X_tenant",
+		"":             "",
+	}
+	for name, expected := range tests {
+		if canonical := canonicalHeaderName(name); canonical != expected {
+			t.Errorf("%q: expected %q, got %q", name, expected, canonical)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package there
+
+import (
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ResponseHeaderPolicy is enforced on every response of the router, right before
+// its headers are sent
+//
+//	router.Configuration.ResponseHeaderPolicy = there.ResponseHeaderPolicy{
+//		Disallowed: []string{"X-Powered-By", "X-Debug-*"},
+//		Required: map[string]string{
+//			"X-Content-Type-Options": "nosniff",
+//			"X-Frame-Options":        "DENY",
+//		},
+//		LogViolations: true,
+//	}
+type ResponseHeaderPolicy struct {
+	// Disallowed headers are removed. A name ending with "*" removes all headers
+	// with that prefix.
+	Disallowed []string
+	// Required headers are set to the value, if the response did not set them
+	Required map[string]string
+	// LogViolations writes a log entry for every removed or missing header.
+	// Meant for development, to find the responses violating the policy.
+	LogViolations bool
+}
+
+func (p ResponseHeaderPolicy) empty() bool {
+	return len(p.Disallowed) == 0 && len(p.Required) == 0
+}
+
+// enforce applies the policy to the headers
+func (p ResponseHeaderPolicy) enforce(headers http.Header, request *http.Request) {
+	for _, name := range p.Disallowed {
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			prefix = http.CanonicalHeaderKey(prefix)
+			for key := range headers {
+				if strings.HasPrefix(key, prefix) {
+					p.violation(request, "removed disallowed header %v", key)
+					delete(headers, key)
+				}
+			}
+			continue
+		}
+		if _, ok := headers[http.CanonicalHeaderKey(name)]; ok {
+			p.violation(request, "removed disallowed header %v", name)
+			headers.Del(name)
+		}
+	}
+	for name, value := range p.Required {
+		if headers.Get(name) == "" {
+			p.violation(request, "added missing header %v", name)
+			headers.Set(name, value)
+		}
+	}
+}
+
+func (p ResponseHeaderPolicy) violation(request *http.Request, format, name string) {
+	if p.LogViolations {
+		log.Printf("responseHeaderPolicy: %v %v "+format, request.Method, request.URL.Path, name)
+	}
+}
+
+// headerPolicyWriter enforces the ResponseHeaderPolicy once, before the headers are sent
+type headerPolicyWriter struct {
+	http.ResponseWriter
+	request  *http.Request
+	policy   ResponseHeaderPolicy
+	enforced bool
+}
+
+func (w *headerPolicyWriter) enforce() {
+	if !w.enforced {
+		w.enforced = true
+		w.policy.enforce(w.ResponseWriter.Header(), w.request)
+	}
+}
+
+func (w *headerPolicyWriter) WriteHeader(code int) {
+	w.enforce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerPolicyWriter) Write(b []byte) (int, error) {
+	w.enforce()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerPolicyWriter) Flush() {
+	w.enforce()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *headerPolicyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish enforces the policy for responses, that never wrote anything
+func (w *headerPolicyWriter) finish() {
+	w.enforce()
+}
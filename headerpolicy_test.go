@@ -0,0 +1,41 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestResponseHeaderPolicy(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.ResponseHeaderPolicy = ResponseHeaderPolicy{
+		Disallowed: []string{"X-Powered-By", "X-Debug-*"},
+		Required:   map[string]string{"X-Content-Type-Options": "nosniff", "X-Frame-Options": "DENY"},
+	}
+	router.Get("/", func(request Request) Response {
+		return Headers(map[string]string{
+			"X-Powered-By":    "there",
+			"X-Debug-Query":   "select",
+			"X-Frame-Options": "SAMEORIGIN",
+		}, String(status.OK, "ok"))
+	})
+
+	for _, route := range []string{"/", "/missing"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		headers := recorder.Header()
+		if headers.Get("X-Powered-By") != "" || headers.Get("X-Debug-Query") != "" {
+			t.Errorf("%v: disallowed headers were sent: %v", route, headers)
+		}
+		if headers.Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%v: required header is missing: %v", route, headers)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/", nil))
+	if recorder.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+		t.Errorf("headers set by the response must not be overwritten")
+	}
+}
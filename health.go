@@ -0,0 +1,179 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorDependencyUnavailable is served by routes declared with RequiresCheck,
+// while one of their health checks fails
+var ErrorDependencyUnavailable = errors.New("dependency unavailable")
+
+// HealthCheck reports whether a dependency, like a database, is healthy
+type HealthCheck func(ctx context.Context) error
+
+type HealthCheckOptions struct {
+	// Interval is the time a result is cached. Defaults to ten seconds.
+	Interval time.Duration
+	// Timeout cancels the context of a check. Defaults to the Interval.
+	Timeout time.Duration
+}
+
+type healthCheck struct {
+	check   HealthCheck
+	options HealthCheckOptions
+
+	mutex   sync.Mutex
+	err     error
+	checked time.Time
+	running atomic.Bool
+}
+
+// result returns the last result and refreshes it in the background, once it is stale
+func (c *healthCheck) result() (time.Time, error) {
+	c.mutex.Lock()
+	err, checked := c.err, c.checked
+	c.mutex.Unlock()
+	if c.check != nil && time.Since(checked) >= c.options.Interval && c.running.CompareAndSwap(false, true) {
+		go c.run()
+	}
+	return checked, err
+}
+
+func (c *healthCheck) run() {
+	defer c.running.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+	err := func() (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("health check panicked: %v", recovered)
+			}
+		}()
+		return c.check(ctx)
+	}()
+	c.report(err)
+}
+
+func (c *healthCheck) report(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.err, c.checked = err, time.Now()
+}
+
+// AddHealthCheck registers a check of a dependency, which routes can require
+// with RequiresCheck. The check runs in the background, right away and
+// whenever its result is older than the Interval and it is asked for. Until
+// the first result is known, the dependency counts as healthy.
+//
+//	router.AddHealthCheck("database", func(ctx context.Context) error {
+//		return db.PingContext(ctx)
+//	}, there.HealthCheckOptions{Interval: 5 * time.Second})
+func (router *Router) AddHealthCheck(name string, check HealthCheck, options ...HealthCheckOptions) {
+	var config HealthCheckOptions
+	if len(options) >= 1 {
+		config = options[0]
+	}
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = config.Interval
+	}
+	c := &healthCheck{check: check, options: config, checked: time.Now()}
+	router.healthChecks.Store(name, c)
+	c.running.Store(true)
+	go c.run()
+}
+
+// ReportHealth sets the result of the check, like from the error callback of a
+// connection pool. Checks, that were not added with AddHealthCheck, are
+// registered and only change with ReportHealth.
+func (router *Router) ReportHealth(name string, err error) {
+	c, _ := router.healthChecks.LoadOrStore(name, &healthCheck{})
+	c.(*healthCheck).report(err)
+}
+
+// CheckHealth returns the last error of the check. Checks, that are not
+// registered, are healthy.
+func (router *Router) CheckHealth(name string) error {
+	c, ok := router.healthChecks.Load(name)
+	if !ok {
+		return nil
+	}
+	_, err := c.(*healthCheck).result()
+	return err
+}
+
+type healthStatus struct {
+	Healthy bool      `json:"healthy"`
+	Error   string    `json:"error,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// Health is an Endpoint listing the results of all health checks. It responds
+// with StatusServiceUnavailable, if any of them fails. As the errors may
+// contain internal details, protect the route accordingly.
+//
+//	router.Get("/health", router.Health)
+func (router *Router) Health(request Request) Response {
+	checks := map[string]healthStatus{}
+	healthy := true
+	var names []string
+	router.healthChecks.Range(func(name, c any) bool {
+		names = append(names, name.(string))
+		return true
+	})
+	sort.Strings(names)
+	for _, name := range names {
+		c, _ := router.healthChecks.Load(name)
+		checked, err := c.(*healthCheck).result()
+		s := healthStatus{Healthy: err == nil, Checked: checked}
+		if err != nil {
+			s.Error = err.Error()
+			healthy = false
+		}
+		checks[name] = s
+	}
+	if !healthy {
+		return Json(status.ServiceUnavailable, checks)
+	}
+	return Json(status.OK, checks)
+}
+
+// RequiresCheck ties the availability of the route to health checks. While
+// one of them fails, the route responds with StatusServiceUnavailable and a
+// Retry-After header right away, instead of running into a timeout in the
+// Endpoint. The middlewares of the route run nevertheless.
+//
+//	router.Get("/orders", GetOrders).RequiresCheck("database")
+func (group *RouteRouteGroupBuilder) RequiresCheck(names ...string) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		endpoint.requiredChecks = append(endpoint.requiredChecks, names...)
+	}
+	return group
+}
+
+// serveRequiringChecks serves the response, if all checks are healthy
+func (router *Router) serveRequiringChecks(rw http.ResponseWriter, r *http.Request, checks []string, response Response) {
+	for _, name := range checks {
+		if err := router.CheckHealth(name); err != nil {
+			retry := 10 * time.Second
+			if c, ok := router.healthChecks.Load(name); ok && c.(*healthCheck).check != nil {
+				retry = c.(*healthCheck).options.Interval
+			}
+			setRetryAfter(rw, r, retry)
+			Error(status.ServiceUnavailable, fmt.Errorf("%w: %v", ErrorDependencyUnavailable, name)).ServeHTTP(rw, r)
+			return
+		}
+	}
+	response.ServeHTTP(rw, r)
+}
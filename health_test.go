@@ -0,0 +1,95 @@
+package there
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRequiresCheck(t *testing.T) {
+	router := NewRouter()
+	router.Get("/orders", handler).RequiresCheck("database")
+	router.Get("/health", router.Health)
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	// checks without a result are healthy
+	if recorder := serve("/orders"); recorder.Code != status.OK {
+		t.Errorf("expected 200, got %v", recorder.Code)
+	}
+
+	router.ReportHealth("database", errors.New("connection refused"))
+	recorder := serve("/orders")
+	if recorder.Code != status.ServiceUnavailable {
+		t.Errorf("expected 503, got %v", recorder.Code)
+	}
+	if recorder.Header().Get(header.ResponseRetryAfter) == "" {
+		t.Errorf("expected a Retry-After header")
+	}
+	if !strings.Contains(recorder.Body.String(), "dependency unavailable: database") {
+		t.Errorf("unexpected body %v", recorder.Body.String())
+	}
+
+	recorder = serve("/health")
+	if recorder.Code != status.ServiceUnavailable {
+		t.Errorf("expected 503, got %v", recorder.Code)
+	}
+	var checks map[string]healthStatus
+	if err := json.Unmarshal(recorder.Body.Bytes(), &checks); err != nil {
+		t.Fatal(err)
+	}
+	if check := checks["database"]; check.Healthy || check.Error != "connection refused" {
+		t.Errorf("unexpected check %+v", check)
+	}
+
+	router.ReportHealth("database", nil)
+	if recorder := serve("/orders"); recorder.Code != status.OK {
+		t.Errorf("expected 200, got %v", recorder.Code)
+	}
+	if recorder := serve("/health"); recorder.Code != status.OK {
+		t.Errorf("expected 200, got %v", recorder.Code)
+	}
+}
+
+func TestAddHealthCheck(t *testing.T) {
+	router := NewRouter()
+	var healthy atomic.Bool
+	router.AddHealthCheck("cache", func(ctx context.Context) error {
+		if !healthy.Load() {
+			return errors.New("cache is down")
+		}
+		return nil
+	}, HealthCheckOptions{Interval: 10 * time.Millisecond})
+
+	eventually := func(expectHealthy bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if (router.CheckHealth("cache") == nil) == expectHealthy {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expected healthy to be %v", expectHealthy)
+	}
+
+	eventually(false)
+	healthy.Store(true)
+	eventually(true)
+
+	if err := router.CheckHealth("unknown"); err != nil {
+		t.Errorf("expected unknown checks to be healthy, got %v", err)
+	}
+}
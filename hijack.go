@@ -0,0 +1,129 @@
+package there
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ErrorHijackUnsupported is returned by Request.Hijack, if the connection can
+// not be taken over, like with HTTP/2
+var ErrorHijackUnsupported = errors.New("hijacking unsupported")
+
+// HijackedConn is a connection taken over from the http server with Request.Hijack.
+// The server does not manage it anymore, but the router closes it, when its
+// server shuts down.
+type HijackedConn struct {
+	net.Conn
+	// ReadWriter contains data the client already sent, which was buffered by
+	// the http server. Read from it instead of the net.Conn directly.
+	ReadWriter *bufio.ReadWriter
+
+	closeOnce   sync.Once
+	connections *hijackedConnections
+	// shutdown closes the connection cleanly, when the server shuts down,
+	// like with a close frame of a WebSocket. Close is called, if nil.
+	shutdown func()
+}
+
+// Close closes the connection and stops tracking it
+func (c *HijackedConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.connections != nil {
+			c.connections.remove(c)
+		}
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+// Hijack takes over the underlying connection, for protocols like custom TCP
+// upgrades. Headers and data already written are flushed before. After a
+// successful Hijack, the returned Response of the Endpoint is ignored by the
+// server, so return nil and handle the connection in a goroutine or inline.
+//
+//	router.Get("/upgrade", func(request there.Request) there.Response {
+//		conn, err := request.Hijack()
+//		if err != nil {
+//			return there.Error(status.InternalServerError, err)
+//		}
+//		defer conn.Close()
+//		conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: custom\r\nConnection: Upgrade\r\n\r\n"))
+//		// speak the custom protocol
+//		return nil
+//	})
+func (r *Request) Hijack() (*HijackedConn, error) {
+	controller := http.NewResponseController(r.ResponseWriter)
+	err := controller.Flush()
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	conn, readWriter, err := controller.Hijack()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil, ErrorHijackUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = readWriter.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	hijacked := &HijackedConn{Conn: conn, ReadWriter: readWriter}
+	if router := routerOf(r.Request); router != nil {
+		hijacked.connections = &router.hijacked
+		router.hijacked.add(hijacked)
+	}
+	return hijacked, nil
+}
+
+// hijackedConnections tracks all connections taken over from the server of
+// a router, so they can be closed on shutdown
+type hijackedConnections struct {
+	mutex       sync.Mutex
+	connections map[*HijackedConn]struct{}
+}
+
+func (h *hijackedConnections) add(conn *HijackedConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.connections == nil {
+		h.connections = map[*HijackedConn]struct{}{}
+	}
+	h.connections[conn] = struct{}{}
+}
+
+func (h *hijackedConnections) remove(conn *HijackedConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.connections, conn)
+}
+
+// closeAll closes every tracked connection, the ones with a shutdown concurrently
+func (h *hijackedConnections) closeAll() {
+	h.mutex.Lock()
+	connections := make([]*HijackedConn, 0, len(h.connections))
+	for conn := range h.connections {
+		connections = append(connections, conn)
+	}
+	h.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, conn := range connections {
+		if conn.shutdown == nil {
+			_ = conn.Close()
+			continue
+		}
+		wg.Add(1)
+		go func(conn *HijackedConn) {
+			defer wg.Done()
+			conn.shutdown()
+		}(conn)
+	}
+	wg.Wait()
+}
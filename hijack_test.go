@@ -0,0 +1,70 @@
+package there
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHijack(t *testing.T) {
+	router := NewRouter()
+	router.Get("/upgrade", func(request Request) Response {
+		conn, err := request.Hijack()
+		if err != nil {
+			t.Errorf("could not hijack: %v", err)
+			return nil
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\nhello\n"))
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go router.Server.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET /upgrade HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("could not read greeting: %v", err)
+		}
+		if line == "hello\n" {
+			break
+		}
+	}
+
+	if err := router.Server.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("hijacked connection was not closed on shutdown: %v", err)
+	}
+}
+
+func TestHijackUnsupported(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		_, err := request.Hijack()
+		if err != ErrorHijackUnsupported {
+			t.Errorf("expected ErrorHijackUnsupported, got %v", err)
+		}
+		return Status(status.OK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, "/", nil))
+}
@@ -0,0 +1,153 @@
+package there
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostPattern is a host with wildcard labels, like "{tenant}.example.com"
+type hostPattern struct {
+	labels []string
+	// canonical is the host the routes are registered with in the http.ServeMux,
+	// in which the wildcard labels are replaced by "*"
+	canonical string
+}
+
+// match returns the values of the wildcard labels, if the host matches
+func (p hostPattern) match(host string) (map[string]string, bool) {
+	labels := strings.Split(host, ".")
+	if len(labels) != len(p.labels) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, label := range p.labels {
+		if name, ok := hostWildcard(label); ok {
+			if labels[i] == "" {
+				return nil, false
+			}
+			params[name] = labels[i]
+		} else if label != labels[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func hostWildcard(label string) (string, bool) {
+	if len(label) > 2 && strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") {
+		return label[1 : len(label)-1], true
+	}
+	return "", false
+}
+
+// Host returns a RouteGroup, whose routes are only served for requests to the
+// host, so different hosts can be served with different routes by the same
+// router. Labels of the host can be wildcards, like "{tenant}.example.com",
+// whose values can be read with RouteParams. Routes of a matching host take
+// precedence over the routes registered without host.
+//
+//	api := router.Host("api.example.com")
+//	api.Get("/users", GetUsers)
+//
+//	tenants := router.Host("{tenant}.example.com")
+//	tenants.Get("/", func(request there.Request) there.Response {
+//		return there.String(status.OK, request.RouteParams.Get("tenant"))
+//	})
+func (router *Router) Host(host string) *RouteGroup {
+	host = strings.ToLower(host)
+	pattern := hostPattern{labels: strings.Split(host, ".")}
+	canonical := make([]string, len(pattern.labels))
+	wildcards := false
+	for i, label := range pattern.labels {
+		router.assert(label != "" && !strings.ContainsAny(label, "/:*"), "host \""+host+"\" is malformed")
+		if _, ok := hostWildcard(label); ok {
+			canonical[i], wildcards = "*", true
+		} else {
+			router.assert(!strings.ContainsAny(label, "{}"), "wildcards of host \""+host+"\" need to be complete labels")
+			canonical[i] = label
+		}
+	}
+	pattern.canonical = strings.Join(canonical, ".")
+
+	router.mutex.Lock()
+	router.hostRouting = true
+	router.mutex.Unlock()
+	if wildcards {
+		router.mutex.Lock()
+		known := false
+		for _, h := range router.hosts {
+			known = known || h.canonical == pattern.canonical
+		}
+		if !known {
+			router.hosts = append(router.hosts, pattern)
+		}
+		router.mutex.Unlock()
+	}
+	return &RouteGroup{Router: router, prefix: "/", host: pattern.canonical, hostParams: routeParameters(host)}
+}
+
+type hostMatchKey struct{}
+
+// hostMatch is the wildcard host a request was routed with
+type hostMatch struct {
+	host   string
+	params map[string]string
+}
+
+// routeHost returns the request the http.ServeMux routes with, and the pattern
+// it matches. If hosts were registered, the Host is routed in lowercase without
+// port, and requests to a wildcard host get its canonical form as Host, unless
+// the ServeMux has routes for their actual host. The actual host and the
+// values of the wildcards are restored by the muxHandler.
+func (router *Router) routeHost(request *http.Request) (*http.Request, string) {
+	if !router.hostRouting {
+		_, pattern := router.serveMux.Handler(request)
+		return request, pattern
+	}
+	host := request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	routed := request.WithContext(context.WithValue(request.Context(), hostMatchKey{}, &hostMatch{host: request.Host}))
+	routed.Host = host
+	_, pattern := router.serveMux.Handler(routed)
+	if pattern != "" && pattern[0] != '/' {
+		return routed, pattern
+	}
+	for _, p := range router.hosts {
+		params, ok := p.match(host)
+		if !ok {
+			continue
+		}
+		wildcard := request.WithContext(context.WithValue(request.Context(), hostMatchKey{}, &hostMatch{host: request.Host, params: params}))
+		wildcard.Host = p.canonical
+		if _, hostPattern := router.serveMux.Handler(wildcard); hostPattern != "" && hostPattern[0] != '/' {
+			return wildcard, hostPattern
+		}
+	}
+	return routed, pattern
+}
+
+// restoreHost undoes the changes of routeHost
+func restoreHost(request *http.Request) {
+	match, ok := request.Context().Value(hostMatchKey{}).(*hostMatch)
+	if !ok {
+		return
+	}
+	request.Host = match.host
+	for name, value := range match.params {
+		request.SetPathValue(name, value)
+	}
+}
+
+// patternPath returns the path of the pattern without its host
+func patternPath(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		return pattern[i:]
+	}
+	return pattern
+}
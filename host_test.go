@@ -0,0 +1,57 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHost(t *testing.T) {
+	router := NewRouter()
+	reply := func(name string) Endpoint {
+		return func(request Request) Response {
+			return String(status.OK, name+" "+request.RouteParams.Get("tenant")+" "+request.Host)
+		}
+	}
+	router.Get("/users", reply("default"))
+	router.Host("api.example.com").Get("/users", reply("api"))
+	tenants := router.Host("{tenant}.example.com")
+	tenants.Get("/users", reply("tenant")).Where("tenant", Slug)
+	tenants.Group("/admin").Get("/", reply("tenant admin"))
+	router.Host("{tenant}.example.com").Get("/", reply("tenant home"))
+
+	for _, test := range []struct {
+		host, path, expected string
+	}{
+		{"example.com", "/users", "default  example.com"},
+		{"api.example.com", "/users", "api  api.example.com"},
+		{"API.example.com:8080", "/users", "api  API.example.com:8080"},
+		{"acme.example.com", "/users", "tenant acme acme.example.com"},
+		{"acme.example.com:8080", "/admin", "tenant admin acme acme.example.com:8080"},
+		{"acme.example.com", "/", "tenant home acme acme.example.com"},
+		{"other.example.com.", "/users", "tenant other other.example.com."},
+		{"a.b.example.com", "/users", "default  a.b.example.com"},
+	} {
+		request := httptest.NewRequest(MethodGet, test.path, nil)
+		request.Host = test.host
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Body.String() != test.expected {
+			t.Errorf("%v%v: expected %q, got %q", test.host, test.path, test.expected, recorder.Body.String())
+		}
+	}
+
+	request := httptest.NewRequest(MethodGet, "/users", nil)
+	request.Host = "ACME_.example.com"
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.NotFound {
+		t.Errorf("expected the constrained host parameter to be not found, got %v", recorder.Code)
+	}
+
+	router.Host("api.{region.example.com")
+	if router.HasError() == nil {
+		t.Errorf("expected malformed hosts to be rejected")
+	}
+}
@@ -0,0 +1,405 @@
+package there
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrorWsQueueFull is returned, when a message is sent to a HubClient, whose
+// send queue is full, because it reads slower than messages are sent
+var ErrorWsQueueFull = errors.New("websocket send queue full")
+
+// HubOverflow defines what happens to a HubClient, whose send queue is full
+type HubOverflow int
+
+const (
+	// HubDisconnect closes the WebSocket of the client with
+	// WsClosePolicyViolation, so it can reconnect and catch up. This is the default.
+	HubDisconnect HubOverflow = iota
+	// HubDropMessage drops the message for the client
+	HubDropMessage
+)
+
+// Hub fans messages out to many WebSockets, which can be grouped into rooms.
+// Messages are written by a goroutine per client from its send queue, so a
+// slow client does not hold up the others. When the server of the router
+// shuts down, every client gets its queued messages and a close frame with
+// WsCloseGoingAway.
+//
+//	hub := &there.Hub{}
+//	router.Get("/chat/{room}", func(request there.Request) there.Response {
+//		room := request.RouteParams.Get("room")
+//		return hub.WebSocket(func(client *there.HubClient) {
+//			client.Join(room)
+//			for {
+//				_, message, err := client.ReadMessage()
+//				if err != nil {
+//					return
+//				}
+//				hub.BroadcastTo(room, there.WsMessageText, message)
+//			}
+//		})
+//	})
+//	router.OnShutdown(hub.Shutdown)
+type Hub struct {
+	// QueueSize is the amount of messages queued per client. Defaults to 64.
+	QueueSize int
+	// Overflow defines what happens, when the queue of a client is full.
+	// Defaults to HubDisconnect.
+	Overflow HubOverflow
+
+	// OnConnect and OnDisconnect are called, when a client connects and disconnects
+	OnConnect    func(client *HubClient)
+	OnDisconnect func(client *HubClient)
+	// OnJoin and OnLeave are called, when a client joins or leaves a room, like
+	// to announce its presence to the other members. Disconnecting clients
+	// leave all their rooms.
+	OnJoin  func(client *HubClient, room string)
+	OnLeave func(client *HubClient, room string)
+
+	mutex   sync.Mutex
+	clients map[*HubClient]struct{}
+	rooms   map[string]map[*HubClient]struct{}
+	closed  bool
+	// connected counts the clients, so Shutdown can wait for them
+	connected sync.WaitGroup
+}
+
+// HubClient is a WebSocket connected to a Hub. Messages sent with Send are
+// queued, while writing to the embedded WsConn directly bypasses the queue.
+type HubClient struct {
+	*WsConn
+	hub *Hub
+	// rooms are the rooms the client is a member of, guarded by the mutex of the hub
+	rooms map[string]struct{}
+	// connected is false, once the client disconnected, guarded by the mutex of the hub
+	connected bool
+
+	mutex  sync.Mutex
+	queue  chan hubMessage
+	closed bool
+	// closeCode is sent after the queued messages, if not zero
+	closeCode   int
+	closeReason string
+	// done is closed, once the queue was written
+	done chan struct{}
+}
+
+type hubMessage struct {
+	messageType WsMessageType
+	data        []byte
+}
+
+// WebSocket upgrades the connection like WebSocket and connects it to the hub,
+// before the handler runs. Once the handler returns, the client leaves all
+// rooms and the queued messages are written, before the WebSocket is closed.
+// Connections are closed with WsCloseGoingAway, after the hub was shut down.
+func (h *Hub) WebSocket(handler func(client *HubClient), options ...WebSocketOptions) Response {
+	return WebSocket(func(conn *WsConn) {
+		client, ok := h.connect(conn)
+		if !ok {
+			_ = conn.Close(WsCloseGoingAway, "server shutting down")
+			return
+		}
+		defer h.disconnect(client)
+		handler(client)
+	}, options...)
+}
+
+func (h *Hub) connect(conn *WsConn) (*HubClient, bool) {
+	queueSize := h.QueueSize
+	if queueSize <= 0 {
+		queueSize = 64
+	}
+	client := &HubClient{
+		WsConn:    conn,
+		hub:       h,
+		rooms:     map[string]struct{}{},
+		connected: true,
+		queue:     make(chan hubMessage, queueSize),
+		done:      make(chan struct{}),
+	}
+
+	h.mutex.Lock()
+	if h.closed {
+		h.mutex.Unlock()
+		return nil, false
+	}
+	if h.clients == nil {
+		h.clients = map[*HubClient]struct{}{}
+	}
+	h.clients[client] = struct{}{}
+	h.connected.Add(1)
+	h.mutex.Unlock()
+
+	conn.writeMutex.Lock()
+	conn.shutdown = client.goingAway
+	conn.writeMutex.Unlock()
+	go client.writeQueue()
+
+	if h.OnConnect != nil {
+		h.OnConnect(client)
+	}
+	return client, true
+}
+
+func (h *Hub) disconnect(client *HubClient) {
+	h.mutex.Lock()
+	client.connected = false
+	rooms := client.roomsLocked()
+	for _, room := range rooms {
+		h.leaveLocked(client, room)
+	}
+	delete(h.clients, client)
+	h.mutex.Unlock()
+
+	if h.OnLeave != nil {
+		for _, room := range rooms {
+			h.OnLeave(client, room)
+		}
+	}
+	if h.OnDisconnect != nil {
+		h.OnDisconnect(client)
+	}
+
+	// the queued messages are written, unless the client stopped reading
+	_ = client.conn.SetWriteDeadline(time.Now().Add(wsShutdownTimeout))
+	client.closeQueue(0, "")
+	<-client.done
+	h.connected.Done()
+}
+
+// Broadcast sends the message to all clients
+func (h *Hub) Broadcast(messageType WsMessageType, data []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for client := range h.clients {
+		_ = client.enqueue(hubMessage{messageType: messageType, data: data})
+	}
+}
+
+// BroadcastTo sends the message to the members of the room
+func (h *Hub) BroadcastTo(room string, messageType WsMessageType, data []byte) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for client := range h.rooms[room] {
+		_ = client.enqueue(hubMessage{messageType: messageType, data: data})
+	}
+}
+
+// BroadcastJson sends the marshalled data as text message to the members of the room
+func (h *Hub) BroadcastJson(room string, data any) error {
+	message, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	h.BroadcastTo(room, WsMessageText, message)
+	return nil
+}
+
+// Members returns the clients in the room
+func (h *Hub) Members(room string) []*HubClient {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	members := make([]*HubClient, 0, len(h.rooms[room]))
+	for client := range h.rooms[room] {
+		members = append(members, client)
+	}
+	return members
+}
+
+// Rooms returns the names of the rooms with at least one member
+func (h *Hub) Rooms() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Len returns the amount of connected clients
+func (h *Hub) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return len(h.clients)
+}
+
+// Shutdown closes all clients with WsCloseGoingAway, after their queued
+// messages were written, and waits for them to disconnect, or until the
+// context is done. New connections are rejected afterwards. Register it with
+// OnShutdown of the router.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mutex.Lock()
+	h.closed = true
+	clients := make([]*HubClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mutex.Unlock()
+
+	for _, client := range clients {
+		client.goingAway()
+	}
+	disconnected := make(chan struct{})
+	go func() {
+		h.connected.Wait()
+		close(disconnected)
+	}()
+	select {
+	case <-disconnected:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) leaveLocked(client *HubClient, room string) {
+	delete(client.rooms, room)
+	delete(h.rooms[room], client)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Join adds the client to the room. Joining a room twice has no effect.
+func (c *HubClient) Join(room string) {
+	h := c.hub
+	h.mutex.Lock()
+	if _, joined := c.rooms[room]; joined || !c.connected {
+		h.mutex.Unlock()
+		return
+	}
+	c.rooms[room] = struct{}{}
+	if h.rooms == nil {
+		h.rooms = map[string]map[*HubClient]struct{}{}
+	}
+	if h.rooms[room] == nil {
+		h.rooms[room] = map[*HubClient]struct{}{}
+	}
+	h.rooms[room][c] = struct{}{}
+	h.mutex.Unlock()
+
+	if h.OnJoin != nil {
+		h.OnJoin(c, room)
+	}
+}
+
+// Leave removes the client from the room
+func (c *HubClient) Leave(room string) {
+	h := c.hub
+	h.mutex.Lock()
+	if _, joined := c.rooms[room]; !joined {
+		h.mutex.Unlock()
+		return
+	}
+	h.leaveLocked(c, room)
+	h.mutex.Unlock()
+
+	if h.OnLeave != nil {
+		h.OnLeave(c, room)
+	}
+}
+
+// Rooms returns the rooms the client is a member of
+func (c *HubClient) Rooms() []string {
+	c.hub.mutex.Lock()
+	defer c.hub.mutex.Unlock()
+	return c.roomsLocked()
+}
+
+func (c *HubClient) roomsLocked() []string {
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Send queues a text or binary message. If the queue is full,
+// ErrorWsQueueFull is returned and the Overflow of the hub applies.
+func (c *HubClient) Send(messageType WsMessageType, data []byte) error {
+	if messageType != WsMessageText && messageType != WsMessageBinary {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.enqueue(hubMessage{messageType: messageType, data: data})
+}
+
+// SendJson queues the marshalled data as text message
+func (c *HubClient) SendJson(data any) error {
+	message, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.Send(WsMessageText, message)
+}
+
+func (c *HubClient) enqueue(message hubMessage) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return ErrorWsClosed
+	}
+	select {
+	case c.queue <- message:
+		return nil
+	default:
+	}
+	if c.hub.Overflow == HubDropMessage {
+		return ErrorWsQueueFull
+	}
+	// the queued messages are dropped, as the client does not keep up anyway
+	c.closed = true
+	close(c.queue)
+	go func() {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(wsShutdownTimeout))
+		_ = c.WsConn.Close(WsClosePolicyViolation, "send queue full")
+	}()
+	return ErrorWsQueueFull
+}
+
+// closeQueue stops queueing messages. The close frame with the code is
+// written after the queued messages, if the code is not zero.
+func (c *HubClient) closeQueue(code int, reason string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.closeCode, c.closeReason = code, reason
+	close(c.queue)
+}
+
+// goingAway closes the client with WsCloseGoingAway after its queued messages
+func (c *HubClient) goingAway() {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsShutdownTimeout))
+	c.closeQueue(WsCloseGoingAway, "server shutting down")
+}
+
+// writeQueue writes the queued messages, until the queue is closed
+func (c *HubClient) writeQueue() {
+	defer close(c.done)
+	for message := range c.queue {
+		if err := c.WriteMessage(message.messageType, message.data); err != nil {
+			// the read of the handler fails as well, which disconnects the client
+			_ = c.conn.Close()
+			for range c.queue {
+			}
+			return
+		}
+	}
+	c.mutex.Lock()
+	code, reason := c.closeCode, c.closeReason
+	c.mutex.Unlock()
+	if code != 0 {
+		_ = c.WsConn.Close(code, reason)
+	}
+}
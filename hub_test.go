@@ -0,0 +1,151 @@
+package there
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHub(t *testing.T) {
+	presence := make(chan string, 16)
+	hub := &Hub{
+		OnJoin: func(client *HubClient, room string) {
+			presence <- "join " + room
+		},
+		OnLeave: func(client *HubClient, room string) {
+			presence <- "leave " + room
+		},
+	}
+	router := NewRouter()
+	router.Get("/rooms/{room}", func(request Request) Response {
+		room := request.RouteParams.Get("room")
+		return hub.WebSocket(func(client *HubClient) {
+			client.Join(room)
+			for {
+				messageType, message, err := client.ReadMessage()
+				if err != nil {
+					return
+				}
+				hub.BroadcastTo(room, messageType, message)
+			}
+		})
+	})
+	server := httptest.NewUnstartedServer(router)
+	server.Config = router.Server
+	server.Start()
+	defer server.Close()
+
+	dial := func(room string) *wsClient {
+		client, response := dialWebSocket(t, server, "/rooms/"+room, nil)
+		if response.StatusCode != status.SwitchingProtocols {
+			t.Fatalf("unexpected handshake %v", response.StatusCode)
+		}
+		_ = client.conn.SetReadDeadline(time.Now().Add(time.Second))
+		if event := <-presence; event != "join "+room {
+			t.Fatalf("expected the client to join %v, got %v", room, event)
+		}
+		return client
+	}
+	expect := func(client *wsClient, expected string) {
+		t.Helper()
+		if opcode, payload, err := client.read(); err != nil || opcode != wsOpText || string(payload) != expected {
+			t.Errorf("expected %q, got %v %q %v", expected, opcode, payload, err)
+		}
+	}
+
+	first, second, other := dial("a"), dial("a"), dial("b")
+	if hub.Len() != 3 || len(hub.Members("a")) != 2 || len(hub.Rooms()) != 2 {
+		t.Errorf("unexpected members %v %v %v", hub.Len(), hub.Members("a"), hub.Rooms())
+	}
+
+	first.write(wsOpText, true, []byte("hello a"))
+	expect(first, "hello a")
+	expect(second, "hello a")
+	if err := hub.BroadcastJson("b", map[string]string{"to": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	expect(other, `{"to":"b"}`)
+	hub.Broadcast(WsMessageText, []byte("everyone"))
+	for _, client := range []*wsClient{first, second, other} {
+		expect(client, "everyone")
+	}
+
+	second.write(wsOpClose, true, []byte{0x03, 0xe8})
+	if event := <-presence; event != "leave a" {
+		t.Errorf("expected the client to leave, got %v", event)
+	}
+	if opcode, _, err := second.read(); err != nil || opcode != wsOpClose {
+		t.Errorf("expected the close to be answered, got %v %v", opcode, err)
+	}
+
+	// shutting the router down closes the remaining clients with a close frame
+	if err := router.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range []*wsClient{first, other} {
+		opcode, payload, err := client.read()
+		if err != nil || opcode != wsOpClose || len(payload) < 2 || binary.BigEndian.Uint16(payload) != WsCloseGoingAway {
+			t.Errorf("expected a going away close frame, got %v %q %v", opcode, payload, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Shutdown(ctx); err != nil {
+		t.Errorf("expected all clients to disconnect, got %v", err)
+	}
+	if hub.Len() != 0 || len(hub.Rooms()) != 0 {
+		t.Errorf("expected no clients, got %v %v", hub.Len(), hub.Rooms())
+	}
+}
+
+func TestHubOverflow(t *testing.T) {
+	for _, overflow := range []HubOverflow{HubDropMessage, HubDisconnect} {
+		connected := make(chan *HubClient, 1)
+		hub := &Hub{QueueSize: 1, Overflow: overflow}
+		router := NewRouter()
+		router.Get("/", func(request Request) Response {
+			return hub.WebSocket(func(client *HubClient) {
+				connected <- client
+				_, _, _ = client.ReadMessage()
+			})
+		})
+		server := httptest.NewServer(router)
+
+		conn, _ := dialWebSocket(t, server, "/", nil)
+		_ = conn.conn.SetReadDeadline(time.Now().Add(time.Second))
+		client := <-connected
+
+		// holding the write lock stalls the writer of the queue, which takes
+		// one message at most, so the queue is full after three messages
+		client.writeMutex.Lock()
+		var err error
+		for i := 0; i < 3 && err == nil; i++ {
+			err = client.Send(WsMessageText, []byte("message"))
+		}
+		client.writeMutex.Unlock()
+		if err != ErrorWsQueueFull {
+			t.Errorf("expected %v, got %v", ErrorWsQueueFull, err)
+		}
+
+		if overflow == HubDisconnect {
+			for {
+				opcode, payload, err := conn.read()
+				if err != nil {
+					t.Fatalf("expected a close frame, got %v", err)
+				}
+				if opcode == wsOpClose {
+					if len(payload) < 2 || binary.BigEndian.Uint16(payload) != WsClosePolicyViolation {
+						t.Errorf("expected a policy violation, got %q", payload)
+					}
+					break
+				}
+			}
+		}
+		conn.conn.Close()
+		server.Close()
+	}
+}
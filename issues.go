@@ -0,0 +1,158 @@
+package there
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// Issue is a non-fatal problem, that was noticed while serving a request, like
+// a deprecated route, a nearly exhausted quota or a slow dependency
+type Issue struct {
+	// Source names the component, that recorded the issue, like "quota"
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Source == "" {
+		return i.Message
+	}
+	return i.Source + ": " + i.Message
+}
+
+// IssueReporting surfaces the issues recorded with Request.AddIssue, so
+// middlewares do not have to invent their own channels
+type IssueReporting struct {
+	// Header the issues are sent in, one value per issue. With header.ResponseWarning,
+	// the values are formatted as warnings with the code 199, like
+	//
+	//	Warning: 199 - "quota: 95% of the daily quota used"
+	//
+	// Otherwise, the values are formatted as "source: message". Empty disables the header.
+	Header string
+	// Log writes the issues of a request to the log, after it was served
+	Log bool
+}
+
+func (r IssueReporting) enabled() bool {
+	return r.Header != "" || r.Log
+}
+
+// issueCollector gathers the issues of a request. Middlewares may record issues concurrently.
+type issueCollector struct {
+	mutex  sync.Mutex
+	issues []Issue
+}
+
+func (c *issueCollector) add(issue Issue) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.issues = append(c.issues, issue)
+}
+
+func (c *issueCollector) list() []Issue {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Issue(nil), c.issues...)
+}
+
+type issueCollectorKey struct{}
+
+// issuesOf returns the issueCollector of the request and creates it, if necessary
+func issuesOf(r *http.Request) *issueCollector {
+	if issues, ok := r.Context().Value(issueCollectorKey{}).(*issueCollector); ok {
+		return issues
+	}
+	issues := &issueCollector{}
+	*r = *r.WithContext(context.WithValue(r.Context(), issueCollectorKey{}, issues))
+	return issues
+}
+
+// AddIssue records a non-fatal issue of the request. Issues are reported
+// according to the IssueReporting of the RouterConfiguration, but they can
+// also be read with Issues, for example by a logging middleware.
+//
+//	if remaining < quota/20 {
+//		request.AddIssue("quota", "95% of the daily quota used")
+//	}
+func (r *Request) AddIssue(source, message string) {
+	issuesOf(r.Request).add(Issue{Source: source, Message: message})
+}
+
+// Issues returns the issues recorded for the request so far
+func (r *Request) Issues() []Issue {
+	issues, ok := r.Request.Context().Value(issueCollectorKey{}).(*issueCollector)
+	if !ok {
+		return nil
+	}
+	return issues.list()
+}
+
+// issueWriter adds the issues as headers, right before the headers are sent
+type issueWriter struct {
+	http.ResponseWriter
+	request   *http.Request
+	reporting IssueReporting
+	issues    *issueCollector
+	written   bool
+}
+
+func (w *issueWriter) writeIssues() {
+	if w.written {
+		return
+	}
+	w.written = true
+	if w.reporting.Header == "" {
+		return
+	}
+	for _, issue := range w.issues.list() {
+		value := issue.String()
+		if http.CanonicalHeaderKey(w.reporting.Header) == header.ResponseWarning {
+			value = "199 - " + strconv.Quote(value)
+		}
+		w.ResponseWriter.Header().Add(w.reporting.Header, value)
+	}
+}
+
+func (w *issueWriter) WriteHeader(code int) {
+	w.writeIssues()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *issueWriter) Write(b []byte) (int, error) {
+	w.writeIssues()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *issueWriter) Flush() {
+	w.writeIssues()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *issueWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish sends the headers of responses without a body and logs the issues
+func (w *issueWriter) finish() {
+	w.writeIssues()
+	if !w.reporting.Log {
+		return
+	}
+	issues := w.issues.list()
+	if len(issues) == 0 {
+		return
+	}
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.String()
+	}
+	log.Printf("issues: %v %v: %v", w.request.Method, w.request.URL.Path, strings.Join(messages, "; "))
+}
@@ -0,0 +1,50 @@
+package there
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestIssues(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(request Request, next Response) Response {
+		request.AddIssue("quota", "95% of the daily quota used")
+		return next
+	})
+	var issues []Issue
+	router.Get("/", func(request Request) Response {
+		request.AddIssue("", `slow "db"`)
+		issues = request.Issues()
+		return Status(status.NoContent)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/", nil))
+		return recorder
+	}
+
+	if recorder := serve(); len(recorder.Header().Values(header.ResponseWarning)) != 0 {
+		t.Errorf("issues must not be reported by default")
+	}
+	expected := []Issue{{Source: "quota", Message: "95% of the daily quota used"}, {Message: `slow "db"`}}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected %v, got %v", expected, issues)
+	}
+
+	router.Configuration.Issues = IssueReporting{Header: header.ResponseWarning, Log: true}
+	warnings := serve().Header().Values(header.ResponseWarning)
+	if !reflect.DeepEqual(warnings, []string{`199 - "quota: 95% of the daily quota used"`, `199 - "slow \"db\""`}) {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	router.Configuration.Issues = IssueReporting{Header: "X-Issues"}
+	values := serve().Header().Values("X-Issues")
+	if !reflect.DeepEqual(values, []string{"quota: 95% of the daily quota used", `slow "db"`}) {
+		t.Errorf("unexpected issues %v", values)
+	}
+}
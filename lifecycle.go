@@ -0,0 +1,143 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// OnStartup registers a hook, that runs before the router accepts connections,
+// like to connect to a database. If a hook fails, then the remaining hooks are
+// skipped and listening returns the error.
+func (router *Router) OnStartup(hook func(ctx context.Context) error) *Router {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	router.startupHooks = append(router.startupHooks, hook)
+	return router
+}
+
+// OnShutdown registers a hook, that runs in Shutdown after the in-flight
+// requests completed, like to close a database. The hooks run in reverse
+// order of their registration, so resources are released in the opposite
+// order they were acquired.
+func (router *Router) OnShutdown(hook func(ctx context.Context) error) *Router {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	router.shutdownHooks = append(router.shutdownHooks, hook)
+	return router
+}
+
+// start checks the router and runs the startup hooks
+func (router *Router) start(ctx context.Context) error {
+	if err := router.HasError(); err != nil {
+		return err
+	}
+	if err := router.SelfTest(); err != nil {
+		return err
+	}
+	router.mutex.Lock()
+	hooks := router.startupHooks
+	router.mutex.Unlock()
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListenWithContext serves on the port until the context is done, and then
+// shuts the router down gracefully with Shutdown. The shutdown waits at most
+// for the ShutdownTimeout of the RouterConfiguration. If the router stopped
+// because of the context or Shutdown, then the error of the shutdown is
+// returned, which is nil if it completed in time.
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//	defer stop()
+//	if err := router.ListenWithContext(ctx, 8080); err != nil {
+//		log.Fatal(err)
+//	}
+func (router *Router) ListenWithContext(ctx context.Context, port Port) error {
+	if err := router.start(ctx); err != nil {
+		return err
+	}
+	router.Server.Addr = port.ToAddr()
+	listener, err := net.Listen("tcp", router.Server.Addr)
+	if err != nil {
+		return err
+	}
+	return router.serveUntilDone(ctx, func() error {
+		return router.Server.Serve(listener)
+	})
+}
+
+// serveUntilDone serves until serve fails or the context is done
+func (router *Router) serveUntilDone(ctx context.Context, serve func() error) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- serve()
+	}()
+
+	select {
+	case err := <-served:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		// Shutdown was called by someone else, wait for it to complete
+		<-router.stopped
+		return router.shutdownErr
+	case <-ctx.Done():
+	}
+
+	shutdownCtx := context.WithoutCancel(ctx)
+	if timeout := router.Configuration.ShutdownTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, timeout)
+		defer cancel()
+	}
+	err := router.Shutdown(shutdownCtx)
+	<-served
+	return err
+}
+
+// Shutdown stops the router gracefully. It marks the router as not Ready,
+// stops accepting connections, waits for the in-flight requests to complete
+// and runs the OnShutdown hooks. If the context is done before, then the
+// remaining connections are closed and the error of the context is returned
+// together with the errors of the hooks, which still run. Only the first call
+// shuts down, others wait for it and return the same error.
+func (router *Router) Shutdown(ctx context.Context) error {
+	router.shutdownOnce.Do(func() {
+		defer close(router.stopped)
+		router.ready.Store(false)
+
+		var errs []error
+		if err := router.Server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+			if err := router.Server.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		router.mutex.Lock()
+		hooks := router.shutdownHooks
+		router.mutex.Unlock()
+		hookCtx := ctx
+		if ctx.Err() != nil {
+			// give the hooks a chance to clean up, even if the drain timed out
+			var cancel context.CancelFunc
+			hookCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+		}
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if err := hooks[i](hookCtx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		router.shutdownErr = errors.Join(errs...)
+	})
+	<-router.stopped
+	return router.shutdownErr
+}
@@ -0,0 +1,87 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	router := NewRouter()
+	started := make(chan struct{})
+	router.Get("/slow", func(request Request) Response {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return String(status.OK, "done")
+	})
+	var order []string
+	router.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	router.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "cache")
+		return errors.New("cache failed")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- router.serveUntilDone(ctx, func() error {
+			return router.Server.Serve(listener)
+		})
+	}()
+
+	response := make(chan string)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	cancel()
+	if body := <-response; body != "done" {
+		t.Errorf("expected the in-flight request to complete, got %v", body)
+	}
+	err = <-stopped
+	if err == nil || err.Error() != "cache failed" {
+		t.Errorf("expected the error of the hook, got %v", err)
+	}
+	if !slices.Equal(order, []string{"cache", "database"}) {
+		t.Errorf("expected the hooks to run in reverse order, got %v", order)
+	}
+	if router.Ready() {
+		t.Errorf("expected the router not to be ready after the shutdown")
+	}
+	if again := router.Shutdown(context.Background()); again != err {
+		t.Errorf("expected the same error again, got %v", again)
+	}
+}
+
+func TestStartupHookFails(t *testing.T) {
+	router := NewRouter()
+	router.OnStartup(func(ctx context.Context) error {
+		return errors.New("database unreachable")
+	})
+	err := router.ListenWithContext(context.Background(), 0)
+	if err == nil || err.Error() != "database unreachable" {
+		t.Errorf("expected the error of the hook, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package there
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// LiveReloadConfiguration configures the development live reload of LiveReload
+type LiveReloadConfiguration struct {
+	// Paths are the files and directories that are watched for changes.
+	// Directories are watched recursively.
+	Paths []string
+	// Interval is the time between two checks for changes. Defaults to 500ms.
+	Interval time.Duration
+	// Route is the path of the event stream the browsers listen to.
+	// Defaults to "/__there/livereload".
+	Route string
+}
+
+// LiveReload is meant for development only. It injects a small script into every
+// html response, which reloads the page whenever one of the watched files changes
+// or the server restarts. The changes are pushed to the browser over an event
+// stream registered on the configured route.
+//
+//	router := there.NewRouter()
+//	if development {
+//		router.LiveReload(there.LiveReloadConfiguration{
+//			Paths: []string{"./templates", "./static"},
+//		})
+//	}
+func (router *Router) LiveReload(configuration LiveReloadConfiguration) *Router {
+	if configuration.Interval <= 0 {
+		configuration.Interval = 500 * time.Millisecond
+	}
+	if configuration.Route == "" {
+		configuration.Route = "/__there/livereload"
+	}
+
+	l := &liveReload{
+		configuration: configuration,
+		clients:       map[chan struct{}]struct{}{},
+		stop:          make(chan struct{}),
+		script: []byte(`<script>(function(){var s=new EventSource("` + configuration.Route + `"),o=false;` +
+			`s.onopen=function(){if(o)location.reload();o=true};s.onmessage=function(){location.reload()}})()</script>`),
+	}
+	go l.watch()
+	router.Server.RegisterOnShutdown(func() {
+		l.stopOnce.Do(func() {
+			close(l.stop)
+		})
+	})
+
+	router.Get(configuration.Route, l.events)
+	router.Use(l.inject)
+	return router
+}
+
+type liveReload struct {
+	configuration LiveReloadConfiguration
+	script        []byte
+
+	mutex    sync.Mutex
+	clients  map[chan struct{}]struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// watch polls the modification times of the watched files and notifies all
+// clients, as soon as something changed
+func (l *liveReload) watch() {
+	ticker := time.NewTicker(l.configuration.Interval)
+	defer ticker.Stop()
+	last := l.snapshot()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			current := l.snapshot()
+			if !snapshotsEqual(last, current) {
+				l.notify()
+			}
+			last = current
+		}
+	}
+}
+
+func (l *liveReload) snapshot() map[string]time.Time {
+	files := map[string]time.Time{}
+	for _, root := range l.configuration.Paths {
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err == nil {
+				files[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func snapshotsEqual(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, modified := range a {
+		if other, ok := b[path]; !ok || !other.Equal(modified) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *liveReload) notify() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for client := range l.clients {
+		select {
+		case client <- struct{}{}:
+		default: // the client already has a pending reload
+		}
+	}
+}
+
+// events streams a reload event to the browser on every change
+func (l *liveReload) events(request Request) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		client := make(chan struct{}, 1)
+		l.mutex.Lock()
+		l.clients[client] = struct{}{}
+		l.mutex.Unlock()
+		defer func() {
+			l.mutex.Lock()
+			delete(l.clients, client)
+			l.mutex.Unlock()
+		}()
+
+		stream := Flusher(rw, r)
+		stream.WriteTimeout = 10 * time.Second
+		stream.Header().Set(header.ContentType, ContentTypeTextEventDashStream)
+		stream.Header().Set(header.CacheControl, "no-cache")
+		stream.WriteHeader(http.StatusOK)
+		if stream.Flush() != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-stream.Done():
+				return
+			case <-l.stop:
+				return
+			case <-client:
+				_, err := stream.Write([]byte("data: reload\n\n"))
+				if err == nil {
+					err = stream.Flush()
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// inject adds the reload script to html responses. Only requests accepting html
+// are buffered, so event streams and api calls pass through untouched.
+func (l *liveReload) inject(request Request, next Response) Response {
+	if !strings.Contains(request.Request.Header.Get(header.RequestAccept), ContentTypeTextHtml) {
+		return next
+	}
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writer := newBufferedResponseWriter(rw, r)
+		next.ServeHTTP(writer, r)
+
+		if strings.HasPrefix(rw.Header().Get(header.ContentType), ContentTypeTextHtml) {
+			body := writer.body.Bytes()
+			index := bytes.LastIndex(bytes.ToLower(body), []byte("</body>"))
+			if index < 0 {
+				index = len(body)
+			}
+			injected := make([]byte, 0, len(body)+len(l.script))
+			injected = append(injected, body[:index]...)
+			injected = append(injected, l.script...)
+			injected = append(injected, body[index:]...)
+			writer.body.Reset()
+			writer.body.Write(injected)
+			rw.Header().Del(header.ContentLength)
+		}
+
+		err := writer.flush()
+		if err != nil && !errors.Is(err, ErrorClientDisconnected) {
+			log.Printf("liveReload: ServeHttp write failed: %v", err)
+		}
+	})
+}
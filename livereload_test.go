@@ -0,0 +1,62 @@
+package there
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestLiveReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.LiveReload(LiveReloadConfiguration{Paths: []string{dir}, Interval: 5 * time.Millisecond})
+	defer router.Server.Shutdown(context.Background())
+	router.Get("/", func(request Request) Response {
+		return Headers(map[string]string{header.ContentType: ContentTypeTextHtml},
+			String(status.OK, "<html><body>Hello there</body></html>"))
+	})
+
+	request := httptest.NewRequest(MethodGet, "/", nil)
+	request.Header.Set(header.RequestAccept, ContentTypeTextHtml)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	body := recorder.Body.String()
+	if !strings.Contains(body, "EventSource(\"/__there/livereload\")") || !strings.HasSuffix(body, "</script></body></html>") {
+		t.Fatalf("script was not injected: %v", body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	events := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(events, httptest.NewRequest(MethodGet, "/__there/livereload", nil).WithContext(ctx))
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(file, []byte("v2"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, time.Now(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+	if !strings.Contains(events.Body.String(), "data: reload") {
+		t.Errorf("no reload event was sent: %q", events.Body.String())
+	}
+}
@@ -0,0 +1,215 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// ErrorUnknownRoute is returned, if no route with the name, or no path for the locale, was registered
+var ErrorUnknownRoute = errors.New("unknown route")
+
+// LocalizedPaths maps a locale to the path of a route in that language
+type LocalizedPaths map[string]string
+
+// HandleLocalized registers the endpoint under a localized path for every
+// locale. All paths resolve to the same endpoint, which can read the locale of
+// the matched path with Request.Locale. Builder calls, like With or Doc, apply
+// to every path. Use LocalizedPath to generate the path of another locale.
+//
+//	router.HandleLocalized("about", there.LocalizedPaths{
+//		"en": "/en/about",
+//		"de": "/de/ueber-uns",
+//	}, About, there.MethodGet)
+func (group *RouteGroup) HandleLocalized(name string, paths LocalizedPaths, endpoint Endpoint, methods ...string) *RouteRouteGroupBuilder {
+	group.assert(len(paths) > 0, "localized route \""+name+"\" needs at least one path")
+
+	locales := make([]string, 0, len(paths))
+	for locale := range paths {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	var builder *RouteRouteGroupBuilder
+	for _, locale := range locales {
+		route := group.Handle(paths[locale], endpoint, methods...)
+		for _, e := range route.endpoints() {
+			e.locale = locale
+		}
+		group.Router.registerLocalized(name, locale, route.muxHandler.pattern)
+		if builder == nil {
+			builder = route
+		} else {
+			builder.aliases = append(builder.aliases, route.Route)
+		}
+	}
+	return builder
+}
+
+func (router *Router) registerLocalized(name, locale, pattern string) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	if router.localized == nil {
+		router.localized = map[string]LocalizedPaths{}
+	}
+	if router.localized[name] == nil {
+		router.localized[name] = LocalizedPaths{}
+	}
+	router.localized[name][locale] = pattern
+}
+
+// LocalizedPath returns the path of the localized route in the locale, with
+// its route parameters filled in. The path does not contain the external
+// prefix, use Request.ExternalPath for links.
+//
+//	path, err := router.LocalizedPath("product", "de", map[string]string{"id": "42"})
+func (router *Router) LocalizedPath(name, locale string, params map[string]string) (string, error) {
+	router.mutex.Lock()
+	pattern, ok := router.localized[name][locale]
+	router.mutex.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %v in locale %v", ErrorUnknownRoute, name, locale)
+	}
+	return fillPattern(patternPath(pattern), params)
+}
+
+// fillPattern replaces the wildcards of a http.ServeMux pattern with the params
+func fillPattern(pattern string, params map[string]string) (string, error) {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := segment[1 : len(segment)-1]
+		if name == "$" {
+			segments[i] = ""
+			continue
+		}
+		remainder := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing route parameter %v", name)
+		}
+		if remainder {
+			parts := strings.Split(value, "/")
+			for j := range parts {
+				parts[j] = url.PathEscape(parts[j])
+			}
+			segments[i] = strings.Join(parts, "/")
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+type localeKey struct{}
+
+// Locale returns the locale of the localized path, the request matched, or the
+// one negotiated by AcceptLanguage. Empty, if neither applies.
+func (r *Request) Locale() string {
+	return localeOf(r.Request)
+}
+
+func localeOf(r *http.Request) string {
+	locale, _ := r.Context().Value(localeKey{}).(string)
+	return locale
+}
+
+// withLocale stores the locale of the matched path in the request
+func withLocale(request *Request, locale string) {
+	if locale != "" {
+		request.WithContext(context.WithValue(request.Context(), localeKey{}, locale))
+	}
+}
+
+// AcceptLanguage negotiates the locale of requests with the Accept-Language
+// header, unless the locale was already determined by a localized path. The
+// first supported locale is used, if none of the accepted ones is supported.
+// A supported locale matches an accepted one exactly, or by its primary
+// language, so "de" is served for "de-AT" and vice versa.
+//
+//	router.Use(there.AcceptLanguage("en", "de", "fr"))
+func AcceptLanguage(supported ...string) Middleware {
+	return func(request Request, next Response) Response {
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Add(header.ResponseVary, header.RequestAcceptLanguage)
+			if localeOf(r) == "" && len(supported) > 0 {
+				request := NewHttpRequest(rw, r)
+				withLocale(&request, negotiateLocale(r.Header.Get(header.RequestAcceptLanguage), supported))
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// negotiateLocale returns the supported locale, that is accepted with the highest quality
+func negotiateLocale(acceptLanguage string, supported []string) string {
+	best, bestQuality := supported[0], 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || quality <= bestQuality {
+			continue
+		}
+		if locale, ok := matchLocale(tag, supported); ok {
+			best, bestQuality = locale, quality
+		}
+	}
+	return best
+}
+
+func matchLocale(tag string, supported []string) (string, bool) {
+	for _, locale := range supported {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	for _, locale := range supported {
+		primary, _, _ := strings.Cut(locale, "-")
+		if strings.EqualFold(primary, language) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// localizedTemplate returns the most specific existing template for the
+// locale, like "page.de-AT.html", "page.de.html" and finally "page.html",
+// together with the locale of the chosen template
+func localizedTemplate(file, locale string) (string, string) {
+	if locale == "" {
+		return file, ""
+	}
+	extension := filepath.Ext(file)
+	base := strings.TrimSuffix(file, extension)
+	for {
+		candidate := base + "." + locale + extension
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, locale
+		}
+		index := strings.LastIndex(locale, "-")
+		if index < 0 {
+			return file, ""
+		}
+		locale = locale[:index]
+	}
+}
@@ -0,0 +1,86 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestHandleLocalized(t *testing.T) {
+	router := NewRouter()
+	router.HandleLocalized("product", LocalizedPaths{
+		"en": "/en/products/{id}",
+		"de": "/de/produkte/{id}",
+	}, func(request Request) Response {
+		return String(status.OK, request.Locale())
+	}, MethodGet).Meta(RouteMeta{"team": "shop"})
+
+	for path, locale := range map[string]string{"/en/products/1": "en", "/de/produkte/1": "de"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, path, nil))
+		if recorder.Code != status.OK || recorder.Body.String() != locale {
+			t.Errorf("%v: expected %v, got %v %v", path, locale, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	for _, e := range router.handlerKeeper["/de/produkte/{id}"].methods {
+		if e.meta["team"] != "shop" {
+			t.Errorf("builder calls must apply to every localized path")
+		}
+	}
+
+	p, err := router.LocalizedPath("product", "de", map[string]string{"id": "a b"})
+	if err != nil || p != "/de/produkte/a%20b" {
+		t.Errorf("unexpected path %v %v", p, err)
+	}
+	if _, err = router.LocalizedPath("product", "fr", nil); !errors.Is(err, ErrorUnknownRoute) {
+		t.Errorf("expected %v, got %v", ErrorUnknownRoute, err)
+	}
+	if _, err = router.LocalizedPath("product", "en", nil); err == nil {
+		t.Errorf("expected an error for the missing parameter")
+	}
+}
+
+func TestLocalizedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	templates := map[string]string{
+		"page.html":    "Hello {{ .Name }}",
+		"page.de.html": "Hallo {{ .Name }}",
+		"page.fr.html": "Bonjour {{ .Name }}",
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := NewRouter()
+	router.Use(AcceptLanguage("en", "de-AT", "fr"))
+	router.Get("/", func(request Request) Response {
+		return Html(status.OK, filepath.Join(dir, "page.html"), map[string]string{"Name": "there"})
+	})
+
+	tests := []struct {
+		acceptLanguage, body, contentLanguage string
+	}{
+		{"", "Hello there", ""},
+		{"de-DE,de;q=0.9", "Hallo there", "de"},
+		{"es, fr;q=0.8, de;q=0.5", "Bonjour there", "fr"},
+		{"es", "Hello there", ""},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(MethodGet, "/", nil)
+		request.Header.Set(header.RequestAcceptLanguage, test.acceptLanguage)
+		router.ServeHTTP(recorder, request)
+		if recorder.Body.String() != test.body || recorder.Header().Get(header.ResponseContentLanguage) != test.contentLanguage {
+			t.Errorf("%q: expected %q in %q, got %q in %q", test.acceptLanguage, test.body, test.contentLanguage,
+				recorder.Body.String(), recorder.Header().Get(header.ResponseContentLanguage))
+		}
+	}
+}
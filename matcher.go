@@ -0,0 +1,208 @@
+package there
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RouteMatch is the route a request path resolves to
+type RouteMatch struct {
+	// Pattern the route was registered with, including the prefix of its group
+	Pattern string
+	// Params are the values of the wildcards in the Pattern
+	Params map[string]string
+}
+
+// Path returns the path of the match, with its Params filled into the Pattern
+func (m RouteMatch) Path() (string, error) {
+	return fillPattern(m.Pattern, m.Params)
+}
+
+// Match resolves the method and target, a path with an optional query, to the
+// route serving it, without serving the request. Paths, that are redirected to
+// their canonical form, do not match. The target must not contain the BasePath.
+//
+//	match, ok := router.Match(there.MethodGet, "/users/42")
+//	// match.Pattern == "/users/{id}", match.Params["id"] == "42"
+func (router *Router) Match(method, target string) (RouteMatch, bool) {
+	parsed, err := url.ParseRequestURI(target)
+	if err != nil {
+		return RouteMatch{}, false
+	}
+	request := &http.Request{Method: method, URL: parsed, Host: "localhost", Header: http.Header{}}
+	handler, pattern := router.serveMux.Handler(request)
+	muxHandler, ok := handler.(*muxHandler)
+	if !ok {
+		return RouteMatch{}, false
+	}
+	endpoint, ok := muxHandler.methods[methodToInt(method)]
+	if !ok && method == MethodHead && router.Configuration.AutoHead {
+		endpoint, ok = muxHandler.methods[methodGet]
+	}
+	if !ok {
+		return RouteMatch{}, false
+	}
+	params := patternParams(pattern, parsed.EscapedPath())
+	if !endpoint.paramsValid(func(name string) string { return params[name] }) {
+		return RouteMatch{}, false
+	}
+	return RouteMatch{Pattern: pattern, Params: params}, true
+}
+
+// patternParams extracts the values of the wildcards of a matching http.ServeMux
+// pattern from the escaped path
+func patternParams(pattern, escapedPath string) map[string]string {
+	params := map[string]string{}
+	patternSegments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.TrimPrefix(escapedPath, "/"), "/")
+	for i, segment := range patternSegments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") || segment == "{$}" {
+			continue
+		}
+		name := segment[1 : len(segment)-1]
+		if remainder, ok := strings.CutSuffix(name, "..."); ok {
+			rest := ""
+			if i < len(pathSegments) {
+				rest = strings.Join(pathSegments[i:], "/")
+			}
+			params[remainder], _ = url.PathUnescape(rest)
+			break
+		}
+		if i < len(pathSegments) {
+			params[name], _ = url.PathUnescape(pathSegments[i])
+		}
+	}
+	return params
+}
+
+// Matcher checks the path matching of a router for panics and inconsistencies.
+// Use it to fuzz custom route sets in the own CI:
+//
+//	func FuzzRoutes(f *testing.F) {
+//		matcher := newRouter().Matcher()
+//		matcher.Seed(f)
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			if err := matcher.Fuzz(data); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+type Matcher struct {
+	router *Router
+}
+
+// Matcher returns a Matcher for the routes of the router
+func (router *Router) Matcher() *Matcher {
+	return &Matcher{router: router}
+}
+
+// Fuzz interprets the data as request line, like "POST /users/1" or just
+// "/users/1" for a GET request. It returns an error, if serving the request
+// panics, or if the matched route does not resolve to itself again, after its
+// params were filled back into the pattern. Invalid request lines are ignored.
+func (m *Matcher) Fuzz(data []byte) (err error) {
+	method, target := MethodGet, string(data)
+	if before, after, ok := strings.Cut(target, " "); ok && !strings.HasPrefix(before, "/") {
+		method, target = before, after
+	}
+	request, requestError := http.NewRequest(method, "http://localhost"+target, nil)
+	if requestError != nil || !strings.HasPrefix(target, "/") || request.URL.Host != "localhost" {
+		return nil
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("matcher: %v %q panicked: %v", method, target, recovered)
+		}
+	}()
+	m.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	match, ok := m.router.Match(method, target)
+	if !ok {
+		return nil
+	}
+	filled, fillError := match.Path()
+	if fillError != nil {
+		return fmt.Errorf("matcher: %v %q matched %v, but its path could not be built: %v", method, target, match.Pattern, fillError)
+	}
+	again, ok := m.router.Match(method, filled)
+	if !ok || again.Pattern != match.Pattern || !reflect.DeepEqual(again.Params, match.Params) {
+		return fmt.Errorf("matcher: %v %q matched %v with %v, but its path %q matched %v with %v",
+			method, target, match.Pattern, match.Params, filled, again.Pattern, again.Params)
+	}
+	return nil
+}
+
+// Corpus returns seed inputs for Fuzz: every registered route with its
+// wildcards filled with regular and unusual values, and some edge cases
+func (m *Matcher) Corpus() [][]byte {
+	values := []string{"1", "a b", "a/b", "ü", "..", "%"}
+	corpus := [][]byte{
+		[]byte("/"), []byte("//"), []byte("/%2F"), []byte("/a/../b"), []byte("/./"), []byte("OPTIONS *"),
+	}
+
+	m.router.mutex.Lock()
+	patterns := make([]string, 0, len(m.router.handlerKeeper))
+	methods := map[string][]string{}
+	for pattern, handler := range m.router.handlerKeeper {
+		patterns = append(patterns, pattern)
+		for method := range handler.methods {
+			methods[pattern] = append(methods[pattern], methodToString(method))
+		}
+	}
+	m.router.mutex.Unlock()
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		sort.Strings(methods[pattern])
+		for _, value := range values {
+			params := map[string]string{}
+			for _, segment := range strings.Split(pattern, "/") {
+				if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+					params[strings.TrimSuffix(segment[1:len(segment)-1], "...")] = value
+				}
+			}
+			filled, err := fillPattern(pattern, params)
+			if err != nil {
+				continue
+			}
+			for _, method := range methods[pattern] {
+				corpus = append(corpus, []byte(method+" "+filled))
+			}
+			if len(params) == 0 {
+				break
+			}
+		}
+	}
+	return dedupe(corpus)
+}
+
+func dedupe(corpus [][]byte) [][]byte {
+	unique := corpus[:0]
+	for _, entry := range corpus {
+		duplicate := false
+		for _, existing := range unique {
+			if bytes.Equal(existing, entry) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, entry)
+		}
+	}
+	return unique
+}
+
+// Seed adds the Corpus to a fuzz test, like a *testing.F
+func (m *Matcher) Seed(f interface{ Add(args ...any) }) {
+	for _, entry := range m.Corpus() {
+		f.Add(entry)
+	}
+}
@@ -0,0 +1,100 @@
+package there
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func newMatcherTestRouter() *Router {
+	router := NewRouter()
+	endpoint := func(request Request) Response {
+		return Status(status.OK)
+	}
+	router.Get("/{$}", endpoint)
+	router.Get("/users/{id}", endpoint)
+	router.Handle("/users/{id}/posts/{post}", endpoint, MethodGet, MethodDelete)
+	router.Get("/files/{path...}", endpoint)
+	router.Group("/static").Get("/", endpoint)
+	return router
+}
+
+func TestMatch(t *testing.T) {
+	router := newMatcherTestRouter()
+	tests := []struct {
+		method, target string
+		match          RouteMatch
+		ok             bool
+	}{
+		{MethodGet, "/users/42?x=1", RouteMatch{"/users/{id}", map[string]string{"id": "42"}}, true},
+		{MethodGet, "/users/a%2Fb", RouteMatch{"/users/{id}", map[string]string{"id": "a/b"}}, true},
+		{MethodDelete, "/users/1/posts/2", RouteMatch{"/users/{id}/posts/{post}", map[string]string{"id": "1", "post": "2"}}, true},
+		{MethodGet, "/files/a/b%20c", RouteMatch{"/files/{path...}", map[string]string{"path": "a/b c"}}, true},
+		{MethodPost, "/users/42", RouteMatch{}, false},
+		{MethodGet, "/unknown", RouteMatch{}, false},
+	}
+	for _, test := range tests {
+		match, ok := router.Match(test.method, test.target)
+		if ok != test.ok || (ok && !reflect.DeepEqual(match, test.match)) {
+			t.Errorf("%v %v: expected %v %v, got %v %v", test.method, test.target, test.match, test.ok, match, ok)
+		}
+	}
+}
+
+func TestCatchAll(t *testing.T) {
+	router := NewRouter()
+	router.Group("/static").Get("/*path", func(request Request) Response {
+		return String(status.OK, "static "+request.RouteParams.Get("path"))
+	})
+	router.Get("/api/users", func(request Request) Response {
+		return String(status.OK, "users")
+	})
+	router.Get("/*route", func(request Request) Response {
+		return String(status.OK, "spa "+request.RouteParams.Get("route"))
+	})
+
+	for target, expected := range map[string]string{
+		"/static/css/app/main.css": "static css/app/main.css",
+		"/static/":                 "static ",
+		"/api/users":               "users",
+		"/settings/profile":        "spa settings/profile",
+		"/":                        "spa ",
+	} {
+		assertBodyResponse(t, router, MethodGet, target, expected)
+	}
+	if match, ok := router.Match(MethodGet, "/static/a/b"); !ok || match.Pattern != "/static/{path...}" {
+		t.Errorf("unexpected match %v %v", match, ok)
+	}
+
+	router.Get("/files/*path/download", nil)
+	router.Get("/assets/*", nil)
+	for i := 0; i < 2; i++ {
+		if router.HasError() == nil {
+			t.Errorf("expected malformed catch-all segments to be rejected")
+		}
+	}
+}
+
+func TestMatcherCorpus(t *testing.T) {
+	matcher := newMatcherTestRouter().Matcher()
+	corpus := matcher.Corpus()
+	if len(corpus) < 20 {
+		t.Errorf("expected a larger corpus, got %v entries", len(corpus))
+	}
+	for _, entry := range corpus {
+		if err := matcher.Fuzz(entry); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func FuzzMatcher(f *testing.F) {
+	matcher := newMatcherTestRouter().Matcher()
+	matcher.Seed(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := matcher.Fuzz(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
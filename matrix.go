@@ -0,0 +1,84 @@
+package there
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MatrixSegment is a segment of the request path with its matrix parameters,
+// like "cars;color=red;year=2012"
+type MatrixSegment struct {
+	// Path is the unescaped segment without its parameters, like "cars"
+	Path string
+	// Params of the segment. Comma separated values, like "color=red,blue", are split.
+	Params MapReader
+}
+
+type matrixKey struct{}
+
+// Matrix returns the segments of the request path with their matrix
+// parameters, if MatrixParams is enabled in the RouterConfiguration and the
+// path contains any. Otherwise, nil is returned.
+func (r *Request) Matrix() []MatrixSegment {
+	segments, _ := r.Request.Context().Value(matrixKey{}).([]MatrixSegment)
+	return segments
+}
+
+// MatrixParams returns the matrix parameters of the first segment with the
+// path, or nil, if there is no such segment
+//
+//	// GET /cars;color=red/2012
+//	color, _ := request.MatrixParams("cars").Get("color")
+func (r *Request) MatrixParams(segment string) MapReader {
+	for _, s := range r.Matrix() {
+		if s.Path == segment {
+			return s.Params
+		}
+	}
+	return nil
+}
+
+// stripMatrixParams returns a shallow copy of the request, whose path has no
+// matrix parameters, and the parsed segments in its context. The request is
+// returned as it is, if the path has no matrix parameters.
+func stripMatrixParams(request *http.Request) *http.Request {
+	escaped := request.URL.EscapedPath()
+	if !strings.Contains(escaped, ";") {
+		return request
+	}
+
+	rawSegments := strings.Split(escaped, "/")
+	segments := make([]MatrixSegment, 0, len(rawSegments))
+	for i, raw := range rawSegments {
+		raw, params, _ := strings.Cut(raw, ";")
+		rawSegments[i] = raw
+		if i == 0 {
+			continue
+		}
+		segment := MatrixSegment{Params: MapReader{}}
+		segment.Path, _ = url.PathUnescape(raw)
+		for params != "" {
+			var param string
+			param, params, _ = strings.Cut(params, ";")
+			key, value, _ := strings.Cut(param, "=")
+			if key, _ = url.PathUnescape(key); key == "" {
+				continue
+			}
+			for _, v := range strings.Split(value, ",") {
+				v, _ = url.PathUnescape(v)
+				segment.Params[key] = append(segment.Params[key], v)
+			}
+		}
+		segments = append(segments, segment)
+	}
+
+	stripped := new(http.Request)
+	*stripped = *request
+	stripped.URL = new(url.URL)
+	*stripped.URL = *request.URL
+	stripped.URL.RawPath = strings.Join(rawSegments, "/")
+	stripped.URL.Path, _ = url.PathUnescape(stripped.URL.RawPath)
+	return stripped.WithContext(context.WithValue(stripped.Context(), matrixKey{}, segments))
+}
@@ -0,0 +1,42 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestMatrixParams(t *testing.T) {
+	router := NewRouter()
+	router.Get("/cars/{year}", func(request Request) Response {
+		segments := request.Matrix()
+		return Json(status.OK, map[string]any{
+			"year":     request.RouteParams.Get("year"),
+			"colors":   request.MatrixParams("cars")["color"],
+			"segments": len(segments),
+		})
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, target, nil))
+		return recorder
+	}
+
+	if recorder := serve("/cars;color=red/2012"); recorder.Code != status.NotFound {
+		t.Errorf("matrix params should be opt-in, got %v", recorder.Code)
+	}
+
+	router.Configuration.MatrixParams = true
+	tests := map[string]string{
+		"/cars;color=red,blue;used/2012;x=1": `{"colors":["red","blue"],"segments":2,"year":"2012"}`,
+		"/cars/2013":                         `{"colors":null,"segments":0,"year":"2013"}`,
+		"/cars;color=gr%C3%BCn/a%3Bb":        `{"colors":["grün"],"segments":2,"year":"a;b"}`,
+	}
+	for target, expected := range tests {
+		if recorder := serve(target); recorder.Body.String() != expected {
+			t.Errorf("%v: expected %v, got %v %v", target, expected, recorder.Code, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package there
+
+import (
+	"context"
+	"strings"
+)
+
+// RouteMeta holds arbitrary metadata of a route, like "auth": "required".
+// Middlewares can read it to decide, whether and how they apply to a route.
+//
+//	router.Get("/billing", GetBilling).Meta(there.RouteMeta{"auth": "required"})
+type RouteMeta map[string]string
+
+// Has reports whether the metadata contains the key with the value
+func (meta RouteMeta) Has(key, value string) bool {
+	v, ok := meta[key]
+	return ok && v == value
+}
+
+// Meta adds the metadata to the route. Existing keys are overwritten.
+func (group *RouteRouteGroupBuilder) Meta(meta RouteMeta) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		if endpoint.meta == nil {
+			endpoint.meta = RouteMeta{}
+		}
+		for key, value := range meta {
+			endpoint.meta[key] = value
+		}
+	}
+	return group
+}
+
+// Tag adds metadata in the form "key:value" to the route. A tag without a colon
+// is added with an empty value.
+//
+//	router.Get("/billing", GetBilling).Tag("auth:required")
+func (group *RouteRouteGroupBuilder) Tag(tags ...string) *RouteRouteGroupBuilder {
+	meta := RouteMeta{}
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(tag, ":")
+		meta[key] = value
+	}
+	return group.Meta(meta)
+}
+
+// NoCompression keeps compression middlewares, like middlewares.Gzip, from
+// compressing the responses of the route. Use it for payloads, that are
+// already compressed, like images, or that have to reach the client right
+// away, like an EventStream. It tags the route with "compression:off".
+//
+//	router.Get("/events", Events).NoCompression()
+func (group *RouteRouteGroupBuilder) NoCompression() *RouteRouteGroupBuilder {
+	return group.Tag("compression:off")
+}
+
+// Compressible reports whether the responses of the route may be compressed
+func (meta RouteMeta) Compressible() bool {
+	return !meta.Has("compression", "off")
+}
+
+type routeMetaKey struct{}
+
+// RouteMeta returns the metadata of the matched route. The result is nil, if
+// no route matched or the route has no metadata.
+func (r *Request) RouteMeta() RouteMeta {
+	meta, _ := r.Request.Context().Value(routeMetaKey{}).(RouteMeta)
+	return meta
+}
+
+// ConditionalMiddleware is a Middleware, which only applies to the routes whose
+// metadata it accepts. Register it once with UseConditional instead of
+// maintaining separate route groups.
+type ConditionalMiddleware interface {
+	// AppliesTo reports whether the middleware should run for a route with the metadata
+	AppliesTo(meta RouteMeta) bool
+	// Handle is the Middleware itself
+	Handle(request Request, next Response) Response
+}
+
+// Conditional creates a ConditionalMiddleware from a Middleware and a condition
+//
+//	router.UseConditional(there.Conditional(func(meta there.RouteMeta) bool {
+//		return meta.Has("auth", "required")
+//	}, Authentication))
+func Conditional(appliesTo func(meta RouteMeta) bool, middleware Middleware) ConditionalMiddleware {
+	return conditionalMiddleware{appliesTo: appliesTo, middleware: middleware}
+}
+
+type conditionalMiddleware struct {
+	appliesTo  func(meta RouteMeta) bool
+	middleware Middleware
+}
+
+func (c conditionalMiddleware) AppliesTo(meta RouteMeta) bool {
+	return c.appliesTo(meta)
+}
+
+func (c conditionalMiddleware) Handle(request Request, next Response) Response {
+	return c.middleware(request, next)
+}
+
+// UseConditional registers ConditionalMiddlewares, which run only on the routes
+// they apply to
+func (router *Router) UseConditional(middlewares ...ConditionalMiddleware) *Router {
+	for _, middleware := range middlewares {
+		middleware := middleware
+		router.Use(func(request Request, next Response) Response {
+			if !middleware.AppliesTo(request.RouteMeta()) {
+				return next
+			}
+			return middleware.Handle(request, next)
+		})
+	}
+	return router
+}
+
+// withRouteMeta stores the metadata of the matched route in the request
+func withRouteMeta(request *Request, meta RouteMeta) {
+	if meta != nil {
+		request.WithContext(context.WithValue(request.Context(), routeMetaKey{}, meta))
+	}
+}
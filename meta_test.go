@@ -0,0 +1,46 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestConditionalMiddleware(t *testing.T) {
+	router := NewRouter()
+	router.UseConditional(Conditional(func(meta RouteMeta) bool {
+		return meta.Has("auth", "required")
+	}, func(request Request, next Response) Response {
+		return Status(status.Unauthorized)
+	}))
+
+	router.Get("/public", func(request Request) Response {
+		return Status(status.OK)
+	})
+	router.Get("/private", func(request Request) Response {
+		return Status(status.OK)
+	}).Tag("auth:required")
+	router.Get("/meta", func(request Request) Response {
+		return String(status.OK, request.RouteMeta()["team"])
+	}).Meta(RouteMeta{"team": "billing"})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	if recorder := serve("/public"); recorder.Code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, recorder.Code)
+	}
+	if recorder := serve("/private"); recorder.Code != status.Unauthorized {
+		t.Errorf("expected %v, got %v", status.Unauthorized, recorder.Code)
+	}
+	if recorder := serve("/meta"); recorder.Body.String() != "billing" {
+		t.Errorf("expected the route meta, got %v", recorder.Body.String())
+	}
+	if recorder := serve("/missing"); recorder.Code != status.NotFound {
+		t.Errorf("expected %v, got %v", status.NotFound, recorder.Code)
+	}
+}
@@ -0,0 +1,243 @@
+// Package metrics records the requests of a Router and exposes them in the
+// Prometheus text format, without depending on the Prometheus client library.
+// Requests are labeled by method, the pattern of the matched route and status
+// code, so paths with parameters, like "/users/{id}", are one series.
+//
+//	collector := metrics.New(metrics.Configuration{Namespace: "shop"})
+//	router.Use(collector.Middleware)
+//	collector.Register(router.RouteGroup)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// UnmatchedRoute is the route label of requests, that matched no route
+const UnmatchedRoute = "unmatched"
+
+var (
+	// DefaultDurationBuckets are the upper bounds of the duration histogram in seconds
+	DefaultDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// DefaultSizeBuckets are the upper bounds of the response size histogram in bytes
+	DefaultSizeBuckets = []float64{100, 1000, 10_000, 100_000, 1_000_000, 10_000_000}
+)
+
+// Configuration of the Collector
+type Configuration struct {
+	// Namespace prefixes the metric names. Defaults to "http".
+	Namespace string
+	// Path is the route Register serves the metrics on. Defaults to "/metrics".
+	Path string
+	// DurationBuckets defaults to the DefaultDurationBuckets
+	DurationBuckets []float64
+	// SizeBuckets defaults to the DefaultSizeBuckets
+	SizeBuckets []float64
+}
+
+// Collector records the requests passing its Middleware
+type Collector struct {
+	config   Configuration
+	inFlight atomic.Int64
+
+	mutex     sync.Mutex
+	series    map[labels]*series
+	durations []float64
+	sizes     []float64
+}
+
+type labels struct {
+	method string
+	route  string
+	status string
+}
+
+type series struct {
+	requests uint64
+	duration histogram
+	size     histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(bounds []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds))
+	}
+	for i, bound := range bounds {
+		if value <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// New creates a Collector
+func New(config Configuration) *Collector {
+	if config.Namespace == "" {
+		config.Namespace = "http"
+	}
+	if config.Path == "" {
+		config.Path = "/metrics"
+	}
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = DefaultDurationBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+	durations := append([]float64(nil), config.DurationBuckets...)
+	sort.Float64s(durations)
+	sizes := append([]float64(nil), config.SizeBuckets...)
+	sort.Float64s(sizes)
+	return &Collector{
+		config:    config,
+		series:    map[labels]*series{},
+		durations: durations,
+		sizes:     sizes,
+	}
+}
+
+// Middleware records the request count, duration, response size and the
+// requests in flight. Register it globally, so unmatched requests are counted too.
+func (c *Collector) Middleware(request there.Request, next there.Response) there.Response {
+	route := request.Pattern()
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	method := request.Method
+	inFlight := there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		c.inFlight.Add(1)
+		defer c.inFlight.Add(-1)
+		next.ServeHTTP(rw, r)
+	})
+	return there.AfterResponse(inFlight, func(info there.ResponseInfo) {
+		c.observe(labels{method: method, route: route, status: strconv.Itoa(info.Status)},
+			info.Duration.Seconds(), float64(info.Bytes))
+	})
+}
+
+func (c *Collector) observe(l labels, duration, size float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s, ok := c.series[l]
+	if !ok {
+		s = &series{}
+		c.series[l] = s
+	}
+	s.requests++
+	s.duration.observe(c.durations, duration)
+	s.size.observe(c.sizes, size)
+}
+
+// Register serves the metrics on the Path of the Configuration
+func (c *Collector) Register(group *there.RouteGroup) {
+	group.Get(c.config.Path, c.Endpoint)
+}
+
+// Endpoint serves the metrics in the Prometheus text format
+func (c *Collector) Endpoint(request there.Request) there.Response {
+	return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set(header.ContentType, ContentType)
+		_, _ = c.WriteTo(rw)
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mutex.Lock()
+	keys := make([]labels, 0, len(c.series))
+	snapshot := make(map[labels]series, len(c.series))
+	for l, s := range c.series {
+		keys = append(keys, l)
+		copied := *s
+		copied.duration.counts = append([]uint64(nil), s.duration.counts...)
+		copied.size.counts = append([]uint64(nil), s.size.counts...)
+		snapshot[l] = copied
+	}
+	c.mutex.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	var b strings.Builder
+	name := c.config.Namespace + "_requests_total"
+	fmt.Fprintf(&b, "# HELP %v Total number of HTTP requests.\n# TYPE %v counter\n", name, name)
+	for _, l := range keys {
+		fmt.Fprintf(&b, "%v{%v} %d\n", name, l.String(), snapshot[l].requests)
+	}
+
+	name = c.config.Namespace + "_request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %v Duration of HTTP requests in seconds.\n# TYPE %v histogram\n", name, name)
+	for _, l := range keys {
+		writeHistogram(&b, name, l.String(), c.durations, snapshot[l].duration)
+	}
+
+	name = c.config.Namespace + "_response_size_bytes"
+	fmt.Fprintf(&b, "# HELP %v Size of HTTP response bodies in bytes.\n# TYPE %v histogram\n", name, name)
+	for _, l := range keys {
+		writeHistogram(&b, name, l.String(), c.sizes, snapshot[l].size)
+	}
+
+	name = c.config.Namespace + "_requests_in_flight"
+	fmt.Fprintf(&b, "# HELP %v Number of HTTP requests being served.\n# TYPE %v gauge\n", name, name)
+	fmt.Fprintf(&b, "%v %d\n", name, c.inFlight.Load())
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHistogram(b *strings.Builder, name, labels string, bounds []float64, h histogram) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		if i < len(h.counts) {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(b, "%v_bucket{%v,le=%q} %d\n", name, labels, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(b, "%v_bucket{%v,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(b, "%v_sum{%v} %v\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(b, "%v_count{%v} %d\n", name, labels, h.count)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func (l labels) String() string {
+	return fmt.Sprintf(`method="%v",route="%v",status="%v"`, escape(l.method), escape(l.route), escape(l.status))
+}
+
+// escape escapes a label value of the Prometheus text format
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
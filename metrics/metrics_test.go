@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestCollector(t *testing.T) {
+	collector := New(Configuration{Namespace: "shop", DurationBuckets: []float64{1, 0.1}})
+	router := there.NewRouter()
+	router.Use(collector.Middleware)
+	collector.Register(router.RouteGroup)
+	router.Get("/users/{id}", func(request there.Request) there.Response {
+		return there.String(status.OK, "user")
+	})
+	router.Get("/slow", func(request there.Request) there.Response {
+		time.Sleep(150 * time.Millisecond)
+		return there.Status(status.NoContent)
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(there.MethodGet, route, nil))
+		return recorder
+	}
+	serve("/users/1")
+	serve("/users/2")
+	serve("/slow")
+	serve("/missing")
+
+	recorder := serve("/metrics")
+	if contentType := recorder.Header().Get(header.ContentType); contentType != ContentType {
+		t.Errorf("unexpected content type %v", contentType)
+	}
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE shop_requests_total counter\n",
+		`shop_requests_total{method="GET",route="/users/{id}",status="200"} 2` + "\n",
+		`shop_requests_total{method="GET",route="unmatched",status="404"} 1` + "\n",
+		`shop_request_duration_seconds_bucket{method="GET",route="/slow",status="204",le="0.1"} 0` + "\n",
+		`shop_request_duration_seconds_bucket{method="GET",route="/slow",status="204",le="1"} 1` + "\n",
+		`shop_request_duration_seconds_count{method="GET",route="/slow",status="204"} 1` + "\n",
+		`shop_response_size_bytes_sum{method="GET",route="/users/{id}",status="200"} 8` + "\n",
+		// the request for the metrics is in flight
+		"shop_requests_in_flight 1\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %q in\n%v", expected, body)
+		}
+	}
+	if strings.Contains(body, "/users/1") {
+		t.Error("expected paths to be labeled by their route")
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if escaped := escape("a\"b\\c\nd"); escaped != `a\"b\\c\nd` {
+		t.Errorf("unexpected escaped value %v", escaped)
+	}
+}
@@ -0,0 +1,147 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2"
+)
+
+// AccessLogEntry is the record of a served request
+type AccessLogEntry struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	// Route is the pattern of the matched route, like "/users/{id}". Empty, if
+	// no route matched.
+	Route      string        `json:"route,omitempty"`
+	Status     int           `json:"status"`
+	Bytes      int64         `json:"bytes"`
+	Duration   time.Duration `json:"duration"`
+	RemoteAddr string        `json:"remoteAddr"`
+}
+
+// String formats the entry as a single line of text
+func (e AccessLogEntry) String() string {
+	route := e.Route
+	if route == "" {
+		route = "-"
+	}
+	return fmt.Sprintf("%v %v %v %v %d %dB %v %v",
+		e.Time.Format(time.RFC3339), e.Method, e.Path, route, e.Status, e.Bytes, e.Duration, e.RemoteAddr)
+}
+
+// AccessLogFormat is the format entries are written in by the WriterSink and LoggerSink
+type AccessLogFormat int
+
+const (
+	// AccessLogText writes an entry as a line of text, see AccessLogEntry.String
+	AccessLogText AccessLogFormat = iota
+	// AccessLogJson writes an entry as a json object
+	AccessLogJson
+)
+
+func (f AccessLogFormat) format(entry AccessLogEntry) string {
+	if f == AccessLogJson {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return entry.String()
+		}
+		return string(data)
+	}
+	return entry.String()
+}
+
+// AccessLogSink receives the entries of the AccessLog
+type AccessLogSink interface {
+	Log(ctx context.Context, entry AccessLogEntry)
+}
+
+// AccessLogSinkFunc is an adapter to use functions as AccessLogSink
+type AccessLogSinkFunc func(ctx context.Context, entry AccessLogEntry)
+
+func (f AccessLogSinkFunc) Log(ctx context.Context, entry AccessLogEntry) {
+	f(ctx, entry)
+}
+
+// WriterSink writes each entry as a line to the io.Writer
+func WriterSink(writer io.Writer, format AccessLogFormat) AccessLogSink {
+	var mutex sync.Mutex
+	return AccessLogSinkFunc(func(ctx context.Context, entry AccessLogEntry) {
+		line := format.format(entry) + "\n"
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, _ = io.WriteString(writer, line)
+	})
+}
+
+// LoggerSink prints each entry with the log.Logger
+func LoggerSink(logger *log.Logger, format AccessLogFormat) AccessLogSink {
+	return AccessLogSinkFunc(func(ctx context.Context, entry AccessLogEntry) {
+		logger.Println(format.format(entry))
+	})
+}
+
+// SlogSink logs each entry with the slog.Logger as attributes. Entries with a
+// 5xx status are logged with slog.LevelError, 4xx with slog.LevelWarn and
+// everything else with slog.LevelInfo.
+func SlogSink(logger *slog.Logger) AccessLogSink {
+	return AccessLogSinkFunc(func(ctx context.Context, entry AccessLogEntry) {
+		logger.LogAttrs(ctx, statusCodeToLevel(entry.Status), "request",
+			slog.String("method", entry.Method),
+			slog.String("path", entry.Path),
+			slog.String("route", entry.Route),
+			slog.Int("status", entry.Status),
+			slog.Int64("bytes", entry.Bytes),
+			slog.Duration("duration", entry.Duration),
+			slog.String("remoteAddr", entry.RemoteAddr),
+		)
+	})
+}
+
+// AccessLogOptions configures the AccessLog middleware
+type AccessLogOptions struct {
+	// Sink receives the entries. Defaults to a LoggerSink with the default
+	// log.Logger and AccessLogText.
+	Sink AccessLogSink
+	// Skip excludes requests from the log, like health checks
+	Skip func(request there.Request) bool
+}
+
+// AccessLog records the method, path, matched route, status code, bytes written
+// and latency of every request, after its response was sent.
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+//	router.Use(middlewares.AccessLog(middlewares.AccessLogOptions{
+//		Sink: middlewares.SlogSink(logger),
+//	}))
+func AccessLog(options AccessLogOptions) there.Middleware {
+	if options.Sink == nil {
+		options.Sink = LoggerSink(log.Default(), AccessLogText)
+	}
+	return func(request there.Request, next there.Response) there.Response {
+		if options.Skip != nil && options.Skip(request) {
+			return next
+		}
+		route := request.Pattern()
+		r := request.Request
+		return there.AfterResponse(next, func(info there.ResponseInfo) {
+			options.Sink.Log(r.Context(), AccessLogEntry{
+				Time:       info.Start,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Route:      route,
+				Status:     info.Status,
+				Bytes:      info.Bytes,
+				Duration:   info.Duration,
+				RemoteAddr: r.RemoteAddr,
+			})
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestAccessLog(t *testing.T) {
+	var entries []AccessLogEntry
+	router := there.NewRouter()
+	router.Use(AccessLog(AccessLogOptions{
+		Sink: AccessLogSinkFunc(func(ctx context.Context, entry AccessLogEntry) {
+			entries = append(entries, entry)
+		}),
+		Skip: func(request there.Request) bool {
+			return request.Pattern() == "/health"
+		},
+	}))
+	router.Get("/users/{id}", func(request there.Request) there.Response {
+		return there.String(status.OK, "user "+request.RouteParams.Get("id"))
+	})
+	router.Post("/users", func(request there.Request) there.Response {
+		return there.Error(status.Conflict, errors.New("exists"))
+	})
+	router.Get("/health", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	})
+
+	for _, route := range []string{"/users/1", "/health", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(there.MethodGet, route, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(there.MethodPost, "/users", nil))
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %v", entries)
+	}
+	if e := entries[0]; e.Method != there.MethodGet || e.Path != "/users/1" || e.Route != "/users/{id}" ||
+		e.Status != status.OK || e.Bytes != int64(len("user 1")) || e.Duration <= 0 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[1]; e.Path != "/missing" || e.Route != "" || e.Status != status.NotFound {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if e := entries[2]; e.Route != "/users" || e.Status != status.Conflict {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestAccessLogSinks(t *testing.T) {
+	entry := AccessLogEntry{Method: there.MethodGet, Path: "/users/1", Route: "/users/{id}", Status: status.NotFound, Bytes: 10}
+
+	var text bytes.Buffer
+	WriterSink(&text, AccessLogText).Log(context.Background(), entry)
+	if !strings.Contains(text.String(), "GET /users/1 /users/{id} 404 10B") || !strings.HasSuffix(text.String(), "\n") {
+		t.Errorf("unexpected text entry %q", text.String())
+	}
+
+	var object bytes.Buffer
+	WriterSink(&object, AccessLogJson).Log(context.Background(), entry)
+	var decoded AccessLogEntry
+	if err := json.Unmarshal(object.Bytes(), &decoded); err != nil || decoded.Route != entry.Route || decoded.Status != entry.Status {
+		t.Errorf("unexpected json entry %q: %v", object.String(), err)
+	}
+
+	var structured bytes.Buffer
+	SlogSink(slog.New(slog.NewTextHandler(&structured, nil))).Log(context.Background(), entry)
+	if !strings.Contains(structured.String(), "level=WARN") || !strings.Contains(structured.String(), "route=/users/{id}") {
+		t.Errorf("unexpected slog entry %q", structured.String())
+	}
+}
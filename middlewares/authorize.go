@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+// Principal is the authenticated caller of a request. Authentication
+// middlewares, like JWT or API key checks, store it with WithPrincipal.
+type Principal struct {
+	// Subject identifies the caller, like a user id or the name of an API key
+	Subject     string
+	Roles       []string
+	Permissions []string
+	// Claims holds additional attributes, like the claims of a token
+	Claims map[string]any
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores the authenticated Principal in the request
+func WithPrincipal(request there.Request, principal Principal) {
+	request.WithContext(context.WithValue(request.Context(), principalKey{}, principal))
+}
+
+// PrincipalOf returns the Principal stored with WithPrincipal
+func PrincipalOf(request there.Request) (Principal, bool) {
+	principal, ok := request.Context().Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Policy decides whether a Principal is granted a permission. Implement it to
+// plug in a policy engine, like an adapter to casbin.
+type Policy interface {
+	Allowed(request there.Request, principal Principal, permission string) bool
+}
+
+// PolicyFunc allows a plain function to be used as Policy
+type PolicyFunc func(request there.Request, principal Principal, permission string) bool
+
+func (f PolicyFunc) Allowed(request there.Request, principal Principal, permission string) bool {
+	return f(request, principal, permission)
+}
+
+// RolePolicy is a role based Policy, which maps roles to the permissions they
+// grant. Permissions of the Principal itself are granted too. A permission
+// ending with "*" grants everything with the same prefix, like "billing:*".
+type RolePolicy map[string][]string
+
+func (p RolePolicy) Allowed(_ there.Request, principal Principal, permission string) bool {
+	if permissionsGrant(principal.Permissions, permission) {
+		return true
+	}
+	for _, role := range principal.Roles {
+		if permissionsGrant(p[role], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionsGrant(granted []string, permission string) bool {
+	for _, g := range granted {
+		if g == permission || (strings.HasSuffix(g, "*") && strings.HasPrefix(permission, g[:len(g)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionMetaKey is the key of the RouteMeta, which holds the permission a route requires
+const PermissionMetaKey = "perm"
+
+// Authorize checks the permission, which the matched route requires with its
+// RouteMeta, against the Policy. Routes without a permission are not affected.
+// If no Principal was stored before, StatusUnauthorized is returned, and if the
+// Policy denies the permission, StatusForbidden. The check runs, when the
+// response is served, so authentication middlewares registered before Authorize
+// have already stored the Principal.
+//
+//	router.Use(Authentication, middlewares.Authorize(middlewares.RolePolicy{
+//		"admin":      {"*"},
+//		"accountant": {"billing:*"},
+//	}))
+//	router.Post("/invoices", CreateInvoice).Meta(there.RouteMeta{"perm": "billing:write"})
+func Authorize(policy Policy) there.Middleware {
+	return func(request there.Request, next there.Response) there.Response {
+		permission, ok := request.RouteMeta()[PermissionMetaKey]
+		if !ok {
+			return next
+		}
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalOf(request)
+			if !ok {
+				there.Error(status.Unauthorized, errors.New("authentication required")).ServeHTTP(rw, r)
+				return
+			}
+			if !policy.Allowed(request, principal, permission) {
+				there.Error(status.Forbidden, errors.New("missing permission "+permission)).ServeHTTP(rw, r)
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
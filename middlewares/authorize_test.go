@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestAuthorize(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(func(request there.Request, next there.Response) there.Response {
+		if role, ok := request.Headers.Get("Role"); ok {
+			WithPrincipal(request, Principal{Subject: "user", Roles: []string{role}})
+		}
+		return next
+	}, Authorize(RolePolicy{
+		"admin":      {"*"},
+		"accountant": {"billing:*"},
+		"viewer":     {"billing:read"},
+	}))
+
+	ok := func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	}
+	router.Get("/public", ok)
+	router.Post("/invoices", ok).Meta(there.RouteMeta{PermissionMetaKey: "billing:write"})
+
+	tests := []struct {
+		method, route, role string
+		expected            int
+	}{
+		{there.MethodGet, "/public", "", status.OK},
+		{there.MethodPost, "/invoices", "", status.Unauthorized},
+		{there.MethodPost, "/invoices", "viewer", status.Forbidden},
+		{there.MethodPost, "/invoices", "accountant", status.OK},
+		{there.MethodPost, "/invoices", "admin", status.OK},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.route, nil)
+		if test.role != "" {
+			request.Header.Set("Role", test.role)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.expected {
+			t.Errorf("%v %v as %q: expected %v, got %v", test.method, test.route, test.role, test.expected, recorder.Code)
+		}
+	}
+}
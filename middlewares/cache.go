@@ -0,0 +1,209 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+type CacheConfiguration struct {
+	// MaxEntries limits the amount of cached responses. Defaults to 1000.
+	MaxEntries int
+	// MaxBodySize is the largest body, that is cached. Defaults to 1 MiB.
+	MaxBodySize int
+}
+
+type cacheEntry struct {
+	code    int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+type responseCache struct {
+	config  CacheConfiguration
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func (c *responseCache) get(key string, now time.Time) *cacheEntry {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if !now.Before(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry
+}
+
+func (c *responseCache) put(key string, entry *cacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.entries) >= c.config.MaxEntries {
+		for k, e := range c.entries {
+			if !entry.stored.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < c.config.MaxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+	c.entries[key] = entry
+}
+
+// Cache stores the responses of routes declared with Cacheable in memory and
+// serves them from there, until their max age is exceeded. Only successful
+// responses to GET and HEAD requests are stored. Responses setting cookies,
+// marked as private or with their own Cache-Control directive forbidding it,
+// are never stored. The Vary headers of the route are part of the cache key.
+//
+//	router.Use(middlewares.Cache(middlewares.CacheConfiguration{}))
+//	router.Get("/products", GetProducts).Cacheable(time.Minute)
+func Cache(configuration ...CacheConfiguration) there.Middleware {
+	config := CacheConfiguration{}
+	if len(configuration) >= 1 {
+		config = configuration[0]
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1000
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 1 << 20
+	}
+	cache := &responseCache{config: config, entries: map[string]*cacheEntry{}}
+
+	return func(request there.Request, next there.Response) there.Response {
+		policy, ok := request.CachePolicy()
+		method := request.Request.Method
+		if !ok || policy.Private || policy.MaxAge <= 0 || (method != there.MethodGet && method != there.MethodHead) {
+			return next
+		}
+
+		key := cacheKey(request, policy)
+		now := time.Now()
+		if entry := cache.get(key, now); entry != nil {
+			return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+				for name, values := range entry.header {
+					// headers of outer middlewares, like a request id, belong to the current request
+					if _, ok := rw.Header()[name]; !ok {
+						rw.Header()[name] = append([]string(nil), values...)
+					}
+				}
+				rw.Header().Set(header.ResponseAge, strconv.Itoa(int(now.Sub(entry.stored)/time.Second)))
+				rw.WriteHeader(entry.code)
+				_, _ = rw.Write(entry.body)
+			})
+		}
+
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			recorder := &cacheRecorder{ResponseWriter: rw, limit: config.MaxBodySize}
+			next.ServeHTTP(recorder, r)
+			if recorder.code == 0 {
+				recorder.code = status.OK
+			}
+			if recorder.code != status.OK || recorder.overflow || !storable(rw.Header()) {
+				return
+			}
+			cache.put(key, &cacheEntry{
+				code:    recorder.code,
+				header:  rw.Header().Clone(),
+				body:    recorder.body.Bytes(),
+				stored:  now,
+				expires: now.Add(policy.MaxAge),
+			})
+		})
+	}
+}
+
+// writtenHeaders returns the headers, that were added or changed since the
+// snapshot, so only the headers of the route are cached
+func writtenHeaders(before, after http.Header) http.Header {
+	written := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			written[name] = append([]string(nil), values...)
+		}
+	}
+	return written
+}
+
+// cacheKey identifies the cached response by the method, URL and the values of the Vary headers
+func cacheKey(request there.Request, policy there.CachePolicy) string {
+	var key strings.Builder
+	key.WriteString(request.Request.Method)
+	key.WriteString(" ")
+	key.WriteString(request.Request.Host)
+	key.WriteString(request.Request.URL.RequestURI())
+	for _, name := range policy.Vary {
+		key.WriteString("\n")
+		key.WriteString(strings.Join(request.Request.Header.Values(name), ","))
+	}
+	return key.String()
+}
+
+// storable reports whether the response headers allow a shared cache to store the response
+func storable(headers http.Header) bool {
+	if len(headers.Values(header.ResponseSetCookie)) > 0 {
+		return false
+	}
+	for _, value := range headers.Values(header.CacheControl) {
+		value = strings.ToLower(value)
+		if strings.Contains(value, "no-store") || strings.Contains(value, "private") || strings.Contains(value, "no-cache") {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheRecorder passes the response through and keeps a copy of the body
+type cacheRecorder struct {
+	http.ResponseWriter
+	code     int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (w *cacheRecorder) WriteHeader(code int) {
+	if w.code == 0 && code >= 200 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheRecorder) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = status.OK
+	}
+	if !w.overflow {
+		if w.body.Len()+len(b) > w.limit {
+			w.overflow = true
+			w.body = bytes.Buffer{}
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *cacheRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestCache(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Cache())
+	calls := 0
+	router.Get("/products", func(request there.Request) there.Response {
+		calls++
+		return there.String(status.OK, request.Request.Header.Get(header.RequestAcceptLanguage)+strconv.Itoa(calls))
+	}).Cacheable(time.Minute, header.RequestAcceptLanguage)
+	router.Get("/uncached", func(request there.Request) there.Response {
+		calls++
+		return there.String(status.OK, strconv.Itoa(calls))
+	})
+
+	serve := func(route, language string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(there.MethodGet, route, nil)
+		request.Header.Set(header.RequestAcceptLanguage, language)
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if body := serve("/products", "en").Body.String(); body != "en1" {
+		t.Errorf("unexpected body %v", body)
+	}
+	recorder := serve("/products", "en")
+	if recorder.Body.String() != "en1" || recorder.Header().Get(header.ResponseAge) != "0" {
+		t.Errorf("expected a cached response, got %v", recorder.Body.String())
+	}
+	if recorder.Header().Get(header.CacheControl) != "public, max-age=60" {
+		t.Errorf("cached responses must keep their headers")
+	}
+	if body := serve("/products", "de").Body.String(); body != "de2" {
+		t.Errorf("the vary headers must be part of the key, got %v", body)
+	}
+	serve("/uncached", "")
+	if body := serve("/uncached", "").Body.String(); body != "4" {
+		t.Errorf("routes without a cache policy must not be cached, got %v", body)
+	}
+}
+
+func TestCacheKeepsHeadersOfOuterMiddlewares(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(RequestId(RequestIdOptions{}), Cors(CorsOptions{AllowOrigins: []string{"https://a.example", "https://b.example"}}), Cache())
+	router.Get("/products", func(request there.Request) there.Response {
+		return there.Headers(map[string]string{"X-Products": "1"}, there.String(status.OK, "products"))
+	}).Cacheable(time.Minute)
+
+	serve := func(origin string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(there.MethodGet, "/products", nil)
+		request.Header.Set(header.RequestOrigin, origin)
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := serve("https://a.example")
+	second := serve("https://b.example")
+	if second.Body.String() != "products" || second.Header().Get(header.ResponseAge) == "" {
+		t.Fatalf("expected a cached response, got %v", second.Body.String())
+	}
+	if second.Header().Get("X-Products") != "1" {
+		t.Errorf("cached responses must keep the headers of the route")
+	}
+	if id := second.Header().Get(header.XRequestId); id == "" || id == first.Header().Get(header.XRequestId) {
+		t.Errorf("expected a new request id, got %v", id)
+	}
+	if origin := second.Header().Get(header.ResponseAccessControlAllowOrigin); origin != "https://b.example" {
+		t.Errorf("expected the origin of the second request, got %v", origin)
+	}
+	if values := second.Header().Values(header.XRequestId); len(values) != 1 {
+		t.Errorf("expected a single request id, got %v", values)
+	}
+}
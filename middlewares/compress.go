@@ -0,0 +1,325 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// Encoder creates a compressing writer, that writes to the http.ResponseWriter
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// CompressOptions configures the Compress middleware
+type CompressOptions struct {
+	// MinSize is the minimum size of a response body in bytes, that is worth
+	// compressing. Defaults to 1024. Flushed responses are always compressed.
+	MinSize int
+	// Level is the compression level of gzip and deflate. Defaults to the
+	// default compression.
+	Level int
+	// ContentTypes are the compressed media types, a wildcard matches all
+	// subtypes, like "text/*". Defaults to text, json, xml and javascript.
+	ContentTypes []string
+	// Encoders adds or replaces content codings, like "br" with a brotli
+	// encoder of a third party package. Gzip and deflate are built in.
+	Encoders map[string]Encoder
+	// Preference orders the content codings for clients, that accept several
+	// with the same quality. Defaults to br, gzip and deflate.
+	Preference []string
+}
+
+var defaultCompressContentTypes = []string{
+	"text/*",
+	there.ContentTypeApplicationJson,
+	there.ContentTypeApplicationProblemPlusJson,
+	there.ContentTypeApplicationXml,
+	there.ContentTypeApplicationJavascript,
+	"image/svg+xml",
+}
+
+// Compress compresses the responses with the content coding, that the client
+// accepts with the highest quality. Responses are only compressed, if they have
+// one of the ContentTypes, are at least MinSize bytes large and are not encoded
+// already. Routes declared with NoCompression are left as they are.
+//
+//	router.Use(middlewares.Compress(middlewares.CompressOptions{
+//		MinSize: 512,
+//		Encoders: map[string]middlewares.Encoder{
+//			"br": func(w io.Writer) (io.WriteCloser, error) {
+//				return brotli.NewWriter(w), nil
+//			},
+//		},
+//	}))
+//
+// Flushing, like with Server-Sent Events, flushes the compressed data as well,
+// so streams are delivered without delay.
+func Compress(options CompressOptions) there.Middleware {
+	if options.MinSize <= 0 {
+		options.MinSize = 1024
+	}
+	if options.Level == 0 {
+		options.Level = gzip.DefaultCompression
+	}
+	if len(options.ContentTypes) == 0 {
+		options.ContentTypes = defaultCompressContentTypes
+	}
+	if len(options.Preference) == 0 {
+		options.Preference = []string{"br", "gzip", "deflate"}
+	}
+	encoders := map[string]Encoder{
+		"gzip": func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, options.Level)
+		},
+		"deflate": func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, options.Level)
+		},
+	}
+	for coding, encoder := range options.Encoders {
+		encoders[strings.ToLower(coding)] = encoder
+	}
+	var available []string
+	for _, coding := range options.Preference {
+		if _, ok := encoders[coding]; ok {
+			available = append(available, coding)
+		}
+	}
+	for coding := range encoders {
+		if !containsFold(available, coding) {
+			available = append(available, coding)
+		}
+	}
+
+	return func(request there.Request, next there.Response) there.Response {
+		if !request.RouteMeta().Compressible() {
+			return next
+		}
+		coding := negotiateEncoding(request.Request.Header.Get(header.RequestAcceptEncoding), available)
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			addVary(rw.Header(), header.RequestAcceptEncoding)
+			if coding == "" || r.Method == there.MethodHead {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			writer := &compressWriter{
+				ResponseWriter: rw,
+				options:        &options,
+				coding:         coding,
+				encoder:        encoders[coding],
+			}
+			defer writer.close()
+			next.ServeHTTP(writer, r)
+		})
+	}
+}
+
+// negotiateEncoding returns the available content coding, that is accepted
+// with the highest quality, or an empty string for identity
+func negotiateEncoding(accept string, available []string) string {
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if coding == "*" {
+			wildcard = quality
+			continue
+		}
+		qualities[coding] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range available {
+		quality, ok := qualities[coding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the response until it is complete, flushed or reaches
+// the MinSize, and then decides whether it is compressed
+type compressWriter struct {
+	http.ResponseWriter
+	options *CompressOptions
+	coding  string
+	encoder Encoder
+
+	code      int
+	buffer    bytes.Buffer
+	decided   bool
+	compress  io.WriteCloser
+	writeFail error
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	// informational responses are sent right away
+	if code >= 100 && code < 200 && code != status.SwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		return w.write(b)
+	}
+	w.buffer.Write(b)
+	if w.buffer.Len() >= w.options.MinSize {
+		w.decide(true)
+		if w.writeFail != nil {
+			return 0, w.writeFail
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.compress != nil {
+		return w.compress.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide sends the header and the buffered body, compressed if worth it
+func (w *compressWriter) decide(worth bool) {
+	w.decided = true
+	if w.code == 0 {
+		w.code = status.OK
+	}
+	if worth && w.compressible() {
+		encoder, err := w.encoder(w.ResponseWriter)
+		if err != nil {
+			log.Printf("compress: creating the %v encoder failed: %v", w.coding, err)
+		} else {
+			w.compress = encoder
+			headers := w.Header()
+			headers.Set(header.ContentEncoding, w.coding)
+			headers.Del(header.ContentLength)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.buffer.Len() > 0 {
+		_, w.writeFail = w.write(w.buffer.Bytes())
+		w.buffer.Reset()
+	}
+}
+
+// compressible reports whether the response may be compressed
+func (w *compressWriter) compressible() bool {
+	if w.code < 200 || w.code == status.NoContent || w.code == status.NotModified || w.code == status.PartialContent {
+		return false
+	}
+	headers := w.Header()
+	if encoding := headers.Get(header.ContentEncoding); encoding != "" && encoding != "identity" {
+		return false
+	}
+	if strings.Contains(headers.Get(header.CacheControl), "no-transform") {
+		return false
+	}
+	contentType := headers.Get(header.ContentType)
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buffer.Bytes())
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range w.options.ContentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends the buffered data, so streams are compressed without delay
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if flusher, ok := w.compress.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			log.Printf("compress: flushing the %v encoder failed: %v", w.coding, err)
+		}
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the response, after the handler returned
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.code == 0 && w.buffer.Len() == 0 {
+			// nothing was written, maybe the connection was hijacked
+			return
+		}
+		w.decide(w.buffer.Len() >= w.options.MinSize)
+	}
+	if w.compress != nil {
+		if err := w.compress.Close(); err != nil {
+			log.Printf("compress: closing the %v encoder failed: %v", w.coding, err)
+		}
+	}
+}
+
+// addVary adds a header name to the Vary header, if it is not there yet
+func addVary(headers http.Header, name string) {
+	for _, value := range headers.Values(header.ResponseVary) {
+		for _, existing := range strings.Split(value, ",") {
+			existing = strings.TrimSpace(existing)
+			if existing == "*" || strings.EqualFold(existing, name) {
+				return
+			}
+		}
+	}
+	headers.Add(header.ResponseVary, name)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
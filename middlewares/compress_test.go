@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	available := []string{"br", "gzip", "deflate"}
+	tests := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"gzip, deflate, br":         "br",
+		"gzip;q=0.5, deflate":       "deflate",
+		"*":                         "br",
+		"*, br;q=0":                 "gzip",
+		"gzip;q=0, identity":        "",
+		"GZIP":                      "gzip",
+		"deflate;q=0.1, gzip;q=0.2": "gzip",
+	}
+	for accept, expected := range tests {
+		if coding := negotiateEncoding(accept, available); coding != expected {
+			t.Errorf("%q: expected %q, got %q", accept, expected, coding)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("compress me ", 100)
+	router := there.NewRouter()
+	router.Use(Compress(CompressOptions{MinSize: 256}))
+	router.Get("/large", func(request there.Request) there.Response {
+		return there.String(status.OK, large)
+	})
+	router.Get("/small", func(request there.Request) there.Response {
+		return there.String(status.OK, "small")
+	})
+	router.Get("/image", func(request there.Request) there.Response {
+		return there.Bytes(status.OK, []byte("\x89PNG\r\n\x1a\n"+strings.Repeat("\x00", 500)))
+	})
+	router.Get("/stream", func(request there.Request) there.Response {
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set(header.ContentType, there.ContentTypeTextPlain)
+			_, _ = rw.Write([]byte("first"))
+			_ = http.NewResponseController(rw).Flush()
+			_, _ = rw.Write([]byte("second"))
+		})
+	})
+
+	serve := func(route, accept string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodGet, route, nil)
+		request.Header.Set(header.RequestAcceptEncoding, accept)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	decode := func(recorder *httptest.ResponseRecorder) string {
+		var reader io.Reader
+		switch recorder.Header().Get(header.ContentEncoding) {
+		case "gzip":
+			gz, err := gzip.NewReader(recorder.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gz
+		case "deflate":
+			reader = flate.NewReader(recorder.Body)
+		default:
+			reader = recorder.Body
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	recorder := serve("/large", "gzip, deflate")
+	if encoding := recorder.Header().Get(header.ContentEncoding); encoding != "gzip" {
+		t.Errorf("expected gzip, got %q", encoding)
+	}
+	if recorder.Body.Len() >= len(large) || decode(recorder) != large {
+		t.Errorf("unexpected compressed body of %d bytes", recorder.Body.Len())
+	}
+	if vary := recorder.Header().Get(header.ResponseVary); vary != header.RequestAcceptEncoding {
+		t.Errorf("expected to vary by Accept-Encoding, got %q", vary)
+	}
+
+	recorder = serve("/large", "deflate")
+	if encoding := recorder.Header().Get(header.ContentEncoding); encoding != "deflate" || decode(recorder) != large {
+		t.Errorf("expected deflate, got %q", encoding)
+	}
+
+	for _, route := range []string{"/small", "/image"} {
+		recorder = serve(route, "gzip")
+		if encoding := recorder.Header().Get(header.ContentEncoding); encoding != "" {
+			t.Errorf("%v: expected an uncompressed response, got %q", route, encoding)
+		}
+	}
+	if body := serve("/large", "").Body.String(); body != large {
+		t.Errorf("expected an uncompressed body, got %d bytes", len(body))
+	}
+
+	recorder = serve("/stream", "gzip")
+	if encoding := recorder.Header().Get(header.ContentEncoding); encoding != "gzip" || !recorder.Flushed {
+		t.Errorf("expected a flushed gzip stream, got %q", encoding)
+	}
+	if body := decode(recorder); body != "firstsecond" {
+		t.Errorf("unexpected stream %q", body)
+	}
+}
@@ -1,12 +1,171 @@
 package middlewares
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gebes/there/v2"
 	"github.com/gebes/there/v2/header"
 	"github.com/gebes/there/v2/status"
 )
 
-func Cors(configuration CorsConfiguration) there.Middleware {
+// CorsOptions configures the Cors middleware
+type CorsOptions struct {
+	// AllowOrigins are the origins, like "https://example.com", that may call
+	// the API. "*" allows all origins, and a wildcard allows all subdomains,
+	// like "https://*.example.com".
+	AllowOrigins []string
+	// AllowOriginFunc allows origins additionally to the AllowOrigins
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods are the methods preflight requests may ask for. Defaults to
+	// the methods registered for the route.
+	AllowMethods []string
+	// AllowHeaders are the request headers preflight requests may ask for.
+	// Defaults to the ones they ask for.
+	AllowHeaders []string
+	// ExposeHeaders are the response headers, that scripts may read
+	ExposeHeaders []string
+	// MaxAge is the time browsers cache the result of a preflight request
+	MaxAge time.Duration
+	// Credentials allows requests with cookies or authorization headers. It
+	// cannot be combined with allowing all origins with "*", allow the origins
+	// explicitly or with an AllowOriginFunc instead.
+	Credentials bool
+
+	// Deprecated: use AllowOrigins
+	AccessControlAllowOrigin string
+	// Deprecated: use AllowMethods
+	AccessControlAllowMethods string
+	// Deprecated: use AllowHeaders
+	AccessControlAllowHeaders string
+}
+
+// CorsConfiguration is the former name of the CorsOptions
+//
+// Deprecated: use CorsOptions
+type CorsConfiguration = CorsOptions
+
+func (o CorsOptions) legacy() bool {
+	return o.AccessControlAllowOrigin != "" || o.AccessControlAllowMethods != "" || o.AccessControlAllowHeaders != ""
+}
+
+// Cors adds the CORS headers to responses for allowed origins, and answers
+// preflight requests with StatusNoContent. Use it globally or per route, as
+// preflight requests pass the middlewares of the route they ask for.
+//
+//	router.Use(middlewares.Cors(middlewares.CorsOptions{
+//		AllowOrigins: []string{"https://example.com"},
+//		MaxAge:       time.Hour,
+//		Credentials:  true,
+//	}))
+//
+// Preflight requests from other origins get a StatusForbidden response, other
+// requests are served without CORS headers, so browsers block them.
+func Cors(options CorsOptions) there.Middleware {
+	if options.legacy() {
+		return legacyCors(options)
+	}
+	allowAll := false
+	var origins, wildcards []string
+	for _, origin := range options.AllowOrigins {
+		switch {
+		case origin == "*":
+			allowAll = true
+		case strings.Contains(origin, "*"):
+			wildcards = append(wildcards, strings.ToLower(origin))
+		default:
+			origins = append(origins, strings.ToLower(origin))
+		}
+	}
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lower := strings.ToLower(origin)
+		for _, o := range origins {
+			if o == lower {
+				return true
+			}
+		}
+		for _, w := range wildcards {
+			prefix, suffix, _ := strings.Cut(w, "*")
+			if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+				return true
+			}
+		}
+		return options.AllowOriginFunc != nil && options.AllowOriginFunc(origin)
+	}
+	if allowAll && options.Credentials {
+		// any site could otherwise read the responses with the cookies of the user
+		panic(`cors: credentials cannot be allowed for all origins "*", use AllowOriginFunc for dynamic origins`)
+	}
+	echoOrigin := !allowAll
+
+	return func(request there.Request, next there.Response) there.Response {
+		origin := request.Request.Header.Get(header.RequestOrigin)
+		preflight := request.Method == there.MethodOptions && request.Request.Header.Get(header.RequestAccessControlRequestMethod) != ""
+		if origin == "" {
+			return next
+		}
+
+		headers := map[string]string{}
+		vary := []string{header.RequestOrigin}
+		if preflight {
+			vary = append(vary, header.RequestAccessControlRequestMethod, header.RequestAccessControlRequests)
+		}
+		if !echoOrigin && !preflight {
+			// the response is the same for all origins
+			vary = nil
+		}
+		if len(vary) > 0 {
+			headers[header.ResponseVary] = strings.Join(vary, ", ")
+		}
+
+		if !allowed(origin) {
+			if preflight {
+				return there.Headers(headers, there.Error(status.Forbidden, errorOriginNotAllowed))
+			}
+			return there.Headers(headers, next)
+		}
+
+		headers[header.ResponseAccessControlAllowOrigin] = "*"
+		if echoOrigin {
+			headers[header.ResponseAccessControlAllowOrigin] = origin
+		}
+		if options.Credentials {
+			headers[header.ResponseAccessControlAllowCredentials] = "true"
+		}
+		if !preflight {
+			if len(options.ExposeHeaders) > 0 {
+				headers[header.ResponseAccessControlExposeHeaders] = strings.Join(options.ExposeHeaders, ", ")
+			}
+			return there.Headers(headers, next)
+		}
+
+		methods := options.AllowMethods
+		if len(methods) == 0 {
+			methods = request.AllowedMethods()
+		}
+		headers[header.ResponseAccessControlAllowMethods] = strings.Join(methods, ", ")
+		if len(options.AllowHeaders) > 0 {
+			headers[header.ResponseAccessControlAllowHeaders] = strings.Join(options.AllowHeaders, ", ")
+		} else if requested := request.Request.Header.Get(header.RequestAccessControlRequests); requested != "" {
+			headers[header.ResponseAccessControlAllowHeaders] = requested
+		}
+		if options.MaxAge > 0 {
+			headers[header.ResponseAccessControlMaxAge] = strconv.FormatInt(int64(options.MaxAge/time.Second), 10)
+		}
+		return there.Headers(headers, there.Status(status.NoContent))
+	}
+}
+
+var errorOriginNotAllowed = errors.New("origin is not allowed")
+
+// legacyCors sets the configured headers on every response and answers all
+// OPTIONS requests
+func legacyCors(configuration CorsOptions) there.Middleware {
 	return func(request there.Request, next there.Response) there.Response {
 		headers := map[string]string{
 			header.ResponseAccessControlAllowOrigin:  configuration.AccessControlAllowOrigin,
@@ -20,12 +179,6 @@ func Cors(configuration CorsConfiguration) there.Middleware {
 	}
 }
 
-type CorsConfiguration struct {
-	AccessControlAllowOrigin  string
-	AccessControlAllowMethods string
-	AccessControlAllowHeaders string
-}
-
 func CorsAllowAllConfiguration() CorsConfiguration {
 	return CorsConfiguration{
 		AccessControlAllowOrigin:  "*",
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gebes/there/v2"
 )
@@ -44,3 +46,131 @@ func checkHeaders(t *testing.T, result *http.Response) {
 		t.Fatal("headers did not match allow all configuration", result.Header)
 	}
 }
+
+func TestCorsOptions(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Cors(CorsOptions{
+		AllowOrigins:  []string{"https://example.com", "https://*.example.org"},
+		ExposeHeaders: []string{"X-Total"},
+		MaxAge:        time.Hour,
+		Credentials:   true,
+	}))
+	router.Get("/users", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	})
+	router.Post("/users", func(request there.Request) there.Response {
+		return there.Status(status.Created)
+	})
+	serve := func(method, route, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, route, nil)
+		if origin != "" {
+			request.Header.Set(header.RequestOrigin, origin)
+		}
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve(there.MethodOptions, "/users", "https://api.example.org", map[string]string{
+		header.RequestAccessControlRequestMethod: there.MethodPost,
+		header.RequestAccessControlRequests:      "Content-Type",
+	})
+	expected := map[string]string{
+		header.ResponseAccessControlAllowOrigin:      "https://api.example.org",
+		header.ResponseAccessControlAllowCredentials: "true",
+		header.ResponseAccessControlAllowMethods:     "GET, POST",
+		header.ResponseAccessControlAllowHeaders:     "Content-Type",
+		header.ResponseAccessControlMaxAge:           "3600",
+	}
+	if recorder.Code != status.NoContent {
+		t.Errorf("expected 204, got %v", recorder.Code)
+	}
+	for key, value := range expected {
+		if actual := recorder.Header().Get(key); actual != value {
+			t.Errorf("expected %v to be %v, got %v", key, value, actual)
+		}
+	}
+
+	recorder = serve(there.MethodGet, "/users", "https://example.com", nil)
+	if recorder.Header().Get(header.ResponseAccessControlAllowOrigin) != "https://example.com" ||
+		recorder.Header().Get(header.ResponseAccessControlExposeHeaders) != "X-Total" ||
+		recorder.Header().Get(header.ResponseVary) != "Origin" {
+		t.Errorf("unexpected headers %v", recorder.Header())
+	}
+
+	recorder = serve(there.MethodGet, "/users", "https://evil.com", nil)
+	if recorder.Code != status.OK || recorder.Header().Get(header.ResponseAccessControlAllowOrigin) != "" {
+		t.Errorf("expected no CORS headers for other origins, got %v", recorder.Header())
+	}
+	recorder = serve(there.MethodOptions, "/users", "https://evil.com", map[string]string{header.RequestAccessControlRequestMethod: there.MethodGet})
+	if recorder.Code != status.Forbidden {
+		t.Errorf("expected 403, got %v", recorder.Code)
+	}
+	if recorder := serve(there.MethodGet, "/users", "", nil); recorder.Header().Get(header.ResponseAccessControlAllowOrigin) != "" {
+		t.Errorf("expected no CORS headers without an Origin, got %v", recorder.Header())
+	}
+
+}
+
+func TestCorsPerRoute(t *testing.T) {
+	router := there.NewRouter()
+	router.Get("/private", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	})
+	// the preflight of the route passes its middlewares, so it can be answered per route
+	router.Delete("/public", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	}).With(Cors(CorsOptions{AllowOrigins: []string{"*"}, AllowHeaders: []string{"Authorization"}}))
+
+	preflight := func(route, method string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodOptions, route, nil)
+		request.Header.Set(header.RequestOrigin, "https://example.com")
+		request.Header.Set(header.RequestAccessControlRequestMethod, method)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := preflight("/public", there.MethodDelete)
+	if recorder.Code != status.NoContent ||
+		recorder.Header().Get(header.ResponseAccessControlAllowOrigin) != "*" ||
+		recorder.Header().Get(header.ResponseAccessControlAllowHeaders) != "Authorization" {
+		t.Errorf("expected the route middleware to answer, got %v %v", recorder.Code, recorder.Header())
+	}
+	if recorder := preflight("/private", there.MethodGet); recorder.Code != status.MethodNotAllowed {
+		t.Errorf("expected 405, got %v", recorder.Code)
+	}
+}
+
+func TestCorsCredentialsForAllOrigins(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected credentials for all origins to be refused")
+			}
+		}()
+		Cors(CorsOptions{AllowOrigins: []string{"*"}, Credentials: true})
+	}()
+
+	// dynamic origins are allowed explicitly
+	router := there.NewRouter()
+	router.Use(Cors(CorsOptions{
+		AllowOriginFunc: func(origin string) bool { return strings.HasSuffix(origin, ".example.com") },
+		Credentials:     true,
+	}))
+	router.Get("/", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	})
+	for origin, expected := range map[string]string{"https://app.example.com": "https://app.example.com", "https://evil.com": ""} {
+		request := httptest.NewRequest(there.MethodGet, "/", nil)
+		request.Header.Set(header.RequestOrigin, origin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if actual := recorder.Header().Get(header.ResponseAccessControlAllowOrigin); actual != expected {
+			t.Errorf("%v: expected %q, got %q", origin, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package middlewares
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+// RequestCost are the resources a single request consumed
+type RequestCost struct {
+	AllocBytes   uint64        `json:"allocBytes"`
+	AllocObjects uint64        `json:"allocObjects"`
+	CPU          time.Duration `json:"cpu"`
+	Duration     time.Duration `json:"duration"`
+}
+
+func (c RequestCost) String() string {
+	return "allocated " + strconv.FormatUint(c.AllocBytes, 10) + " B in " +
+		strconv.FormatUint(c.AllocObjects, 10) + " objects, " + c.CPU.String() + " cpu"
+}
+
+// RouteCost sums up the RequestCost of all sampled requests of a route
+type RouteCost struct {
+	Method        string        `json:"method"`
+	Pattern       string        `json:"pattern"`
+	Requests      int           `json:"requests"`
+	AllocBytes    uint64        `json:"allocBytes"`
+	MaxAllocBytes uint64        `json:"maxAllocBytes"`
+	AllocObjects  uint64        `json:"allocObjects"`
+	CPU           time.Duration `json:"cpu"`
+	Duration      time.Duration `json:"duration"`
+}
+
+type DiagnosticsConfiguration struct {
+	// SampleRate is the fraction of requests, that get measured. Defaults to 1.
+	SampleRate float64
+	// TopN is the amount of routes the Endpoint lists. Defaults to 10.
+	TopN int
+}
+
+// Diagnostics measures the allocations and the CPU time of requests with
+// runtime/metrics. The numbers are process-wide, so requests running at the
+// same time, and the garbage collector, are accounted to each other. They are
+// a debugging aid to find expensive routes, not an exact profile, and are best
+// read under low concurrency.
+//
+// The Logger appends the RequestCost to its log line, if Diagnostics measured
+// the request.
+//
+//	diagnostics := middlewares.NewDiagnostics()
+//	router.Use(middlewares.Logger())
+//	router.Use(diagnostics.Middleware)
+//	router.Get("/debug/costs", diagnostics.Endpoint)
+type Diagnostics struct {
+	config DiagnosticsConfiguration
+	mutex  sync.Mutex
+	routes map[string]*RouteCost
+}
+
+func NewDiagnostics(configuration ...DiagnosticsConfiguration) *Diagnostics {
+	config := DiagnosticsConfiguration{}
+	if len(configuration) >= 1 {
+		config = configuration[0]
+	}
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+	if config.TopN <= 0 {
+		config.TopN = 10
+	}
+	return &Diagnostics{
+		config: config,
+		routes: map[string]*RouteCost{},
+	}
+}
+
+type requestCostKey struct{}
+
+// RequestCostOf returns the RequestCost of the request, once Diagnostics
+// measured it
+func RequestCostOf(request there.Request) (RequestCost, bool) {
+	return requestCostOf(request.Context())
+}
+
+func requestCostOf(ctx context.Context) (RequestCost, bool) {
+	cost, ok := ctx.Value(requestCostKey{}).(*RequestCost)
+	if !ok || cost.Duration == 0 {
+		return RequestCost{}, false
+	}
+	return *cost, true
+}
+
+var diagnosticsMetrics = []string{
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+	"/cpu/classes/user:cpu-seconds",
+}
+
+func readDiagnosticsMetrics() RequestCost {
+	samples := make([]metrics.Sample, len(diagnosticsMetrics))
+	for i, name := range diagnosticsMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	cost := RequestCost{}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		cost.AllocBytes = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		cost.AllocObjects = samples[1].Value.Uint64()
+	}
+	if samples[2].Value.Kind() == metrics.KindFloat64 {
+		cost.CPU = time.Duration(samples[2].Value.Float64() * float64(time.Second))
+	}
+	return cost
+}
+
+// Middleware measures the sampled requests
+func (d *Diagnostics) Middleware(request there.Request, next there.Response) there.Response {
+	return there.ResponseFunc(func(w http.ResponseWriter, r *http.Request) {
+		pattern := request.Pattern()
+		if pattern == "" || rand.Float64() >= d.config.SampleRate {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cost := &RequestCost{}
+		request.WithContext(context.WithValue(request.Context(), requestCostKey{}, cost))
+
+		start := time.Now()
+		before := readDiagnosticsMetrics()
+		next.ServeHTTP(w, r)
+		after := readDiagnosticsMetrics()
+
+		cost.AllocBytes = after.AllocBytes - before.AllocBytes
+		cost.AllocObjects = after.AllocObjects - before.AllocObjects
+		cost.CPU = max(after.CPU-before.CPU, 0)
+		cost.Duration = max(time.Since(start), 1)
+		d.record(request.Method, pattern, *cost)
+	})
+}
+
+func (d *Diagnostics) record(method, pattern string, cost RequestCost) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	key := method + " " + pattern
+	route, ok := d.routes[key]
+	if !ok {
+		route = &RouteCost{Method: method, Pattern: pattern}
+		d.routes[key] = route
+	}
+	route.Requests++
+	route.AllocBytes += cost.AllocBytes
+	route.MaxAllocBytes = max(route.MaxAllocBytes, cost.AllocBytes)
+	route.AllocObjects += cost.AllocObjects
+	route.CPU += cost.CPU
+	route.Duration += cost.Duration
+}
+
+// Top returns the n routes, that allocated the most bytes in total. With by set
+// to "cpu" or "duration", they are ordered by their CPU time or duration instead.
+func (d *Diagnostics) Top(n int, by string) []RouteCost {
+	d.mutex.Lock()
+	routes := make([]RouteCost, 0, len(d.routes))
+	for _, route := range d.routes {
+		routes = append(routes, *route)
+	}
+	d.mutex.Unlock()
+
+	less := func(a, b RouteCost) bool { return a.AllocBytes > b.AllocBytes }
+	switch by {
+	case "cpu":
+		less = func(a, b RouteCost) bool { return a.CPU > b.CPU }
+	case "duration":
+		less = func(a, b RouteCost) bool { return a.Duration > b.Duration }
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if less(routes[i], routes[j]) != less(routes[j], routes[i]) {
+			return less(routes[i], routes[j])
+		}
+		return routes[i].Method+" "+routes[i].Pattern < routes[j].Method+" "+routes[j].Pattern
+	})
+	if len(routes) > n {
+		routes = routes[:n]
+	}
+	return routes
+}
+
+// Endpoint lists the TopN most expensive routes. The query parameter "by"
+// orders them by "alloc", the default, "cpu" or "duration". As it exposes the
+// routes of the server, protect the route accordingly.
+func (d *Diagnostics) Endpoint(request there.Request) there.Response {
+	by := request.Params.GetDefault("by", "alloc")
+	return there.Json(status.OK, d.Top(d.config.TopN, by))
+}
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+var diagnosticsSink []byte
+
+func TestDiagnostics(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.New(&buffer, "", 0)
+
+	diagnostics := NewDiagnostics()
+	router := there.NewRouter()
+	router.Use(Logger(LoggerConfiguration{InfoLogger: logger, ErrorLogger: logger}))
+	router.Use(diagnostics.Middleware)
+	router.Get("/heavy/{id}", func(request there.Request) there.Response {
+		diagnosticsSink = make([]byte, 8<<20)
+		return there.Status(status.OK)
+	})
+	router.Get("/light", dummyStatusEndpoint(status.OK))
+	router.Get("/debug/costs", diagnostics.Endpoint)
+
+	for _, route := range []string{"/heavy/1", "/heavy/2", "/light"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(there.MethodGet, route, nil))
+	}
+
+	if !strings.Contains(buffer.String(), "allocated") {
+		t.Fatalf("logger did not include the request cost: %v", buffer.String())
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(there.MethodGet, "/debug/costs", nil))
+	var routes []RouteCost
+	if err := json.Unmarshal(recorder.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %v", routes)
+	}
+	heavy := routes[0]
+	if heavy.Pattern != "/heavy/{id}" || heavy.Requests != 2 || heavy.AllocBytes < 16<<20 || heavy.MaxAllocBytes < 8<<20 {
+		t.Fatalf("unexpected top route %+v", heavy)
+	}
+
+	if top := diagnostics.Top(1, "duration"); len(top) != 1 {
+		t.Fatalf("expected 1 route, got %v", top)
+	}
+}
@@ -0,0 +1,13 @@
+package middlewares
+
+import (
+	"github.com/gebes/there/v2"
+)
+
+// ETag tags every successful GET and HEAD response with an entity tag and answers
+// conditional requests with StatusNotModified. See there.ETag for the details.
+func ETag(configuration ...there.ETagConfiguration) there.Middleware {
+	return func(request there.Request, next there.Response) there.Response {
+		return there.ETag(next, configuration...)
+	}
+}
@@ -0,0 +1,117 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// RequestFingerprint identifies a client by more than its IP address alone
+type RequestFingerprint struct {
+	IP        string
+	UserAgent string
+	// HeaderHash is a hash over the names of the sent headers. As net/http does
+	// not preserve the order of headers, only the set of names is hashed.
+	HeaderHash string
+	// Hash combines IP, UserAgent and HeaderHash into a single key
+	Hash string
+	// BotScore is the highest score any BotScorer returned. Zero, if no scorer
+	// was configured.
+	BotScore float64
+}
+
+// BotScorer rates how likely a request was sent by a bot. Scores range from 0
+// (certainly human) to 1 (certainly a bot). Implement it to plug in a provider.
+type BotScorer interface {
+	Score(request there.Request, fingerprint RequestFingerprint) float64
+}
+
+// BotScorerFunc allows a plain function to be used as BotScorer
+type BotScorerFunc func(request there.Request, fingerprint RequestFingerprint) float64
+
+func (f BotScorerFunc) Score(request there.Request, fingerprint RequestFingerprint) float64 {
+	return f(request, fingerprint)
+}
+
+type FingerprintConfiguration struct {
+	// Scorers are asked for a bot score of every request
+	Scorers []BotScorer
+	// BotThreshold rejects requests with a BotScore greater or equal to it.
+	// Zero never rejects requests, the score is then only exposed.
+	BotThreshold float64
+	// BotHandler is served for rejected requests. Defaults to StatusForbidden.
+	BotHandler there.Endpoint
+}
+
+type fingerprintKey struct{}
+
+// Fingerprint computes the RequestFingerprint of every request and exposes it
+// to later middlewares and handlers through FingerprintOf.
+//
+//	router.Use(middlewares.Fingerprint(middlewares.FingerprintConfiguration{
+//		Scorers:      []middlewares.BotScorer{myProvider},
+//		BotThreshold: 0.9,
+//	}))
+func Fingerprint(configuration ...FingerprintConfiguration) there.Middleware {
+	config := FingerprintConfiguration{}
+	if len(configuration) >= 1 {
+		config = configuration[0]
+	}
+	if config.BotHandler == nil {
+		config.BotHandler = func(request there.Request) there.Response {
+			return there.Status(status.Forbidden)
+		}
+	}
+
+	return func(request there.Request, next there.Response) there.Response {
+		fingerprint := computeFingerprint(request)
+		for _, scorer := range config.Scorers {
+			score := scorer.Score(request, fingerprint)
+			if score > fingerprint.BotScore {
+				fingerprint.BotScore = score
+			}
+		}
+		request.WithContext(context.WithValue(request.Context(), fingerprintKey{}, fingerprint))
+
+		if config.BotThreshold > 0 && fingerprint.BotScore >= config.BotThreshold {
+			return config.BotHandler(request)
+		}
+		return next
+	}
+}
+
+// FingerprintOf returns the RequestFingerprint computed by the Fingerprint middleware.
+// The second return value is false, if the middleware did not run.
+func FingerprintOf(request there.Request) (RequestFingerprint, bool) {
+	fingerprint, ok := request.Context().Value(fingerprintKey{}).(RequestFingerprint)
+	return fingerprint, ok
+}
+
+func computeFingerprint(request there.Request) RequestFingerprint {
+	ip, _, err := net.SplitHostPort(request.RemoteAddress)
+	if err != nil {
+		ip = request.RemoteAddress
+	}
+	names := make([]string, 0, len(request.Request.Header))
+	for name := range request.Request.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	headerHash := sha256.Sum256([]byte(strings.Join(names, ",")))
+	fingerprint := RequestFingerprint{
+		IP:         ip,
+		UserAgent:  request.Request.Header.Get(header.RequestUserAgent),
+		HeaderHash: hex.EncodeToString(headerHash[:8]),
+	}
+	hash := sha256.Sum256([]byte(fingerprint.IP + "\x00" + fingerprint.UserAgent + "\x00" + fingerprint.HeaderHash))
+	fingerprint.Hash = hex.EncodeToString(hash[:16])
+	return fingerprint
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestFingerprint(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Fingerprint(FingerprintConfiguration{
+		Scorers: []BotScorer{BotScorerFunc(func(request there.Request, fingerprint RequestFingerprint) float64 {
+			if strings.Contains(fingerprint.UserAgent, "curl") {
+				return 1
+			}
+			return 0.1
+		})},
+		BotThreshold: 0.5,
+	}))
+	router.Get("/", func(request there.Request) there.Response {
+		fingerprint, ok := FingerprintOf(request)
+		if !ok {
+			return there.Status(status.InternalServerError)
+		}
+		return there.String(status.OK, fingerprint.IP+" "+fingerprint.Hash)
+	})
+
+	serve := func(userAgent string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodGet, "/", nil)
+		request.Header.Set(header.RequestUserAgent, userAgent)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first, second := serve("Mozilla/5.0"), serve("Mozilla/5.0")
+	if first.Code != status.OK || !strings.HasPrefix(first.Body.String(), "192.0.2.1 ") {
+		t.Fatalf("unexpected response %v %v", first.Code, first.Body.String())
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("fingerprint is not stable: %v != %v", first.Body.String(), second.Body.String())
+	}
+	if other := serve("Safari"); other.Body.String() == first.Body.String() {
+		t.Errorf("different user agents share a fingerprint")
+	}
+	if bot := serve("curl/8.0"); bot.Code != status.Forbidden {
+		t.Errorf("bot was not rejected, got %v", bot.Code)
+	}
+}
@@ -4,6 +4,11 @@ import (
 	"github.com/gebes/there/v2"
 )
 
+// Gzip compresses the responses, if the client accepts gzip. Routes declared
+// with NoCompression are left as they are.
 func Gzip(request there.Request, next there.Response) there.Response {
+	if !request.RouteMeta().Compressible() {
+		return next
+	}
 	return there.Gzip(next)
 }
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestGzipNoCompression(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Gzip)
+	router.Get("/text", func(request there.Request) there.Response {
+		return there.String(status.OK, "compress me")
+	})
+	router.Get("/image", func(request there.Request) there.Response {
+		return there.Bytes(status.OK, []byte{0x89, 'P', 'N', 'G'})
+	}).NoCompression()
+
+	serve := func(route string) string {
+		request := httptest.NewRequest(there.MethodGet, route, nil)
+		request.Header.Set(header.RequestAcceptEncoding, "gzip")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Header().Get(header.ContentEncoding)
+	}
+
+	if encoding := serve("/text"); encoding != "gzip" {
+		t.Errorf("expected a compressed response, got %q", encoding)
+	}
+	if encoding := serve("/image"); encoding != "" {
+		t.Errorf("expected an uncompressed response, got %q", encoding)
+	}
+}
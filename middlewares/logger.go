@@ -1,9 +1,13 @@
 package middlewares
 
 import (
+	"context"
+	"errors"
 	"github.com/gebes/there/v2/middlewares/color"
 	"github.com/gebes/there/v2/status"
 	"log"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"strconv"
 	"time"
@@ -34,18 +38,34 @@ func (r *responseWriterWrapper) WriteHeader(statusCode int) {
 type LoggerConfiguration struct {
 	InfoLogger  *log.Logger
 	ErrorLogger *log.Logger
+
+	// Level is the minimum level a request needs to be logged. Requests resulting
+	// in a 5xx status are logged with slog.LevelError, 4xx with slog.LevelWarn and
+	// everything else with slog.LevelInfo. As a slog.LevelVar, it can be changed
+	// at runtime, for example with the LevelEndpoint. Defaults to slog.LevelInfo.
+	Level *slog.LevelVar
+
+	// SampleRates maps a status class (100, 200, 300, 400, 500) to the fraction of
+	// requests within that class that get logged. Classes without an entry are
+	// always logged. For example, {200: 0.01} logs 1% of all successful requests.
+	SampleRates map[int]float64
 }
 
 func Logger(configuration ...LoggerConfiguration) func(request there.Request, next there.Response) there.Response {
 
-	config := &LoggerConfiguration{
-		InfoLogger:  log.Default(),
-		ErrorLogger: log.Default(),
-	}
-
+	config := &LoggerConfiguration{}
 	if len(configuration) >= 1 {
 		config = &configuration[0]
 	}
+	if config.InfoLogger == nil {
+		config.InfoLogger = log.Default()
+	}
+	if config.ErrorLogger == nil {
+		config.ErrorLogger = log.Default()
+	}
+	if config.Level == nil {
+		config.Level = &slog.LevelVar{}
+	}
 
 	return func(request there.Request, next there.Response) there.Response {
 		fn := func(w http.ResponseWriter, r *http.Request) {
@@ -57,8 +77,14 @@ func Logger(configuration ...LoggerConfiguration) func(request there.Request, ne
 			start := time.Now()
 			defer func() {
 				code := ww.writtenHeader
+				if !config.shouldLog(r, code) {
+					return
+				}
 				diff := time.Since(start)
 				toLog := color.Blue(r.Method+" "+r.URL.Path) + " resulted in " + statusCodeToColoredString(code) + " (" + status.Text(code) + ") after " + diff.String()
+				if cost, ok := requestCostOf(r.Context()); ok {
+					toLog += ", " + cost.String()
+				}
 
 				if code == status.InternalServerError {
 					config.ErrorLogger.Println(toLog+":", string(*ww.writtenBytes))
@@ -72,6 +98,69 @@ func Logger(configuration ...LoggerConfiguration) func(request there.Request, ne
 	}
 }
 
+// shouldLog decides, whether a request with the given status code gets logged
+// based on its level and the sample rate of its status class
+func (config *LoggerConfiguration) shouldLog(r *http.Request, code int) bool {
+	minimum := config.Level.Level()
+	if override, ok := r.Context().Value(logLevelKey{}).(slog.Level); ok {
+		minimum = override
+	}
+	if statusCodeToLevel(code) < minimum {
+		return false
+	}
+	rate, ok := config.SampleRates[code-(code%100)]
+	return !ok || rand.Float64() < rate
+}
+
+func statusCodeToLevel(code int) slog.Level {
+	switch {
+	case code >= 500:
+		return slog.LevelError
+	case code >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type logLevelKey struct{}
+
+// LogLevel overrides the minimum level of the Logger for the routes it is added to.
+//
+//	router.Get("/health", Health).With(middlewares.LogLevel(slog.LevelError))
+func LogLevel(level slog.Level) there.Middleware {
+	return func(request there.Request, next there.Response) there.Response {
+		request.WithContext(context.WithValue(request.Context(), logLevelKey{}, level))
+		return next
+	}
+}
+
+// LevelEndpoint exposes the given level, so it can be changed at runtime. A GET
+// request returns the current level and any other method sets it to the value of
+// the "level" query parameter, like "debug", "info", "warn" or "error".
+// As this allows everyone to change the level, protect the route accordingly.
+//
+//	level := &slog.LevelVar{}
+//	router.Use(middlewares.Logger(middlewares.LoggerConfiguration{Level: level}))
+//	router.Handle("/admin/log-level", middlewares.LevelEndpoint(level), there.MethodGet, there.MethodPut)
+func LevelEndpoint(level *slog.LevelVar) there.Endpoint {
+	return func(request there.Request) there.Response {
+		if request.Method != there.MethodGet {
+			value, ok := request.Params.Get("level")
+			if !ok {
+				return there.Error(status.BadRequest, errors.New("query parameter level is missing"))
+			}
+			err := level.UnmarshalText([]byte(value))
+			if err != nil {
+				return there.Error(status.BadRequest, err)
+			}
+		}
+		return there.Json(status.OK, map[string]string{
+			"level": level.Level().String(),
+		})
+	}
+}
+
 func statusCodeToColoredString(code int) string {
 	c := code - (code % 100)
 	cs := strconv.Itoa(code)
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestLoggerLevelsAndSampling(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.New(&buffer, "", 0)
+	level := &slog.LevelVar{}
+
+	router := there.NewRouter()
+	router.Use(Logger(LoggerConfiguration{
+		InfoLogger:  logger,
+		ErrorLogger: logger,
+		Level:       level,
+		SampleRates: map[int]float64{200: 0},
+	}))
+	router.Get("/ok", dummyStatusEndpoint(status.OK))
+	router.Get("/fail", dummyStatusEndpoint(status.InternalServerError))
+	router.Get("/missing", dummyStatusEndpoint(status.NotFound)).With(LogLevel(slog.LevelError))
+	router.Handle("/level", LevelEndpoint(level), there.MethodGet, there.MethodPut)
+
+	serve := func(method, route string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, route, nil))
+	}
+
+	serve(there.MethodGet, "/ok")
+	if buffer.Len() != 0 {
+		t.Fatalf("sampled out request was logged: %v", buffer.String())
+	}
+	serve(there.MethodGet, "/missing")
+	if buffer.Len() != 0 {
+		t.Fatalf("request below the route level was logged: %v", buffer.String())
+	}
+	serve(there.MethodGet, "/fail")
+	if !strings.Contains(buffer.String(), "/fail") {
+		t.Fatalf("failed request was not logged: %v", buffer.String())
+	}
+
+	serve(there.MethodPut, "/level?level=error")
+	if level.Level() != slog.LevelError {
+		t.Fatalf("level was not changed: %v", level.Level())
+	}
+}
+
+func dummyStatusEndpoint(code int) there.Endpoint {
+	return func(request there.Request) there.Response {
+		return there.Status(code)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/gebes/there/v2"
 )
 
+// Recoverer responds to panics with an Error containing the panic. The Router
+// already recovers panics without revealing them, see the RecoverHandler of the
+// RouterConfiguration.
 func Recoverer(request there.Request, next there.Response) there.Response {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -0,0 +1,145 @@
+package middlewares
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+// NonceStore remembers the nonces of accepted requests
+type NonceStore interface {
+	// Remember stores the nonce until it expires and reports, whether it was
+	// already stored. It must be atomic, if the store is shared by instances.
+	Remember(nonce string, expires time.Time) (seen bool)
+}
+
+// MemoryNonceStore is a NonceStore for a single instance. Expired nonces are
+// removed while new ones are stored.
+type MemoryNonceStore struct {
+	mutex     sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+func (s *MemoryNonceStore) Remember(nonce string, expires time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if s.nonces == nil {
+		s.nonces = map[string]time.Time{}
+	}
+	if now.Sub(s.lastSweep) > time.Minute {
+		for n, e := range s.nonces {
+			if now.After(e) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastSweep = now
+	}
+	if e, ok := s.nonces[nonce]; ok && now.Before(e) {
+		return true
+	}
+	s.nonces[nonce] = expires
+	return false
+}
+
+// ReplayStats counts the requests rejected by ReplayProtection
+type ReplayStats struct {
+	replayed atomic.Uint64
+	expired  atomic.Uint64
+	invalid  atomic.Uint64
+}
+
+// Replayed is the amount of requests rejected, because their nonce was already used
+func (s *ReplayStats) Replayed() uint64 {
+	return s.replayed.Load()
+}
+
+// Expired is the amount of requests rejected, because their timestamp was outside the window
+func (s *ReplayStats) Expired() uint64 {
+	return s.expired.Load()
+}
+
+// Invalid is the amount of requests rejected, because the nonce or timestamp was missing or malformed
+func (s *ReplayStats) Invalid() uint64 {
+	return s.invalid.Load()
+}
+
+type ReplayConfiguration struct {
+	// NonceHeader defaults to "X-Nonce"
+	NonceHeader string
+	// TimestampHeader holds the unix time in seconds, the request was signed at. Defaults to "X-Timestamp".
+	TimestampHeader string
+	// Window is the maximum age and clock skew of a timestamp. Defaults to five minutes.
+	Window time.Duration
+	// Store defaults to a MemoryNonceStore
+	Store NonceStore
+	// Scope separates the nonces of different clients, like the API key or the
+	// sender of a webhook. Defaults to one scope for all requests.
+	Scope func(request there.Request) string
+	// Stats counts the rejected requests, if not nil
+	Stats *ReplayStats
+}
+
+// ReplayProtection rejects requests, that were already received before. Every
+// request needs a unique nonce and a current timestamp, both of which should be
+// covered by the signature of the request. Only the nonces within the window
+// need to be remembered, as older timestamps are rejected anyway.
+//
+// Requests with a missing or malformed nonce or timestamp, or a timestamp
+// outside the window, are rejected with StatusUnauthorized. Replayed requests
+// are rejected with StatusConflict.
+//
+//	stats := &middlewares.ReplayStats{}
+//	router.Post("/webhook", Webhook).With(middlewares.ReplayProtection(middlewares.ReplayConfiguration{
+//		Window: time.Minute,
+//		Stats:  stats,
+//	}))
+func ReplayProtection(configuration ReplayConfiguration) there.Middleware {
+	if configuration.NonceHeader == "" {
+		configuration.NonceHeader = "X-Nonce"
+	}
+	if configuration.TimestampHeader == "" {
+		configuration.TimestampHeader = "X-Timestamp"
+	}
+	if configuration.Window == 0 {
+		configuration.Window = 5 * time.Minute
+	}
+	if configuration.Store == nil {
+		configuration.Store = &MemoryNonceStore{}
+	}
+	if configuration.Stats == nil {
+		configuration.Stats = &ReplayStats{}
+	}
+	stats := configuration.Stats
+
+	return func(request there.Request, next there.Response) there.Response {
+		nonce, _ := request.Headers.Get(configuration.NonceHeader)
+		timestamp, _ := request.Headers.Get(configuration.TimestampHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if nonce == "" || err != nil {
+			stats.invalid.Add(1)
+			return there.Error(status.Unauthorized, errors.New("missing or invalid nonce or timestamp"))
+		}
+
+		sent := time.Unix(seconds, 0)
+		if age := time.Since(sent); age > configuration.Window || age < -configuration.Window {
+			stats.expired.Add(1)
+			return there.Error(status.Unauthorized, errors.New("timestamp outside of the allowed window"))
+		}
+
+		if configuration.Scope != nil {
+			nonce = configuration.Scope(request) + "\x00" + nonce
+		}
+		if configuration.Store.Remember(nonce, sent.Add(configuration.Window)) {
+			stats.replayed.Add(1)
+			return there.Error(status.Conflict, errors.New("request was already received"))
+		}
+		return next
+	}
+}
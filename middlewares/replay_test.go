@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestReplayProtection(t *testing.T) {
+	stats := &ReplayStats{}
+	router := there.NewRouter()
+	router.Post("/webhook", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	}).With(ReplayProtection(ReplayConfiguration{Window: time.Minute, Stats: stats}))
+
+	serve := func(nonce string, sent time.Time) int {
+		request := httptest.NewRequest(there.MethodPost, "/webhook", nil)
+		if nonce != "" {
+			request.Header.Set("X-Nonce", nonce)
+		}
+		request.Header.Set("X-Timestamp", strconv.FormatInt(sent.Unix(), 10))
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	now := time.Now()
+	if code := serve("a", now); code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, code)
+	}
+	if code := serve("a", now); code != status.Conflict {
+		t.Errorf("expected %v, got %v", status.Conflict, code)
+	}
+	if code := serve("b", now.Add(-2*time.Minute)); code != status.Unauthorized {
+		t.Errorf("expected %v, got %v", status.Unauthorized, code)
+	}
+	if code := serve("", now); code != status.Unauthorized {
+		t.Errorf("expected %v, got %v", status.Unauthorized, code)
+	}
+	if stats.Replayed() != 1 || stats.Expired() != 1 || stats.Invalid() != 1 {
+		t.Errorf("unexpected stats %v %v %v", stats.Replayed(), stats.Expired(), stats.Invalid())
+	}
+}
@@ -0,0 +1,272 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorInvalidRequestId is served, if RejectInvalid is enabled and a client sent
+// a malformed request id
+var ErrorInvalidRequestId = errors.New("invalid request id")
+
+// RequestIdGenerator generates the ids of requests, that arrive without one
+type RequestIdGenerator interface {
+	NewId() string
+}
+
+// RequestIdGeneratorFunc is an adapter to use functions as RequestIdGenerator
+type RequestIdGeneratorFunc func() string
+
+func (f RequestIdGeneratorFunc) NewId() string {
+	return f()
+}
+
+// RequestIdValidator is implemented by generators, that know the format of
+// their ids. The RequestId middleware uses it to check inbound ids, if
+// RequestIdOptions.Validate is nil.
+type RequestIdValidator interface {
+	Valid(id string) bool
+}
+
+// RequestIdOptions configures the RequestId middleware
+type RequestIdOptions struct {
+	// Header carries the request id. Defaults to X-Request-Id.
+	Header string
+	// Generator creates ids for requests without a valid one. Defaults to UUIDv7.
+	Generator RequestIdGenerator
+	// Validate checks inbound ids. Defaults to the Valid method of the
+	// Generator, if it is a RequestIdValidator, or else to printable ids of up
+	// to 128 characters.
+	Validate func(id string) bool
+	// IgnoreInbound always generates a new id, like at the edge of a network,
+	// where clients are not trusted
+	IgnoreInbound bool
+	// RejectInvalid answers requests with a malformed id with StatusBadRequest.
+	// Otherwise, the id is replaced with a generated one.
+	RejectInvalid bool
+}
+
+type requestIdKey struct{}
+
+// RequestIdOf returns the id the RequestId middleware assigned to the request
+func RequestIdOf(request there.Request) string {
+	id, _ := request.Context().Value(requestIdKey{}).(string)
+	return id
+}
+
+// RequestId assigns every request an id, that is stored in its context and
+// sent back in the Header. Ids sent by the client are kept, if they are valid.
+// The id is also set as Request.RequestId, so 5xx errors include it.
+//
+//	generator, err := middlewares.Snowflake(7)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	router.Use(middlewares.RequestId(middlewares.RequestIdOptions{
+//		Generator:     generator,
+//		RejectInvalid: true,
+//	}))
+func RequestId(options RequestIdOptions) there.Middleware {
+	if options.Header == "" {
+		options.Header = header.XRequestId
+	}
+	if options.Generator == nil {
+		options.Generator = UUIDv7()
+	}
+	if options.Validate == nil {
+		if validator, ok := options.Generator.(RequestIdValidator); ok {
+			options.Validate = validator.Valid
+		} else {
+			options.Validate = printableRequestId
+		}
+	}
+
+	return func(request there.Request, next there.Response) there.Response {
+		id := request.Request.Header.Get(options.Header)
+		if options.IgnoreInbound {
+			id = ""
+		}
+		if id != "" && !options.Validate(id) {
+			if options.RejectInvalid {
+				return there.Error(status.BadRequest, fmt.Errorf("%w in the %v header", ErrorInvalidRequestId, options.Header))
+			}
+			id = ""
+		}
+		if id == "" {
+			id = options.Generator.NewId()
+			request.Request.Header.Set(options.Header, id)
+		}
+		request.WithContext(context.WithValue(request.Context(), requestIdKey{}, id))
+		request.SetRequestId(id)
+		return there.Headers(map[string]string{options.Header: id}, next)
+	}
+}
+
+func printableRequestId(id string) bool {
+	if len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+type uuidV7Generator struct{}
+
+// UUIDv7 generates time ordered UUIDs of version 7, like
+// "01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47"
+func UUIDv7() RequestIdGenerator {
+	return uuidV7Generator{}
+}
+
+func (uuidV7Generator) NewId() string {
+	var id [16]byte
+	_, _ = rand.Read(id[6:])
+	milliseconds := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint16(id[0:], uint16(milliseconds>>32))
+	binary.BigEndian.PutUint32(id[2:], uint32(milliseconds))
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+
+	buffer := make([]byte, 36)
+	hex.Encode(buffer[0:8], id[0:4])
+	buffer[8] = '-'
+	hex.Encode(buffer[9:13], id[4:6])
+	buffer[13] = '-'
+	hex.Encode(buffer[14:18], id[6:8])
+	buffer[18] = '-'
+	hex.Encode(buffer[19:23], id[8:10])
+	buffer[23] = '-'
+	hex.Encode(buffer[24:], id[10:])
+	return string(buffer)
+}
+
+// Valid accepts UUIDs of any version in their canonical form
+func (uuidV7Generator) Valid(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if id[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(id[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+type ulidGenerator struct{}
+
+// ULID generates lexicographically sortable identifiers, like
+// "01HZX3JY6T8W9QK2V4M5N7P0RS"
+func ULID() RequestIdGenerator {
+	return ulidGenerator{}
+}
+
+func (ulidGenerator) NewId() string {
+	var random [10]byte
+	_, _ = rand.Read(random[:])
+	// 48 bits of milliseconds and 80 random bits, encoded as 26 base32 characters
+	high := uint64(time.Now().UnixMilli())<<16 | uint64(binary.BigEndian.Uint16(random[0:]))
+	low := binary.BigEndian.Uint64(random[2:])
+
+	buffer := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		buffer[i] = crockford[low&31]
+		low = low>>5 | high<<59
+		high >>= 5
+	}
+	return string(buffer)
+}
+
+// Valid accepts ULIDs in upper or lower case
+func (ulidGenerator) Valid(id string) bool {
+	if len(id) != 26 || id[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c == 'I' || c == 'L' || c == 'O' || c == 'U' || !('0' <= c && c <= '9' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// snowflakeEpoch is the start of the timestamps of Snowflake ids
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+type snowflakeGenerator struct {
+	node     int64
+	mutex    sync.Mutex
+	last     int64
+	sequence int64
+}
+
+// Snowflake generates decimal, time ordered ids of 41 bits milliseconds since
+// 2020, 10 bits node and 12 bits sequence. Every instance needs its own node,
+// between 0 and 1023, so ids are unique across instances.
+func Snowflake(node int64) (RequestIdGenerator, error) {
+	if node < 0 || node > 1023 {
+		return nil, fmt.Errorf("snowflake node %d is not between 0 and 1023", node)
+	}
+	return &snowflakeGenerator{node: node}, nil
+}
+
+func (g *snowflakeGenerator) NewId() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	now := time.Now().UnixMilli() - snowflakeEpoch
+	if now < g.last {
+		// the clock went backwards, keep counting on the last millisecond
+		now = g.last
+	}
+	if now == g.last {
+		g.sequence = (g.sequence + 1) & 4095
+		if g.sequence == 0 {
+			for now <= g.last {
+				time.Sleep(100 * time.Microsecond)
+				now = time.Now().UnixMilli() - snowflakeEpoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.last = now
+	return strconv.FormatInt(now<<22|g.node<<12|g.sequence, 10)
+}
+
+// Valid accepts positive decimal ids
+func (g *snowflakeGenerator) Valid(id string) bool {
+	value, err := strconv.ParseInt(id, 10, 64)
+	return err == nil && value > 0 && strconv.FormatInt(value, 10) == id
+}
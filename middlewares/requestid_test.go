@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRequestIdGenerators(t *testing.T) {
+	snowflake, err := Snowflake(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Snowflake(1024); err == nil {
+		t.Error("expected an error for an invalid node")
+	}
+
+	generators := map[string]RequestIdGenerator{"uuid": UUIDv7(), "ulid": ULID(), "snowflake": snowflake}
+	for name, generator := range generators {
+		validator := generator.(RequestIdValidator)
+		ids := make([]string, 100)
+		seen := map[string]bool{}
+		for i := range ids {
+			ids[i] = generator.NewId()
+			if !validator.Valid(ids[i]) {
+				t.Errorf("%v: generated an invalid id %v", name, ids[i])
+			}
+			if seen[ids[i]] {
+				t.Errorf("%v: generated %v twice", name, ids[i])
+			}
+			seen[ids[i]] = true
+		}
+		if name == "snowflake" && !sort.StringsAreSorted(ids) {
+			t.Errorf("%v: expected ordered ids, got %v", name, ids)
+		}
+		if validator.Valid("not-an-id") {
+			t.Errorf("%v: accepted a malformed id", name)
+		}
+	}
+
+	if id := UUIDv7().NewId(); id[14] != '7' {
+		t.Errorf("expected a version 7 uuid, got %v", id)
+	}
+}
+
+func TestRequestId(t *testing.T) {
+	newRouter := func(options RequestIdOptions) *there.Router {
+		router := there.NewRouter()
+		router.Use(RequestId(options))
+		router.Get("/", func(request there.Request) there.Response {
+			return there.String(status.OK, RequestIdOf(request))
+		})
+		router.Get("/error", func(request there.Request) there.Response {
+			return there.Error(status.InternalServerError, errors.New("failed"))
+		})
+		return router
+	}
+	serve := func(router *there.Router, id string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodGet, "/", nil)
+		if id != "" {
+			request.Header.Set(header.XRequestId, id)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	const valid = "01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47"
+	router := newRouter(RequestIdOptions{})
+	recorder := serve(router, valid)
+	if recorder.Body.String() != valid || recorder.Header().Get(header.XRequestId) != valid {
+		t.Errorf("expected the inbound id to be kept, got %v", recorder.Body.String())
+	}
+	recorder = serve(router, "malformed")
+	if id := recorder.Body.String(); id == "malformed" || !UUIDv7().(RequestIdValidator).Valid(id) || recorder.Header().Get(header.XRequestId) != id {
+		t.Errorf("expected the malformed id to be replaced, got %v", id)
+	}
+
+	recorder = serve(newRouter(RequestIdOptions{RejectInvalid: true}), "malformed")
+	if recorder.Code != status.BadRequest {
+		t.Errorf("expected the malformed id to be rejected, got %v", recorder.Code)
+	}
+
+	recorder = serve(newRouter(RequestIdOptions{IgnoreInbound: true, Generator: RequestIdGeneratorFunc(func() string {
+		return "generated"
+	})}), valid)
+	if recorder.Body.String() != "generated" {
+		t.Errorf("expected the inbound id to be ignored, got %v", recorder.Body.String())
+	}
+
+	request := httptest.NewRequest(there.MethodGet, "/error", nil)
+	request.Header.Set(header.XRequestId, valid)
+	recorder = httptest.NewRecorder()
+	newRouter(RequestIdOptions{}).ServeHTTP(recorder, request)
+	if recorder.Body.String() != `{"error":"failed","requestId":"`+valid+`"}` {
+		t.Errorf("expected the id in the error, got %v", recorder.Body.String())
+	}
+}
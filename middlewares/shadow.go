@@ -0,0 +1,110 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2"
+)
+
+// ShadowHeader is set on mirrored requests, so the upstream can tell them apart
+const ShadowHeader = "X-Shadow-Request"
+
+type ShadowConfiguration struct {
+	// Upstream is the base URL requests are mirrored to, like "http://checkout-v2:8080"
+	Upstream string
+	// Rate is the fraction of requests, that are mirrored, from 0 to 1
+	Rate float64
+	// Client sends the mirrored requests. Defaults to a client with a timeout of ten seconds.
+	Client *http.Client
+	// MaxBodySize is the largest body, that is mirrored. Requests with larger
+	// bodies are not mirrored at all. Defaults to 1 MiB.
+	MaxBodySize int64
+	// MaxInFlight limits the mirrored requests in progress. Further requests
+	// are not mirrored. Defaults to 100.
+	MaxInFlight int64
+}
+
+// hopHeaders are only meaningful for a single connection and are not mirrored
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// Shadow asynchronously mirrors a sample of the requests, including their bodies,
+// to a secondary upstream and discards its responses. Use it to test a new
+// version of a service with production traffic, without affecting clients.
+//
+//	router.Use(middlewares.Shadow(middlewares.ShadowConfiguration{
+//		Upstream: "http://checkout-v2:8080",
+//		Rate:     0.05,
+//	}))
+func Shadow(configuration ShadowConfiguration) there.Middleware {
+	if configuration.Client == nil {
+		configuration.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if configuration.MaxBodySize == 0 {
+		configuration.MaxBodySize = 1 << 20
+	}
+	if configuration.MaxInFlight == 0 {
+		configuration.MaxInFlight = 100
+	}
+	upstream := strings.TrimSuffix(configuration.Upstream, "/")
+	var inFlight atomic.Int64
+
+	return func(request there.Request, next there.Response) there.Response {
+		if configuration.Rate <= 0 || rand.Float64() >= configuration.Rate {
+			return next
+		}
+
+		var body []byte
+		r := request.Request
+		if r.Body != nil && r.Body != http.NoBody {
+			read, err := io.ReadAll(io.LimitReader(r.Body, configuration.MaxBodySize+1))
+			// the original request has to see the whole body, whether it is mirrored or not
+			r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(read), r.Body), Closer: r.Body}
+			if err != nil || int64(len(read)) > configuration.MaxBodySize {
+				return next
+			}
+			body = read
+		}
+
+		if inFlight.Add(1) > configuration.MaxInFlight {
+			inFlight.Add(-1)
+			return next
+		}
+		mirror, err := http.NewRequestWithContext(context.Background(), r.Method, upstream+r.URL.RequestURI(), bytes.NewReader(body))
+		if err != nil {
+			inFlight.Add(-1)
+			log.Printf("shadow: creating request failed: %v", err)
+			return next
+		}
+		mirror.Header = r.Header.Clone()
+		for _, name := range hopHeaders {
+			mirror.Header.Del(name)
+		}
+		mirror.Header.Set(ShadowHeader, "1")
+		mirror.Host = r.Host
+
+		go func() {
+			defer inFlight.Add(-1)
+			response, err := configuration.Client.Do(mirror)
+			if err != nil {
+				log.Printf("shadow: %v %v failed: %v", mirror.Method, mirror.URL, err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}()
+		return next
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestShadow(t *testing.T) {
+	mirrored := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mirrored <- r.Method + " " + r.URL.RequestURI() + " " + string(body) + " " + r.Header.Get(ShadowHeader)
+		rw.WriteHeader(status.InternalServerError)
+	}))
+	defer upstream.Close()
+
+	router := there.NewRouter()
+	router.Use(Shadow(ShadowConfiguration{Upstream: upstream.URL, Rate: 1}))
+	router.Post("/orders", func(request there.Request) there.Response {
+		body, err := request.Body.ToString()
+		if err != nil {
+			return there.Error(status.InternalServerError, err)
+		}
+		return there.String(status.Created, body)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(there.MethodPost, "/orders?id=1", strings.NewReader("order")))
+	if recorder.Code != status.Created || recorder.Body.String() != "order" {
+		t.Errorf("the original request was affected: %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	select {
+	case request := <-mirrored:
+		if request != "POST /orders?id=1 order 1" {
+			t.Errorf("unexpected mirrored request %q", request)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("request was not mirrored")
+	}
+}
@@ -0,0 +1,166 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorLoadShed is served, if a request was rejected to protect more important traffic
+var ErrorLoadShed = errors.New("server is overloaded")
+
+// Priority is the tier of a request. Under load, lower tiers are shed first.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	// PriorityCritical requests are never shed, like health checks or payments
+	PriorityCritical
+)
+
+// ParsePriority parses "low", "normal", "high" and "critical"
+func ParsePriority(value string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "low":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "high":
+		return PriorityHigh, true
+	case "critical":
+		return PriorityCritical, true
+	}
+	return PriorityNormal, false
+}
+
+// PriorityFromMeta classifies requests by the RouteMeta of their route.
+// Routes without a valid priority are PriorityNormal.
+//
+//	router.Get("/health", Health).Tag("priority:critical")
+func PriorityFromMeta(key string) func(request there.Request) Priority {
+	return func(request there.Request) Priority {
+		priority, _ := ParsePriority(request.RouteMeta()[key])
+		return priority
+	}
+}
+
+// PriorityFromHeader classifies requests by a request header. As clients can
+// set it freely, it should only be trusted behind a gateway, that sets it.
+// Requests without a valid priority are PriorityNormal.
+func PriorityFromHeader(name string) func(request there.Request) Priority {
+	return func(request there.Request) Priority {
+		priority, _ := ParsePriority(request.Request.Header.Get(name))
+		return priority
+	}
+}
+
+type LoadSheddingConfiguration struct {
+	// Priority classifies the requests. Defaults to PriorityFromMeta("priority").
+	Priority func(request there.Request) Priority
+	// MaxInFlight is the amount of requests served at once, that counts as full
+	// load. Zero ignores the in-flight count.
+	MaxInFlight int
+	// MaxLatency is the moving average of the response time, that counts as full
+	// load. Zero ignores the latency.
+	MaxLatency time.Duration
+	// Smoothing is the weight of the latest response time in the moving average.
+	// Defaults to 0.1.
+	Smoothing float64
+	// Thresholds is the load, as a fraction of the full load, at which requests
+	// of a priority are shed. Defaults to 0.6 for PriorityLow, 0.8 for
+	// PriorityNormal and 1 for PriorityHigh. PriorityCritical is never shed.
+	Thresholds map[Priority]float64
+	// RetryAfter is suggested to shed clients. Defaults to one second.
+	RetryAfter time.Duration
+}
+
+type loadShedder struct {
+	config   LoadSheddingConfiguration
+	inFlight atomic.Int64
+	mutex    sync.Mutex
+	latency  float64 // moving average in seconds
+}
+
+// load returns the current load as a fraction of the full load
+func (s *loadShedder) load() float64 {
+	load := 0.0
+	if s.config.MaxInFlight > 0 {
+		load = float64(s.inFlight.Load()) / float64(s.config.MaxInFlight)
+	}
+	if s.config.MaxLatency > 0 {
+		s.mutex.Lock()
+		latency := s.latency
+		s.mutex.Unlock()
+		load = max(load, latency/s.config.MaxLatency.Seconds())
+	}
+	return load
+}
+
+func (s *loadShedder) observe(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency += s.config.Smoothing * (latency.Seconds() - s.latency)
+}
+
+// LoadShedding rejects requests with StatusServiceUnavailable and a
+// Retry-After header, once the server is overloaded. The load is measured by
+// the requests in flight and the moving average of the response time, and
+// requests of lower priority are shed first, so critical traffic keeps being
+// served. Only requests passing the middleware are measured, so register it
+// as one of the first global middlewares.
+//
+//	router.Use(middlewares.LoadShedding(middlewares.LoadSheddingConfiguration{
+//		MaxInFlight: 500,
+//		MaxLatency:  time.Second,
+//	}))
+//	router.Get("/search", Search).Tag("priority:low")
+//	router.Post("/checkout", Checkout).Tag("priority:critical")
+func LoadShedding(configuration LoadSheddingConfiguration) there.Middleware {
+	if configuration.MaxInFlight <= 0 && configuration.MaxLatency <= 0 {
+		panic("loadShedding: MaxInFlight or MaxLatency needs to be positive")
+	}
+	if configuration.Priority == nil {
+		configuration.Priority = PriorityFromMeta("priority")
+	}
+	if configuration.Smoothing <= 0 || configuration.Smoothing > 1 {
+		configuration.Smoothing = 0.1
+	}
+	if configuration.Thresholds == nil {
+		configuration.Thresholds = map[Priority]float64{
+			PriorityLow:    0.6,
+			PriorityNormal: 0.8,
+			PriorityHigh:   1,
+		}
+	}
+	if configuration.RetryAfter <= 0 {
+		configuration.RetryAfter = time.Second
+	}
+	s := &loadShedder{config: configuration}
+
+	return func(request there.Request, next there.Response) there.Response {
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			priority := configuration.Priority(request)
+			threshold, ok := configuration.Thresholds[priority]
+			if priority < PriorityCritical && ok && s.load() >= threshold {
+				there.RetryAfter(configuration.RetryAfter, there.Error(status.ServiceUnavailable, ErrorLoadShed)).ServeHTTP(rw, r)
+				return
+			}
+
+			s.inFlight.Add(1)
+			start := time.Now()
+			defer func() {
+				s.inFlight.Add(-1)
+				s.observe(time.Since(start))
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
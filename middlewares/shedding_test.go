@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestLoadSheddingInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	router := there.NewRouter()
+	router.Use(LoadShedding(LoadSheddingConfiguration{MaxInFlight: 10}))
+	router.Get("/slow", func(request there.Request) there.Response {
+		started <- struct{}{}
+		<-release
+		return there.Status(status.OK)
+	})
+	router.Get("/search", dummyStatusEndpoint(status.OK)).Tag("priority:low")
+	router.Get("/profile", dummyStatusEndpoint(status.OK))
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(there.MethodGet, route, nil))
+		return recorder
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 7; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("/slow")
+		}()
+		<-started
+	}
+
+	recorder := serve("/search")
+	if recorder.Code != status.ServiceUnavailable || recorder.Header().Get(header.ResponseRetryAfter) == "" {
+		t.Errorf("low priority request was not shed: %v %v", recorder.Code, recorder.Header())
+	}
+	if recorder = serve("/profile"); recorder.Code != status.OK {
+		t.Errorf("normal priority request was shed: %v", recorder.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	if recorder = serve("/search"); recorder.Code != status.OK {
+		t.Errorf("low priority request was shed without load: %v", recorder.Code)
+	}
+}
+
+func TestLoadSheddingLatency(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(LoadShedding(LoadSheddingConfiguration{
+		MaxLatency: 10 * time.Millisecond,
+		Smoothing:  1,
+		Priority:   PriorityFromHeader("X-Priority"),
+	}))
+	router.Get("/slow", func(request there.Request) there.Response {
+		time.Sleep(20 * time.Millisecond)
+		return there.Status(status.OK)
+	})
+
+	serve := func(priority string) int {
+		request := httptest.NewRequest(there.MethodGet, "/slow", nil)
+		request.Header.Set("X-Priority", priority)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	if code := serve("high"); code != status.OK {
+		t.Fatalf("first request was shed: %v", code)
+	}
+	if code := serve("high"); code != status.ServiceUnavailable {
+		t.Errorf("high priority request was not shed above full load: %v", code)
+	}
+	if code := serve("critical"); code != status.OK {
+		t.Errorf("critical request was shed: %v", code)
+	}
+}
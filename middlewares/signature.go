@@ -0,0 +1,18 @@
+package middlewares
+
+import (
+	"github.com/gebes/there/v2"
+)
+
+// Signature signs every response as described in RFC 9421. See there.Sign for the details.
+//
+//	router.Use(middlewares.Signature(there.SignatureConfiguration{
+//		Signer:  there.Ed25519Signer(privateKey),
+//		KeyID:   "2024-01",
+//		Headers: []string{header.ContentType},
+//	}))
+func Signature(configuration there.SignatureConfiguration) there.Middleware {
+	return func(request there.Request, next there.Response) there.Response {
+		return there.Sign(next, configuration)
+	}
+}
@@ -0,0 +1,187 @@
+package middlewares
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorThrottled is served, if a key exceeded its ThrottleLimit
+var ErrorThrottled = errors.New("too many requests")
+
+// ThrottleLimit allows Requests per time window. Up to Requests may be sent at
+// once, after which the quota refills evenly over the window.
+type ThrottleLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l ThrottleLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// ThrottleOverrides provides individual limits for keys, like for tenants on a
+// higher plan. It is asked on every request, so it should cache its limits.
+type ThrottleOverrides interface {
+	Limit(key string) (ThrottleLimit, bool)
+}
+
+// ThrottleOverridesFunc allows a plain function to be used as ThrottleOverrides
+type ThrottleOverridesFunc func(key string) (ThrottleLimit, bool)
+
+func (f ThrottleOverridesFunc) Limit(key string) (ThrottleLimit, bool) {
+	return f(key)
+}
+
+// StaticThrottleOverrides are ThrottleOverrides, that never change
+type StaticThrottleOverrides map[string]ThrottleLimit
+
+func (o StaticThrottleOverrides) Limit(key string) (ThrottleLimit, bool) {
+	limit, ok := o[key]
+	return limit, ok
+}
+
+// ThrottleKeyHeader throttles by the value of the request header, like a tenant or an API key
+func ThrottleKeyHeader(name string) func(request there.Request) string {
+	return func(request there.Request) string {
+		return request.Request.Header.Get(name)
+	}
+}
+
+// ThrottleKeyPrincipal throttles by the subject of the Principal of the request
+func ThrottleKeyPrincipal(request there.Request) string {
+	if principal, ok := PrincipalOf(request); ok {
+		return principal.Subject
+	}
+	return ""
+}
+
+// ThrottleKeyIP throttles by the IP address of the client
+func ThrottleKeyIP(request there.Request) string {
+	host, _, err := net.SplitHostPort(request.Request.RemoteAddr)
+	if err != nil {
+		return request.Request.RemoteAddr
+	}
+	return host
+}
+
+type ThrottleConfiguration struct {
+	// Key extracts the key requests are throttled by. Requests with an empty key
+	// are not throttled. Defaults to ThrottleKeyIP.
+	Key func(request there.Request) string
+	// Limit applies to every key without an override
+	Limit ThrottleLimit
+	// Overrides provides individual limits for keys, if not nil
+	Overrides ThrottleOverrides
+	// Policy names the limit in the RateLimit headers. Defaults to "default".
+	Policy string
+}
+
+type throttleBucket struct {
+	limit  ThrottleLimit
+	tokens float64
+	last   time.Time
+}
+
+type throttle struct {
+	config    ThrottleConfiguration
+	mutex     sync.Mutex
+	buckets   map[string]*throttleBucket
+	lastSweep time.Time
+}
+
+// take removes a token from the bucket of the key and returns the state of the
+// bucket afterwards
+func (t *throttle) take(key string, limit ThrottleLimit, now time.Time) (allowed bool, remaining int, reset time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if now.Sub(t.lastSweep) > time.Minute {
+		// full buckets carry no state and can be recreated on demand
+		for k, b := range t.buckets {
+			if now.Sub(b.last) >= b.limit.Per {
+				delete(t.buckets, k)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	bucket, ok := t.buckets[key]
+	if !ok || bucket.limit != limit {
+		bucket = &throttleBucket{limit: limit, tokens: float64(limit.Requests), last: now}
+		t.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+now.Sub(bucket.last).Seconds()*limit.rate())
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		allowed = true
+	}
+	missing := float64(limit.Requests) - bucket.tokens
+	reset = time.Duration(missing / limit.rate() * float64(time.Second))
+	if !allowed {
+		reset = time.Duration((1 - bucket.tokens) / limit.rate() * float64(time.Second))
+	}
+	return allowed, int(bucket.tokens), reset
+}
+
+// Throttle limits the requests per key, like per tenant, user or IP address,
+// with a separate token bucket for every key. Keys can get individual limits
+// from ThrottleOverrides. Responses announce the quota with the RateLimit
+// headers, and throttled requests are rejected with StatusTooManyRequests and a
+// Retry-After header, see there.RateLimited.
+//
+//	router.Use(middlewares.Throttle(middlewares.ThrottleConfiguration{
+//		Key:       middlewares.ThrottleKeyHeader("X-Tenant"),
+//		Limit:     middlewares.ThrottleLimit{Requests: 100, Per: time.Minute},
+//		Overrides: middlewares.StaticThrottleOverrides{"enterprise": {Requests: 1000, Per: time.Minute}},
+//	}))
+func Throttle(configuration ThrottleConfiguration) there.Middleware {
+	if configuration.Key == nil {
+		configuration.Key = ThrottleKeyIP
+	}
+	if configuration.Limit.Requests <= 0 || configuration.Limit.Per <= 0 {
+		panic("throttle: limit needs positive requests and window")
+	}
+	t := &throttle{config: configuration, buckets: map[string]*throttleBucket{}}
+
+	return func(request there.Request, next there.Response) there.Response {
+		// the key is extracted when the response is served, so it can depend on
+		// middlewares registered earlier, like the ones storing the Principal
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			key := configuration.Key(request)
+			if key == "" {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			limit := configuration.Limit
+			if configuration.Overrides != nil {
+				if override, ok := configuration.Overrides.Limit(key); ok && override.Requests > 0 && override.Per > 0 {
+					limit = override
+				}
+			}
+
+			allowed, remaining, reset := t.take(key, limit, time.Now())
+			rateLimit := there.RateLimit{
+				Policy:    configuration.Policy,
+				Quota:     limit.Requests,
+				Window:    limit.Per,
+				Remaining: remaining,
+				Reset:     reset,
+			}
+			if !allowed {
+				there.RateLimited(rateLimit, there.Error(status.TooManyRequests, ErrorThrottled)).ServeHTTP(rw, r)
+				return
+			}
+			rateLimit.SetHeaders(rw.Header())
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
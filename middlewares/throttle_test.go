@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestThrottle(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Throttle(ThrottleConfiguration{
+		Key:       ThrottleKeyHeader("X-Tenant"),
+		Limit:     ThrottleLimit{Requests: 2, Per: time.Hour},
+		Overrides: StaticThrottleOverrides{"enterprise": {Requests: 5, Per: time.Hour}},
+	}))
+	router.Get("/", func(request there.Request) there.Response {
+		return there.Status(status.OK)
+	})
+
+	serve := func(tenant string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(there.MethodGet, "/", nil)
+		if tenant != "" {
+			request.Header.Set("X-Tenant", tenant)
+		}
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	counts := func(tenant string, requests int) (allowed int) {
+		for i := 0; i < requests; i++ {
+			if serve(tenant).Code == status.OK {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	recorder := serve("free")
+	if value := recorder.Header().Get(header.ResponseRateLimit); value != `"default";r=1;t=1800` {
+		t.Errorf("unexpected rate limit %v", value)
+	}
+	serve("free")
+	recorder = serve("free")
+	if recorder.Code != status.TooManyRequests || recorder.Header().Get(header.ResponseRetryAfter) != "1800" {
+		t.Errorf("expected %v with Retry-After, got %v %v", status.TooManyRequests, recorder.Code, recorder.Header())
+	}
+	if allowed := counts("other", 10); allowed != 2 {
+		t.Errorf("keys must have separate buckets, got %v", allowed)
+	}
+	if allowed := counts("enterprise", 10); allowed != 5 {
+		t.Errorf("expected the override to allow 5 requests, got %v", allowed)
+	}
+	if allowed := counts("", 10); allowed != 10 {
+		t.Errorf("requests without a key must not be throttled, got %v", allowed)
+	}
+}
+
+func TestThrottleRefill(t *testing.T) {
+	th := &throttle{buckets: map[string]*throttleBucket{}}
+	limit := ThrottleLimit{Requests: 2, Per: 2 * time.Second}
+	now := time.Now()
+	th.take("a", limit, now)
+	th.take("a", limit, now)
+	if allowed, _, _ := th.take("a", limit, now); allowed {
+		t.Errorf("expected the bucket to be empty")
+	}
+	if allowed, _, _ := th.take("a", limit, now.Add(time.Second)); !allowed {
+		t.Errorf("expected a token to be refilled after a second")
+	}
+}
@@ -0,0 +1,73 @@
+package there
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// mockDepth limits how deep mockValue descends, so recursive types terminate
+const mockDepth = 8
+
+// mockEndpoint returns an Endpoint serving the documented response of a route
+// without handler. The success response with the lowest status code is preferred.
+// If no response was documented, StatusNotImplemented is returned.
+func mockEndpoint(doc *RouteDoc) Endpoint {
+	return func(request Request) Response {
+		if doc == nil || len(doc.Responses) == 0 {
+			return Status(status.NotImplemented)
+		}
+		codes := make([]int, 0, len(doc.Responses))
+		for code := range doc.Responses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		code := codes[0]
+		for _, c := range codes {
+			if c >= 200 && c < 300 {
+				code = c
+				break
+			}
+		}
+		body := doc.Responses[code]
+		if body == nil {
+			return Status(code)
+		}
+		return Auto(code, mockValue(body))
+	}
+}
+
+// mockValue returns the given value, if it was set to something other than the
+// zero value, as it is then an example provided by the caller. Otherwise, a
+// value of the same type is built, in which nil pointers are allocated and
+// slices contain a single element, so the rendered body shows the full shape.
+func mockValue(v any) any {
+	value := reflect.ValueOf(v)
+	if !value.IsZero() {
+		return v
+	}
+	return mockFill(value.Type(), 0).Interface()
+}
+
+func mockFill(t reflect.Type, depth int) reflect.Value {
+	value := reflect.New(t).Elem()
+	if depth >= mockDepth {
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		value.Set(mockFill(t.Elem(), depth+1).Addr())
+	case reflect.Slice:
+		value.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), mockFill(t.Elem(), depth+1)))
+	case reflect.Map:
+		value.Set(reflect.MakeMap(t))
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).IsExported() {
+				value.Field(i).Set(mockFill(t.Field(i).Type, depth+1))
+			}
+		}
+	}
+	return value
+}
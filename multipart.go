@@ -0,0 +1,131 @@
+package there
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+)
+
+var (
+	// ErrorPartTooLarge is returned by Part.Read, if the part exceeds MaxPartSize
+	ErrorPartTooLarge = errors.New("multipart part too large")
+	// ErrorTooManyParts is returned by MultipartStream, if the body has more than MaxParts parts
+	ErrorTooManyParts = errors.New("too many multipart parts")
+)
+
+// MultipartOptions limit the parts read by MultipartStream. Zero values mean no limit.
+type MultipartOptions struct {
+	// MaxPartSize is the maximum size of a single part in bytes
+	MaxPartSize int64
+	// MaxParts is the maximum amount of parts
+	MaxParts int
+}
+
+// Part is a single part of a multipart body. Reading from it streams the data
+// straight from the connection, nothing is buffered except for the first bytes
+// used for sniffing.
+type Part struct {
+	// FormName is the name of the form field
+	FormName string
+	// FileName is empty, if the part is no file
+	FileName string
+	Header   textproto.MIMEHeader
+	// ContentType is the type the client declared
+	ContentType string
+	// SniffedType is the type detected from the first 512 bytes of the data,
+	// with the algorithm of http.DetectContentType. Do not trust ContentType
+	// alone, when storing uploads.
+	SniffedType string
+
+	reader    *bufio.Reader
+	remaining int64
+	limited   bool
+}
+
+// Read reads the data of the part. If the part exceeds the MaxPartSize, then
+// ErrorPartTooLarge is returned.
+func (p *Part) Read(b []byte) (int, error) {
+	if p.limited {
+		if p.remaining <= 0 {
+			// check whether there is more data than allowed
+			if _, err := p.reader.Peek(1); err == io.EOF {
+				return 0, io.EOF
+			}
+			return 0, fmt.Errorf("%w: part %q", ErrorPartTooLarge, p.FormName)
+		}
+		if int64(len(b)) > p.remaining {
+			b = b[:p.remaining]
+		}
+	}
+	n, err := p.reader.Read(b)
+	p.remaining -= int64(n)
+	return n, err
+}
+
+// MultipartStream iterates over the parts of a multipart/form-data body and
+// calls handle for each of them, without buffering whole files in memory or on
+// disk. Data of a part, that was not read by handle, is skipped. Iteration stops
+// at the first error, which is returned.
+//
+//	err := request.MultipartStream(func(part *there.Part) error {
+//		if part.FileName == "" {
+//			return nil
+//		}
+//		return bucket.Upload(part.FileName, part.SniffedType, part)
+//	}, there.MultipartOptions{MaxPartSize: 100 << 20})
+//
+// If the request is no multipart request, then http.ErrNotMultipart is returned.
+func (r *Request) MultipartStream(handle func(part *Part) error, options ...MultipartOptions) error {
+	var config MultipartOptions
+	if len(options) >= 1 {
+		config = options[0]
+	}
+	reader, err := r.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for count := 0; ; count++ {
+		raw, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				return bodyTooLargeError(maxBytesError.Limit)
+			}
+			return err
+		}
+		if config.MaxParts > 0 && count >= config.MaxParts {
+			raw.Close()
+			return ErrorTooManyParts
+		}
+
+		part := newPart(raw, config)
+		err = handle(part)
+		raw.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newPart(raw *multipart.Part, config MultipartOptions) *Part {
+	reader := bufio.NewReaderSize(raw, 512)
+	sniff, _ := reader.Peek(512)
+	return &Part{
+		FormName:    raw.FormName(),
+		FileName:    raw.FileName(),
+		Header:      raw.Header,
+		ContentType: raw.Header.Get("Content-Type"),
+		SniffedType: http.DetectContentType(sniff),
+		reader:      reader,
+		remaining:   config.MaxPartSize,
+		limited:     config.MaxPartSize > 0,
+	}
+}
@@ -0,0 +1,65 @@
+package there
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestMultipartStream(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("title", "holiday")
+	file, _ := writer.CreateFormFile("photo", "photo.png")
+	_, _ = file.Write([]byte("\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)))
+	_ = writer.Close()
+
+	router := NewRouter()
+	router.Post("/upload", func(request Request) Response {
+		var result []string
+		limit, _ := request.Params.Get("limit")
+		options := MultipartOptions{}
+		if limit != "" {
+			options.MaxPartSize = 50
+		}
+		err := request.MultipartStream(func(part *Part) error {
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			result = append(result, part.FormName+":"+part.FileName+":"+part.SniffedType+":"+strconv.Itoa(len(data)))
+			return nil
+		}, options)
+		if errors.Is(err, ErrorPartTooLarge) {
+			return Error(status.RequestEntityTooLarge, err)
+		}
+		if err != nil {
+			return Error(status.BadRequest, err)
+		}
+		return String(status.OK, strings.Join(result, ","))
+	})
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodPost, target, bytes.NewReader(body.Bytes()))
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/upload")
+	expected := "title::text/plain; charset=utf-8:7,photo:photo.png:image/png:108"
+	if recorder.Code != status.OK || recorder.Body.String() != expected {
+		t.Errorf("expected %v, got %v %v", expected, recorder.Code, recorder.Body.String())
+	}
+	if recorder = serve("/upload?limit=1"); recorder.Code != status.RequestEntityTooLarge {
+		t.Errorf("expected %v, got %v %v", status.RequestEntityTooLarge, recorder.Code, recorder.Body.String())
+	}
+}
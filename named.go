@@ -0,0 +1,101 @@
+package there
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Params are the route parameters to generate the URL of a named route with.
+// Params, that are not part of the pattern, are added as query.
+type Params map[string]string
+
+// Name names the route, so its URL can be generated with Router.URL instead of
+// hardcoding the path in redirects and templates. Names must be unique.
+//
+//	router.Get("/user/{id}", GetUser).Name("user.show")
+func (group *RouteRouteGroupBuilder) Name(name string) *RouteRouteGroupBuilder {
+	router := group.RouteGroup.Router
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	pattern, ok := router.named[name]
+	group.assert(!ok || pattern == group.muxHandler.pattern, "route name \""+name+"\" is already used by "+pattern)
+	if router.named == nil {
+		router.named = map[string]string{}
+	}
+	router.named[name] = group.muxHandler.pattern
+	return group
+}
+
+// URL returns the path of the named route with its route parameters filled in.
+// The path does not contain the external prefix, use Request.URL for links.
+//
+//	path, err := router.URL("user.show", there.Params{"id": "42", "tab": "posts"})
+//	// "/user/42?tab=posts"
+func (router *Router) URL(name string, params Params) (string, error) {
+	router.mutex.Lock()
+	pattern, ok := router.named[name]
+	router.mutex.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %v", ErrorUnknownRoute, name)
+	}
+	p, err := fillPattern(patternPath(pattern), params)
+	if err != nil {
+		return "", fmt.Errorf("route %v: %w", name, err)
+	}
+
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	for _, parameter := range routeParameters(pattern) {
+		query.Del(parameter)
+	}
+	if len(query) > 0 {
+		p += "?" + query.Encode()
+	}
+	return p, nil
+}
+
+// URL returns the URL of the named route as the client has to request it, like
+// for links in a response body. See Router.URL and Request.ExternalPath.
+// Redirect adds the external prefix by itself, so pass it the Router.URL.
+//
+//	link, err := request.URL("user.show", there.Params{"id": user.Id})
+//	if err != nil {
+//		return there.Error(status.InternalServerError, err)
+//	}
+//	return there.Json(status.OK, map[string]string{"self": link})
+func (r *Request) URL(name string, params Params) (string, error) {
+	return namedURL(r.Request, name, params)
+}
+
+// namedURL generates the URL of the named route with the external prefix
+func namedURL(request *http.Request, name string, params Params) (string, error) {
+	router := routerOf(request)
+	if router == nil {
+		return "", fmt.Errorf("%w: %v", ErrorUnknownRoute, name)
+	}
+	p, err := router.URL(name, params)
+	if err != nil {
+		return "", err
+	}
+	return externalPrefix(request) + p, nil
+}
+
+// urlTemplateFunc is the "url" function of Html templates, which takes the name
+// of the route and the params as key value pairs.
+//
+//	<a href="{{ url "user.show" "id" .Id }}">Profile</a>
+func urlTemplateFunc(request *http.Request) func(name string, pairs ...string) (string, error) {
+	return func(name string, pairs ...string) (string, error) {
+		if len(pairs)%2 != 0 {
+			return "", fmt.Errorf("url %v: params need to be key value pairs", name)
+		}
+		params := Params{}
+		for i := 0; i < len(pairs); i += 2 {
+			params[pairs[i]] = pairs[i+1]
+		}
+		return namedURL(request, name, params)
+	}
+}
@@ -0,0 +1,66 @@
+package there
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestNamedRoutes(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.BasePath = "/app"
+	router.Get("/user/{id}", func(request Request) Response {
+		return String(status.OK, request.RouteParams.Get("id"))
+	}).Name("user.show")
+	router.Group("/files").Get("/{path...}", nil).Name("files")
+	router.Get("/link/{id}", func(request Request) Response {
+		link, err := request.URL("user.show", Params{"id": request.RouteParams.Get("id")})
+		if err != nil {
+			return Error(status.InternalServerError, err)
+		}
+		return String(status.OK, link)
+	})
+
+	for name, test := range map[string]struct {
+		params   Params
+		expected string
+	}{
+		"user.show": {Params{"id": "4 2", "tab": "posts", "page": "2"}, "/user/4%202?page=2&tab=posts"},
+		"files":     {Params{"path": "docs/read me.md"}, "/files/docs/read%20me.md"},
+	} {
+		p, err := router.URL(name, test.params)
+		if err != nil || p != test.expected {
+			t.Errorf("%v: expected %v, got %v %v", name, test.expected, p, err)
+		}
+	}
+	if _, err := router.URL("user.edit", nil); !errors.Is(err, ErrorUnknownRoute) {
+		t.Errorf("expected %v, got %v", ErrorUnknownRoute, err)
+	}
+	if _, err := router.URL("user.show", nil); err == nil {
+		t.Errorf("expected an error for the missing parameter")
+	}
+
+	assertBodyResponse(t, router, MethodGet, "/app/link/7", "/app/user/7")
+
+	router.Get("/profile/{id}", nil).Name("user.show")
+	if router.HasError() == nil {
+		t.Errorf("expected duplicate names to be rejected")
+	}
+}
+
+func TestNamedRouteTemplate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(file, []byte(`<a href="{{ url "user.show" "id" .Id }}">`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter()
+	router.Get("/user/{id}", nil).Name("user.show")
+	router.Get("/", func(request Request) Response {
+		return Html(status.OK, file, map[string]string{"Id": "42"})
+	})
+
+	assertBodyResponse(t, router, MethodGet, "/", `<a href="/user/42">`)
+}
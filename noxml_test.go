@@ -0,0 +1,54 @@
+//go:build there_noxml
+
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestNoXml(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	router := NewRouter()
+	router.Configuration.ErrorFormats = ErrorFormats{ContentTypes: []string{ContentTypeApplicationXml}}
+	router.Get("/auto", func(request Request) Response {
+		return Auto(status.OK, user{Name: "John"})
+	})
+	router.Get("/error", func(request Request) Response {
+		return Error(status.NotFound, errors.New("user not found"))
+	})
+	var bindErr error
+	router.Post("/bind", func(request Request) Response {
+		var dest user
+		bindErr = request.Body.Bind(&dest)
+		return Status(status.OK)
+	})
+
+	// without xml, the responses fall back to json
+	for route, expected := range map[string]string{
+		"/auto":  `{"name":"John"}`,
+		"/error": `{"error":"user not found"}`,
+	} {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		request.Header.Set(header.RequestAccept, "application/xml")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if !strings.HasPrefix(recorder.Header().Get(header.ContentType), ContentTypeApplicationJson) || strings.TrimSpace(recorder.Body.String()) != expected {
+			t.Errorf("%v: unexpected response %v %v", route, recorder.Header().Get(header.ContentType), recorder.Body.String())
+		}
+	}
+
+	request := httptest.NewRequest(MethodPost, "/bind", strings.NewReader("<user><name>John</name></user>"))
+	request.Header.Set(header.ContentType, ContentTypeApplicationXml)
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	if !errors.Is(bindErr, ErrorUnsupportedContentType) {
+		t.Errorf("expected xml bodies to be unsupported, got %v", bindErr)
+	}
+}
@@ -0,0 +1,361 @@
+package there
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of OpenAPIDocument
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPIInfo describes the API. The Title defaults to "API" and the Version
+// to "1.0.0".
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// OpenAPIDocument is an OpenAPI 3 document describing the routes of a Router
+type OpenAPIDocument struct {
+	OpenAPI string                     `json:"openapi" yaml:"openapi"`
+	Info    OpenAPIInfo                `json:"info" yaml:"info"`
+	Paths   map[string]OpenAPIPathItem `json:"paths" yaml:"paths"`
+}
+
+// OpenAPIPathItem maps the lower case methods of a path to their operation
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a method of a path
+type OpenAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+// OpenAPIParameter describes a route parameter
+type OpenAPIParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"`
+	Required bool           `json:"required" yaml:"required"`
+	Schema   map[string]any `json:"schema" yaml:"schema"`
+}
+
+// OpenAPIRequestBody describes the body documented with Request
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required" yaml:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+// OpenAPIResponse describes a response documented with Response
+type OpenAPIResponse struct {
+	Description string                      `json:"description" yaml:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a body
+type OpenAPIMediaType struct {
+	Schema   map[string]any            `json:"schema" yaml:"schema"`
+	Examples map[string]OpenAPIExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+// OpenAPIExample is an example declared with Example
+type OpenAPIExample struct {
+	Value any `json:"value" yaml:"value"`
+}
+
+// OpenAPI describes the routes of the router with everything documented with
+// RouteDoc as OpenAPI document. Request and response bodies are described
+// by the schema of their sample values, route parameters as strings, and
+// routes marked with Deprecated are deprecated.
+//
+//	router.Configuration.OpenAPIInfo = there.OpenAPIInfo{Title: "Shop", Version: "2.1.0"}
+//	data, err := router.OpenAPI().Yaml()
+func (router *Router) OpenAPI() OpenAPIDocument {
+	info := router.Configuration.OpenAPIInfo
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	document := OpenAPIDocument{OpenAPI: OpenAPIVersion, Info: info, Paths: map[string]OpenAPIPathItem{}}
+
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	for pattern, handler := range router.handlerKeeper {
+		item := OpenAPIPathItem{}
+		for m, endpoint := range handler.methods {
+			if endpoint.undocumented {
+				continue
+			}
+			item[strings.ToLower(methodToString(m))] = router.openAPIOperation(pattern, m, endpoint)
+		}
+		if len(item) > 0 {
+			document.Paths[openAPIPath(pattern)] = item
+		}
+	}
+	return document
+}
+
+func (router *Router) openAPIOperation(pattern string, m method, endpoint *muxHandlerEndpoint) *OpenAPIOperation {
+	operation := &OpenAPIOperation{Responses: map[string]OpenAPIResponse{}}
+	for _, parameter := range routeParameters(pattern) {
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name:     parameter,
+			In:       "path",
+			Required: true,
+			Schema:   map[string]any{"type": "string"},
+		})
+	}
+	if endpoint.sparseFields != nil {
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name:   FieldsParameter,
+			In:     "query",
+			Schema: map[string]any{"type": "string"},
+		})
+	}
+	for _, deprecation := range router.deprecations {
+		if deprecation.pattern == pattern && slices.Contains(deprecation.methods, methodToString(m)) {
+			operation.Deprecated = true
+		}
+	}
+	if doc := endpoint.doc; doc != nil {
+		operation.Summary = doc.Summary
+		operation.Description = doc.Description
+		operation.Tags = doc.Tags
+		if doc.Request != nil {
+			operation.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{ContentTypeApplicationJson: {Schema: schemaOf(doc.Request)}},
+			}
+		}
+		for code, body := range doc.Responses {
+			response := OpenAPIResponse{Description: http.StatusText(code)}
+			if body != nil {
+				response.Content = map[string]OpenAPIMediaType{ContentTypeApplicationJson: {Schema: schemaOf(body)}}
+			}
+			operation.Responses[strconv.Itoa(code)] = response
+		}
+		for _, example := range doc.Examples {
+			if example.Body == nil {
+				continue
+			}
+			code := strconv.Itoa(example.Code)
+			response, ok := operation.Responses[code]
+			if !ok {
+				response = OpenAPIResponse{Description: http.StatusText(example.Code)}
+			}
+			if response.Content == nil {
+				response.Content = map[string]OpenAPIMediaType{ContentTypeApplicationJson: {Schema: schemaOf(example.Body)}}
+			}
+			media := response.Content[ContentTypeApplicationJson]
+			if media.Examples == nil {
+				media.Examples = map[string]OpenAPIExample{}
+			}
+			media.Examples[example.Name] = OpenAPIExample{Value: example.Body}
+			response.Content[ContentTypeApplicationJson] = media
+			operation.Responses[code] = response
+		}
+	}
+	if len(operation.Responses) == 0 {
+		operation.Responses["default"] = OpenAPIResponse{Description: "Undocumented response"}
+	}
+	return operation
+}
+
+// openAPIPath turns a ServeMux pattern into an OpenAPI path, like
+// "/files/{path...}" into "/files/{path}"
+func openAPIPath(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:] // without host
+	}
+	pattern = strings.ReplaceAll(pattern, "...}", "}")
+	return strings.TrimSuffix(pattern, "{$}")
+}
+
+// Json encodes the document as json
+func (d OpenAPIDocument) Json() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Yaml encodes the document as yaml
+func (d OpenAPIDocument) Yaml() ([]byte, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	writeYaml(&b, value, 0)
+	return b.Bytes(), nil
+}
+
+var (
+	// plainYamlKey matches the keys, that can be written without quotes
+	plainYamlKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+	// yamlKeywords are plain scalars, that yaml parsers do not read as string
+	yamlKeywords = []string{"true", "false", "null", "yes", "no", "on", "off", "y", "n"}
+)
+
+// writeYaml writes a value decoded from json as block yaml. Strings are
+// written as json strings, which are valid double quoted yaml scalars.
+func writeYaml(b *bytes.Buffer, value any, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b.WriteString(prefix)
+			if plainYamlKey.MatchString(key) && !slices.Contains(yamlKeywords, strings.ToLower(key)) {
+				b.WriteString(key)
+			} else {
+				b.WriteString(yamlScalar(key))
+			}
+			b.WriteByte(':')
+			writeYamlValue(b, v[key], indent)
+		}
+	case []any:
+		for _, element := range v {
+			b.WriteString(prefix)
+			b.WriteByte('-')
+			writeYamlValue(b, element, indent)
+		}
+	}
+}
+
+// writeYamlValue writes the value of a key or sequence entry, nested
+// collections on the following lines
+func writeYamlValue(b *bytes.Buffer, value any, indent int) {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteByte('\n')
+		writeYaml(b, v, indent+2)
+	case []any:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteByte('\n')
+		writeYaml(b, v, indent+2)
+	default:
+		b.WriteByte(' ')
+		b.WriteString(yamlScalar(v))
+		b.WriteByte('\n')
+	}
+}
+
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// OpenAPIDocs serves a Swagger UI on the prefix, and the OpenAPI document of the
+// router as "openapi.json" and "openapi.yaml" below it. The document is
+// generated on every request, so it includes routes registered later. The
+// routes are not part of the document themselves.
+//
+//	router.OpenAPIDocs("/docs").With(middlewares.BasicAuth(...))
+//
+// The Swagger UI is loaded from the unpkg CDN.
+func (group *RouteGroup) OpenAPIDocs(prefix string) *RouteRouteGroupBuilder {
+	ui := group.Get(prefix, func(request Request) Response {
+		return openAPIUi{title: group.Router.OpenAPI().Info.Title, path: path.Join(request.Pattern(), "openapi.json")}
+	})
+	document := group.Get(path.Join(prefix, "openapi.json"), func(request Request) Response {
+		data, err := group.Router.OpenAPI().Json()
+		if err != nil {
+			return Error(status.InternalServerError, err)
+		}
+		return openAPIFile{contentType: ContentTypeApplicationJson, data: data}
+	})
+	yaml := group.Get(path.Join(prefix, "openapi.yaml"), func(request Request) Response {
+		data, err := group.Router.OpenAPI().Yaml()
+		if err != nil {
+			return Error(status.InternalServerError, err)
+		}
+		return openAPIFile{contentType: "application/yaml", data: data}
+	})
+	ui.aliases = append(ui.aliases, document.Route, yaml.Route)
+	for _, endpoint := range ui.endpoints() {
+		endpoint.undocumented = true
+	}
+	return ui
+}
+
+type openAPIFile struct {
+	contentType string
+	data        []byte
+}
+
+func (f openAPIFile) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set(header.ContentType, f.contentType)
+	if _, err := rw.Write(f.data); err != nil {
+		log.Printf("openAPIFile: ServeHttp write failed: %v", err)
+	}
+}
+
+type openAPIUi struct {
+	title string
+	// path of the json document
+	path string
+}
+
+func (u openAPIUi) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	url, _ := json.Marshal(externalPrefix(r) + u.path)
+	rw.Header().Set(header.ContentType, ContentTypeTextHtml+"; charset=utf-8")
+	_, err := fmt.Fprintf(rw, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%v</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: %s, dom_id: "#swagger-ui"})</script>
+</body>
+</html>
+`, html.EscapeString(u.title), url)
+	if err != nil {
+		log.Printf("openAPIUi: ServeHttp write failed: %v", err)
+	}
+}
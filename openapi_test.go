@@ -0,0 +1,108 @@
+package there
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestOpenAPI(t *testing.T) {
+	type user struct {
+		Id   string `json:"id"`
+		Name string `json:"name,omitempty"`
+	}
+	router := NewRouter()
+	router.Configuration.OpenAPIInfo = OpenAPIInfo{Title: "Users"}
+	router.Post("/users", nil).
+		Doc("Create a user", "Creates a new user").
+		Tags("users").
+		Request(user{}).
+		Response(status.Created, user{}).
+		Response(status.BadRequest, nil)
+	router.Get("/users/{id}", nil).Tags("users").Deprecated(time.Time{}, "")
+	router.Get("/files/{path...}", nil)
+
+	document := router.OpenAPI()
+	if document.OpenAPI != OpenAPIVersion || document.Info.Title != "Users" || document.Info.Version != "1.0.0" {
+		t.Errorf("unexpected document %+v", document)
+	}
+
+	create := document.Paths["/users"]["post"]
+	if create == nil {
+		t.Fatalf("expected the post operation, got %+v", document.Paths)
+	}
+	if create.Summary != "Create a user" || create.Description != "Creates a new user" ||
+		len(create.Tags) != 1 || create.Tags[0] != "users" || create.Deprecated {
+		t.Errorf("unexpected operation %+v", create)
+	}
+	if create.RequestBody == nil || create.RequestBody.Content[ContentTypeApplicationJson].Schema["type"] != "object" {
+		t.Errorf("unexpected request body %+v", create.RequestBody)
+	}
+	if created := create.Responses["201"]; created.Description != "Created" || created.Content == nil {
+		t.Errorf("unexpected created response %+v", created)
+	}
+	if bad := create.Responses["400"]; bad.Description != "Bad Request" || bad.Content != nil {
+		t.Errorf("unexpected bad request response %+v", bad)
+	}
+
+	get := document.Paths["/users/{id}"]["get"]
+	if get == nil || !get.Deprecated || len(get.Parameters) != 1 || get.Parameters[0].Name != "id" ||
+		get.Parameters[0].In != "path" || !get.Parameters[0].Required || get.Responses["default"].Description == "" {
+		t.Errorf("unexpected operation %+v", get)
+	}
+	if files := document.Paths["/files/{path}"]["get"]; files == nil || files.Parameters[0].Name != "path" {
+		t.Errorf("expected the wildcard as parameter, got %+v", document.Paths)
+	}
+
+	data, err := document.Yaml()
+	if err != nil {
+		t.Fatal(err)
+	}
+	yaml := string(data)
+	for _, line := range []string{
+		"openapi: \"3.0.3\"\n",
+		"  title: \"Users\"\n",
+		"  \"/users/{id}\":\n",
+		"        \"201\":\n",
+		"        - \"users\"\n",
+		"        -\n          in: \"path\"\n",
+	} {
+		if !strings.Contains(yaml, line) {
+			t.Errorf("expected the yaml to contain %q, got\n%v", line, yaml)
+		}
+	}
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	router := NewRouter()
+	router.Get("/users", nil).Doc("List users", "")
+	router.Group("/api").OpenAPIDocs("/docs")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/api/docs", nil))
+	if recorder.Code != status.OK || !strings.Contains(recorder.Body.String(), `url: "/api/docs/openapi.json"`) {
+		t.Errorf("unexpected swagger ui %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/api/docs/openapi.json", nil))
+	var document OpenAPIDocument
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Paths) != 1 || document.Paths["/users"]["get"].Summary != "List users" {
+		t.Errorf("expected only the documented route, got %+v", document.Paths)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/api/docs/openapi.yaml", nil))
+	if recorder.Header().Get(header.ContentType) != "application/yaml" ||
+		!strings.Contains(recorder.Body.String(), "summary: \"List users\"") {
+		t.Errorf("unexpected yaml document %v", recorder.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package there
+
+import (
+	"strconv"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// Pagination is the page a client requested with the "page" and "limit" query
+// parameters. Pages start at one.
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Offset returns the amount of items before the page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Pagination reads the requested page from the query. Invalid or missing
+// values fall back to the first page and the defaultLimit, and the limit is
+// capped to the maxLimit.
+//
+//	pagination := request.Pagination(20, 100)
+//	users, total := db.ListUsers(pagination.Offset(), pagination.Limit)
+//	return there.Paginated(status.OK, users, pagination, total)
+func (r *Request) Pagination(defaultLimit, maxLimit int) Pagination {
+	page := r.Params.GetIntDefault("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := r.Params.GetIntDefault("limit", defaultLimit)
+	if limit < 1 {
+		limit = defaultLimit
+	}
+	if maxLimit > 0 && limit > maxLimit {
+		limit = maxLimit
+	}
+	return Pagination{Page: page, Limit: limit}
+}
+
+// PaginationMeta is the pagination in the meta of an Envelope
+type PaginationMeta struct {
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Total int `json:"total"`
+	Pages int `json:"pages"`
+}
+
+// Paginated responds with a page of items like Json. The total amount of items
+// is sent in the X-Total-Count header and, if envelopes are enabled, as
+// PaginationMeta in the meta of the Envelope.
+func Paginated(code int, items any, pagination Pagination, total int) Response {
+	pages := 0
+	if pagination.Limit > 0 {
+		pages = (total + pagination.Limit - 1) / pagination.Limit
+	}
+	meta := PaginationMeta{Page: pagination.Page, Limit: pagination.Limit, Total: total, Pages: pages}
+	return Headers(map[string]string{
+		header.ResponseXTotalCount: strconv.Itoa(total),
+	}, jsonDataResponse{code: code, data: items, meta: map[string]any{"pagination": meta}})
+}
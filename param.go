@@ -0,0 +1,114 @@
+package there
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParamUnmarshaler is implemented by types, that can be parsed from a single
+// query or route parameter, like enums
+//
+//	type OrderStatus string
+//
+//	func (s *OrderStatus) UnmarshalParam(value string) error {
+//		parsed, err := there.ParseEnum(value, OrderStatus("open"), OrderStatus("closed"))
+//		*s = parsed
+//		return err
+//	}
+type ParamUnmarshaler interface {
+	UnmarshalParam(value string) error
+}
+
+// ParamError is returned, if a parameter could not be unmarshalled. Like the
+// BindingError, it is safe to be shown to clients and can be rendered as it is:
+//
+//	var s OrderStatus
+//	if err := request.Params.Unmarshal("status", &s); err != nil {
+//		return there.Json(status.BadRequest, err)
+//	}
+//
+// Which results in
+//
+//	{"message":"invalid value \"x\" for parameter \"status\", valid values are open, closed","param":"status","value":"x","valid":["open","closed"]}
+type ParamError struct {
+	Message string   `json:"message" xml:"Message"`
+	Param   string   `json:"param" xml:"Param"`
+	Value   string   `json:"value" xml:"Value"`
+	Valid   []string `json:"valid,omitempty" xml:"Valid,omitempty"`
+
+	err error
+}
+
+func (e *ParamError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error of the ParamUnmarshaler
+func (e *ParamError) Unwrap() error {
+	return e.err
+}
+
+// EnumError is returned by ParseEnum and lists the valid values
+type EnumError struct {
+	Value string
+	Valid []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %q, valid values are %v", e.Value, strings.Join(e.Valid, ", "))
+}
+
+// ParseEnum returns the value as T, if it is one of the valid values. Otherwise,
+// an *EnumError is returned.
+func ParseEnum[T ~string](value string, valid ...T) (T, error) {
+	for _, v := range valid {
+		if string(v) == value {
+			return v, nil
+		}
+	}
+	names := make([]string, len(valid))
+	for i, v := range valid {
+		names[i] = string(v)
+	}
+	return "", &EnumError{Value: value, Valid: names}
+}
+
+func unmarshalParam(key, value string, dest ParamUnmarshaler) error {
+	err := dest.UnmarshalParam(value)
+	if err == nil {
+		return nil
+	}
+	paramError := &ParamError{
+		Message: fmt.Sprintf("invalid value %q for parameter %q", value, key),
+		Param:   key,
+		Value:   value,
+		err:     err,
+	}
+	if enumError, ok := err.(*EnumError); ok {
+		paramError.Valid = enumError.Valid
+		paramError.Message += ", valid values are " + strings.Join(enumError.Valid, ", ")
+	}
+	return paramError
+}
+
+// Unmarshal parses the first value of the key into dest. If the key is not
+// present, then ErrorParameterNotPresent is returned, and if the value is
+// invalid, a *ParamError.
+func (reader MapReader) Unmarshal(key string, dest ParamUnmarshaler) error {
+	value, ok := reader.Get(key)
+	if !ok {
+		return ErrorParameterNotPresent
+	}
+	return unmarshalParam(key, value, dest)
+}
+
+// Unmarshal parses the route parameter into dest. If the parameter is empty,
+// then ErrorParameterNotPresent is returned, and if the value is invalid, a
+// *ParamError.
+func (reader RouteParamReader) Unmarshal(key string, dest ParamUnmarshaler) error {
+	value := reader.Get(key)
+	if value == "" {
+		return ErrorParameterNotPresent
+	}
+	return unmarshalParam(key, value, dest)
+}
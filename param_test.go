@@ -0,0 +1,52 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+type orderStatus string
+
+func (s *orderStatus) UnmarshalParam(value string) error {
+	parsed, err := ParseEnum(value, orderStatus("open"), orderStatus("closed"))
+	*s = parsed
+	return err
+}
+
+func TestParamUnmarshaler(t *testing.T) {
+	router := NewRouter()
+	router.Get("/orders/{status}", func(request Request) Response {
+		var route, query orderStatus
+		if err := request.RouteParams.Unmarshal("status", &route); err != nil {
+			return Json(status.BadRequest, err)
+		}
+		err := request.Params.Unmarshal("filter", &query)
+		if errors.Is(err, ErrorParameterNotPresent) {
+			query = "none"
+		} else if err != nil {
+			return Json(status.BadRequest, err)
+		}
+		return String(status.OK, string(route)+","+string(query))
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	if recorder := serve("/orders/open?filter=closed"); recorder.Body.String() != "open,closed" {
+		t.Errorf("unexpected response %v", recorder.Body.String())
+	}
+	if recorder := serve("/orders/open"); recorder.Body.String() != "open,none" {
+		t.Errorf("unexpected response %v", recorder.Body.String())
+	}
+	recorder := serve("/orders/x")
+	expected := `{"message":"invalid value \"x\" for parameter \"status\", valid values are open, closed","param":"status","value":"x","valid":["open","closed"]}`
+	if recorder.Code != status.BadRequest || recorder.Body.String() != expected {
+		t.Errorf("expected %v, got %v %v", expected, recorder.Code, recorder.Body.String())
+	}
+}
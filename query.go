@@ -0,0 +1,56 @@
+package there
+
+import (
+	"net/url"
+	"strings"
+)
+
+// QueryParam is a single key value pair of the query string
+type QueryParam struct {
+	Key   string
+	Value string
+}
+
+// RawQuery returns the query string of the request, without the '?', exactly
+// as it was sent
+func (r *Request) RawQuery() string {
+	return r.Request.URL.RawQuery
+}
+
+// QueryParams returns the parameters of the query string in the order they
+// were sent, including duplicates. Unlike the Params, which are grouped by key,
+// it allows APIs to depend on the order of the parameters, like for sorting
+// criteria. Pairs are separated by '&', keys without '=' have an empty value.
+// If a pair is not escaped correctly, it is returned as it is and the first
+// such error is returned as well.
+//
+//	// ?sort=name&sort=-created&filter=a
+//	params, err := request.QueryParams()
+//	// [{sort name} {sort -created} {filter a}]
+func (r *Request) QueryParams() ([]QueryParam, error) {
+	raw := r.Request.URL.RawQuery
+	var params []QueryParam
+	var firstErr error
+	for raw != "" {
+		var pair string
+		pair, raw, _ = strings.Cut(raw, "&")
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		unescapedKey, err := url.QueryUnescape(key)
+		if err == nil {
+			key = unescapedKey
+		} else if firstErr == nil {
+			firstErr = err
+		}
+		unescapedValue, err := url.QueryUnescape(value)
+		if err == nil {
+			value = unescapedValue
+		} else if firstErr == nil {
+			firstErr = err
+		}
+		params = append(params, QueryParam{Key: key, Value: value})
+	}
+	return params, firstErr
+}
@@ -0,0 +1,28 @@
+package there
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestQueryParams(t *testing.T) {
+	request := NewHttpRequest(nil, httptest.NewRequest(MethodGet, "/?sort=name&sort=-created&flag&&filter=a%20b&bad=%zz", nil))
+	if raw := request.RawQuery(); raw != "sort=name&sort=-created&flag&&filter=a%20b&bad=%zz" {
+		t.Errorf("unexpected raw query %v", raw)
+	}
+	params, err := request.QueryParams()
+	if err == nil {
+		t.Error("expected an error for the invalid escape")
+	}
+	expected := []QueryParam{
+		{"sort", "name"},
+		{"sort", "-created"},
+		{"flag", ""},
+		{"filter", "a b"},
+		{"bad", "%zz"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("expected %v, got %v", expected, params)
+	}
+}
@@ -5,6 +5,7 @@ package there
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 func (reader MapReader) GetInt(key string) (int, error) {
@@ -60,3 +61,175 @@ func (reader MapReader) GetIntSliceDefault(key, delimiter string, defaultValue [
 	}
 	return ints
 }
+
+func (reader MapReader) GetInt64(key string) (int64, error) {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return 0, ErrorParameterNotPresent
+	}
+	return strconv.ParseInt(list[0], 10, 64)
+}
+
+func (reader MapReader) GetInt64Default(key string, defaultValue int64) int64 {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return defaultValue
+	}
+	v, err := strconv.ParseInt(list[0], 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader MapReader) GetBool(key string) (bool, error) {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return false, ErrorParameterNotPresent
+	}
+	return strconv.ParseBool(list[0])
+}
+
+func (reader MapReader) GetBoolDefault(key string, defaultValue bool) bool {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return defaultValue
+	}
+	v, err := strconv.ParseBool(list[0])
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader MapReader) GetFloat64(key string) (float64, error) {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return 0, ErrorParameterNotPresent
+	}
+	return strconv.ParseFloat(list[0], 64)
+}
+
+func (reader MapReader) GetFloat64Default(key string, defaultValue float64) float64 {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return defaultValue
+	}
+	v, err := strconv.ParseFloat(list[0], 64)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader MapReader) GetTime(key, layout string) (time.Time, error) {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return time.Time{}, ErrorParameterNotPresent
+	}
+	return time.Parse(layout, list[0])
+}
+
+func (reader MapReader) GetTimeDefault(key, layout string, defaultValue time.Time) time.Time {
+	list, ok := reader.GetSlice(key)
+	if !ok {
+		return defaultValue
+	}
+	v, err := time.Parse(layout, list[0])
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader RouteParamReader) GetInt(key string) (int, error) {
+	value := reader.Get(key)
+	if value == "" {
+		return 0, ErrorParameterNotPresent
+	}
+	return strconv.Atoi(value)
+}
+
+func (reader RouteParamReader) GetIntDefault(key string, defaultValue int) int {
+	value := reader.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader RouteParamReader) GetInt64(key string) (int64, error) {
+	value := reader.Get(key)
+	if value == "" {
+		return 0, ErrorParameterNotPresent
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (reader RouteParamReader) GetInt64Default(key string, defaultValue int64) int64 {
+	value := reader.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader RouteParamReader) GetBool(key string) (bool, error) {
+	value := reader.Get(key)
+	if value == "" {
+		return false, ErrorParameterNotPresent
+	}
+	return strconv.ParseBool(value)
+}
+
+func (reader RouteParamReader) GetBoolDefault(key string, defaultValue bool) bool {
+	value := reader.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader RouteParamReader) GetFloat64(key string) (float64, error) {
+	value := reader.Get(key)
+	if value == "" {
+		return 0, ErrorParameterNotPresent
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func (reader RouteParamReader) GetFloat64Default(key string, defaultValue float64) float64 {
+	value := reader.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
+func (reader RouteParamReader) GetTime(key, layout string) (time.Time, error) {
+	value := reader.Get(key)
+	if value == "" {
+		return time.Time{}, ErrorParameterNotPresent
+	}
+	return time.Parse(layout, value)
+}
+
+func (reader RouteParamReader) GetTimeDefault(key, layout string, defaultValue time.Time) time.Time {
+	value := reader.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+	v, err := time.Parse(layout, value)
+	if err != nil {
+		return defaultValue
+	}
+	return v
+}
@@ -0,0 +1,65 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestTypedReaders(t *testing.T) {
+	params := MapReader{
+		"limit":  {"9000000000"},
+		"active": {"true"},
+		"ratio":  {"0.25"},
+		"since":  {"2024-01-02"},
+		"broken": {"abc"},
+	}
+	if v, err := params.GetInt64("limit"); err != nil || v != 9000000000 {
+		t.Errorf("unexpected int64 %v %v", v, err)
+	}
+	if v, err := params.GetBool("active"); err != nil || !v {
+		t.Errorf("unexpected bool %v %v", v, err)
+	}
+	if v, err := params.GetFloat64("ratio"); err != nil || v != 0.25 {
+		t.Errorf("unexpected float64 %v %v", v, err)
+	}
+	if v, err := params.GetTime("since", time.DateOnly); err != nil || !v.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %v %v", v, err)
+	}
+	if _, err := params.GetBool("missing"); !errors.Is(err, ErrorParameterNotPresent) {
+		t.Errorf("expected ErrorParameterNotPresent, got %v", err)
+	}
+	if _, err := params.GetFloat64("broken"); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+	if v := params.GetInt64Default("broken", 7); v != 7 {
+		t.Errorf("expected the default, got %v", v)
+	}
+
+	router := NewRouter()
+	router.Get("/users/{id}/since/{date}", func(request Request) Response {
+		id, err := request.RouteParams.GetInt("id")
+		if err != nil {
+			return Error(status.BadRequest, err)
+		}
+		since := request.RouteParams.GetTimeDefault("date", time.DateOnly, time.Time{})
+		if _, err := request.RouteParams.GetBool("missing"); !errors.Is(err, ErrorParameterNotPresent) {
+			return Error(status.InternalServerError, err)
+		}
+		return Json(status.OK, map[string]any{"id": id, "year": since.Year()})
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/users/42/since/2023-05-06", nil))
+	if body := recorder.Body.String(); recorder.Code != status.OK || body != `{"id":42,"year":2023}` {
+		t.Errorf("unexpected response %v %v", recorder.Code, body)
+	}
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/users/abc/since/never", nil))
+	if recorder.Code != status.BadRequest {
+		t.Errorf("expected %v, got %v", status.BadRequest, recorder.Code)
+	}
+}
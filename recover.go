@@ -0,0 +1,50 @@
+package there
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// RecoverHandler renders the response for a panic in an Endpoint or
+// Middleware. The stack is the one of the panicking goroutine.
+type RecoverHandler func(request Request, recovered any, stack []byte) Response
+
+// DefaultRecoverHandler logs the panic with its stack and responds with
+// StatusInternalServerError, without revealing the panic to the client
+func DefaultRecoverHandler(request Request, recovered any, stack []byte) Response {
+	log.Printf("panic serving %v %v: %v\n%s", request.Request.Method, request.Request.URL.Path, recovered, stack)
+	return Error(status.InternalServerError, errors.New("internal server error"))
+}
+
+// recoveredPanicKey stores an *any in the context of a request, which receives
+// the recovered panic, like for the SelfTest
+type recoveredPanicKey struct{}
+
+// recoverPanic serves the RecoverHandler, if the request panicked. If the
+// response was already started, the panic is only reported to the handler.
+func (router *Router) recoverPanic(rw http.ResponseWriter, request *http.Request, bodyless *bodylessWriter) {
+	handler := router.Configuration.RecoverHandler
+	if handler == nil {
+		return
+	}
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		// aborting the response on purpose is no error
+		panic(recovered)
+	}
+	tracePanic(request, recovered)
+	if holder, ok := request.Context().Value(recoveredPanicKey{}).(*any); ok {
+		*holder = recovered
+	}
+	response := handler(NewHttpRequest(rw, request), recovered, debug.Stack())
+	if response != nil && bodyless.code == 0 {
+		response.ServeHTTP(rw, request)
+	}
+}
@@ -0,0 +1,62 @@
+package there
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRecoverHandler(t *testing.T) {
+	router := NewRouter()
+	router.Get("/panic", func(request Request) Response {
+		panic("secret connection string")
+	})
+	router.Get("/started", func(request Request) Response {
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(status.Accepted)
+			panic("too late")
+		})
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	recorder := serve("/panic")
+	if recorder.Code != status.InternalServerError || strings.Contains(recorder.Body.String(), "secret") {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	var stack []byte
+	router.Configuration.RecoverHandler = func(request Request, recovered any, s []byte) Response {
+		stack = s
+		return String(status.ServiceUnavailable, fmt.Sprint("recovered: ", recovered))
+	}
+	recorder = serve("/panic")
+	if recorder.Code != status.ServiceUnavailable || recorder.Body.String() != "recovered: secret connection string" {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if !strings.Contains(string(stack), "recover_test.go") {
+		t.Errorf("expected the stack of the panic, got %s", stack)
+	}
+
+	// the status of a started response can not be changed anymore
+	recorder = serve("/started")
+	if recorder.Code != status.Accepted || recorder.Body.Len() != 0 {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	router.Configuration.RecoverHandler = nil
+	defer func() {
+		if recovered := recover(); recovered != "secret connection string" {
+			t.Errorf("expected the panic to propagate, got %v", recovered)
+		}
+	}()
+	serve("/panic")
+}
@@ -2,17 +2,40 @@ package there
 
 import (
 	"context"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/gebes/there/v2/status"
 )
 
 var (
 	ErrorParameterNotPresent = errors.New("parameter not present")
+	// ErrorBodyTooLarge is returned by the BodyReader, if the body exceeds the
+	// MaxBodySize of the RouterConfiguration or the limit set with WithMaxBody.
+	// Respond with BodyError, which uses StatusRequestEntityTooLarge in this case:
+	//
+	//	if err := request.Body.BindJson(&user); err != nil {
+	//		return there.BodyError(status.BadRequest, err)
+	//	}
+	ErrorBodyTooLarge = errors.New("request body too large")
 )
 
+func bodyTooLargeError(limit int64) error {
+	return fmt.Errorf("%w: the limit is %d bytes", ErrorBodyTooLarge, limit)
+}
+
+// BodyError responds with the error of reading or binding the body. Bodies
+// exceeding the limit are answered with StatusRequestEntityTooLarge, every
+// other error with the code.
+func BodyError(code int, err error) Response {
+	if errors.Is(err, ErrorBodyTooLarge) {
+		return Error(status.RequestEntityTooLarge, err)
+	}
+	return Error(code, err)
+}
+
 type Request struct {
 	Request        *http.Request
 	ResponseWriter http.ResponseWriter
@@ -25,11 +48,14 @@ type Request struct {
 	RemoteAddress string
 	Host          string
 	URI           string
+
+	// pattern of the matched route
+	pattern string
 }
 
 func NewHttpRequest(responseWriter http.ResponseWriter, request *http.Request) Request {
 	paramReader := MapReader(request.URL.Query())
-	headerReader := MapReader(request.Header)
+	headerReader := newHeaderReader(request)
 	return Request{
 		Request:        request,
 		ResponseWriter: responseWriter,
@@ -39,10 +65,17 @@ func NewHttpRequest(responseWriter http.ResponseWriter, request *http.Request) R
 		Headers:        &headerReader,
 		RouteParams:    &RouteParamReader{request},
 		RemoteAddress:  request.RemoteAddr,
+		Host:           request.Host,
 		URI:            request.RequestURI,
 	}
 }
 
+// Pattern returns the pattern the route was registered with, including the
+// prefix of its group, like "/users/{id}". Empty, if no route matched.
+func (r *Request) Pattern() string {
+	return r.pattern
+}
+
 func (r *Request) Context() context.Context {
 	return r.Request.Context()
 }
@@ -51,17 +84,32 @@ func (r *Request) WithContext(ctx context.Context) {
 	*r.Request = *r.Request.WithContext(ctx)
 }
 
+// Done returns a channel, that is closed as soon as the client disconnects or
+// the deadline of the request is exceeded. Long-running endpoints should stop
+// their work then.
+func (r *Request) Done() <-chan struct{} {
+	return r.Request.Context().Done()
+}
+
+// Disconnected reports whether the client went away before the response was completed
+func (r *Request) Disconnected() bool {
+	return errors.Is(r.Request.Context().Err(), context.Canceled)
+}
+
 // BodyReader reads the body and unmarshal it to the specified destination
 type BodyReader struct {
 	request *http.Request
 }
 
+// BindJson unmarshalls the json body into dest. If the body is invalid, then a
+// *BindingError is returned. Times and durations are expected in the JsonTimeFormat
+// and JsonDurationFormat of the RouterConfiguration, unless a json Serializer is
+// registered there, which unmarshalls the body instead.
 func (read BodyReader) BindJson(dest any) error {
-	return read.bind(dest, json.Unmarshal)
-}
-
-func (read BodyReader) BindXml(dest any) error {
-	return read.bind(dest, xml.Unmarshal)
+	if serializer := serializerOf(read.request, ContentTypeApplicationJson); serializer != nil {
+		return read.bind(dest, serializer.Unmarshal)
+	}
+	return read.bind(dest, jsonOptionsOf(read.request).unmarshal)
 }
 
 func (read BodyReader) bind(dest any, formatter func(data []byte, v any) error) error {
@@ -70,7 +118,10 @@ func (read BodyReader) bind(dest any, formatter func(data []byte, v any) error)
 		return err
 	}
 	err = formatter(body, dest)
-	return err
+	if err != nil {
+		return newBindingError(body, err)
+	}
+	return nil
 }
 
 func (read BodyReader) ToString() (string, error) {
@@ -85,6 +136,10 @@ func (read BodyReader) ToBytes() ([]byte, error) {
 	data, err := io.ReadAll(read.request.Body)
 	defer read.request.Body.Close()
 	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return nil, bodyTooLargeError(maxBytesError.Limit)
+		}
 		return nil, err
 	}
 	return data, nil
@@ -0,0 +1,66 @@
+package there
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestHeaderRules transform the headers of every request, before it is
+// routed and any middleware or Endpoint sees it. The rules are applied in the
+// order Strip, Rename and Inject.
+//
+//	router.Configuration.RequestHeaders = there.RequestHeaderRules{
+//		Strip:  []string{"X-Internal-*", "X-Debug"},
+//		Rename: map[string]string{"X-Client-Ip": "X-Real-Ip"},
+//		Inject: map[string]string{"X-Environment": "production"},
+//	}
+type RequestHeaderRules struct {
+	// Strip removes the headers. A name ending with "*" removes all headers
+	// with that prefix, like "X-Internal-*".
+	Strip []string
+	// Trusted skips the Strip rules for requests from trusted sources, like
+	// other internal services. Strip applies to all requests, if nil.
+	Trusted func(request *http.Request) bool
+	// Rename moves the values of a header to another name, replacing the
+	// values of the new name
+	Rename map[string]string
+	// Inject sets the headers, replacing values sent by the client
+	Inject map[string]string
+}
+
+func (rules RequestHeaderRules) empty() bool {
+	return len(rules.Strip) == 0 && len(rules.Rename) == 0 && len(rules.Inject) == 0
+}
+
+// apply transforms the headers of the request in place
+func (rules RequestHeaderRules) apply(request *http.Request) {
+	headers := request.Header
+	if rules.Trusted == nil || !rules.Trusted(request) {
+		for _, name := range rules.Strip {
+			if prefix, ok := strings.CutSuffix(name, "*"); ok {
+				prefix = http.CanonicalHeaderKey(prefix)
+				for key := range headers {
+					if strings.HasPrefix(key, prefix) {
+						delete(headers, key)
+					}
+				}
+				continue
+			}
+			headers.Del(name)
+		}
+	}
+	for from, to := range rules.Rename {
+		values := headers.Values(from)
+		if len(values) == 0 {
+			continue
+		}
+		headers.Del(from)
+		headers.Del(to)
+		for _, value := range values {
+			headers.Add(to, value)
+		}
+	}
+	for name, value := range rules.Inject {
+		headers.Set(name, value)
+	}
+}
@@ -0,0 +1,49 @@
+package there
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRequestHeaderRules(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.RequestHeaders = RequestHeaderRules{
+		Strip:   []string{"X-Internal-*", "X-Debug"},
+		Trusted: func(request *http.Request) bool { return request.RemoteAddr == "10.0.0.1:1234" },
+		Rename:  map[string]string{"X-Client-Ip": "X-Real-Ip"},
+		Inject:  map[string]string{"X-Environment": "production"},
+	}
+	router.Get("/", func(request Request) Response {
+		var names []string
+		for _, name := range []string{"X-Internal-User", "X-Debug", "X-Client-Ip", "X-Real-Ip", "X-Environment"} {
+			names = append(names, name+"="+request.Request.Header.Get(name))
+		}
+		return String(status.OK, strings.Join(names, ","))
+	})
+
+	serve := func(remote string) string {
+		request := httptest.NewRequest(MethodGet, "/", nil)
+		request.RemoteAddr = remote
+		request.Header.Set("X-Internal-User", "admin")
+		request.Header.Set("X-Debug", "1")
+		request.Header.Set("X-Client-Ip", "1.2.3.4")
+		request.Header.Set("X-Real-Ip", "spoofed")
+		request.Header.Set("X-Environment", "spoofed")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	expected := "X-Internal-User=,X-Debug=,X-Client-Ip=,X-Real-Ip=1.2.3.4,X-Environment=production"
+	if body := serve("203.0.113.1:1234"); body != expected {
+		t.Errorf("expected %v, got %v", expected, body)
+	}
+	expected = "X-Internal-User=admin,X-Debug=1,X-Client-Ip=,X-Real-Ip=1.2.3.4,X-Environment=production"
+	if body := serve("10.0.0.1:1234"); body != expected {
+		t.Errorf("expected %v, got %v", expected, body)
+	}
+}
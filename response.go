@@ -6,7 +6,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"github.com/gebes/there/v2/header"
@@ -17,6 +16,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -180,10 +180,10 @@ type stringResponse struct {
 }
 
 func (s stringResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	rw.WriteHeader(s.code)
 	if rw.Header().Get(header.ContentType) == "" {
 		rw.Header().Set(header.ContentType, ContentTypeTextPlain)
 	}
+	rw.WriteHeader(s.code)
 	_, err := rw.Write(s.data)
 	if err != nil {
 		log.Printf("stringResponse: ServeHttp write failed: %v", err)
@@ -208,6 +208,13 @@ func (s stringResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 //
 // For optimal performance the use of json.Marshal is avoided and the response
 // body is built directly. With this way, there is no error that could occur.
+// If a json Serializer is registered in the RouterConfiguration, it is used instead.
+// Configure ErrorFormats in the RouterConfiguration to negotiate other formats,
+// like html error pages for browsers.
+//
+// 5xx errors include the Request.RequestId, so clients can quote it to support,
+// like {"error":"something went wrong","requestId":"42"}. Enable
+// HideInternalErrors in the RouterConfiguration to send only the status text.
 func Error(code int, err error) Response {
 	e := err.Error()
 	var b bytes.Buffer
@@ -238,7 +245,45 @@ func Error(code int, err error) Response {
 		}
 	}
 	b.Write(errorJsonClose)
-	return &jsonResponse{code: code, data: b.Bytes()}
+	return errorResponse{jsonResponse: jsonResponse{code: code, data: b.Bytes()}, message: e, err: err}
+}
+
+// errorResponse is marshalled again when it is served, if a json Serializer
+// is registered
+type errorResponse struct {
+	jsonResponse
+	message string
+	err     error
+	// requestId is included in 5xx errors
+	requestId string
+}
+
+func (e errorResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	e = e.internal(r)
+	if negotiateError(rw, r, e) {
+		return
+	}
+	if enveloped(r.Context()) {
+		envelope := Envelope{Errors: []EnvelopeError{{Message: e.message}}}
+		if e.requestId != "" {
+			envelope.Meta = map[string]any{"requestId": e.requestId}
+		}
+		jsonDataResponse{code: e.code, data: envelope, raw: true}.ServeHTTP(rw, r)
+		return
+	}
+	if serializer := serializerOf(r, ContentTypeApplicationJson); serializer != nil {
+		body := map[string]string{"error": e.message}
+		if e.requestId != "" {
+			body["requestId"] = e.requestId
+		}
+		data, err := serializer.Marshal(body)
+		if err == nil {
+			jsonResponse{code: e.code, data: data}.ServeHTTP(rw, r)
+			return
+		}
+		log.Printf("errorResponse: serializer failed, falling back to the built-in encoding: %v", err)
+	}
+	e.jsonResponse.ServeHTTP(rw, r)
 }
 
 var (
@@ -248,13 +293,42 @@ var (
 
 const errorJsonLength = 14 // the total length of errorJsonOpen + errorJsonClose
 
-// Html takes a status code, the path to the html file and a map for the template parsing
+// Html takes a status code, the path to the html file and a map for the template parsing.
+// The template is rendered, when the response is served. Besides the data, it can
+// use the functions "flag", which reports whether a feature flag is enabled,
+// "flags", which returns all flags evaluated for the request so far, and "url",
+// which generates the URL of a named route, like {{ url "user.show" "id" .Id }}.
+//
+// If the request has a Locale, like from AcceptLanguage or a localized path, the
+// most specific template of the locale is rendered instead. For the file
+// "page.html" and the locale "de-AT", "page.de-AT.html", "page.de.html" and
+// "page.html" are tried in this order.
 func Html(code int, file string, template any) Response {
-	content, err := parseTemplate(file, template)
+	return htmlTemplateResponse{code: code, file: file, data: template}
+}
+
+type htmlTemplateResponse struct {
+	code int
+	file string
+	data any
+}
+
+func (h htmlTemplateResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	flags := flagsOf(r)
+	file, locale := localizedTemplate(h.file, localeOf(r))
+	content, err := parseTemplate(file, h.data, template.FuncMap{
+		"flag":  flags.Enabled,
+		"flags": flags.Evaluated,
+		"url":   urlTemplateFunc(r),
+	})
 	if err != nil {
-		return Error(status.InternalServerError, fmt.Errorf("html: parseTemplate: %v", err))
+		Error(status.InternalServerError, fmt.Errorf("html: parseTemplate: %v", err)).ServeHTTP(rw, r)
+		return
 	}
-	return htmlResponse{code: code, data: []byte(*content)}
+	if locale != "" {
+		rw.Header().Set(header.ResponseContentLanguage, locale)
+	}
+	htmlResponse{code: h.code, data: []byte(*content)}.ServeHTTP(rw, r)
 }
 
 type htmlResponse struct {
@@ -263,16 +337,16 @@ type htmlResponse struct {
 }
 
 func (h htmlResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	rw.WriteHeader(h.code)
 	rw.Header().Set(header.ContentType, ContentTypeTextHtml)
+	rw.WriteHeader(h.code)
 	_, err := rw.Write(h.data)
 	if err != nil {
 		log.Printf("htmlResponse: ServeHttp write failed: %v", err)
 	}
 }
 
-func parseTemplate(templateFileName string, data any) (*string, error) {
-	t, err := template.ParseFiles(templateFileName)
+func parseTemplate(templateFileName string, data any, funcs template.FuncMap) (*string, error) {
+	t, err := template.New(filepath.Base(templateFileName)).Funcs(funcs).ParseFiles(templateFileName)
 	if err != nil {
 		return nil, err
 	}
@@ -301,13 +375,13 @@ func parseTemplate(templateFileName string, data any) (*string, error) {
 //
 //	{"firstname":"John","surname":"Smith"}
 //
+// Times and durations are encoded as configured with the JsonTimeFormat and
+// JsonDurationFormat of the RouterConfiguration. If a json Serializer is
+// registered in the RouterConfiguration, it marshals the data instead.
+//
 // If the json.Marshal fails with an error, then an Error with StatusInternalServerError will be returned, with the error format "json: json.Marshal: %v"
 func Json(code int, data any) Response {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return Error(status.InternalServerError, fmt.Errorf("json: json.Marshal: %v", err))
-	}
-	return jsonResponse{code: code, data: jsonData}
+	return jsonDataResponse{code: code, data: data}
 }
 
 // JsonError marshalls the given data parameter with the json.Marshal function
@@ -337,7 +411,81 @@ func JsonError(code int, data any) (Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	return jsonResponse{code: code, data: jsonData}, nil
+	return jsonDataResponse{code: code, data: data, encoded: jsonData}, nil
+}
+
+// CanonicalJson works like Json, but emits canonical json. The keys of all objects,
+// including structs, are sorted by their names, floats are formatted as the
+// shortest representation and HTML characters are not escaped. Use it for
+// responses, that are signed or hashed by clients, as the same data always
+// results in the same bytes.
+//
+//	func ExampleCanonicalGet(request there.Request) there.Response {
+//		return there.CanonicalJson(status.OK, map[string]any{"b": 1, "a": 2.5})
+//	}
+//
+// When this handler gets called, the final rendered result will be
+//
+//	{"a":2.5,"b":1}
+func CanonicalJson(code int, data any) Response {
+	return jsonDataResponse{code: code, data: data, canonical: true}
+}
+
+// jsonDataResponse marshals its data when it is served, as the encoding depends
+// on the configuration of the Router
+type jsonDataResponse struct {
+	code      int
+	data      any
+	encoded   []byte
+	canonical bool
+	// meta is added to the Envelope, if envelopes are enabled
+	meta map[string]any
+	// raw data is never wrapped in an Envelope
+	raw bool
+}
+
+func (j jsonDataResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	// fields selected with SparseFields only apply to the data of successful responses
+	var fields fieldSet
+	if selected := selectedFieldsOf(r.Context()); selected != nil && !j.raw && j.code >= 200 && j.code < 300 {
+		fields = selected.set
+	}
+	if !j.raw && enveloped(r.Context()) {
+		j.data, j.encoded, j.raw = Envelope{Data: j.data, Meta: j.meta}, nil, true
+		if fields != nil {
+			fields = fieldSet{"data": fields, "meta": nil}
+		}
+	}
+	var data []byte
+	if serializer := serializerOf(r, ContentTypeApplicationJson); serializer != nil && !j.canonical {
+		var err error
+		data, err = serializer.Marshal(j.data)
+		if err != nil {
+			Error(status.InternalServerError, fmt.Errorf("json: serializer: %v", err)).ServeHTTP(rw, r)
+			return
+		}
+	} else {
+		options := jsonOptionsOf(r)
+		options.canonical = j.canonical
+		data = j.encoded
+		if data == nil || !options.isDefault() {
+			var err error
+			data, err = options.marshal(j.data)
+			if err != nil {
+				Error(status.InternalServerError, fmt.Errorf("json: json.Marshal: %v", err)).ServeHTTP(rw, r)
+				return
+			}
+		}
+	}
+	if fields != nil {
+		var err error
+		data, err = fields.filter(data)
+		if err != nil {
+			Error(status.InternalServerError, fmt.Errorf("json: fields: %v", err)).ServeHTTP(rw, r)
+			return
+		}
+	}
+	jsonResponse{code: j.code, data: data}.ServeHTTP(rw, r)
 }
 
 type jsonResponse struct {
@@ -346,10 +494,10 @@ type jsonResponse struct {
 }
 
 func (j jsonResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	rw.WriteHeader(j.code)
 	if rw.Header().Get(header.ContentType) == "" {
 		rw.Header().Set(header.ContentType, ContentTypeApplicationJson)
 	}
+	rw.WriteHeader(j.code)
 	_, err := rw.Write(j.data)
 	if err != nil {
 		log.Printf("jsonResponse: ServeHttp write failed: %v", err)
@@ -405,7 +553,8 @@ func Message(code int, message string) Response {
 	return jsonResponse{code: code, data: []byte(jsonOpen + b.String() + jsonClose)}
 }
 
-// Redirect redirects to the specific URL
+// Redirect redirects to the specific URL. If the URL is an absolute path, then
+// it is prefixed the same way as Request.ExternalPath does.
 func Redirect(code int, url string) Response {
 	return &redirectResponse{code: code, url: url}
 }
@@ -416,113 +565,123 @@ type redirectResponse struct {
 }
 
 func (j redirectResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	http.Redirect(rw, r, j.url, j.code)
+	url := j.url
+	if strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "//") {
+		url = externalPrefix(r) + url
+	}
+	http.Redirect(rw, r, url, j.code)
 }
 
-// Xml marshalls the given data parameter with the xml.Marshal function and
-// writes the result with the given status code to the http.ResponseWriter
-//
-// The Content-Type header is set accordingly to application/xml
-//
-//	type User struct {
-//		Firstname string `xml:"firstname"`
-//		Surname string  `xml:"surname"`
-//	}
-//
-//	func ExampleXmlGet(request there.Request) there.Response{
-//		user := User{"John", "Smith"}
-//		return there.Xml(status.OK, user)
-//	}
-//
-// When this handler gets called, the final rendered result will be
-//
-//	<User><firstname>John</firstname><surname>Smith</surname></User>
-//
-// If the xml.Marshal fails with an error, then an Error with StatusInternalServerError will be returned, with the error format "xml: xml.Marshal: %v"
-func Xml(code int, data any) Response {
-	xmlData, err := xml.Marshal(data)
-	if err != nil {
-		return Error(status.InternalServerError, fmt.Errorf("xml: xml.Marshal: %v", err))
-	}
-	return xmlResponse{code: code, data: xmlData}
+// AutoHandlers maps the content types Auto can respond with to their responses.
+// The xml handler is only registered, unless the there_noxml build tag is set.
+// Register further encoders, like for yaml, by adding them to the map at
+// startup. The "fallback" is served, if the client accepts none of them.
+var AutoHandlers = map[string]func(code int, data any) Response{
+	"fallback":                 Json,
+	ContentTypeApplicationJson: Json,
+	ContentTypeTextPlain:       plainText,
 }
 
-// XmlError marshalls the given data parameter with the xml.Marshal function and
-// writes the result with the given status code to the http.ResponseWriter
+// Auto renders the data in the format the client prefers, according to its
+// Accept header, with one of the AutoHandlers or the Serializers of the
+// RouterConfiguration. By default, json, xml and plain text are supported, and
+// json is served, if the client has no preference.
 //
-// The Content-Type header is set accordingly to application/xml
-//
-//	type User struct {
-//		Firstname string `xml:"firstname"`
-//		Surname   string `xml:"surname"`
+//	func GetUser(request there.Request) there.Response {
+//		return there.Auto(status.OK, user)
 //	}
-//
-//	func ExampleXmlErrorGet(request there.Request) there.Response {
-//		user := User{"John", "Smith"}
-//		resp, err := there.XmlError(status.OK, user)
-//		if err != nil {
-//			return there.Error(status.InternalServerError, fmt.Errorf("something went wrong: %v", err))
-//		}
-//		return resp
-//	}
-//
-// When this handler gets called, the final rendered result will be
-//
-//	<User><firstname>John</firstname><surname>Smith</surname></User>
-//
-// If the xml.Marshal fails with an error, then a nil response with a non-nil error will be returned to handle.
-func XmlError(code int, data any) (Response, error) {
-	xmlData, err := xml.Marshal(data)
-	if err != nil {
-		return nil, err
-	}
-	return xmlResponse{code: code, data: xmlData}, nil
+func Auto(code int, data any) Response {
+	return autoResponse{code, data}
 }
 
-type xmlResponse struct {
+type autoResponse struct {
 	code int
-	data []byte
+	data any
 }
 
-func (x xmlResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	if rw.Header().Get(header.ContentType) == "" {
-		rw.Header().Set(header.ContentType, ContentTypeApplicationXml)
+func (a autoResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	responses := make(map[string]Response, len(AutoHandlers))
+	for contentType, handler := range AutoHandlers {
+		if contentType != "fallback" {
+			responses[contentType] = handler(a.code, a.data)
+		}
 	}
-	rw.WriteHeader(x.code)
-	_, err := rw.Write(x.data)
-	if err != nil {
-		log.Printf("xmlResponse: ServeHttp write failed: %v", err)
+	if router := routerOf(r); router != nil {
+		for contentType := range router.Configuration.Serializers {
+			if _, ok := responses[contentType]; !ok {
+				responses[contentType] = Serialize(a.code, contentType, a.data)
+			}
+		}
+	}
+	var otherwise Response = Error(status.BadRequest, errors.New("no suitable content-type provided"))
+	if handler, ok := AutoHandlers["fallback"]; ok {
+		otherwise = handler(a.code, a.data)
 	}
+	negotiateResponse{responses: responses, otherwise: otherwise}.ServeHTTP(rw, r)
 }
 
-var AutoHandlers = map[string]func(code int, data any) Response{
-	"fallback":                 Json,
-	ContentTypeApplicationJson: Json,
-	ContentTypeApplicationXml:  Xml,
+// sortOffers orders the offers, so wildcards in the Accept header match json
+// first and the others in a stable order
+func sortOffers(offers []string) {
+	sort.Slice(offers, func(i, j int) bool {
+		if (offers[i] == ContentTypeApplicationJson) != (offers[j] == ContentTypeApplicationJson) {
+			return offers[i] == ContentTypeApplicationJson
+		}
+		return offers[i] < offers[j]
+	})
 }
 
-func Auto(code int, data any) Response {
-	return autoResponse{code, data}
+// plainText renders the data with fmt, errors with their message
+func plainText(code int, data any) Response {
+	switch data := data.(type) {
+	case string:
+		return String(code, data)
+	case error:
+		return String(code, data.Error())
+	default:
+		return String(code, fmt.Sprint(data))
+	}
 }
 
-type autoResponse struct {
-	code int
-	data any
+// Negotiate serves the response of the content type the client prefers,
+// according to its Accept header. If it accepts none of them, then the
+// response of the fallback content type is served. Auto negotiates between
+// the AutoHandlers and the registered Codecs the same way.
+//
+//	return there.Negotiate(there.ContentTypeTextHtml, map[string]there.Response{
+//		there.ContentTypeTextHtml:        there.Html(status.OK, "user.html", user),
+//		there.ContentTypeApplicationJson: there.Json(status.OK, user),
+//	})
+func Negotiate(fallback string, responses map[string]Response) Response {
+	return negotiateResponse{fallback: fallback, responses: responses}
 }
 
-func (a autoResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	contentTypes := make([]string, 0, len(AutoHandlers))
-	for s := range AutoHandlers {
+type negotiateResponse struct {
+	fallback  string
+	responses map[string]Response
+	// otherwise is served, if the client accepts none of the responses and
+	// there is none for the fallback
+	otherwise Response
+}
+
+func (n negotiateResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	contentTypes := make([]string, 0, len(n.responses))
+	for s := range n.responses {
 		contentTypes = append(contentTypes, s)
 	}
+	sortOffers(contentTypes)
 
-	contentType := NegotiateContentType(r.Header[header.RequestAccept], contentTypes, "fallback")
-	handler, ok := AutoHandlers[contentType]
+	rw.Header().Add(header.ResponseVary, header.RequestAccept)
+	contentType := NegotiateContentType(r.Header[header.RequestAccept], contentTypes, n.fallback)
+	response, ok := n.responses[contentType]
+	if !ok && n.otherwise != nil {
+		response, ok = n.otherwise, true
+	}
 	if !ok {
-		Error(status.BadRequest, errors.New("no suitable content-type provided")).ServeHTTP(rw, r)
-	} else {
-		handler(a.code, a.data).ServeHTTP(rw, r)
+		Error(status.NotAcceptable, errors.New("no suitable content-type provided")).ServeHTTP(rw, r)
+		return
 	}
+	response.ServeHTTP(rw, r)
 }
 
 // NegotiateContentType returns the best offered content type for the request's
@@ -703,11 +862,12 @@ func init() {
 // File returns the contents of the file provided by the path. The content type gets automatically guessed by the file extension.
 // If the extension is unknown, then the fallback ContentType is ContentTypeTextPlain. Additionally, a custom ContentType can be set,
 // by providing a second argument.
+//
+// The ETag and Last-Modified headers are set, and conditional requests with
+// If-None-Match or If-Modified-Since are answered with StatusNotModified,
+// without reading the file again. If the file does not exist, then an Error
+// with StatusNotFound is returned.
 func File(path string, contentType ...string) Response {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return Error(status.NotFound, err)
-	}
 	var header string
 	if len(contentType) >= 1 {
 		header = contentType[0]
@@ -725,20 +885,39 @@ func File(path string, contentType ...string) Response {
 	return fileResponse{
 		code:   status.OK,
 		header: header,
-		data:   data,
+		path:   path,
 	}
 }
 
 type fileResponse struct {
 	code   int
 	header string
-	data   []byte
+	path   string
 }
 
 func (f fileResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	validators, data, err := fileValidatorsOf(f.path)
+	if err != nil {
+		Error(status.NotFound, err).ServeHTTP(rw, r)
+		return
+	}
+	rw.Header().Set(header.ResponseEtag, validators.etag)
+	rw.Header().Set(header.ResponseLastModified, validators.modified.UTC().Format(http.TimeFormat))
+	if validators.notModified(r) {
+		rw.WriteHeader(status.NotModified)
+		return
+	}
+
+	if data == nil {
+		data, err = os.ReadFile(f.path)
+		if err != nil {
+			Error(status.NotFound, err).ServeHTTP(rw, r)
+			return
+		}
+	}
 	rw.Header().Set(header.ContentType, f.header)
 	rw.WriteHeader(f.code)
-	_, err := rw.Write(f.data)
+	_, err = rw.Write(data)
 	if err != nil {
 		log.Printf("fileResponse: ServeHttp write failed: %v", err)
 	}
@@ -0,0 +1,131 @@
+package there
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorResponseTooLarge is returned by writes, that exceed the ResponseSizeLimit
+var ErrorResponseTooLarge = errors.New("response too large")
+
+// ResponseSizeLimit guards against accidentally huge responses, like the
+// serialization of a whole object graph with Json. A zero MaxBytes means no limit.
+type ResponseSizeLimit struct {
+	// MaxBytes is the maximum size of a response body
+	MaxBytes int64
+	// Truncate sends the response up to MaxBytes and drops the rest. Otherwise,
+	// the response is replaced with a StatusInternalServerError. Responses that
+	// were already flushed, like streams, can only be truncated.
+	// Either way, a log entry is written.
+	Truncate bool
+}
+
+func (l ResponseSizeLimit) enabled() bool {
+	return l.MaxBytes > 0
+}
+
+// responseSizeWriter buffers the response until it is complete, flushed or
+// exceeds the limit, so an oversized response can still be replaced
+type responseSizeWriter struct {
+	http.ResponseWriter
+	request   *http.Request
+	limit     ResponseSizeLimit
+	code      int
+	body      bytes.Buffer
+	written   int64
+	committed bool
+	exceeded  bool
+}
+
+func (w *responseSizeWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *responseSizeWriter) Write(b []byte) (int, error) {
+	if w.exceeded {
+		return 0, ErrorResponseTooLarge
+	}
+	if w.written+int64(len(b)) <= w.limit.MaxBytes {
+		w.written += int64(len(b))
+		if w.committed {
+			return w.ResponseWriter.Write(b)
+		}
+		return w.body.Write(b)
+	}
+
+	w.exceeded = true
+	log.Printf("responseSizeLimit: %v %v exceeded the limit of %d bytes",
+		w.request.Method, w.request.URL.Path, w.limit.MaxBytes)
+
+	if !w.committed && !w.limit.Truncate {
+		headers := w.ResponseWriter.Header()
+		for name := range headers {
+			delete(headers, name)
+		}
+		w.body.Reset()
+		w.committed = true
+		Error(status.InternalServerError, ErrorResponseTooLarge).ServeHTTP(w.ResponseWriter, w.request)
+		return 0, ErrorResponseTooLarge
+	}
+
+	allowed := w.limit.MaxBytes - w.written
+	if !w.committed {
+		w.ResponseWriter.Header().Del(header.ContentLength)
+		w.commit()
+	}
+	n, err := w.ResponseWriter.Write(b[:allowed])
+	w.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, ErrorResponseTooLarge
+}
+
+func (w *responseSizeWriter) Flush() {
+	if !w.committed {
+		w.commit()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *responseSizeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// commit sends the status code and the buffered body
+func (w *responseSizeWriter) commit() {
+	w.committed = true
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if w.body.Len() > 0 {
+		_, err := w.ResponseWriter.Write(w.body.Bytes())
+		if err != nil {
+			log.Printf("responseSizeWriter: commit write failed: %v", err)
+		}
+		w.body.Reset()
+	}
+}
+
+// finish sends the response, after the handler returned
+func (w *responseSizeWriter) finish() {
+	if !w.committed {
+		w.commit()
+	}
+}
+
+// WithResponseSizeLimit overrides the ResponseSizeLimit of the RouterConfiguration for the route
+func (group *RouteRouteGroupBuilder) WithResponseSizeLimit(limit ResponseSizeLimit) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		endpoint.responseSizeLimit = &limit
+	}
+	return group
+}
@@ -0,0 +1,44 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestResponseSizeLimit(t *testing.T) {
+	huge := func(request Request) Response {
+		return String(status.OK, strings.Repeat("a", 100))
+	}
+
+	router := NewRouter()
+	router.Configuration.ResponseSizeLimit = ResponseSizeLimit{MaxBytes: 50}
+	router.Get("/error", huge)
+	router.Get("/small", func(request Request) Response {
+		return String(status.Created, "small")
+	})
+	router.Get("/truncate", huge).WithResponseSizeLimit(ResponseSizeLimit{MaxBytes: 10, Truncate: true})
+	router.Get("/unlimited", huge).WithResponseSizeLimit(ResponseSizeLimit{})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	recorder := serve("/error")
+	if recorder.Code != status.InternalServerError || strings.Contains(recorder.Body.String(), "aaa") {
+		t.Errorf("expected %v, got %v %v", status.InternalServerError, recorder.Code, recorder.Body.String())
+	}
+	if recorder = serve("/small"); recorder.Code != status.Created || recorder.Body.String() != "small" {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder = serve("/truncate"); recorder.Code != status.OK || recorder.Body.String() != strings.Repeat("a", 10) {
+		t.Errorf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder = serve("/unlimited"); recorder.Body.Len() != 100 {
+		t.Errorf("expected the full body, got %v bytes", recorder.Body.Len())
+	}
+}
@@ -0,0 +1,121 @@
+package there
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// RetryPolicy shapes the backoff suggested to clients with the Retry-After
+// header, whenever a StatusTooManyRequests or StatusServiceUnavailable is
+// served, be it by RetryAfter, RateLimited or built-in limits like WithConcurrency.
+type RetryPolicy struct {
+	// Min is the shortest backoff suggested. Defaults to one second, the resolution of Retry-After.
+	Min time.Duration
+	// Max caps the suggested backoff, if not zero
+	Max time.Duration
+	// Jitter adds a random duration of up to Jitter, so rejected clients do not
+	// all come back at once
+	Jitter time.Duration
+}
+
+// suggest applies the policy to the suggested backoff and returns it in whole seconds
+func (p RetryPolicy) suggest(suggested time.Duration) int {
+	if p.Jitter > 0 {
+		suggested += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+	if p.Max > 0 && suggested > p.Max {
+		suggested = p.Max
+	}
+	if suggested < p.Min {
+		suggested = p.Min
+	}
+	seconds := int((suggested + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// setRetryAfter sets the Retry-After header with the RetryPolicy of the router serving the request
+func setRetryAfter(rw http.ResponseWriter, r *http.Request, suggested time.Duration) {
+	var policy RetryPolicy
+	if router := routerOf(r); router != nil {
+		policy = router.Configuration.RetryPolicy
+	}
+	rw.Header().Set(header.ResponseRetryAfter, strconv.Itoa(policy.suggest(suggested)))
+}
+
+// RetryAfter wraps around your current Response and suggests the client to
+// retry after the given duration. The RetryPolicy of the RouterConfiguration is applied.
+//
+//	return there.RetryAfter(30*time.Second, there.Error(status.ServiceUnavailable, ErrorMaintenance))
+func RetryAfter(after time.Duration, response Response) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		setRetryAfter(rw, r, after)
+		response.ServeHTTP(rw, r)
+	})
+}
+
+// RateLimit describes the quota of a client, as sent in the RateLimit and
+// RateLimit-Policy headers of draft-ietf-httpapi-ratelimit-headers
+type RateLimit struct {
+	// Policy is the name of the quota policy. Defaults to "default".
+	Policy string
+	// Quota is the amount of requests allowed per Window
+	Quota  int
+	Window time.Duration
+	// Remaining is the amount of requests left in the current window
+	Remaining int
+	// Reset is the time until the quota is restored
+	Reset time.Duration
+}
+
+func (l RateLimit) policy() string {
+	if l.Policy == "" {
+		return "default"
+	}
+	return l.Policy
+}
+
+// seconds rounds the duration up to whole seconds
+func seconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// SetHeaders sets the RateLimit and RateLimit-Policy headers
+func (l RateLimit) SetHeaders(headers http.Header) {
+	name := strconv.Quote(l.policy())
+	headers.Set(header.ResponseRateLimitPolicy, name+";q="+strconv.Itoa(l.Quota)+";w="+strconv.Itoa(seconds(l.Window)))
+	remaining := l.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	headers.Set(header.ResponseRateLimit, strings.Join([]string{
+		name, "r=" + strconv.Itoa(remaining), "t=" + strconv.Itoa(seconds(l.Reset)),
+	}, ";"))
+}
+
+// RateLimited wraps around your current Response and announces the quota of the
+// client. If the quota is exhausted, then the Retry-After header is set to the
+// Reset of the quota as well, with the RetryPolicy of the RouterConfiguration applied.
+//
+//	if !allowed {
+//		return there.RateLimited(limit, there.Error(status.TooManyRequests, ErrorRateLimit))
+//	}
+func RateLimited(limit RateLimit, response Response) Response {
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		limit.SetHeaders(rw.Header())
+		if limit.Remaining <= 0 {
+			setRetryAfter(rw, r, limit.Reset)
+		}
+		response.ServeHTTP(rw, r)
+	})
+}
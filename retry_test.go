@@ -0,0 +1,58 @@
+package there
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRetryAfter(t *testing.T) {
+	router := NewRouter()
+	router.Get("/busy", func(request Request) Response {
+		return RetryAfter(90*time.Second, Status(status.ServiceUnavailable))
+	})
+	router.Get("/limited", func(request Request) Response {
+		limit := RateLimit{Quota: 100, Window: time.Minute, Remaining: 0, Reset: 1500 * time.Millisecond}
+		return RateLimited(limit, Status(status.TooManyRequests))
+	})
+
+	serve := func(route string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder
+	}
+
+	if value := serve("/busy").Header().Get(header.ResponseRetryAfter); value != "90" {
+		t.Errorf("expected 90, got %v", value)
+	}
+	recorder := serve("/limited")
+	if value := recorder.Header().Get(header.ResponseRateLimitPolicy); value != `"default";q=100;w=60` {
+		t.Errorf("unexpected policy %v", value)
+	}
+	if value := recorder.Header().Get(header.ResponseRateLimit); value != `"default";r=0;t=2` {
+		t.Errorf("unexpected limit %v", value)
+	}
+	if value := recorder.Header().Get(header.ResponseRetryAfter); value != "2" {
+		t.Errorf("expected 2, got %v", value)
+	}
+
+	router.Configuration.RetryPolicy = RetryPolicy{Min: 5 * time.Second, Max: time.Minute}
+	if value := serve("/busy").Header().Get(header.ResponseRetryAfter); value != "60" {
+		t.Errorf("expected the maximum of 60, got %v", value)
+	}
+	if value := serve("/limited").Header().Get(header.ResponseRetryAfter); value != "5" {
+		t.Errorf("expected the minimum of 5, got %v", value)
+	}
+
+	router.Configuration.RetryPolicy = RetryPolicy{Jitter: 10 * time.Second}
+	for i := 0; i < 10; i++ {
+		value, _ := strconv.Atoi(serve("/limited").Header().Get(header.ResponseRetryAfter))
+		if value < 2 || value > 12 {
+			t.Errorf("expected a value between 2 and 12, got %v", value)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package there
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// RobotsRule tells the crawlers matching the UserAgent, which paths they may visit
+type RobotsRule struct {
+	// UserAgent of the crawler, like "Googlebot". Defaults to "*".
+	UserAgent string
+	Allow     []string
+	Disallow  []string
+	// CrawlDelay asks the crawler to wait the amount of seconds between requests, if positive
+	CrawlDelay int
+}
+
+// RobotsPolicy is served as robots.txt, see RFC 9309
+type RobotsPolicy struct {
+	Rules []RobotsRule
+	// Sitemaps are the absolute URLs of the sitemaps of the site
+	Sitemaps []string
+}
+
+// String renders the policy in the robots.txt format. A policy without rules
+// allows everything.
+func (p RobotsPolicy) String() string {
+	var builder strings.Builder
+	rules := p.Rules
+	if len(rules) == 0 {
+		rules = []RobotsRule{{Disallow: []string{""}}}
+	}
+	for i, rule := range rules {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		userAgent := rule.UserAgent
+		if userAgent == "" {
+			userAgent = "*"
+		}
+		builder.WriteString("User-agent: " + userAgent + "\n")
+		for _, path := range rule.Allow {
+			builder.WriteString("Allow: " + path + "\n")
+		}
+		for _, path := range rule.Disallow {
+			builder.WriteString("Disallow: " + path + "\n")
+		}
+		if rule.CrawlDelay > 0 {
+			builder.WriteString("Crawl-delay: " + strconv.Itoa(rule.CrawlDelay) + "\n")
+		}
+	}
+	if len(p.Sitemaps) > 0 {
+		builder.WriteString("\n")
+	}
+	for _, sitemap := range p.Sitemaps {
+		builder.WriteString("Sitemap: " + sitemap + "\n")
+	}
+	return builder.String()
+}
+
+// Robots serves the policy at /robots.txt
+//
+//	router.Robots(there.RobotsPolicy{
+//		Rules:    []there.RobotsRule{{Disallow: []string{"/admin/"}}},
+//		Sitemaps: []string{"https://example.com/sitemap.xml"},
+//	})
+func (router *Router) Robots(policy RobotsPolicy) *RouteRouteGroupBuilder {
+	body := policy.String()
+	return router.Get("/robots.txt", func(request Request) Response {
+		return String(status.OK, body)
+	}).Tag(sitemapExclude)
+}
@@ -0,0 +1,277 @@
+package there
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorInvalidRouteConfig is returned by LoadRoutes for configurations, that
+// can not be registered
+var ErrorInvalidRouteConfig = errors.New("invalid route config")
+
+// RouteConfig declares a route without code, like a redirect or proxy rule
+// managed by operations. Exactly one of Redirect, Proxy and Static is set.
+type RouteConfig struct {
+	// Path is the pattern of the route, like "/old-docs/{path...}"
+	Path string `json:"path" yaml:"path"`
+	// Methods defaults to GET for redirects, GET and HEAD for static files and
+	// all methods for proxies
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	// Redirect is the location the route redirects to
+	Redirect string `json:"redirect,omitempty" yaml:"redirect,omitempty"`
+	// Status is the status code of the Redirect. Defaults to StatusFound.
+	Status int `json:"status,omitempty" yaml:"status,omitempty"`
+	// Proxy is the URL of the upstream, that requests are forwarded to with
+	// their path and query
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	// Static is the directory, whose files are served below the Path
+	Static string `json:"static,omitempty" yaml:"static,omitempty"`
+	// Middlewares are the names of middlewares registered with NamedMiddleware,
+	// in the order they run
+	Middlewares []string `json:"middlewares,omitempty" yaml:"middlewares,omitempty"`
+}
+
+// RouteConfigs is the file format of LoadRoutes
+type RouteConfigs struct {
+	Routes []RouteConfig `json:"routes" yaml:"routes"`
+}
+
+// ParseRouteConfigs reads json RouteConfigs. Use a yaml package to decode yaml
+// files into RouteConfigs instead.
+func ParseRouteConfigs(reader io.Reader) (RouteConfigs, error) {
+	var configs RouteConfigs
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&configs); err != nil {
+		return RouteConfigs{}, fmt.Errorf("%w: %v", ErrorInvalidRouteConfig, err)
+	}
+	return configs, nil
+}
+
+// NamedMiddleware registers the middleware under the name, so RouteConfigs can
+// reference it
+func (router *Router) NamedMiddleware(name string, middleware Middleware) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	if router.namedMiddlewares == nil {
+		router.namedMiddlewares = map[string]Middleware{}
+	}
+	router.namedMiddlewares[name] = middleware
+}
+
+// configuredRoute is a method of a pattern registered by LoadRoutes. Its
+// endpoint is replaced, when the routes are loaded again.
+type configuredRoute struct {
+	endpoint atomic.Pointer[Endpoint]
+}
+
+// configuredRoutes are the routes registered by LoadRoutes
+type configuredRoutes struct {
+	mutex  sync.Mutex
+	routes map[string]*configuredRoute
+}
+
+// LoadRoutes registers the routes of the configuration in the group, next to
+// the ones registered in code. Loading again replaces the configured routes,
+// without a restart: changed routes serve their new target right away, and
+// routes missing in the new configuration are not found anymore. Routes
+// registered in code can not be overridden.
+//
+// The configuration is validated completely, before any route is changed.
+//
+//	router.NamedMiddleware("auth", Auth)
+//	file, _ := os.Open("routes.json")
+//	configs, err := there.ParseRouteConfigs(file)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = router.LoadRoutes(configs)
+//
+// with routes.json like
+//
+//	{"routes": [
+//		{"path": "/blog/{path...}", "redirect": "https://blog.example.com", "status": 301},
+//		{"path": "/legacy/{path...}", "proxy": "http://legacy:8080", "middlewares": ["auth"]},
+//		{"path": "/downloads", "static": "/var/www/downloads"}
+//	]}
+func (group *RouteGroup) LoadRoutes(configs RouteConfigs) error {
+	router := group.Router
+	router.configured.mutex.Lock()
+	defer router.configured.mutex.Unlock()
+
+	type loaded struct {
+		pattern  string
+		methods  []string
+		endpoint Endpoint
+	}
+	var routes []loaded
+	keys := map[string]bool{}
+	for i, config := range configs.Routes {
+		endpoint, pattern, methods, err := group.configuredEndpoint(config)
+		if err != nil {
+			return fmt.Errorf("%w: route %d %q: %v", ErrorInvalidRouteConfig, i, config.Path, err)
+		}
+		for _, m := range methods {
+			key := m + " " + pattern
+			if keys[key] {
+				return fmt.Errorf("%w: route %d %q: %v is declared twice", ErrorInvalidRouteConfig, i, config.Path, key)
+			}
+			if group.registeredInCode(pattern, m) {
+				return fmt.Errorf("%w: route %d %q: %v is registered in code", ErrorInvalidRouteConfig, i, config.Path, key)
+			}
+			keys[key] = true
+		}
+		routes = append(routes, loaded{pattern: pattern, methods: methods, endpoint: endpoint})
+	}
+
+	if router.configured.routes == nil {
+		router.configured.routes = map[string]*configuredRoute{}
+	}
+	for key, route := range router.configured.routes {
+		if !keys[key] {
+			route.endpoint.Store(nil)
+		}
+	}
+	for _, route := range routes {
+		for _, m := range route.methods {
+			endpoint := route.endpoint
+			key := m + " " + route.pattern
+			if existing, ok := router.configured.routes[key]; ok {
+				existing.endpoint.Store(&endpoint)
+				continue
+			}
+			configured := &configuredRoute{}
+			configured.endpoint.Store(&endpoint)
+			router.configured.routes[key] = configured
+			group.Router.Handle(route.pattern, configured.serve, m)
+		}
+	}
+	return nil
+}
+
+// serve serves the current endpoint of the route
+func (c *configuredRoute) serve(request Request) Response {
+	endpoint := c.endpoint.Load()
+	if endpoint == nil {
+		return routerOf(request.Request).Configuration.RouteNotFoundHandler(request)
+	}
+	return (*endpoint)(request)
+}
+
+// registeredInCode reports whether the method of the pattern was registered
+// with Handle instead of LoadRoutes
+func (group *RouteGroup) registeredInCode(pattern, m string) bool {
+	if _, configured := group.Router.configured.routes[m+" "+pattern]; configured {
+		return false
+	}
+	group.Router.mutex.Lock()
+	defer group.Router.mutex.Unlock()
+	handler, ok := group.Router.handlerKeeper[pattern]
+	if !ok {
+		return false
+	}
+	_, ok = handler.methods[methodToInt(m)]
+	return ok
+}
+
+// configuredEndpoint validates the config and returns its endpoint with the
+// middlewares applied, and the pattern and methods to register it with
+func (group *RouteGroup) configuredEndpoint(config RouteConfig) (Endpoint, string, []string, error) {
+	targets := 0
+	for _, target := range []string{config.Redirect, config.Proxy, config.Static} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return nil, "", nil, errors.New("exactly one of redirect, proxy and static is required")
+	}
+	if config.Path == "" {
+		return nil, "", nil, errors.New("the path is missing")
+	}
+
+	routePath := config.Path
+	var endpoint Endpoint
+	var methods []string
+	switch {
+	case config.Redirect != "":
+		code := config.Status
+		if code == 0 {
+			code = status.Found
+		}
+		if code < 300 || code > 399 {
+			return nil, "", nil, fmt.Errorf("%d is no redirect status", code)
+		}
+		redirect := Redirect(code, config.Redirect)
+		endpoint = func(request Request) Response {
+			return redirect
+		}
+		methods = []string{MethodGet}
+	case config.Proxy != "":
+		target, err := url.Parse(config.Proxy)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return nil, "", nil, fmt.Errorf("the proxy %q is no absolute url", config.Proxy)
+		}
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		endpoint = func(request Request) Response {
+			return proxy
+		}
+		methods = AllMethods
+	case config.Static != "":
+		info, err := os.Stat(config.Static)
+		if err != nil || !info.IsDir() {
+			return nil, "", nil, fmt.Errorf("the static directory %q does not exist", config.Static)
+		}
+		if !strings.HasSuffix(routePath, "{path...}") {
+			routePath = path.Join("/", routePath, "{path...}")
+		}
+		static := &staticFiles{fileSystem: os.DirFS(config.Static)}
+		endpoint = static.endpoint
+		methods = []string{MethodGet, MethodHead}
+	}
+	if len(config.Methods) > 0 {
+		methods = nil
+		for _, m := range config.Methods {
+			m = strings.ToUpper(m)
+			if !slices.Contains(AllMethods, m) {
+				return nil, "", nil, fmt.Errorf("unknown method %q", m)
+			}
+			methods = append(methods, m)
+		}
+	}
+
+	var middlewares []Middleware
+	group.Router.mutex.Lock()
+	for _, name := range config.Middlewares {
+		middleware, ok := group.Router.namedMiddlewares[name]
+		if !ok {
+			group.Router.mutex.Unlock()
+			return nil, "", nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		middlewares = append(middlewares, middleware)
+	}
+	group.Router.mutex.Unlock()
+	if len(middlewares) > 0 {
+		next := endpoint
+		endpoint = func(request Request) Response {
+			response := next(request)
+			for i := len(middlewares) - 1; i >= 0; i-- {
+				response = middlewares[i](request, response)
+			}
+			return response
+		}
+	}
+	return endpoint, group.resolvePath(routePath), methods, nil
+}
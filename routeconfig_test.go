@@ -0,0 +1,145 @@
+package there
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestParseRouteConfigs(t *testing.T) {
+	configs, err := ParseRouteConfigs(strings.NewReader(`{"routes": [
+		{"path": "/old", "redirect": "/new", "status": 301, "methods": ["get", "head"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := RouteConfig{Path: "/old", Redirect: "/new", Status: 301, Methods: []string{"get", "head"}}
+	if len(configs.Routes) != 1 || configs.Routes[0].Path != expected.Path ||
+		configs.Routes[0].Redirect != expected.Redirect || configs.Routes[0].Status != expected.Status ||
+		len(configs.Routes[0].Methods) != 2 {
+		t.Errorf("expected %+v, got %+v", expected, configs.Routes)
+	}
+
+	_, err = ParseRouteConfigs(strings.NewReader(`{"routes": [{"path": "/old", "target": "/new"}]}`))
+	if !errors.Is(err, ErrorInvalidRouteConfig) {
+		t.Errorf("expected unknown fields to be rejected, got %v", err)
+	}
+}
+
+func TestLoadRoutes(t *testing.T) {
+	upstream := httptest.NewServer(ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Upstream", r.URL.Path)
+		rw.WriteHeader(status.Accepted)
+	}))
+	defer upstream.Close()
+
+	directory := t.TempDir()
+	if err := os.WriteFile(filepath.Join(directory, "report.txt"), []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		return String(status.OK, "code")
+	})
+	router.NamedMiddleware("tag", func(request Request, next Response) Response {
+		return Headers(map[string]string{"X-Tag": "configured"}, next)
+	})
+	api := router.Group("/api")
+
+	err := api.LoadRoutes(RouteConfigs{Routes: []RouteConfig{
+		{Path: "/docs/{path...}", Redirect: "https://docs.example.com", Status: status.MovedPermanently},
+		{Path: "/legacy/{path...}", Proxy: upstream.URL, Middlewares: []string{"tag"}},
+		{Path: "/files", Static: directory},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := serveRoute(router, MethodGet, "/api/docs/intro")
+	if recorder.Code != status.MovedPermanently || recorder.Header().Get(header.ResponseLocation) != "https://docs.example.com" {
+		t.Errorf("unexpected redirect %v %v", recorder.Code, recorder.Header())
+	}
+	recorder = serveRoute(router, MethodDelete, "/api/legacy/orders/1")
+	if recorder.Code != status.Accepted || recorder.Header().Get("X-Upstream") != "/api/legacy/orders/1" ||
+		recorder.Header().Get("X-Tag") != "configured" {
+		t.Errorf("unexpected proxy response %v %v", recorder.Code, recorder.Header())
+	}
+	recorder = serveRoute(router, MethodGet, "/api/files/report.txt")
+	if recorder.Code != status.OK || recorder.Body.String() != "report" {
+		t.Errorf("unexpected static response %v %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder = serveRoute(router, MethodGet, "/"); recorder.Body.String() != "code" {
+		t.Errorf("expected the code route to stay, got %q", recorder.Body.String())
+	}
+
+	// reload with a changed redirect and without the proxy and static routes
+	err = api.LoadRoutes(RouteConfigs{Routes: []RouteConfig{
+		{Path: "/docs/{path...}", Redirect: "https://v2.docs.example.com"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder = serveRoute(router, MethodGet, "/api/docs/intro")
+	if recorder.Code != status.Found || recorder.Header().Get(header.ResponseLocation) != "https://v2.docs.example.com" {
+		t.Errorf("unexpected reloaded redirect %v %v", recorder.Code, recorder.Header())
+	}
+	if recorder = serveRoute(router, MethodGet, "/api/legacy/orders/1"); recorder.Code != status.NotFound {
+		t.Errorf("expected the removed proxy to be not found, got %v", recorder.Code)
+	}
+	if recorder = serveRoute(router, MethodGet, "/api/files/report.txt"); recorder.Code != status.NotFound {
+		t.Errorf("expected the removed static route to be not found, got %v", recorder.Code)
+	}
+}
+
+func TestLoadRoutesInvalid(t *testing.T) {
+	router := NewRouter()
+	router.Get("/taken", func(request Request) Response {
+		return Status(status.OK)
+	})
+	valid := RouteConfig{Path: "/docs", Redirect: "/documentation"}
+	if err := router.LoadRoutes(RouteConfigs{Routes: []RouteConfig{valid}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, config := range map[string]RouteConfig{
+		"code route":         {Path: "/taken", Redirect: "/elsewhere"},
+		"unknown middleware": {Path: "/a", Redirect: "/b", Middlewares: []string{"missing"}},
+		"two targets":        {Path: "/a", Redirect: "/b", Proxy: "http://upstream"},
+		"no target":          {Path: "/a"},
+		"no redirect status": {Path: "/a", Redirect: "/b", Status: status.OK},
+		"relative proxy":     {Path: "/a", Proxy: "upstream:8080"},
+		"missing directory":  {Path: "/a", Static: filepath.Join(t.TempDir(), "missing")},
+		"unknown method":     {Path: "/a", Redirect: "/b", Methods: []string{"FETCH"}},
+	} {
+		err := router.LoadRoutes(RouteConfigs{Routes: []RouteConfig{{Path: "/c", Redirect: "/d"}, config}})
+		if !errors.Is(err, ErrorInvalidRouteConfig) {
+			t.Errorf("%v: expected ErrorInvalidRouteConfig, got %v", name, err)
+		}
+	}
+	err := router.LoadRoutes(RouteConfigs{Routes: []RouteConfig{valid, valid}})
+	if !errors.Is(err, ErrorInvalidRouteConfig) {
+		t.Errorf("expected duplicates to be rejected, got %v", err)
+	}
+
+	// failed loads do not change the configured routes
+	if recorder := serveRoute(router, MethodGet, "/docs"); recorder.Code != status.Found {
+		t.Errorf("expected the first configuration to stay, got %v", recorder.Code)
+	}
+	if recorder := serveRoute(router, MethodGet, "/c"); recorder.Code != status.NotFound {
+		t.Errorf("expected no route of a failed load, got %v", recorder.Code)
+	}
+}
+
+func serveRoute(router *Router, method, target string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	return recorder
+}
@@ -0,0 +1,69 @@
+package there
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	// Handler is the name of the Endpoint func, like "main.GetUser"
+	Handler string `json:"handler"`
+	// Middlewares is the amount of route middlewares. The global middlewares
+	// run for every route in addition.
+	Middlewares int `json:"middlewares"`
+}
+
+// Routes lists the registered routes ordered by pattern and method, like to
+// log them at startup or to test that all routes are registered. Use Snapshot
+// for the names of the middlewares and the RouteMeta.
+func (router *Router) Routes() []RouteInfo {
+	routes := router.Snapshot().Routes
+	infos := make([]RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		infos = append(infos, RouteInfo{
+			Method:      route.Method,
+			Pattern:     route.Pattern,
+			Handler:     route.Endpoint,
+			Middlewares: len(route.Middlewares),
+		})
+	}
+	return infos
+}
+
+// PrintRoutes writes the routes as table
+//
+//	METHOD  PATTERN      HANDLER        MIDDLEWARES
+//	GET     /users/{id}  main.GetUser   1
+//	POST    /users       main.PostUser  0
+func (router *Router) PrintRoutes(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "METHOD\tPATTERN\tHANDLER\tMIDDLEWARES")
+	for _, route := range router.Routes() {
+		handler := route.Handler
+		if handler == "" {
+			handler = "-"
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%d\n", route.Method, route.Pattern, handler, route.Middlewares)
+	}
+	return writer.Flush()
+}
+
+// RoutesEndpoint lists the routes of the router as json, or as table for
+// clients preferring plain text. Protect it like other debug endpoints.
+//
+//	router.Get("/debug/routes", router.RoutesEndpoint).With(adminOnly)
+func (router *Router) RoutesEndpoint(request Request) Response {
+	var table strings.Builder
+	_ = router.PrintRoutes(&table)
+	return Negotiate(ContentTypeApplicationJson, map[string]Response{
+		ContentTypeApplicationJson: Json(status.OK, router.Routes()),
+		ContentTypeTextPlain:       String(status.OK, table.String()),
+	})
+}
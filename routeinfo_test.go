@@ -0,0 +1,62 @@
+package there
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func routeInfoUser(request Request) Response {
+	return Status(status.OK)
+}
+
+func TestRoutes(t *testing.T) {
+	router := NewRouter()
+	router.Get("/users/{id}", routeInfoUser).With(tracingRoute)
+	router.Post("/users", nil)
+	router.Get("/debug/routes", router.RoutesEndpoint)
+
+	routes := router.Routes()
+	expected := []RouteInfo{
+		{Method: MethodGet, Pattern: "/debug/routes", Handler: "github.com/gebes/there/v2.(*Router).RoutesEndpoint"},
+		{Method: MethodPost, Pattern: "/users"},
+		{Method: MethodGet, Pattern: "/users/{id}", Handler: "github.com/gebes/there/v2.routeInfoUser", Middlewares: 1},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, routes)
+	}
+	for i, route := range routes {
+		if route != expected[i] {
+			t.Errorf("%d: expected %+v, got %+v", i, expected[i], route)
+		}
+	}
+
+	var table strings.Builder
+	if err := router.PrintRoutes(&table); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "METHOD  PATTERN") ||
+		strings.Join(strings.Fields(lines[2]), " ") != "POST /users - 0" {
+		t.Errorf("unexpected table\n%v", table.String())
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/debug/routes", nil))
+	var listed []RouteInfo
+	if err := json.Unmarshal(recorder.Body.Bytes(), &listed); err != nil || len(listed) != 3 {
+		t.Errorf("unexpected listing %v %v", err, recorder.Body.String())
+	}
+
+	request := httptest.NewRequest(MethodGet, "/debug/routes", nil)
+	request.Header.Set(header.RequestAccept, ContentTypeTextPlain)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Body.String() != table.String() {
+		t.Errorf("expected the table for plain text, got %v", recorder.Body.String())
+	}
+}
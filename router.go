@@ -1,11 +1,15 @@
 package there
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gebes/there/v2/status"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Router struct {
@@ -22,6 +26,48 @@ type Router struct {
 	serveMux      *http.ServeMux
 	handlerKeeper map[string]*muxHandler
 	mutex         sync.Mutex
+
+	// hijacked contains the connections taken over with Request.Hijack
+	hijacked hijackedConnections
+
+	stats routerStats
+
+	// localized maps the names of localized routes to their patterns per locale
+	localized map[string]LocalizedPaths
+	// named maps the names of routes set with Name to their patterns
+	named map[string]string
+
+	// deprecations are the routes marked with Deprecated
+	deprecations []*routeDeprecation
+
+	// ready is set, once the SelfTest passed
+	ready atomic.Bool
+
+	// healthChecks are the checks added with AddHealthCheck or ReportHealth
+	healthChecks sync.Map // map[string]*healthCheck
+
+	// assets are the contents registered with Asset
+	assets assetStore
+
+	// namedMiddlewares are the middlewares RouteConfigs can reference
+	namedMiddlewares map[string]Middleware
+	// configured are the routes registered with LoadRoutes
+	configured configuredRoutes
+
+	startupHooks  []func(ctx context.Context) error
+	shutdownHooks []func(ctx context.Context) error
+	shutdownOnce  sync.Once
+	shutdownErr   error
+	// stopped is closed, once Shutdown completed
+	stopped chan struct{}
+
+	// maintenance answers all requests with ErrorMaintenance, while enabled
+	maintenance maintenanceMode
+
+	// hostRouting is set, once a RouteGroup for a host was created
+	hostRouting bool
+	// hosts are the hosts with wildcard labels registered with Host
+	hosts []hostPattern
 }
 
 func NewRouter() *Router {
@@ -42,13 +88,28 @@ func NewRouter() *Router {
 					Method: request.Request.Method,
 				})
 			},
-			SanitizePaths: true,
+			MethodNotAllowedHandler: func(request Request) Response {
+				type Error struct {
+					Error  string `json:"error,omitempty" xml:"Error" yaml:"error" bson:"error"`
+					Path   string `json:"path,omitempty" xml:"Path" yaml:"path" bson:"path"`
+					Method string `json:"method,omitempty" xml:"Method" yaml:"method" bson:"method"`
+				}
+				return Auto(status.MethodNotAllowed, Error{
+					Error:  "method is not allowed for the specified path",
+					Path:   request.Request.URL.Path,
+					Method: request.Request.Method,
+				})
+			},
+			RecoverHandler: DefaultRecoverHandler,
+			SanitizePaths:  true,
 		},
 		serveMux:      http.NewServeMux(),
 		handlerKeeper: map[string]*muxHandler{},
+		stopped:       make(chan struct{}),
 	}
 
 	r.Server.Handler = r
+	r.Server.RegisterOnShutdown(r.hijacked.closeAll)
 	r.RouteGroup = NewRouteGroup(r, "/")
 	return r
 }
@@ -59,22 +120,18 @@ func (p Port) ToAddr() string {
 	return fmt.Sprintf(":%d", p)
 }
 
+// Listen serves on the port. Use ListenWithContext to shut down gracefully.
 func (router *Router) Listen(port Port) error {
-	err := router.HasError()
-	if err != nil {
+	if err := router.start(context.Background()); err != nil {
 		return err
 	}
 	router.Server.Addr = port.ToAddr()
 	return router.Server.ListenAndServe()
 }
 
+// ListenToTLS serves HTTPS on the port, see ListenTLS
 func (router *Router) ListenToTLS(port Port, certFile, keyFile string) error {
-	err := router.HasError()
-	if err != nil {
-		return err
-	}
-	router.Server.Addr = port.ToAddr()
-	return router.Server.ListenAndServeTLS(certFile, keyFile)
+	return router.ListenTLS(port.ToAddr(), certFile, keyFile)
 }
 
 // Use registers a Middleware
@@ -87,7 +144,149 @@ func (router *Router) Use(middleware ...Middleware) *Router {
 type RouterConfiguration struct {
 	// RouteNotFoundHandler gets invoked, when the specified URL and method have no handlers
 	RouteNotFoundHandler Endpoint
-	SanitizePaths        bool
+	// MethodNotAllowedHandler gets invoked, when the specified URL has handlers,
+	// but none for the method. The Allow header already lists the methods of the
+	// URL. If nil, the RouteNotFoundHandler is invoked instead.
+	MethodNotAllowedHandler Endpoint
+	// RecoverHandler renders the response for panics in endpoints and
+	// middlewares. Defaults to DefaultRecoverHandler. If nil, panics are not
+	// recovered, so net/http logs them and closes the connection.
+	RecoverHandler RecoverHandler
+	SanitizePaths  bool
+	// MaxBodySize limits the size of request bodies in bytes. Zero means no limit.
+	// Can be overridden per route with WithMaxBody. Requests announcing a larger
+	// body get a StatusRequestEntityTooLarge response, otherwise reading the
+	// body fails with ErrorBodyTooLarge.
+	MaxBodySize int64
+	// BodyDrainLimit is the amount of unread request body bytes, that are
+	// discarded after the response, so keep-alive connections can be reused when
+	// a middleware responded early. Defaults to 256 KiB, negative disables draining.
+	// The drained bytes are counted in the RouterStats.
+	BodyDrainLimit int64
+	// ResponseHeaderLimits limits the amount and size of response headers.
+	// Can be overridden per route with WithHeaderLimits.
+	ResponseHeaderLimits HeaderLimits
+	// ResponseSizeLimit limits the size of response bodies.
+	// Can be overridden per route with WithResponseSizeLimit.
+	ResponseSizeLimit ResponseSizeLimit
+
+	// MockMode serves the documented responses of routes that were registered
+	// without an Endpoint, so clients can be built against the API skeleton
+	// before the backend logic exists. See RouteDoc.
+	MockMode bool
+	// StubMode serves the Example responses of routes instead of their
+	// endpoints, so clients can integrate against the API while the handlers
+	// are still under construction. Middlewares still run. Routes without
+	// examples are served as usual. Enable it with a command line flag, like
+	//
+	//	stub := flag.Bool("stub", false, "serve the example responses")
+	//	flag.Parse()
+	//	router.Configuration.StubMode = *stub
+	StubMode bool
+
+	// MatrixParams removes matrix parameters, like "/cars;color=red", from the
+	// path segments before routing, so they can be read with Request.Matrix.
+	MatrixParams bool
+
+	// TrailingSlash defines how paths are handled, that only differ from a
+	// route in their trailing slash, like "/users/" for the route "/users".
+	// Defaults to TrailingSlashStrict.
+	TrailingSlash TrailingSlash
+	// CaseInsensitivePaths serves routes for paths, whose literal segments only
+	// differ in their case, like "/Users/42" for the route "/users/{id}". The
+	// endpoint gets the path in the case of the route. Exact matches are
+	// preferred.
+	CaseInsensitivePaths bool
+
+	// OptionsDiscovery answers OPTIONS requests on routes without an explicit
+	// OPTIONS handler with a json description of the route: its allowed methods,
+	// route parameters and the schemas documented with RouteDoc.
+	OptionsDiscovery bool
+	// AutoHead answers HEAD requests on routes without an explicit HEAD handler
+	// with their GET endpoint, without the body.
+	AutoHead bool
+	// AutoOptions answers OPTIONS requests on routes without an explicit
+	// OPTIONS handler with StatusNoContent and the Allow header, listing the
+	// registered methods. The OptionsDiscovery takes precedence.
+	AutoOptions bool
+
+	// BasePath is the path the router is mounted under, like "/service-name".
+	// Routes are registered without it, and it is stripped from incoming paths
+	// before matching. Requests outside the BasePath are not found. Generated
+	// paths, like in Redirect or with Request.ExternalPath, include it.
+	BasePath string
+
+	// TrustForwardedPrefix honors the X-Forwarded-Prefix header of a path
+	// stripping reverse proxy, when paths are generated for the client, like
+	// in Redirect or with Request.ExternalPath. Only enable it, if the header
+	// is always set or removed by the proxy.
+	TrustForwardedPrefix bool
+
+	// ShutdownTimeout limits how long ListenWithContext waits for in-flight
+	// requests, once its context is done. Zero means no limit.
+	ShutdownTimeout time.Duration
+
+	// RequestTimeoutFromHeaders applies the timeout a caller sent in the
+	// X-Request-Timeout or Grpc-Timeout header as deadline of the request context,
+	// so callers can propagate their remaining budget. Only enable it for
+	// trusted callers.
+	RequestTimeoutFromHeaders bool
+	// MaxRequestTimeout caps the timeout taken from the headers. Zero means no cap.
+	MaxRequestTimeout time.Duration
+	// JsonTimeFormat defines how time.Time values are encoded by Json and parsed
+	// by BindJson. Defaults to TimeFormatRFC3339.
+	JsonTimeFormat TimeFormat
+	// JsonDurationFormat defines how time.Duration values are encoded by Json and
+	// parsed by BindJson. Defaults to DurationFormatNanoseconds.
+	JsonDurationFormat DurationFormat
+	// Envelope wraps json responses in an Envelope. Can be overridden per group
+	// with WithEnvelope.
+	Envelope bool
+	// ErrorFormats are the formats Error responses are negotiated between, besides json
+	ErrorFormats ErrorFormats
+	// HideInternalErrors replaces the message of 5xx Error responses with the
+	// status text, so internal details, like database errors, are never exposed
+	// to clients. They are still recorded on the Span. 5xx errors include the
+	// Request.RequestId either way.
+	HideInternalErrors bool
+	// Serializers maps content types to the Serializer marshalling and
+	// unmarshalling them. A json Serializer replaces encoding/json in Json,
+	// Error and BindJson. Others are served by Serialize and Auto, and read by
+	// BodyReader.Bind. Register them with RegisterSerializer.
+	Serializers map[string]Serializer
+	// RequestHeaders strips, renames and injects request headers, before the requests are routed
+	RequestHeaders RequestHeaderRules
+	// HeaderMergePolicy defines how the Headers reader handles headers, that
+	// were sent several times. Defaults to HeaderFirstWins.
+	HeaderMergePolicy HeaderMergePolicy
+	// ResponseHeaderPolicy removes disallowed and adds required headers to every response
+	ResponseHeaderPolicy ResponseHeaderPolicy
+	// FlagProvider evaluates the feature flags of Flags. All flags are disabled, if nil.
+	FlagProvider FlagProvider
+	// FlagAttributes extracts the attributes, like the user or tenant, flags are evaluated with
+	FlagAttributes func(request Request) FlagAttributes
+	// SelfTests are served by SelfTest, before Listen accepts connections
+	SelfTests []SelfTestCase
+	// RetryPolicy shapes the Retry-After backoff suggested with StatusTooManyRequests
+	// and StatusServiceUnavailable responses
+	RetryPolicy RetryPolicy
+	// Issues reports the non-fatal issues recorded with Request.AddIssue in headers and logs
+	Issues IssueReporting
+	// OpenAPIInfo describes the API in the document of Router.OpenAPI
+	OpenAPIInfo OpenAPIInfo
+	// Tracer starts a span for every request, that covers the global
+	// middlewares, records the timing of every middleware and the errors of
+	// Error responses. Not traced, if nil.
+	Tracer Tracer
+	// RouteStats counts the requests, errors and durations of every route, which
+	// can be read with Router.RouteStats or the Admin router
+	RouteStats bool
+	// LogLevel is the level the Admin router reads and changes at runtime. Pass
+	// the same LevelVar to the slog.Handler of the service.
+	LogLevel *slog.LevelVar
+	// Consumer identifies the client of a request, like by its API key, to count
+	// the usage of Deprecated routes per consumer
+	Consumer func(request Request) string
 }
 
 type assertionErrors []error
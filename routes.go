@@ -8,6 +8,14 @@ import (
 type RouteGroup struct {
 	*Router
 	prefix string
+	// envelope overrides the Envelope of the RouterConfiguration, if not nil
+	envelope *bool
+	// middlewares are added to the routes registered on the group, before their own
+	middlewares []Middleware
+	// host the routes of the group are served for, with "*" for wildcard labels. Empty for all hosts.
+	host string
+	// hostParams are the names of the wildcard labels of the host
+	hostParams []string
 }
 
 func (group RouteGroup) Group(prefix string) *RouteGroup {
@@ -47,8 +55,16 @@ type Endpoint func(request Request) Response
 type Route struct {
 	muxHandler *muxHandler
 	methods    []method
+	// aliases are further routes, like localized paths, which the builder methods apply to as well
+	aliases []*Route
+	// routeToggle enables and disables the route, if not nil
+	routeToggle *routeToggle
 }
 
+// Handle registers the endpoint for the methods. The path is a http.ServeMux
+// pattern, like "/users/{id}" or "/files/{path...}". A trailing catch-all
+// segment can also be written as "/files/*path", and parameters can be
+// constrained with a regular expression, like "/users/{id:[0-9]+}".
 func (group *RouteGroup) Handle(path string, endpoint Endpoint, methodsString ...string) *RouteRouteGroupBuilder {
 	group.Router.mutex.Lock()
 	defer group.Router.mutex.Unlock()
@@ -58,34 +74,32 @@ func (group *RouteGroup) Handle(path string, endpoint Endpoint, methodsString ..
 		methods = append(methods, methodToInt(m))
 	}
 
-	if path == "" {
-		path = "/"
-	}
-	if path[0] == '/' {
-		path = "/" + path
-	}
-
-	path = group.prefix + path
-	path = path2.Clean(path)
+	path, constraints := group.resolveConstrainedPath(path)
 
 	var ok bool
 	var muxHandler *muxHandler
 	muxHandler, ok = group.Router.handlerKeeper[path]
 	if !ok {
-		muxHandler = newMuxHandler(group.Router, endpoint)
+		muxHandler = newMuxHandler(group.Router, path)
 		group.serveMux.Handle(path, muxHandler)
 		group.Router.handlerKeeper[path] = muxHandler
 	}
 
 	for _, m := range methods {
 		muxHandler.methods[m] = &muxHandlerEndpoint{
-			endpoint: endpoint,
+			endpoint:    endpoint,
+			envelope:    group.envelope,
+			middlewares: append([]Middleware(nil), group.middlewares...),
+			stats:       &endpointStats{},
+		}
+		for name, constraint := range constraints {
+			muxHandler.methods[m].constrain(name, constraint)
 		}
 	}
 
 	route := &Route{
-		muxHandler,
-		methods,
+		muxHandler: muxHandler,
+		methods:    methods,
 	}
 
 	return &RouteRouteGroupBuilder{
@@ -94,6 +108,44 @@ func (group *RouteGroup) Handle(path string, endpoint Endpoint, methodsString ..
 	}
 }
 
+// resolvePath returns the pattern the path is registered with in the group
+func (group *RouteGroup) resolvePath(path string) string {
+	pattern, _ := group.resolveConstrainedPath(path)
+	return pattern
+}
+
+// resolveConstrainedPath is like resolvePath, but also returns the inline
+// constraints of the route parameters
+func (group *RouteGroup) resolveConstrainedPath(path string) (string, map[string]ParamConstraint) {
+	if path == "" {
+		path = "/"
+	}
+	if path[0] == '/' {
+		path = "/" + path
+	}
+	pattern, constraints := group.inlineConstraints(path2.Clean(group.prefix + path))
+	return group.host + group.catchAll(pattern), constraints
+}
+
+// catchAll turns a trailing "*name" segment, like in "/static/*path", into the
+// {name...} wildcard of http.ServeMux, which matches the remainder of the path
+// at any depth. The remainder can be read with RouteParams.
+func (group *RouteGroup) catchAll(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		last, named := i == len(segments)-1, len(segment) > 1
+		group.assert(last, "catch-all segment \""+segment+"\" must be the last one of \""+pattern+"\"")
+		group.assert(named, "catch-all segment of \""+pattern+"\" needs a name, like *path")
+		if last && named {
+			segments[i] = "{" + segment[1:] + "...}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
 type RouteRouteGroupBuilder struct {
 	*Route
 	*RouteGroup
@@ -135,11 +187,54 @@ func (group *RouteGroup) Options(route string, endpoint Endpoint) *RouteRouteGro
 	return group.Handle(route, endpoint, MethodOptions)
 }
 
-// With adds a middleware to the handler the method is called on
-func (group *RouteRouteGroupBuilder) With(middleware Middleware) *RouteRouteGroupBuilder {
-	for _, method := range group.methods {
-		endpoint := group.muxHandler.methods[method]
-		endpoint.AddMiddleware(middleware)
+// With adds middlewares to the route the method is called on. They run after
+// the global middlewares and the ones of the RouteGroup, in the given order.
+//
+//	router.Get("/admin", Admin).With(Auth, AuditLog)
+func (group *RouteRouteGroupBuilder) With(middlewares ...Middleware) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		for _, middleware := range middlewares {
+			endpoint.AddMiddleware(middleware)
+		}
 	}
 	return group
 }
+
+// With adds middlewares to the routes registered on the group afterwards, and
+// on the groups created from it. Unlike the global middlewares added with Use,
+// they only run for matched routes.
+//
+//	admin := router.Group("/admin").With(Auth)
+//	admin.Get("/users", GetUsers)
+func (group *RouteGroup) With(middlewares ...Middleware) *RouteGroup {
+	// the full slice expression copies the slice, so groups never share appended middlewares
+	group.middlewares = append(group.middlewares[:len(group.middlewares):len(group.middlewares)], middlewares...)
+	return group
+}
+
+// WithMaxBody overrides the MaxBodySize of the RouterConfiguration for the route.
+// A negative limit removes the limit for the route.
+//
+//	router.Post("/upload", Upload).WithMaxBody(50 << 20)
+func (group *RouteRouteGroupBuilder) WithMaxBody(limit int64) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		endpoint.maxBodySize = limit
+	}
+	return group
+}
+
+// endpoints returns the muxHandlerEndpoint of every method the route was registered with
+func (group *RouteRouteGroupBuilder) endpoints() []*muxHandlerEndpoint {
+	return group.Route.endpoints()
+}
+
+func (route *Route) endpoints() []*muxHandlerEndpoint {
+	endpoints := make([]*muxHandlerEndpoint, 0, len(route.methods))
+	for _, method := range route.methods {
+		endpoints = append(endpoints, route.muxHandler.methods[method])
+	}
+	for _, alias := range route.aliases {
+		endpoints = append(endpoints, alias.endpoints()...)
+	}
+	return endpoints
+}
@@ -0,0 +1,275 @@
+package there
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// Redacted replaces the values of redacted fields in the samples of a BodySampler
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are redacted by a BodySampler, if no Redact list is set
+var DefaultRedactedFields = []string{"password", "secret", "token", "key", "authorization", "credential", "ssn", "card"}
+
+// BodySampler records samples of the json request bodies of every route and
+// infers their JSON Schema, so typed input structs and the documentation of
+// legacy handlers, that read untyped bodies, can be backfilled. As the samples
+// may contain personal data, only use it during development.
+//
+//	sampler := &there.BodySampler{}
+//	router.Use(sampler.Middleware)
+//	router.Get("/debug/bodies", sampler.Endpoint)
+type BodySampler struct {
+	// MaxSamples is the amount of samples kept per route. Defaults to 10.
+	// The schema is inferred from all bodies nevertheless.
+	MaxSamples int
+	// MaxBodySize is the size of the largest body, that is sampled. Defaults to 64 KiB.
+	MaxBodySize int64
+	// Redact are the parts of field names, whose values are replaced with
+	// Redacted in the samples, compared case-insensitively. Defaults to
+	// DefaultRedactedFields.
+	Redact []string
+
+	mutex  sync.Mutex
+	routes map[string]*sampledRoute
+}
+
+type sampledRoute struct {
+	bodies  int
+	samples []any
+	schema  *inferredSchema
+}
+
+// SampledRoute is what a BodySampler learned about the request bodies of a route
+type SampledRoute struct {
+	// Bodies is the amount of bodies the schema was inferred from
+	Bodies  int            `json:"bodies"`
+	Schema  map[string]any `json:"schema"`
+	Samples []any          `json:"samples"`
+}
+
+// Middleware samples the json request bodies. Register it globally with Use.
+func (s *BodySampler) Middleware(request Request, next Response) Response {
+	if request.Request.Body == nil || request.Pattern() == "" || !isJsonContentType(request.Request.Header.Get(header.ContentType)) {
+		return next
+	}
+	limit := s.MaxBodySize
+	if limit <= 0 {
+		limit = 64 << 10
+	}
+	data, err := io.ReadAll(io.LimitReader(request.Request.Body, limit+1))
+	// the endpoint reads the body as if it was never sampled
+	request.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), request.Request.Body), request.Request.Body}
+	if err != nil || int64(len(data)) > limit {
+		return next
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var body any
+	if decoder.Decode(&body) != nil {
+		return next
+	}
+	s.record(request.Method+" "+request.Pattern(), body)
+	return next
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func isJsonContentType(contentType string) bool {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	contentType = strings.TrimSpace(strings.ToLower(contentType))
+	return contentType == ContentTypeApplicationJson || strings.HasSuffix(contentType, "+json")
+}
+
+func (s *BodySampler) record(route string, body any) {
+	maxSamples := s.MaxSamples
+	if maxSamples <= 0 {
+		maxSamples = 10
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.routes == nil {
+		s.routes = map[string]*sampledRoute{}
+	}
+	sampled, ok := s.routes[route]
+	if !ok {
+		sampled = &sampledRoute{schema: &inferredSchema{}}
+		s.routes[route] = sampled
+	}
+	sampled.bodies++
+	sampled.schema.merge(body)
+	if len(sampled.samples) < maxSamples {
+		sampled.samples = append(sampled.samples, s.redact(body))
+	}
+}
+
+// redact returns a copy of the value, in which the values of the redacted fields are replaced
+func (s *BodySampler) redact(value any) any {
+	redact := s.Redact
+	if redact == nil {
+		redact = DefaultRedactedFields
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, field := range v {
+			redacted[key] = s.redact(field)
+			lower := strings.ToLower(key)
+			for _, part := range redact {
+				if strings.Contains(lower, strings.ToLower(part)) {
+					redacted[key] = Redacted
+					break
+				}
+			}
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = s.redact(item)
+		}
+		return redacted
+	}
+	return value
+}
+
+// Routes returns the samples and inferred schemas per route, like "POST /users"
+func (s *BodySampler) Routes() map[string]SampledRoute {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	routes := make(map[string]SampledRoute, len(s.routes))
+	for route, sampled := range s.routes {
+		routes[route] = SampledRoute{
+			Bodies:  sampled.bodies,
+			Schema:  sampled.schema.jsonSchema(),
+			Samples: append([]any(nil), sampled.samples...),
+		}
+	}
+	return routes
+}
+
+// Endpoint lists the Routes as json
+func (s *BodySampler) Endpoint(request Request) Response {
+	return Json(status.OK, s.Routes())
+}
+
+// inferredSchema is the schema of all values merged into it
+type inferredSchema struct {
+	// values is the amount of values merged, to tell whether a property is required
+	values     int
+	types      map[string]bool
+	objects    int
+	properties map[string]*inferredSchema
+	items      *inferredSchema
+	// strings is the amount of strings merged, and formats how many of them had a format
+	strings int
+	formats map[string]int
+}
+
+func (s *inferredSchema) merge(value any) {
+	s.values++
+	if s.types == nil {
+		s.types = map[string]bool{}
+	}
+	switch v := value.(type) {
+	case nil:
+		s.types["null"] = true
+	case bool:
+		s.types["boolean"] = true
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			s.types["integer"] = true
+		} else {
+			s.types["number"] = true
+		}
+	case string:
+		s.types["string"] = true
+		s.strings++
+		if s.formats == nil {
+			s.formats = map[string]int{}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			s.formats["date-time"]++
+		} else if UUID(v) {
+			s.formats["uuid"]++
+		}
+	case []any:
+		s.types["array"] = true
+		if s.items == nil {
+			s.items = &inferredSchema{}
+		}
+		for _, item := range v {
+			s.items.merge(item)
+		}
+	case map[string]any:
+		s.types["object"] = true
+		s.objects++
+		if s.properties == nil {
+			s.properties = map[string]*inferredSchema{}
+		}
+		for key, property := range v {
+			if s.properties[key] == nil {
+				s.properties[key] = &inferredSchema{}
+			}
+			s.properties[key].merge(property)
+		}
+	}
+}
+
+// jsonSchema returns the schema. Properties are required, if every object had
+// them, and strings get a format, if all of them had it.
+func (s *inferredSchema) jsonSchema() map[string]any {
+	schema := map[string]any{}
+	types := make([]string, 0, len(s.types))
+	for t := range s.types {
+		// integers are numbers as well
+		if t != "integer" || !s.types["number"] {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	switch len(types) {
+	case 0:
+		// only empty arrays were merged, so the items can be anything
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+
+	if s.objects > 0 {
+		properties := map[string]any{}
+		var required []string
+		for key, property := range s.properties {
+			properties[key] = property.jsonSchema()
+			if property.values == s.objects {
+				required = append(required, key)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+	}
+	if s.items != nil {
+		schema["items"] = s.items.jsonSchema()
+	}
+	for format, count := range s.formats {
+		if count == s.strings {
+			schema["format"] = format
+		}
+	}
+	return schema
+}
@@ -0,0 +1,103 @@
+package there
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestBodySampler(t *testing.T) {
+	sampler := &BodySampler{MaxSamples: 2}
+	router := NewRouter()
+	router.Use(sampler.Middleware)
+	router.Post("/users/{id}", func(request Request) Response {
+		var body map[string]any
+		if err := request.Body.BindJson(&body); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		return String(status.OK, body["name"].(string))
+	})
+	router.Get("/debug/bodies", sampler.Endpoint)
+
+	for _, body := range []string{
+		`{"name":"John","age":42,"password":"hunter2","tags":["a"],"created":"2025-01-02T03:04:05Z"}`,
+		`{"name":"Jane","age":41.5,"password":"secret","tags":[],"id":"01966c2e-5ab4-7c3b-9d5e-2f6a1c0b8e47"}`,
+		`{"name":"Joe","age":null,"tags":[{"apiToken":"abc"}]}`,
+	} {
+		request := httptest.NewRequest(MethodPost, "/users/1", strings.NewReader(body))
+		request.Header.Set(header.ContentType, ContentTypeApplicationJson)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != status.OK {
+			t.Fatalf("expected the endpoint to read the sampled body, got %v %v", recorder.Code, recorder.Body.String())
+		}
+	}
+	// bodies of other content types are not sampled
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodPost, "/users/1", strings.NewReader(`{"name":"plain"}`)))
+
+	sampled, ok := sampler.Routes()["POST /users/{id}"]
+	if !ok || sampled.Bodies != 3 || len(sampled.Samples) != 2 {
+		t.Fatalf("expected 3 bodies and 2 samples, got %+v", sampler.Routes())
+	}
+	if sample := sampled.Samples[0].(map[string]any); sample["password"] != Redacted || sample["name"] != "John" {
+		t.Errorf("expected the password to be redacted, got %v", sample)
+	}
+
+	schema, _ := json.Marshal(sampled.Schema)
+	var actual map[string]any
+	_ = json.Unmarshal(schema, &actual)
+	var expected map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["age", "name", "tags"],
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": ["null", "number"]},
+			"password": {"type": "string"},
+			"created": {"type": "string", "format": "date-time"},
+			"id": {"type": "string", "format": "uuid"},
+			"tags": {"type": "array", "items": {
+				"type": ["object", "string"],
+				"properties": {"apiToken": {"type": "string"}},
+				"required": ["apiToken"]
+			}}
+		}
+	}`), &expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected schema %s", schema)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/debug/bodies", nil))
+	if recorder.Code != status.OK || !strings.Contains(recorder.Body.String(), `"POST /users/{id}"`) || strings.Contains(recorder.Body.String(), "hunter2") {
+		t.Errorf("unexpected listing %v", recorder.Body.String())
+	}
+}
+
+func TestBodySamplerMaxBodySize(t *testing.T) {
+	sampler := &BodySampler{MaxBodySize: 8}
+	router := NewRouter()
+	router.Use(sampler.Middleware)
+	router.Post("/", func(request Request) Response {
+		body, _ := request.Body.ToString()
+		return String(status.OK, body)
+	})
+
+	body := `{"name":"too long"}`
+	request := httptest.NewRequest(MethodPost, "/", strings.NewReader(body))
+	request.Header.Set(header.ContentType, ContentTypeApplicationJson+"; charset=utf-8")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Body.String() != body {
+		t.Errorf("expected the complete body, got %v", recorder.Body.String())
+	}
+	if len(sampler.Routes()) != 0 {
+		t.Errorf("expected the body not to be sampled, got %v", sampler.Routes())
+	}
+}
@@ -0,0 +1,102 @@
+package there
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorRouteDisabled is served, if a disabled route answers with StatusServiceUnavailable
+var ErrorRouteDisabled = errors.New("route is currently disabled")
+
+// routeToggle decides, whether a route is served. It is shared by all methods
+// and aliases of the route.
+type routeToggle struct {
+	disabled atomic.Bool
+	// from and until limit the time window the route is served in, if not zero
+	from, until time.Time
+	// code is either StatusNotFound or StatusServiceUnavailable
+	code int
+	now  func() time.Time
+}
+
+func (t *routeToggle) enabled() bool {
+	if t.disabled.Load() {
+		return false
+	}
+	if t.from.IsZero() && t.until.IsZero() {
+		return true
+	}
+	now := t.now()
+	return (t.from.IsZero() || !now.Before(t.from)) && (t.until.IsZero() || now.Before(t.until))
+}
+
+// endpoint is served instead of the actual Endpoint, while the route is disabled
+func (t *routeToggle) endpoint(router *Router) Endpoint {
+	if t.code == status.NotFound {
+		return router.Configuration.RouteNotFoundHandler
+	}
+	return func(request Request) Response {
+		var retryAfter time.Duration
+		if now := t.now(); !t.disabled.Load() && now.Before(t.from) {
+			retryAfter = t.from.Sub(now)
+		}
+		return RetryAfter(retryAfter, Error(t.code, ErrorRouteDisabled))
+	}
+}
+
+// toggle returns the routeToggle of the route and creates it, if necessary
+func (route *Route) toggle() *routeToggle {
+	if route.routeToggle == nil {
+		route.routeToggle = &routeToggle{code: status.NotFound, now: time.Now}
+		for _, endpoint := range route.endpoints() {
+			endpoint.toggle = route.routeToggle
+		}
+	}
+	return route.routeToggle
+}
+
+// Disable stops serving the route at runtime. Requests are answered like
+// unknown routes, or with StatusServiceUnavailable if configured with
+// WhenDisabled. Keep the returned builder as a handle to toggle the route later.
+//
+//	beta := router.Get("/beta", Beta)
+//	beta.Disable()
+//	// ...
+//	beta.Enable()
+func (route *Route) Disable() {
+	route.toggle().disabled.Store(true)
+}
+
+// Enable serves a route again, that was disabled with Disable. The Schedule still applies.
+func (route *Route) Enable() {
+	route.toggle().disabled.Store(false)
+}
+
+// Enabled reports, whether the route is currently served
+func (route *Route) Enabled() bool {
+	return route.routeToggle == nil || route.routeToggle.enabled()
+}
+
+// Schedule serves the route only from the first until the second point in time,
+// like for launch embargoes or temporary campaigns. A zero time leaves the
+// respective side of the window open.
+//
+//	router.Get("/sale", Sale).Schedule(launch, launch.Add(48*time.Hour))
+func (group *RouteRouteGroupBuilder) Schedule(from, until time.Time) *RouteRouteGroupBuilder {
+	group.assert(from.IsZero() || until.IsZero() || from.Before(until), "schedule must start before it ends")
+	toggle := group.toggle()
+	toggle.from, toggle.until = from, until
+	return group
+}
+
+// WhenDisabled sets the status code served while the route is disabled or outside
+// of its Schedule. Either StatusNotFound, the default, to hide the route, or
+// StatusServiceUnavailable to announce a temporary outage.
+func (group *RouteRouteGroupBuilder) WhenDisabled(code int) *RouteRouteGroupBuilder {
+	group.assert(code == status.NotFound || code == status.ServiceUnavailable, "disabled routes must answer with 404 or 503")
+	group.toggle().code = code
+	return group
+}
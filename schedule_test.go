@@ -0,0 +1,80 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRouteToggle(t *testing.T) {
+	router := NewRouter()
+	unauthorized := func(request Request, next Response) Response {
+		return Status(status.Unauthorized)
+	}
+	beta := router.Get("/beta", func(request Request) Response {
+		return String(status.OK, "beta")
+	}).With(unauthorized)
+	maintenance := router.Get("/maintenance", func(request Request) Response {
+		return String(status.OK, "ok")
+	}).WhenDisabled(status.ServiceUnavailable)
+
+	serve := func(route string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		return recorder.Code
+	}
+
+	if code := serve("/beta"); code != status.Unauthorized {
+		t.Errorf("expected %v, got %v", status.Unauthorized, code)
+	}
+	beta.Disable()
+	maintenance.Disable()
+	if beta.Enabled() {
+		t.Errorf("expected the route to be disabled")
+	}
+	if code := serve("/beta"); code != status.NotFound {
+		t.Errorf("expected %v, got %v", status.NotFound, code)
+	}
+	if code := serve("/maintenance"); code != status.ServiceUnavailable {
+		t.Errorf("expected %v, got %v", status.ServiceUnavailable, code)
+	}
+	maintenance.Enable()
+	if code := serve("/maintenance"); code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, code)
+	}
+}
+
+func TestRouteSchedule(t *testing.T) {
+	router := NewRouter()
+	launch := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	sale := router.Get("/sale", func(request Request) Response {
+		return String(status.OK, "sale")
+	}).Schedule(launch, launch.Add(48*time.Hour))
+
+	now := launch.Add(-time.Minute)
+	sale.routeToggle.now = func() time.Time { return now }
+
+	serve := func() int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/sale", nil))
+		return recorder.Code
+	}
+
+	tests := []struct {
+		now  time.Time
+		code int
+	}{
+		{launch.Add(-time.Minute), status.NotFound},
+		{launch, status.OK},
+		{launch.Add(47 * time.Hour), status.OK},
+		{launch.Add(48 * time.Hour), status.NotFound},
+	}
+	for _, test := range tests {
+		now = test.now
+		if code := serve(); code != test.code {
+			t.Errorf("at %v: expected %v, got %v", test.now, test.code, code)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package there
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// schemaDepth limits how deep typeSchema descends, so recursive types terminate
+const schemaDepth = 16
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// schemaOf describes the type of v as JSON Schema, as far as it can be derived
+// from the Go type and its json struct tags. Returns nil, if v is nil.
+func schemaOf(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	return typeSchema(reflect.TypeOf(v), 0)
+}
+
+func typeSchema(t reflect.Type, depth int) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if depth >= schemaDepth {
+		return map[string]any{}
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t == rawMessageType {
+		// any json value
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), depth+1)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), depth+1)}
+	case reflect.Struct:
+		properties := map[string]any{}
+		var required []string
+		structSchema(t, depth, properties, &required)
+		schema := map[string]any{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema adds the properties of the struct to properties. Fields without
+// omitempty are treated as required. Embedded structs are flattened with the
+// rules of encoding/json, which also stops at recursively embedded structs.
+func structSchema(t reflect.Type, depth int, properties map[string]any, required *[]string) {
+	for _, field := range jsonStructFields(t) {
+		properties[field.name] = typeSchema(field.typ, depth+1)
+		if !field.omitEmpty {
+			*required = append(*required, field.name)
+		}
+	}
+}
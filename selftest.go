@@ -0,0 +1,150 @@
+package there
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// SelfTestCase is a request, that is served in-process by SelfTest
+type SelfTestCase struct {
+	// Name identifies the case in errors. Defaults to the method and path.
+	Name   string
+	Method string
+	// Path including the query, without the BasePath
+	Path   string
+	Header http.Header
+	Body   []byte
+	// Status is the expected status code. If zero, any status below 400 is accepted.
+	Status int
+	// Check can inspect the response further. A returned error fails the case.
+	Check func(response *httptest.ResponseRecorder) error
+}
+
+func (c SelfTestCase) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Method + " " + c.Path
+}
+
+// SelfTestError describes a failed SelfTestCase
+type SelfTestError struct {
+	Case   string
+	Status int
+	// Body of the response, shortened to 256 bytes
+	Body string
+	Err  error
+}
+
+func (e *SelfTestError) Error() string {
+	message := fmt.Sprintf("self-test %q failed with status %d", e.Case, e.Status)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+	return message
+}
+
+func (e *SelfTestError) Unwrap() error {
+	return e.Err
+}
+
+// SelfTest serves the given cases, or the SelfTests of the RouterConfiguration
+// if none are given, in-process through the whole router, including all
+// middlewares. Use it to warm up caches and to fail fast on missing templates
+// or misconfigured dependencies before the service is marked as ready.
+// Panics of endpoints fail the respective case.
+//
+// If all cases pass, the router is marked as ready, see Readiness. Otherwise,
+// the errors of all failed cases are joined.
+//
+//	router.Configuration.SelfTests = []there.SelfTestCase{
+//		{Method: there.MethodGet, Path: "/"},
+//		{Method: there.MethodGet, Path: "/users/1", Status: status.OK},
+//	}
+func (router *Router) SelfTest(cases ...SelfTestCase) error {
+	if len(cases) == 0 {
+		cases = router.Configuration.SelfTests
+	}
+	var errs []error
+	for _, c := range cases {
+		if err := router.selfTest(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	router.ready.Store(true)
+	return nil
+}
+
+func (router *Router) selfTest(c SelfTestCase) (err error) {
+	method := c.Method
+	if method == "" {
+		method = MethodGet
+	}
+	request := httptest.NewRequest(method, strings.TrimSuffix(router.Configuration.BasePath, "/")+c.Path, bytes.NewReader(c.Body))
+	for name, values := range c.Header {
+		request.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	// panics recovered by the RecoverHandler are reported here
+	var recovered any
+	request = request.WithContext(context.WithValue(request.Context(), recoveredPanicKey{}, &recovered))
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &SelfTestError{Case: c.name(), Status: status.InternalServerError, Err: fmt.Errorf("panic: %v", recovered)}
+		}
+	}()
+	router.ServeHTTP(recorder, request)
+	if recovered != nil {
+		return &SelfTestError{Case: c.name(), Status: recorder.Code, Err: fmt.Errorf("panic: %v", recovered)}
+	}
+
+	failed := &SelfTestError{Case: c.name(), Status: recorder.Code, Body: recorder.Body.String()}
+	if len(failed.Body) > 256 {
+		failed.Body = failed.Body[:256]
+	}
+	if c.Status != 0 && recorder.Code != c.Status {
+		failed.Err = fmt.Errorf("expected status %d", c.Status)
+		return failed
+	}
+	if c.Status == 0 && recorder.Code >= 400 {
+		return failed
+	}
+	if c.Check != nil {
+		if err := c.Check(recorder); err != nil {
+			failed.Err = err
+			return failed
+		}
+	}
+	return nil
+}
+
+// Ready reports, whether the router passed its SelfTest. Listen and ListenToTLS
+// run the SelfTest before they accept connections.
+func (router *Router) Ready() bool {
+	return router.ready.Load()
+}
+
+// Readiness is an Endpoint for readiness probes. It answers with StatusOK once
+// the router is Ready, and with StatusServiceUnavailable before.
+//
+//	router.Get("/ready", router.Readiness)
+func (router *Router) Readiness(request Request) Response {
+	if !router.Ready() {
+		return RetryAfter(0, Status(status.ServiceUnavailable))
+	}
+	return Status(status.OK)
+}
@@ -0,0 +1,64 @@
+package there
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+func TestSelfTest(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		return String(status.OK, "home")
+	})
+	router.Get("/broken", func(request Request) Response {
+		return Html(status.OK, "./missing.html", nil)
+	})
+	router.Get("/panic", func(request Request) Response {
+		panic("dependency missing")
+	})
+	router.Get("/ready", router.Readiness)
+
+	ready := func() int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/ready", nil))
+		return recorder.Code
+	}
+	if code := ready(); code != status.ServiceUnavailable {
+		t.Errorf("expected %v, got %v", status.ServiceUnavailable, code)
+	}
+
+	err := router.SelfTest(
+		SelfTestCase{Path: "/"},
+		SelfTestCase{Name: "template", Path: "/broken"},
+		SelfTestCase{Path: "/panic"},
+	)
+	var selfTestError *SelfTestError
+	if !errors.As(err, &selfTestError) || selfTestError.Case != "template" {
+		t.Fatalf("expected the template case to fail, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "dependency missing") {
+		t.Errorf("expected the panic to be reported, got %v", err)
+	}
+	if router.Ready() {
+		t.Errorf("router must not be ready after a failed self-test")
+	}
+
+	router.Configuration.SelfTests = []SelfTestCase{
+		{Path: "/", Status: status.OK, Check: func(response *httptest.ResponseRecorder) error {
+			if response.Body.String() != "home" {
+				return errors.New("unexpected body")
+			}
+			return nil
+		}},
+	}
+	if err := router.SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+	if code := ready(); code != status.OK {
+		t.Errorf("expected %v, got %v", status.OK, code)
+	}
+}
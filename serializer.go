@@ -0,0 +1,123 @@
+package there
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// ErrorUnsupportedContentType is returned by BodyReader.Bind, if no serializer
+// is registered for the Content-Type of the request
+var ErrorUnsupportedContentType = errors.New("unsupported content type")
+
+// Serializer encodes and decodes bodies of a content type. Libraries like
+// jsoniter satisfy it as they are:
+//
+//	router.Configuration.RegisterSerializer(there.ContentTypeApplicationJson, jsoniter.ConfigCompatibleWithStandardLibrary)
+type Serializer interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// SerializerFuncs adapts a pair of functions to a Serializer
+//
+//	router.Configuration.RegisterSerializer("application/msgpack", there.SerializerFuncs{
+//		MarshalFunc:   msgpack.Marshal,
+//		UnmarshalFunc: msgpack.Unmarshal,
+//	})
+type SerializerFuncs struct {
+	MarshalFunc   func(v any) ([]byte, error)
+	UnmarshalFunc func(data []byte, v any) error
+}
+
+func (s SerializerFuncs) Marshal(v any) ([]byte, error) {
+	return s.MarshalFunc(v)
+}
+
+func (s SerializerFuncs) Unmarshal(data []byte, v any) error {
+	return s.UnmarshalFunc(data, v)
+}
+
+// RegisterSerializer registers the serializer for the content type, like
+// "application/msgpack". Registering one for ContentTypeApplicationJson
+// replaces encoding/json in Json, Error and BindJson.
+func (c *RouterConfiguration) RegisterSerializer(contentType string, serializer Serializer) {
+	if c.Serializers == nil {
+		c.Serializers = map[string]Serializer{}
+	}
+	c.Serializers[contentType] = serializer
+}
+
+// serializerOf returns the serializer the Router serving the request registered
+// for the content type. Nil, if there is none.
+func serializerOf(r *http.Request, contentType string) Serializer {
+	router := routerOf(r)
+	if router == nil {
+		return nil
+	}
+	return router.Configuration.Serializers[contentType]
+}
+
+// Serialize marshals the data with the serializer registered for the content
+// type and writes the result with the given status code to the
+// http.ResponseWriter. Auto also responds with the registered serializers.
+//
+//	return there.Serialize(status.OK, "application/msgpack", user)
+//
+// If no serializer is registered or marshalling fails, then an Error with
+// StatusInternalServerError is returned.
+func Serialize(code int, contentType string, data any) Response {
+	return serializedResponse{code: code, contentType: contentType, data: data}
+}
+
+type serializedResponse struct {
+	code        int
+	contentType string
+	data        any
+}
+
+func (s serializedResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	serializer := serializerOf(r, s.contentType)
+	if serializer == nil {
+		Error(status.InternalServerError, fmt.Errorf("serialize: no serializer registered for %v", s.contentType)).ServeHTTP(rw, r)
+		return
+	}
+	data, err := serializer.Marshal(s.data)
+	if err != nil {
+		Error(status.InternalServerError, fmt.Errorf("serialize: %v: %v", s.contentType, err)).ServeHTTP(rw, r)
+		return
+	}
+	rw.Header().Set(header.ContentType, s.contentType)
+	rw.WriteHeader(s.code)
+	if _, err := rw.Write(data); err != nil {
+		log.Printf("serializedResponse: ServeHttp write failed: %v", err)
+	}
+}
+
+// Bind unmarshalls the body into dest with the Codec or serializer registered
+// for the Content-Type of the request. Json, the default if no Content-Type is
+// set, is decoded like with BindJson, and xml like with BindXml. If the body is invalid, then a *BindingError is
+// returned, and ErrorUnsupportedContentType, if no serializer is registered.
+func (read BodyReader) Bind(dest any) error {
+	contentType := ContentTypeApplicationJson
+	if value := read.request.Header.Get(header.ContentType); value != "" {
+		parsed, _, err := mime.ParseMediaType(value)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrorUnsupportedContentType, value)
+		}
+		contentType = parsed
+	}
+	if contentType == ContentTypeApplicationJson {
+		return read.BindJson(dest)
+	}
+	serializer := codecOf(read.request, contentType)
+	if serializer == nil {
+		return fmt.Errorf("%w: %v", ErrorUnsupportedContentType, contentType)
+	}
+	return read.bind(dest, serializer.Unmarshal)
+}
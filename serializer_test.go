@@ -0,0 +1,114 @@
+package there
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// upperJson is a json serializer, that can be told apart from encoding/json
+type upperJson struct{}
+
+func (upperJson) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return []byte(strings.ToUpper(string(data))), err
+}
+
+func (upperJson) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal([]byte(strings.ToLower(string(data))), v)
+}
+
+// keyValue serializes a map[string]string as "key=value" lines
+var keyValue = SerializerFuncs{
+	MarshalFunc: func(v any) ([]byte, error) {
+		var b strings.Builder
+		for key, value := range v.(map[string]string) {
+			b.WriteString(key + "=" + value + "\n")
+		}
+		return []byte(b.String()), nil
+	},
+	UnmarshalFunc: func(data []byte, v any) error {
+		dest := v.(*map[string]string)
+		*dest = map[string]string{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return fmt.Errorf("line %q is missing a =", line)
+			}
+			(*dest)[key] = value
+		}
+		return nil
+	},
+}
+
+func TestSerializers(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.RegisterSerializer(ContentTypeApplicationJson, upperJson{})
+	router.Configuration.RegisterSerializer("text/key-value", keyValue)
+
+	router.Get("/json", func(request Request) Response {
+		return Json(status.OK, map[string]string{"name": "there"})
+	})
+	router.Get("/error", func(request Request) Response {
+		return Error(status.BadRequest, errors.New("invalid"))
+	})
+	router.Get("/auto", func(request Request) Response {
+		return Auto(status.OK, map[string]string{"name": "there"})
+	})
+	router.Post("/bind", func(request Request) Response {
+		var body map[string]string
+		if err := request.Body.Bind(&body); err != nil {
+			if errors.Is(err, ErrorUnsupportedContentType) {
+				return Error(status.UnsupportedMediaType, err)
+			}
+			return Error(status.BadRequest, err)
+		}
+		return String(status.OK, body["name"])
+	})
+
+	serve := func(method, route, contentType, accept, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, route, strings.NewReader(body))
+		if contentType != "" {
+			request.Header.Set(header.ContentType, contentType)
+		}
+		if accept != "" {
+			request.Header.Set(header.RequestAccept, accept)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if body := serve(MethodGet, "/json", "", "", "").Body.String(); body != `{"NAME":"THERE"}` {
+		t.Errorf("expected the json serializer, got %v", body)
+	}
+	if body := serve(MethodGet, "/error", "", "", "").Body.String(); body != `{"ERROR":"INVALID"}` {
+		t.Errorf("expected the json serializer, got %v", body)
+	}
+
+	recorder := serve(MethodGet, "/auto", "", "text/key-value", "")
+	if recorder.Body.String() != "name=there\n" || recorder.Header().Get(header.ContentType) != "text/key-value" {
+		t.Errorf("unexpected response %v %v", recorder.Header(), recorder.Body.String())
+	}
+
+	if body := serve(MethodPost, "/bind", "", "", `{"NAME":"JSON"}`).Body.String(); body != "json" {
+		t.Errorf("expected the json serializer to bind, got %v", body)
+	}
+	if body := serve(MethodPost, "/bind", "text/key-value; charset=utf-8", "", "name=kv").Body.String(); body != "kv" {
+		t.Errorf("expected the key-value serializer to bind, got %v", body)
+	}
+	recorder = serve(MethodPost, "/bind", "text/key-value", "", "invalid")
+	if recorder.Code != status.BadRequest {
+		t.Errorf("expected 400, got %v", recorder.Code)
+	}
+	recorder = serve(MethodPost, "/bind", "application/msgpack", "", "")
+	if recorder.Code != status.UnsupportedMediaType {
+		t.Errorf("expected 415, got %v", recorder.Code)
+	}
+}
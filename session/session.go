@@ -0,0 +1,339 @@
+// Package session provides cookie based sessions. The values of a session are
+// encrypted and authenticated with AES-GCM, so clients can neither read nor
+// alter them.
+//
+//	router.Use(session.Middleware(session.Configuration{Key: key}))
+//	router.Get("/visits", func(request there.Request) there.Response {
+//		s := session.Of(request)
+//		visits, _ := strconv.Atoi(s.Get("visits"))
+//		s.Set("visits", strconv.Itoa(visits+1))
+//		return there.String(status.OK, strconv.Itoa(visits))
+//	})
+package session
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/header"
+)
+
+var (
+	// ErrorInvalidKey is returned, if the key is not 16, 24 or 32 bytes long
+	ErrorInvalidKey = errors.New("session key must be 16, 24 or 32 bytes long")
+	// ErrorInvalidCookie is returned, if the cookie was altered, expired or encrypted with another key
+	ErrorInvalidCookie = errors.New("invalid session cookie")
+	// ErrorCookieTooLarge is logged, if the encrypted session exceeds the
+	// maxCookieSize, as browsers would drop the cookie silently
+	ErrorCookieTooLarge = errors.New("session cookie too large")
+)
+
+// maxCookieSize is the size of a cookie with its attributes, that all browsers store
+const maxCookieSize = 4096
+
+// Configuration of the session cookie
+type Configuration struct {
+	// Key encrypts the cookie. It must be 16, 24 or 32 bytes long, to select
+	// AES-128, AES-192 or AES-256. Keep it secret and stable across restarts.
+	Key []byte
+	// Name of the cookie. Defaults to "there_session".
+	Name string
+	// Path of the cookie. Defaults to "/".
+	Path   string
+	Domain string
+	// MaxAge is the absolute lifetime of a session, counted from its creation or
+	// the last Renew. Defaults to 24 hours.
+	MaxAge time.Duration
+	// IdleTimeout ends sessions, which were not used for the duration. Every
+	// request then rolls the expiry of the cookie forward, but never beyond
+	// the MaxAge. Zero disables the idle timeout.
+	IdleTimeout time.Duration
+	// SameSite of the cookie. Defaults to http.SameSiteLaxMode. With
+	// http.SameSiteNoneMode the cookie is always marked as Secure, as browsers
+	// reject it otherwise.
+	SameSite http.SameSite
+	// Secure always restricts the cookie to https. Otherwise, it is restricted,
+	// if the request itself was made over https.
+	Secure bool
+	// TrustForwardedProto detects https behind a reverse proxy with the
+	// X-Forwarded-Proto header. Only enable it, if the proxy sets the header.
+	TrustForwardedProto bool
+}
+
+func (c *Configuration) defaults() {
+	if c.Name == "" {
+		c.Name = "there_session"
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 24 * time.Hour
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+}
+
+// secure reports whether the cookie should only be sent over https
+func (c *Configuration) secure(r *http.Request) bool {
+	if c.Secure || c.SameSite == http.SameSiteNoneMode || r.TLS != nil {
+		return true
+	}
+	return c.TrustForwardedProto && strings.EqualFold(r.Header.Get(header.RequestXForwardedProto), "https")
+}
+
+// Session holds the values of a client. Changes are sent to the client, when
+// the response is written.
+type Session struct {
+	id      string
+	values  map[string]string
+	created time.Time
+	seen    time.Time
+	changed bool
+	cleared bool
+}
+
+func newSession() *Session {
+	now := time.Now()
+	return &Session{id: newID(), values: map[string]string{}, created: now, seen: now}
+}
+
+// ID identifies the session. It changes with Renew.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Get returns the value of the key or an empty string
+func (s *Session) Get(key string) string {
+	return s.values[key]
+}
+
+// Set stores the value under the key
+func (s *Session) Set(key, value string) {
+	s.values[key] = value
+	s.changed = true
+}
+
+// Delete removes the key
+func (s *Session) Delete(key string) {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+// Clear removes all values and deletes the cookie
+func (s *Session) Clear() {
+	s.values = map[string]string{}
+	s.changed = true
+	s.cleared = true
+}
+
+// Renew issues a new ID and restarts the MaxAge, while keeping the values.
+// Call it whenever the privileges of the client change, like on login, so a
+// session planted by an attacker before can not be used afterwards.
+func (s *Session) Renew() {
+	now := time.Now()
+	s.id = newID()
+	s.created = now
+	s.seen = now
+	s.changed = true
+	s.cleared = false
+}
+
+type sessionKey struct{}
+
+// Of returns the Session of the request. Requires the Middleware, otherwise
+// an empty Session is returned, which is never saved.
+func Of(request there.Request) *Session {
+	s, ok := request.Context().Value(sessionKey{}).(*Session)
+	if !ok {
+		return newSession()
+	}
+	return s
+}
+
+// Middleware loads the Session from the cookie and saves it, before the
+// response is written. Invalid or expired cookies result in an empty Session.
+// It panics, if the Key has an invalid length.
+func Middleware(configuration Configuration) there.Middleware {
+	configuration.defaults()
+	codec, err := newCodec(configuration.Key)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(request there.Request, next there.Response) there.Response {
+		s := newSession()
+		if cookie, err := request.Request.Cookie(configuration.Name); err == nil {
+			if loaded, err := codec.decode(cookie.Value, configuration); err == nil {
+				s = loaded
+			}
+		}
+		if configuration.IdleTimeout > 0 && len(s.values) > 0 {
+			s.seen = time.Now()
+			s.changed = true
+		}
+		request.WithContext(context.WithValue(request.Context(), sessionKey{}, s))
+
+		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			writer := &sessionWriter{ResponseWriter: rw, save: func() {
+				save(rw, r, s, codec, configuration)
+			}}
+			next.ServeHTTP(writer, r)
+			writer.saveOnce()
+		})
+	}
+}
+
+// save sets the cookie, if the session changed. A cookie, that exceeds the
+// maxCookieSize, is not set, so the client keeps the previous one.
+func save(rw http.ResponseWriter, r *http.Request, s *Session, codec *codec, configuration Configuration) {
+	if !s.changed {
+		return
+	}
+	cookie := &http.Cookie{
+		Name:     configuration.Name,
+		Path:     configuration.Path,
+		Domain:   configuration.Domain,
+		Secure:   configuration.secure(r),
+		HttpOnly: true,
+		SameSite: configuration.SameSite,
+	}
+	if s.cleared && len(s.values) == 0 {
+		cookie.MaxAge = -1
+		http.SetCookie(rw, cookie)
+		return
+	}
+	value, err := codec.encode(s)
+	if err != nil {
+		log.Printf("session: encode failed: %v", err)
+		return
+	}
+	cookie.Value = value
+	cookie.Expires = s.expires(configuration)
+	if size := len(cookie.String()); size > maxCookieSize {
+		log.Printf("session: %v: %d of %d bytes, store less values in the session", ErrorCookieTooLarge, size, maxCookieSize)
+		return
+	}
+	http.SetCookie(rw, cookie)
+}
+
+// expires returns the time the session ends, whichever policy is reached first
+func (s *Session) expires(configuration Configuration) time.Time {
+	expires := s.created.Add(configuration.MaxAge)
+	if configuration.IdleTimeout > 0 {
+		if idle := s.seen.Add(configuration.IdleTimeout); idle.Before(expires) {
+			return idle
+		}
+	}
+	return expires
+}
+
+// sessionWriter saves the session right before the headers are sent
+type sessionWriter struct {
+	http.ResponseWriter
+	save  func()
+	saved bool
+}
+
+func (w *sessionWriter) saveOnce() {
+	if !w.saved {
+		w.saved = true
+		w.save()
+	}
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	w.saveOnce()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.saveOnce()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap allows the http.ResponseController to access the original http.ResponseWriter
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type payload struct {
+	ID      string            `json:"i"`
+	Values  map[string]string `json:"v"`
+	Created int64             `json:"c"`
+	Seen    int64             `json:"s"`
+}
+
+// codec encrypts sessions into cookie values
+type codec struct {
+	aead cipher.AEAD
+}
+
+func newCodec(key []byte) (*codec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrorInvalidKey
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &codec{aead: aead}, nil
+}
+
+func (c *codec) encode(s *Session) (string, error) {
+	plain, err := json.Marshal(payload{ID: s.id, Values: s.values, Created: s.created.Unix(), Seen: s.seen.Unix()})
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(c.aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+// decode decrypts the cookie value and rejects sessions, that expired according
+// to the configuration, regardless of the expiry the client kept the cookie for
+func (c *codec) decode(value string, configuration Configuration) (*Session, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return nil, ErrorInvalidCookie
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrorInvalidCookie
+	}
+	var p payload
+	if err = json.Unmarshal(plain, &p); err != nil {
+		return nil, ErrorInvalidCookie
+	}
+	if p.Values == nil {
+		p.Values = map[string]string{}
+	}
+	s := &Session{id: p.ID, values: p.Values, created: time.Unix(p.Created, 0), seen: time.Unix(p.Seen, 0)}
+	if time.Now().After(s.expires(configuration)) {
+		return nil, ErrorInvalidCookie
+	}
+	return s, nil
+}
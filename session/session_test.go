@@ -0,0 +1,116 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestSession(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Middleware(Configuration{Key: []byte("0123456789abcdef")}))
+	router.Get("/set", func(request there.Request) there.Response {
+		Of(request).Set("user", "john")
+		return there.Status(status.OK)
+	})
+	router.Get("/get", func(request there.Request) there.Response {
+		return there.String(status.OK, Of(request).Get("user"))
+	})
+
+	serve := func(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(there.MethodGet, target, nil)
+		if cookie != nil {
+			request.AddCookie(cookie)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	cookies := serve("/set", nil).Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("expected a http only session cookie, got %v", cookies)
+	}
+	if recorder := serve("/get", cookies[0]); recorder.Body.String() != "john" {
+		t.Errorf("expected the stored value, got %v", recorder.Body.String())
+	}
+	if recorder := serve("/get", nil); len(recorder.Result().Cookies()) != 0 {
+		t.Errorf("unchanged sessions must not set a cookie")
+	}
+
+	tampered := *cookies[0]
+	tampered.Value = tampered.Value[:len(tampered.Value)-2] + "AA"
+	if recorder := serve("/get", &tampered); recorder.Body.String() != "" {
+		t.Errorf("tampered cookie was accepted: %v", recorder.Body.String())
+	}
+}
+
+func TestSessionHardening(t *testing.T) {
+	configuration := Configuration{
+		Key:                 []byte("0123456789abcdef"),
+		SameSite:            http.SameSiteStrictMode,
+		TrustForwardedProto: true,
+		MaxAge:              time.Hour,
+		IdleTimeout:         time.Minute,
+	}
+	router := there.NewRouter()
+	router.Use(Middleware(configuration))
+	var ids []string
+	router.Get("/login", func(request there.Request) there.Response {
+		s := Of(request)
+		ids = append(ids, s.ID())
+		s.Renew()
+		ids = append(ids, s.ID())
+		s.Set("user", "john")
+		return there.Status(status.OK)
+	})
+
+	request := httptest.NewRequest(there.MethodGet, "/login", nil)
+	request.Header.Set("X-Forwarded-Proto", "https")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].Secure || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected a secure strict cookie, got %v", cookies)
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("expected Renew to issue a new id, got %v", ids)
+	}
+	if expires := time.Until(cookies[0].Expires); expires > time.Minute || expires < 50*time.Second {
+		t.Errorf("expected the idle timeout to bound the expiry, got %v", expires)
+	}
+
+	codec, _ := newCodec(configuration.Key)
+	configuration.defaults()
+	idle := &Session{id: "id", values: map[string]string{"user": "john"}, created: time.Now(), seen: time.Now().Add(-2 * time.Minute)}
+	value, _ := codec.encode(idle)
+	if _, err := codec.decode(value, configuration); err == nil {
+		t.Errorf("expected the idle session to be rejected")
+	}
+	old := &Session{id: "id", values: map[string]string{}, created: time.Now().Add(-2 * time.Hour), seen: time.Now()}
+	value, _ = codec.encode(old)
+	if _, err := codec.decode(value, configuration); err == nil {
+		t.Errorf("expected the session beyond the max age to be rejected")
+	}
+}
+
+func TestSessionCookieTooLarge(t *testing.T) {
+	router := there.NewRouter()
+	router.Use(Middleware(Configuration{Key: []byte("0123456789abcdef")}))
+	router.Get("/", func(request there.Request) there.Response {
+		Of(request).Set("large", strings.Repeat("a", maxCookieSize))
+		return there.Status(status.OK)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(there.MethodGet, "/", nil))
+	if cookies := recorder.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("expected no cookie, that browsers would drop, got %v bytes", len(cookies[0].String()))
+	}
+}
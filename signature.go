@@ -0,0 +1,142 @@
+package there
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// ResponseSigner signs the signature base of a response
+type ResponseSigner interface {
+	// Algorithm is the name of the algorithm in the HTTP Signature Algorithms
+	// registry of RFC 9421, like "hmac-sha256"
+	Algorithm() string
+	Sign(base []byte) ([]byte, error)
+}
+
+type hmacSigner []byte
+
+func (s hmacSigner) Algorithm() string {
+	return "hmac-sha256"
+}
+
+func (s hmacSigner) Sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s)
+	mac.Write(base)
+	return mac.Sum(nil), nil
+}
+
+// HmacSigner signs responses with HMAC using SHA-256 and the shared key
+func HmacSigner(key []byte) ResponseSigner {
+	return hmacSigner(key)
+}
+
+type ed25519Signer ed25519.PrivateKey
+
+func (s ed25519Signer) Algorithm() string {
+	return "ed25519"
+}
+
+func (s ed25519Signer) Sign(base []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), base), nil
+}
+
+// Ed25519Signer signs responses with the private key, so clients only need the public key to verify them
+func Ed25519Signer(key ed25519.PrivateKey) ResponseSigner {
+	return ed25519Signer(key)
+}
+
+// SignatureConfiguration controls how Sign signs responses
+type SignatureConfiguration struct {
+	Signer ResponseSigner
+	// KeyID tells the clients, which key to verify the signature with
+	KeyID string
+	// Label of the signature. Defaults to "sig1".
+	Label string
+	// Headers are the response headers covered by the signature, besides the
+	// status code and the Content-Digest of the body. Headers missing in the
+	// response are left out.
+	Headers []string
+}
+
+// Sign wraps around your current Response and signs it as described in
+// RFC 9421, so clients can verify the integrity of the payload end-to-end. The
+// body is covered with a Content-Digest header, which is signed together with
+// the status code and the configured headers. The signature is sent in the
+// Signature and the signed components in the Signature-Input header:
+//
+//	Content-Digest: sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:
+//	Signature-Input: sig1=("@status" "content-digest" "content-type");created=1618884473;keyid="key-1";alg="hmac-sha256"
+//	Signature: sig1=:P0wLUszWQjoi54udOtydf9IWTfNhy+r53jGFj9XZuP4=:
+//
+// The response is buffered completely, to compute the digest before the headers are sent.
+func Sign(response Response, configuration SignatureConfiguration) Response {
+	if configuration.Label == "" {
+		configuration.Label = "sig1"
+	}
+	return &signedResponse{config: configuration, response: response}
+}
+
+type signedResponse struct {
+	config   SignatureConfiguration
+	response Response
+}
+
+func (s signedResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	writer := newBufferedResponseWriter(rw, r)
+	s.response.ServeHTTP(writer, r)
+
+	if err := s.sign(rw.Header(), writer.statusCode(), writer.body.Bytes()); err != nil {
+		log.Printf("signedResponse: signing failed: %v", err)
+	}
+
+	err := writer.flush()
+	if err != nil && !errors.Is(err, ErrorClientDisconnected) {
+		log.Printf("signedResponse: ServeHttp write failed: %v", err)
+	}
+}
+
+func (s signedResponse) sign(headers http.Header, code int, body []byte) error {
+	sum := sha256.Sum256(body)
+	headers.Set(header.ResponseContentDigest, "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+
+	components := []string{`"@status"`}
+	lines := []string{`"@status": ` + strconv.Itoa(code)}
+	for _, name := range append([]string{header.ResponseContentDigest}, s.config.Headers...) {
+		values := headers.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		name = strconv.Quote(strings.ToLower(name))
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.TrimSpace(value)
+		}
+		components = append(components, name)
+		lines = append(lines, name+": "+strings.Join(trimmed, ", "))
+	}
+
+	params := "(" + strings.Join(components, " ") + ");created=" + strconv.FormatInt(time.Now().Unix(), 10)
+	if s.config.KeyID != "" {
+		params += ";keyid=" + strconv.Quote(s.config.KeyID)
+	}
+	params += ";alg=" + strconv.Quote(s.config.Signer.Algorithm())
+	lines = append(lines, `"@signature-params": `+params)
+
+	signature, err := s.config.Signer.Sign([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	headers.Set(header.ResponseSignatureInput, s.config.Label+"="+params)
+	headers.Set(header.ResponseSignature, s.config.Label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
@@ -0,0 +1,75 @@
+package there
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestSign(t *testing.T) {
+	key := []byte("secret")
+	public, private, _ := ed25519.GenerateKey(nil)
+	signers := map[string]ResponseSigner{"/hmac": HmacSigner(key), "/ed25519": Ed25519Signer(private)}
+
+	router := NewRouter()
+	for route, signer := range signers {
+		router.Get(route, func(request Request) Response {
+			return Sign(String(status.OK, "hello"), SignatureConfiguration{
+				Signer:  signer,
+				KeyID:   "key-1",
+				Headers: []string{header.ContentType, "X-Missing"},
+			})
+		})
+	}
+
+	input := regexp.MustCompile(`^sig1=\("@status" "content-digest" "content-type"\);created=\d+;keyid="key-1";alg="([a-z0-9-]+)"$`)
+	for route := range signers {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, route, nil))
+		if recorder.Body.String() != "hello" {
+			t.Fatalf("unexpected body %v", recorder.Body.String())
+		}
+
+		headers := recorder.Header()
+		sum := sha256.Sum256([]byte("hello"))
+		digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+		if headers.Get(header.ResponseContentDigest) != digest {
+			t.Errorf("unexpected digest %v", headers.Get(header.ResponseContentDigest))
+		}
+		signatureInput := headers.Get(header.ResponseSignatureInput)
+		if !input.MatchString(signatureInput) {
+			t.Fatalf("unexpected signature input %v", signatureInput)
+		}
+
+		base := strings.Join([]string{
+			`"@status": 200`,
+			`"content-digest": ` + digest,
+			`"content-type": ` + headers.Get(header.ContentType),
+			`"@signature-params": ` + strings.TrimPrefix(signatureInput, "sig1="),
+		}, "\n")
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(headers.Get(header.ResponseSignature), "sig1=:"), ":"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var valid bool
+		if route == "/hmac" {
+			mac := hmac.New(sha256.New, key)
+			mac.Write([]byte(base))
+			valid = hmac.Equal(mac.Sum(nil), signature)
+		} else {
+			valid = ed25519.Verify(public, []byte(base), signature)
+		}
+		if !valid {
+			t.Errorf("%v: signature does not verify", route)
+		}
+	}
+}
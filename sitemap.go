@@ -0,0 +1,147 @@
+package there
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// sitemapExclude is the tag, that keeps a route out of the Sitemap
+const sitemapExclude = "sitemap:exclude"
+
+// SitemapURL is an entry of the Sitemap, see https://www.sitemaps.org/protocol.html
+type SitemapURL struct {
+	// Loc is the path or the absolute URL of the page
+	Loc          string
+	LastModified time.Time
+	// ChangeFrequency is "always", "hourly", "daily", "weekly", "monthly", "yearly" or "never"
+	ChangeFrequency string
+	// Priority between 0 and 1, relative to the other pages of the site, if positive
+	Priority float64
+}
+
+// Sitemap lists the pages of the site for crawlers. It contains every GET
+// route without wildcards, except the ones tagged with "sitemap:exclude", and
+// the URLs added to it. Routes with wildcards, like "/posts/{slug}", have to
+// be added with Dynamic.
+type Sitemap struct {
+	router  *Router
+	baseURL string
+	urls    []SitemapURL
+	dynamic []func(request Request) ([]SitemapURL, error)
+}
+
+// Sitemap serves a Sitemap for the site at the baseURL, like
+// "https://example.com", at /sitemap.xml. It is compressed, if the client
+// accepts gzip.
+//
+//	router.Sitemap("https://example.com").
+//		Dynamic(func(request there.Request) ([]there.SitemapURL, error) {
+//			return postURLs(request.Context())
+//		})
+//	router.Get("/admin", Admin).Tag("sitemap:exclude")
+func (router *Router) Sitemap(baseURL string) *Sitemap {
+	sitemap := &Sitemap{router: router, baseURL: strings.TrimSuffix(baseURL, "/")}
+	router.Get("/sitemap.xml", sitemap.Endpoint).Tag(sitemapExclude)
+	return sitemap
+}
+
+// Add adds fixed URLs to the Sitemap
+func (s *Sitemap) Add(urls ...SitemapURL) *Sitemap {
+	s.urls = append(s.urls, urls...)
+	return s
+}
+
+// Dynamic adds URLs, that are listed whenever the Sitemap is requested, like
+// the pages of blog posts in a database
+func (s *Sitemap) Dynamic(urls func(request Request) ([]SitemapURL, error)) *Sitemap {
+	s.dynamic = append(s.dynamic, urls)
+	return s
+}
+
+// URLs returns the entries of the Sitemap with absolute URLs, ordered by their location
+func (s *Sitemap) URLs(request Request) ([]SitemapURL, error) {
+	urls := append(s.routeURLs(), s.urls...)
+	for _, dynamic := range s.dynamic {
+		added, err := dynamic(request)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, added...)
+	}
+
+	for i, url := range urls {
+		if strings.HasPrefix(url.Loc, "/") {
+			urls[i].Loc = s.baseURL + s.router.Configuration.BasePath + url.Loc
+		}
+	}
+	sort.SliceStable(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+	return urls, nil
+}
+
+// routeURLs lists the paths of all GET routes without wildcards
+func (s *Sitemap) routeURLs() []SitemapURL {
+	s.router.mutex.Lock()
+	defer s.router.mutex.Unlock()
+	var urls []SitemapURL
+	for pattern, handler := range s.router.handlerKeeper {
+		endpoint, ok := handler.methods[methodGet]
+		if !ok || endpoint.meta.Has("sitemap", "exclude") {
+			continue
+		}
+		pattern = strings.TrimSuffix(pattern, "{$}")
+		if strings.Contains(pattern, "{") {
+			continue
+		}
+		urls = append(urls, SitemapURL{Loc: pattern})
+	}
+	return urls
+}
+
+// sitemapEscaper escapes the text of the sitemap elements, as the sitemap is
+// written by hand to work without encoding/xml, like with the there_noxml tag
+var sitemapEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&apos;", `"`, "&quot;")
+
+// writeSitemapElement writes the element, if it has a value
+func writeSitemapElement(builder *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	builder.WriteString("<" + name + ">")
+	sitemapEscaper.WriteString(builder, value)
+	builder.WriteString("</" + name + ">")
+}
+
+// Endpoint serves the Sitemap as xml
+func (s *Sitemap) Endpoint(request Request) Response {
+	urls, err := s.URLs(request)
+	if err != nil {
+		return Error(status.InternalServerError, err)
+	}
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for _, url := range urls {
+		builder.WriteString("<url>")
+		writeSitemapElement(&builder, "loc", url.Loc)
+		if !url.LastModified.IsZero() {
+			writeSitemapElement(&builder, "lastmod", url.LastModified.UTC().Format(time.RFC3339))
+		}
+		writeSitemapElement(&builder, "changefreq", url.ChangeFrequency)
+		if url.Priority > 0 {
+			writeSitemapElement(&builder, "priority", strconv.FormatFloat(min(url.Priority, 1), 'f', 1, 64))
+		}
+		builder.WriteString("</url>")
+	}
+	builder.WriteString("</urlset>")
+	return Headers(map[string]string{
+		header.ContentType:  ContentTypeApplicationXml,
+		header.ResponseVary: header.RequestAcceptEncoding,
+	}, Gzip(Bytes(status.OK, []byte(builder.String()))))
+}
@@ -0,0 +1,87 @@
+package there
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRobots(t *testing.T) {
+	router := NewRouter()
+	router.Robots(RobotsPolicy{
+		Rules: []RobotsRule{
+			{Disallow: []string{"/admin/"}},
+			{UserAgent: "BadBot", Disallow: []string{"/"}, CrawlDelay: 10},
+		},
+		Sitemaps: []string{"https://example.com/sitemap.xml"},
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/robots.txt", nil))
+	expected := "User-agent: *\nDisallow: /admin/\n\nUser-agent: BadBot\nDisallow: /\nCrawl-delay: 10\n\nSitemap: https://example.com/sitemap.xml\n"
+	if recorder.Body.String() != expected {
+		t.Errorf("unexpected robots.txt %q", recorder.Body.String())
+	}
+	if allowAll := (RobotsPolicy{}).String(); allowAll != "User-agent: *\nDisallow: \n" {
+		t.Errorf("unexpected empty policy %q", allowAll)
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	router := NewRouter()
+	router.Get("/{$}", handler)
+	router.Get("/about", handler)
+	router.Get("/posts/{slug}", handler)
+	router.Post("/contact", handler)
+	router.Get("/admin", handler).Tag("sitemap:exclude")
+	router.Robots(RobotsPolicy{})
+	router.Sitemap("https://example.com/").
+		Add(SitemapURL{Loc: "https://cdn.example.com/guide.pdf"}, SitemapURL{Loc: "/search?q=a&b=<c>", ChangeFrequency: "daily"}).
+		Dynamic(func(request Request) ([]SitemapURL, error) {
+			return []SitemapURL{{
+				Loc:          "/posts/hello",
+				LastModified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Priority:     0.8,
+			}}, nil
+		})
+
+	request := httptest.NewRequest(MethodGet, "/sitemap.xml", nil)
+	request.Header.Set(header.RequestAcceptEncoding, "gzip")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.OK || recorder.Header().Get(header.ContentEncoding) != "gzip" {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Header())
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sitemap := string(body)
+	for _, expected := range []string{
+		"<loc>https://example.com/</loc>",
+		"<loc>https://example.com/about</loc>",
+		"<loc>https://cdn.example.com/guide.pdf</loc>",
+		"<url><loc>https://example.com/search?q=a&amp;b=&lt;c&gt;</loc><changefreq>daily</changefreq></url>",
+		"<url><loc>https://example.com/posts/hello</loc><lastmod>2024-01-02T03:04:05Z</lastmod><priority>0.8</priority></url>",
+	} {
+		if !strings.Contains(sitemap, expected) {
+			t.Errorf("sitemap is missing %v: %v", expected, sitemap)
+		}
+	}
+	for _, unexpected := range []string{"{slug}", "/contact", "/admin", "robots.txt", "sitemap.xml"} {
+		if strings.Contains(sitemap, unexpected) {
+			t.Errorf("sitemap contains %v: %v", unexpected, sitemap)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package there
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RouteSnapshot describes a route of a Snapshot
+type RouteSnapshot struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	// Endpoint is the name of the Endpoint func, like "main.GetUser". Closures
+	// are named after the enclosing func, like "main.main.func1".
+	Endpoint string `json:"endpoint"`
+	// Middlewares are the names of the route middlewares in the order they run
+	Middlewares []string  `json:"middlewares,omitempty"`
+	Meta        RouteMeta `json:"meta,omitempty"`
+}
+
+func (r RouteSnapshot) key() string {
+	return r.Method + " " + r.Pattern
+}
+
+// Snapshot is the routing state of a router. As it can be marshalled, deploy
+// tooling can store the snapshot of the running build and compare it with the
+// next build, to gate releases on unexpected routing changes.
+type Snapshot struct {
+	// Middlewares are the names of the global middlewares in the order they run
+	Middlewares []string        `json:"middlewares,omitempty"`
+	Routes      []RouteSnapshot `json:"routes"`
+}
+
+// Snapshot captures the current routes and middleware chains of the router,
+// ordered by pattern and method
+func (router *Router) Snapshot() Snapshot {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	snapshot := Snapshot{Middlewares: funcNames(router.globalMiddlewares)}
+	for pattern, handler := range router.handlerKeeper {
+		for m, endpoint := range handler.methods {
+			route := RouteSnapshot{
+				Method:      methodToString(m),
+				Pattern:     pattern,
+				Endpoint:    funcName(endpoint.endpoint),
+				Middlewares: funcNames(endpoint.middlewares),
+			}
+			if len(endpoint.meta) > 0 {
+				route.Meta = RouteMeta{}
+				for key, value := range endpoint.meta {
+					route.Meta[key] = value
+				}
+			}
+			snapshot.Routes = append(snapshot.Routes, route)
+		}
+	}
+	sort.Slice(snapshot.Routes, func(i, j int) bool {
+		a, b := snapshot.Routes[i], snapshot.Routes[j]
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		return methodToInt(a.Method) < methodToInt(b.Method)
+	})
+	return snapshot
+}
+
+func funcNames[T any](funcs []T) []string {
+	var names []string
+	for _, f := range funcs {
+		names = append(names, funcName(f))
+	}
+	return names
+}
+
+// funcName returns the name of the func. Empty, if it is nil.
+func funcName(f any) string {
+	value := reflect.ValueOf(f)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return ""
+	}
+	if fn := runtime.FuncForPC(value.Pointer()); fn != nil {
+		return strings.TrimSuffix(fn.Name(), "-fm")
+	}
+	return ""
+}
+
+// RouteChange is a route, that exists in both snapshots, but differs
+type RouteChange struct {
+	Before RouteSnapshot `json:"before"`
+	After  RouteSnapshot `json:"after"`
+}
+
+// SnapshotDiff are the differences between two snapshots
+type SnapshotDiff struct {
+	Added   []RouteSnapshot `json:"added,omitempty"`
+	Removed []RouteSnapshot `json:"removed,omitempty"`
+	Changed []RouteChange   `json:"changed,omitempty"`
+	// MiddlewaresBefore and MiddlewaresAfter are the global middlewares, if they changed
+	MiddlewaresBefore []string `json:"middlewaresBefore,omitempty"`
+	MiddlewaresAfter  []string `json:"middlewaresAfter,omitempty"`
+}
+
+// Empty reports whether the snapshots are equal
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.MiddlewaresBefore) == 0 && len(d.MiddlewaresAfter) == 0
+}
+
+// String lists the differences line by line, like "+ GET /users" for added,
+// "- GET /users" for removed and "~ GET /users" for changed routes
+func (d SnapshotDiff) String() string {
+	var builder strings.Builder
+	if len(d.MiddlewaresBefore) > 0 || len(d.MiddlewaresAfter) > 0 {
+		builder.WriteString("~ middlewares [" + strings.Join(d.MiddlewaresBefore, " ") + "] -> [" + strings.Join(d.MiddlewaresAfter, " ") + "]\n")
+	}
+	for _, route := range d.Added {
+		builder.WriteString("+ " + route.key() + "\n")
+	}
+	for _, route := range d.Removed {
+		builder.WriteString("- " + route.key() + "\n")
+	}
+	for _, change := range d.Changed {
+		builder.WriteString("~ " + change.After.key() + "\n")
+	}
+	return builder.String()
+}
+
+// Diff returns the changes from the snapshot to the other one
+//
+//	diff := deployed.Diff(router.Snapshot())
+//	if len(diff.Removed) > 0 {
+//		log.Fatalf("routes were removed:\n%v", diff)
+//	}
+func (s Snapshot) Diff(other Snapshot) SnapshotDiff {
+	var diff SnapshotDiff
+	if !slices.Equal(s.Middlewares, other.Middlewares) {
+		diff.MiddlewaresBefore, diff.MiddlewaresAfter = s.Middlewares, other.Middlewares
+	}
+
+	before := map[string]RouteSnapshot{}
+	for _, route := range s.Routes {
+		before[route.key()] = route
+	}
+	after := map[string]bool{}
+	for _, route := range other.Routes {
+		after[route.key()] = true
+		previous, ok := before[route.key()]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, route)
+		case previous.Endpoint != route.Endpoint ||
+			!slices.Equal(previous.Middlewares, route.Middlewares) ||
+			!reflect.DeepEqual(normalizeMeta(previous.Meta), normalizeMeta(route.Meta)):
+			diff.Changed = append(diff.Changed, RouteChange{Before: previous, After: route})
+		}
+	}
+	for _, route := range s.Routes {
+		if !after[route.key()] {
+			diff.Removed = append(diff.Removed, route)
+		}
+	}
+	return diff
+}
+
+func normalizeMeta(meta RouteMeta) RouteMeta {
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
@@ -0,0 +1,60 @@
+package there
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func snapshotEndpoint(request Request) Response {
+	return nil
+}
+
+func snapshotMiddleware(request Request, next Response) Response {
+	return next
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	blue := NewRouter()
+	blue.Get("/users", handler)
+	blue.Get("/users/{id}", handler)
+	blue.Delete("/users/{id}", handler)
+
+	green := NewRouter()
+	green.Use(snapshotMiddleware)
+	green.Get("/users", handler)
+	green.Get("/users/{id}", snapshotEndpoint).With(snapshotMiddleware)
+	green.Post("/users", handler).Tag("audit")
+
+	before := blue.Snapshot()
+	if before.Routes[0].Endpoint != "github.com/gebes/there/v2.handler" {
+		t.Errorf("unexpected endpoint name %v", before.Routes[0].Endpoint)
+	}
+
+	// snapshots are meant to be stored between builds
+	data, err := json.Marshal(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Snapshot
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if diff := stored.Diff(blue.Snapshot()); !diff.Empty() {
+		t.Errorf("expected no difference, got\n%v", diff)
+	}
+
+	diff := stored.Diff(green.Snapshot())
+	expected := "~ middlewares [] -> [github.com/gebes/there/v2.snapshotMiddleware]\n" +
+		"+ POST /users\n" +
+		"- DELETE /users/{id}\n" +
+		"~ GET /users/{id}\n"
+	if diff.String() != expected {
+		t.Errorf("unexpected diff\n%v", diff)
+	}
+	if change := diff.Changed[0]; change.After.Endpoint != "github.com/gebes/there/v2.snapshotEndpoint" || len(change.After.Middlewares) != 1 {
+		t.Errorf("unexpected change %+v", change)
+	}
+	if _, ok := diff.Added[0].Meta["audit"]; !ok {
+		t.Errorf("meta is missing %+v", diff.Added[0])
+	}
+}
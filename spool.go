@@ -0,0 +1,167 @@
+package there
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// SpoolOptions configures Spool
+type SpoolOptions struct {
+	// MemoryLimit is the amount of bytes kept in memory, before the response is
+	// spooled to a temporary file. Defaults to 4 MiB.
+	MemoryLimit int64
+	// Dir is the directory of the temporary files. Defaults to os.TempDir.
+	Dir string
+}
+
+// Spool wraps around your current Response and buffers it completely, before
+// it is sent. Small responses are kept in memory, while larger ones, like
+// exports, are written to a temporary file, so they cannot exhaust the memory.
+// The file is removed, once the response was sent or the client disconnected.
+//
+// As the complete response is known before it is sent, the Content-Length is
+// set and resources used to generate the response, like database connections,
+// are released before a slow client starts reading it. Flushes of the wrapped
+// Response are ignored.
+//
+//	func ExportOrders(request there.Request) there.Response {
+//		return there.Spool(there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+//			for rows.Next() {
+//				writeCsvRow(rw, rows)
+//			}
+//		}), there.SpoolOptions{MemoryLimit: 1 << 20})
+//	}
+func Spool(response Response, options ...SpoolOptions) Response {
+	config := SpoolOptions{}
+	if len(options) >= 1 {
+		config = options[0]
+	}
+	if config.MemoryLimit <= 0 {
+		config.MemoryLimit = 4 << 20
+	}
+	return &spoolResponse{options: config, response: response}
+}
+
+type spoolResponse struct {
+	options  SpoolOptions
+	response Response
+}
+
+func (s spoolResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	writer := &spoolWriter{ResponseWriter: rw, ctx: r.Context(), options: s.options}
+	defer writer.close()
+	s.response.ServeHTTP(writer, r)
+
+	err := writer.flush()
+	if err != nil && !errors.Is(err, ErrorClientDisconnected) {
+		log.Printf("spoolResponse: ServeHttp write failed: %v", err)
+	}
+}
+
+// spoolWriter buffers the body in memory up to the MemoryLimit and in a
+// temporary file beyond. Headers are set on the underlying http.ResponseWriter directly.
+type spoolWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	options SpoolOptions
+	code    int
+	memory  bytes.Buffer
+	file    *os.File
+	size    int64
+	// err is the first error of the temporary file
+	err error
+}
+
+func (w *spoolWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *spoolWriter) Write(b []byte) (int, error) {
+	if w.ctx.Err() != nil {
+		return 0, ErrorClientDisconnected
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if w.file == nil && int64(w.memory.Len()+len(b)) > w.options.MemoryLimit {
+		w.file, w.err = os.CreateTemp(w.options.Dir, "there-spool-*")
+		if w.err != nil {
+			return 0, w.err
+		}
+		if _, w.err = w.memory.WriteTo(w.file); w.err != nil {
+			return 0, w.err
+		}
+		w.memory = bytes.Buffer{}
+	}
+	var n int
+	if w.file != nil {
+		n, w.err = w.file.Write(b)
+	} else {
+		n, _ = w.memory.Write(b)
+	}
+	w.size += int64(n)
+	return n, w.err
+}
+
+// flush sends the spooled response to the underlying http.ResponseWriter
+func (w *spoolWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.ctx.Err() != nil {
+		return ErrorClientDisconnected
+	}
+	code := w.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	if w.Header().Get(header.ContentLength) == "" {
+		w.Header().Set(header.ContentLength, strconv.FormatInt(w.size, 10))
+	}
+	w.ResponseWriter.WriteHeader(code)
+	if w.file == nil {
+		_, err := w.ResponseWriter.Write(w.memory.Bytes())
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.Copy(w.ResponseWriter, contextReader{ctx: w.ctx, reader: w.file})
+	return err
+}
+
+// close removes the temporary file
+func (w *spoolWriter) close() {
+	w.memory = bytes.Buffer{}
+	if w.file == nil {
+		return
+	}
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
+
+// contextReader stops reading, as soon as the context is done
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, ErrorClientDisconnected
+	}
+	return r.reader.Read(p)
+}
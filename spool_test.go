@@ -0,0 +1,47 @@
+package there
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	router := NewRouter()
+	router.Get("/export/{rows}", func(request Request) Response {
+		rows, _ := strconv.Atoi(request.RouteParams.Get("rows"))
+		return Spool(ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set(header.ContentType, "text/csv")
+			rw.WriteHeader(status.Created)
+			for i := 0; i < rows; i++ {
+				_, _ = rw.Write([]byte("0123456789\n"))
+			}
+			entries, _ := os.ReadDir(dir)
+			if spooled := len(entries) == 1; spooled != (rows > 10) {
+				t.Errorf("with %v rows, expected spooling to be %v", rows, rows > 10)
+			}
+		}), SpoolOptions{MemoryLimit: 110, Dir: dir})
+	})
+
+	for _, rows := range []int{3, 10, 500} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/export/"+strconv.Itoa(rows), nil))
+		expected := bytes.Repeat([]byte("0123456789\n"), rows)
+		if recorder.Code != status.Created || !bytes.Equal(recorder.Body.Bytes(), expected) {
+			t.Errorf("with %v rows, unexpected response %v with %v bytes", rows, recorder.Code, recorder.Body.Len())
+		}
+		if length := recorder.Header().Get(header.ContentLength); length != strconv.Itoa(len(expected)) {
+			t.Errorf("with %v rows, expected the content length %v, got %v", rows, len(expected), length)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the temporary files to be removed, got %v", entries)
+	}
+}
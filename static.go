@@ -0,0 +1,155 @@
+package there
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// Static serves the files of the directory below the prefix. See StaticFS.
+//
+//	router.Static("/assets", "./public")
+func (group *RouteGroup) Static(prefix, dir string) *RouteRouteGroupBuilder {
+	return group.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS serves the files of the file system below the prefix, like an
+// embed.FS. Requests for a directory serve its index.html, directories are
+// never listed. Paths leaving the file system, like ones containing "..", are
+// answered with StatusNotFound.
+//
+// The Content-Type is guessed by the file extension, and every file gets an
+// ETag and, if the file system knows it, a Last-Modified header, so
+// conditional and Range requests are answered by http.ServeContent. Add a
+// Cache-Control header with Cacheable:
+//
+//	//go:embed public
+//	var public embed.FS
+//
+//	assets, _ := fs.Sub(public, "public")
+//	router.StaticFS("/assets", assets).Cacheable(24 * time.Hour)
+func (group *RouteGroup) StaticFS(prefix string, fileSystem fs.FS) *RouteRouteGroupBuilder {
+	static := &staticFiles{fileSystem: fileSystem}
+	return group.Handle(path.Join("/", prefix, "{path...}"), static.endpoint, MethodGet, MethodHead)
+}
+
+type staticFiles struct {
+	fileSystem fs.FS
+	// etags caches the ETag of the served files, until they changed
+	etags validatorCache
+}
+
+func (s *staticFiles) endpoint(request Request) Response {
+	name := request.RouteParams.Get("path")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return Error(status.NotFound, fs.ErrNotExist)
+	}
+	return staticResponse{files: s, name: name}
+}
+
+type staticResponse struct {
+	files *staticFiles
+	name  string
+}
+
+func (s staticResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	file, info, name, err := s.files.open(s.name)
+	if err != nil {
+		Error(status.NotFound, err).ServeHTTP(rw, r)
+		return
+	}
+	defer file.Close()
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			Error(status.InternalServerError, err).ServeHTTP(rw, r)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	etag, err := s.files.etag(name, info, content)
+	if err != nil {
+		Error(status.InternalServerError, err).ServeHTTP(rw, r)
+		return
+	}
+
+	headers := rw.Header()
+	contentType := ContentTypeApplicationOctetDashStream
+	if extension := path.Ext(name); extension != "" {
+		if guessed := ContentType(extension[1:]); guessed != "" {
+			contentType = guessed
+		}
+	}
+	headers.Set(header.ContentType, contentType)
+	headers.Set(header.ResponseEtag, etag)
+
+	// http.ServeContent handles Range, If-Range and the conditional headers
+	http.ServeContent(rw, r, "", info.ModTime(), content)
+}
+
+// open opens the file, or the index.html of a directory, and returns the name
+// of the opened file
+func (s *staticFiles) open(name string) (fs.File, fs.FileInfo, string, error) {
+	file, err := s.fileSystem.Open(name)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, "", err
+	}
+	if !info.IsDir() {
+		return file, info, name, nil
+	}
+	file.Close()
+
+	name = path.Join(name, "index.html")
+	file, err = s.fileSystem.Open(name)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	info, err = file.Stat()
+	if err != nil || info.IsDir() {
+		file.Close()
+		return nil, nil, "", errors.Join(fs.ErrNotExist, err)
+	}
+	return file, info, name, nil
+}
+
+// etag returns the cached ETag of the file, or hashes its content
+func (s *staticFiles) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if validators, ok := s.etags.load(name); ok {
+		if validators.modified.Equal(info.ModTime()) && validators.size == info.Size() {
+			return validators.etag, nil
+		}
+	}
+	h := fnv.New64a()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	validators := fileValidators{
+		etag:     "\"" + hex.EncodeToString(h.Sum(nil)) + "\"",
+		modified: info.ModTime(),
+		size:     info.Size(),
+	}
+	s.etags.store(name, validators)
+	return validators.etag, nil
+}
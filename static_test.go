@@ -0,0 +1,87 @@
+package there
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestStaticFS(t *testing.T) {
+	router := NewRouter()
+	router.StaticFS("/assets", fstest.MapFS{
+		"index.html":    {Data: []byte("<h1>home</h1>")},
+		"css/site.css":  {Data: []byte("body{}")},
+		"docs/readme":   {Data: []byte("read me")},
+		"docs/empty/.k": {Data: []byte{}},
+	})
+
+	serve := func(target string, headers ...string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, target, nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			request.Header.Set(headers[i], headers[i+1])
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := serve("/assets/css/site.css")
+	if recorder.Code != status.OK || recorder.Body.String() != "body{}" {
+		t.Fatalf("unexpected response %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get(header.ContentType) != ContentTypeTextCss {
+		t.Fatalf("unexpected content type %v", recorder.Header().Get(header.ContentType))
+	}
+	etag := recorder.Header().Get(header.ResponseEtag)
+	if etag == "" {
+		t.Fatal("etag is missing")
+	}
+	if recorder = serve("/assets/css/site.css", header.RequestIfNoneMatch, etag); recorder.Code != status.NotModified {
+		t.Fatalf("expected %v, got %v", status.NotModified, recorder.Code)
+	}
+	if recorder = serve("/assets/css/site.css", header.RequestRange, "bytes=0-3"); recorder.Code != status.PartialContent || recorder.Body.String() != "body" {
+		t.Fatalf("unexpected range response %v %v", recorder.Code, recorder.Body.String())
+	}
+
+	if recorder = serve("/assets/"); recorder.Body.String() != "<h1>home</h1>" {
+		t.Fatalf("index was not served: %v %v", recorder.Code, recorder.Body.String())
+	}
+	if recorder = serve("/assets/docs/readme"); recorder.Header().Get(header.ContentType) != ContentTypeApplicationOctetDashStream {
+		t.Fatalf("unexpected content type %v", recorder.Header().Get(header.ContentType))
+	}
+	for _, target := range []string{"/assets/docs/empty", "/assets/missing.js", "/assets/..%2f..%2fetc%2fpasswd"} {
+		if recorder = serve(target); recorder.Code != status.NotFound {
+			t.Fatalf("%v: expected %v, got %v", target, status.NotFound, recorder.Code)
+		}
+	}
+}
+
+func TestStatic(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("run()"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter()
+	router.Group("/public").Static("/", dir)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/public/app.js", nil))
+	if recorder.Body.String() != "run()" || recorder.Header().Get(header.ResponseLastModified) == "" {
+		t.Fatalf("unexpected response %v %v", recorder.Body.String(), recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/public/..%2fsecret.txt", nil))
+	if recorder.Code != status.NotFound {
+		t.Fatalf("expected %v, got %v %v", status.NotFound, recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,115 @@
+package there
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// RouterStats contains counters about the requests a Router served since it was created
+type RouterStats struct {
+	// ClientDisconnects counts the requests, whose client went away before the
+	// response was completed
+	ClientDisconnects uint64 `json:"clientDisconnects"`
+	// DrainedRequests counts the requests, whose body was not read completely
+	// and had to be drained after the response
+	DrainedRequests uint64 `json:"drainedRequests"`
+	// DrainedBytes counts the bytes discarded while draining request bodies
+	DrainedBytes uint64 `json:"drainedBytes"`
+	// DrainLimitExceeded counts the requests, whose unread body exceeded the
+	// BodyDrainLimit, so their connection could not be reused
+	DrainLimitExceeded uint64 `json:"drainLimitExceeded"`
+}
+
+type routerStats struct {
+	clientDisconnects  atomic.Uint64
+	drainedRequests    atomic.Uint64
+	drainedBytes       atomic.Uint64
+	drainLimitExceeded atomic.Uint64
+}
+
+// Stats returns a snapshot of the counters of the router
+func (router *Router) Stats() RouterStats {
+	return RouterStats{
+		ClientDisconnects:  router.stats.clientDisconnects.Load(),
+		DrainedRequests:    router.stats.drainedRequests.Load(),
+		DrainedBytes:       router.stats.drainedBytes.Load(),
+		DrainLimitExceeded: router.stats.drainLimitExceeded.Load(),
+	}
+}
+
+// RouteStats contains the counters of a route, collected while RouteStats is
+// enabled in the RouterConfiguration
+type RouteStats struct {
+	Method   string `json:"method"`
+	Pattern  string `json:"pattern"`
+	Requests uint64 `json:"requests"`
+	// ClientErrors counts the responses with a 4xx status code
+	ClientErrors uint64 `json:"clientErrors"`
+	// ServerErrors counts the responses with a 5xx status code
+	ServerErrors uint64 `json:"serverErrors"`
+	// InFlight is the amount of requests currently served
+	InFlight int64 `json:"inFlight"`
+	// Duration is the total time spent serving the requests, including the middlewares
+	Duration time.Duration `json:"duration"`
+}
+
+type endpointStats struct {
+	requests     atomic.Uint64
+	clientErrors atomic.Uint64
+	serverErrors atomic.Uint64
+	inFlight     atomic.Int64
+	duration     atomic.Int64
+}
+
+// serve serves the response and counts it
+func (s *endpointStats) serve(rw http.ResponseWriter, request *http.Request, response http.Handler) {
+	s.inFlight.Add(1)
+	start := time.Now()
+	writer := NewCaptureWriter(rw)
+	defer func() {
+		s.inFlight.Add(-1)
+		s.requests.Add(1)
+		s.duration.Add(int64(time.Since(start)))
+		switch code := writer.Status(); {
+		case code >= 500:
+			s.serverErrors.Add(1)
+		case code >= 400:
+			s.clientErrors.Add(1)
+		}
+	}()
+	response.ServeHTTP(writer, request)
+}
+
+// RouteStats returns a snapshot of the counters of every route ordered by
+// pattern and method. Empty, unless RouteStats is enabled in the RouterConfiguration.
+func (router *Router) RouteStats() []RouteStats {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+	var stats []RouteStats
+	for pattern, handler := range router.handlerKeeper {
+		for m, endpoint := range handler.methods {
+			s := endpoint.stats
+			if s == nil || s.requests.Load() == 0 && s.inFlight.Load() == 0 {
+				continue
+			}
+			stats = append(stats, RouteStats{
+				Method:       methodToString(m),
+				Pattern:      pattern,
+				Requests:     s.requests.Load(),
+				ClientErrors: s.clientErrors.Load(),
+				ServerErrors: s.serverErrors.Load(),
+				InFlight:     s.inFlight.Load(),
+				Duration:     time.Duration(s.duration.Load()),
+			})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Pattern != stats[j].Pattern {
+			return stats[i].Pattern < stats[j].Pattern
+		}
+		return stats[i].Method < stats[j].Method
+	})
+	return stats
+}
@@ -0,0 +1,132 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+var (
+	// ErrorStreamingUnsupported is returned, if the http.ResponseWriter is not able to flush
+	ErrorStreamingUnsupported = errors.New("streaming unsupported")
+	// ErrorClientDisconnected is returned, if the client went away during streaming
+	ErrorClientDisconnected = errors.New("client disconnected")
+	// ErrorWriteTimeout is returned, if the client did not accept the data within the write timeout
+	ErrorWriteTimeout = errors.New("write timeout exceeded")
+)
+
+// StreamWriter writes to a client piece by piece. Before every write, it checks
+// whether the client is still connected. Writes that take longer than the
+// WriteTimeout are aborted, so a slow client cannot block the stream forever.
+type StreamWriter struct {
+	// WriteTimeout is the time a single Write and Flush may take. Zero means no timeout.
+	WriteTimeout time.Duration
+
+	rw         http.ResponseWriter
+	ctx        context.Context
+	controller *http.ResponseController
+}
+
+// Flusher returns a StreamWriter for streaming responses, like event streams,
+// NDJSON or proxies. Use it inside of a custom Response:
+//
+//	func Numbers(request there.Request) there.Response {
+//		return there.ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+//			stream := there.Flusher(rw, r)
+//			stream.WriteTimeout = 5 * time.Second
+//			for i := 0; ; i++ {
+//				_, err := stream.Write([]byte(strconv.Itoa(i) + "\n"))
+//				if err == nil {
+//					err = stream.Flush()
+//				}
+//				if err != nil {
+//					return // the client is gone or too slow
+//				}
+//			}
+//		})
+//	}
+func Flusher(rw http.ResponseWriter, r *http.Request) *StreamWriter {
+	return &StreamWriter{
+		rw:         rw,
+		ctx:        r.Context(),
+		controller: http.NewResponseController(rw),
+	}
+}
+
+// Header returns the header map of the underlying http.ResponseWriter
+func (s *StreamWriter) Header() http.Header {
+	return s.rw.Header()
+}
+
+// WriteHeader sends the status code
+func (s *StreamWriter) WriteHeader(code int) {
+	s.rw.WriteHeader(code)
+}
+
+// Write writes p, unless the client disconnected. The returned errors are
+// ErrorClientDisconnected, ErrorWriteTimeout or the error of the connection.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if err := s.alive(); err != nil {
+		return 0, err
+	}
+	s.deadline()
+	n, err := s.rw.Write(p)
+	s.clearDeadline()
+	return n, s.translate(err)
+}
+
+// Flush sends all buffered data to the client. If the http.ResponseWriter does
+// not support flushing, ErrorStreamingUnsupported is returned.
+func (s *StreamWriter) Flush() error {
+	if err := s.alive(); err != nil {
+		return err
+	}
+	s.deadline()
+	err := s.controller.Flush()
+	s.clearDeadline()
+	if errors.Is(err, http.ErrNotSupported) {
+		return ErrorStreamingUnsupported
+	}
+	return s.translate(err)
+}
+
+// Done returns a channel, that is closed as soon as the client disconnects
+func (s *StreamWriter) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+func (s *StreamWriter) alive() error {
+	if s.ctx.Err() != nil {
+		return ErrorClientDisconnected
+	}
+	return nil
+}
+
+func (s *StreamWriter) deadline() {
+	if s.WriteTimeout > 0 {
+		// not every http.ResponseWriter supports deadlines, like the httptest.ResponseRecorder
+		_ = s.controller.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+	}
+}
+
+func (s *StreamWriter) clearDeadline() {
+	if s.WriteTimeout > 0 {
+		_ = s.controller.SetWriteDeadline(time.Time{})
+	}
+}
+
+// translate maps errors of the connection to the typed errors of the StreamWriter
+func (s *StreamWriter) translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrorWriteTimeout
+	case s.ctx.Err() != nil:
+		return ErrorClientDisconnected
+	default:
+		return err
+	}
+}
@@ -0,0 +1,43 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestFlusher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	request := httptest.NewRequest(MethodGet, "/", nil).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+
+	stream := Flusher(recorder, request)
+	if _, err := stream.Write([]byte("first\n")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := stream.Flush(); err != nil || !recorder.Flushed {
+		t.Fatalf("expected a flush, got %v", err)
+	}
+
+	cancel()
+	if _, err := stream.Write([]byte("second\n")); !errors.Is(err, ErrorClientDisconnected) {
+		t.Errorf("expected ErrorClientDisconnected, got %v", err)
+	}
+	if err := stream.Flush(); !errors.Is(err, ErrorClientDisconnected) {
+		t.Errorf("expected ErrorClientDisconnected, got %v", err)
+	}
+	if recorder.Body.String() != "first\n" {
+		t.Errorf("unexpected body %q", recorder.Body.String())
+	}
+
+	unsupported := Flusher(nonFlushingWriter{httptest.NewRecorder()}, httptest.NewRequest(MethodGet, "/", nil))
+	if err := unsupported.Flush(); !errors.Is(err, ErrorStreamingUnsupported) {
+		t.Errorf("expected ErrorStreamingUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package there
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// RouteExample is an example response of a route, which is served in the
+// StubMode of the RouterConfiguration and listed in the OpenAPI document
+type RouteExample struct {
+	// Name identifies the example, so clients can request it with the Prefer header
+	Name string
+	Code int
+	// Body is rendered with Auto. Use json.RawMessage for handwritten json.
+	Body any
+}
+
+// Example adds an example response to the route. In StubMode, the first
+// example is served instead of the Endpoint, or the one the client asks for
+// with "Prefer: example=<name>", so API consumers can integrate against
+// realistic responses while the handler is still under construction.
+//
+//	router.Get("/user/{id}", GetUser).
+//		Example("found", status.OK, User{Id: "42", Name: "John"}).
+//		Example("missing", status.NotFound, json.RawMessage(`{"error":"user not found"}`))
+func (group *RouteRouteGroupBuilder) Example(name string, code int, body any) *RouteRouteGroupBuilder {
+	for _, endpoint := range group.endpoints() {
+		doc := endpoint.documentation()
+		for _, example := range doc.Examples {
+			group.assert(example.Name != name, "example \""+name+"\" of route \""+group.muxHandler.pattern+"\" is declared twice")
+		}
+		doc.Examples = append(doc.Examples, RouteExample{Name: name, Code: code, Body: body})
+	}
+	return group
+}
+
+// stubEndpoint returns an Endpoint serving the examples in StubMode
+func stubEndpoint(examples []RouteExample) Endpoint {
+	return func(request Request) Response {
+		example := examples[0]
+		name, preferred := preferredExample(request.Request.Header.Values(header.RequestPrefer))
+		if preferred {
+			found := false
+			for _, e := range examples {
+				if e.Name == name {
+					example, found = e, true
+					break
+				}
+			}
+			if !found {
+				return Error(status.NotFound, fmt.Errorf("the route has no example %q", name))
+			}
+		}
+
+		var response Response
+		if example.Body == nil {
+			response = Status(example.Code)
+		} else {
+			response = Auto(example.Code, example.Body)
+		}
+		if preferred {
+			response = Headers(map[string]string{header.ResponsePreferenceApplied: "example=" + name}, response)
+		}
+		return response
+	}
+}
+
+// preferredExample returns the example requested with the Prefer header
+func preferredExample(prefer []string) (string, bool) {
+	for _, value := range prefer {
+		for _, preference := range strings.Split(value, ",") {
+			// parameters of the preference, like "; lenient", are ignored
+			preference, _, _ = strings.Cut(preference, ";")
+			key, name, ok := strings.Cut(strings.TrimSpace(preference), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "example") {
+				continue
+			}
+			name = strings.TrimSpace(name)
+			if unquoted, err := strconv.Unquote(name); err == nil {
+				name = unquoted
+			}
+			return name, true
+		}
+	}
+	return "", false
+}
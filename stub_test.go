@@ -0,0 +1,75 @@
+package there
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestStubMode(t *testing.T) {
+	type user struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	router := NewRouter()
+	router.Get("/user/{id}", func(request Request) Response {
+		return String(status.OK, "endpoint")
+	}).
+		Example("found", status.OK, user{Id: "42", Name: "John"}).
+		Example("missing", status.NotFound, json.RawMessage(`{"error":"user not found"}`)).
+		Example("deleted", status.Gone, nil)
+	router.Get("/health", func(request Request) Response {
+		return String(status.OK, "healthy")
+	})
+
+	serve := func(target, prefer string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(MethodGet, target, nil)
+		if prefer != "" {
+			request.Header.Set(header.RequestPrefer, prefer)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if body := serve("/user/1", "").Body.String(); body != "endpoint" {
+		t.Errorf("expected the endpoint without StubMode, got %v", body)
+	}
+
+	router.Configuration.StubMode = true
+	for _, test := range []struct {
+		prefer, body string
+		code         int
+	}{
+		{"", `{"id":"42","name":"John"}`, status.OK},
+		{"example=missing", `{"error":"user not found"}`, status.NotFound},
+		{`respond-async, example="deleted"; lenient`, "", status.Gone},
+	} {
+		recorder := serve("/user/1", test.prefer)
+		if recorder.Code != test.code || recorder.Body.String() != test.body {
+			t.Errorf("%q: expected %v %v, got %v %v", test.prefer, test.code, test.body, recorder.Code, recorder.Body.String())
+		}
+		if test.prefer != "" && recorder.Header().Get(header.ResponsePreferenceApplied) == "" {
+			t.Errorf("%q: expected the preference to be applied", test.prefer)
+		}
+	}
+	if recorder := serve("/user/1", "example=other"); recorder.Code != status.NotFound {
+		t.Errorf("expected unknown examples to be not found, got %v", recorder.Code)
+	}
+	if body := serve("/health", "").Body.String(); body != "healthy" {
+		t.Errorf("expected routes without examples to be served as usual, got %v", body)
+	}
+
+	operation := router.OpenAPI().Paths["/user/{id}"]["get"]
+	if examples := operation.Responses["404"].Content[ContentTypeApplicationJson].Examples; len(examples) != 1 {
+		t.Errorf("expected the examples in the OpenAPI document, got %v", operation.Responses)
+	}
+
+	router.Get("/users", nil).Example("all", status.OK, nil).Example("all", status.OK, nil)
+	if router.HasError() == nil {
+		t.Errorf("expected duplicate examples to be rejected")
+	}
+}
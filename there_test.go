@@ -3,7 +3,6 @@ package there
 import (
 	"context"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"github.com/gebes/there/v2/header"
 	"github.com/gebes/there/v2/status"
@@ -11,8 +10,11 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -82,17 +84,11 @@ func CreateRouter() *Router {
 	json := func(request Request) Response {
 		return Json(status.OK, sampleData)
 	}
-	// Samle User
-	xml := func(request Request) Response {
-		return Xml(status.OK, sampleUser)
-	}
-
 	router := NewRouter()
 
 	data := router.Group("/data")
 
 	data.Handle("/json", json, MethodGet, MethodPost, MethodPut, MethodDelete)
-	data.Get("/xml", xml)
 	data.Get("/empty", func(request Request) Response {
 		return Status(status.Accepted)
 	})
@@ -122,14 +118,6 @@ func CreateRouter() *Router {
 		}
 		return String(status.OK, user.Name)
 	})
-	data.Post("/return/xml", func(request Request) Response {
-		var user simpleUser
-		err := request.Body.BindXml(&user)
-		if err != nil {
-			log.Fatalln("Could not bind", err)
-		}
-		return String(status.OK, user.Name)
-	})
 	data.Post("/return/string", func(request Request) Response {
 		body, err := request.Body.ToString()
 		if err != nil {
@@ -171,9 +159,6 @@ func readAndUnmarshal(router *Router, t *testing.T, method, route string, body i
 func readJsonBody(router *Router, t *testing.T, method, route string, body io.Reader, res any) {
 	readAndUnmarshal(router, t, method, route, body, json.Unmarshal, res)
 }
-func readXmlBody(router *Router, t *testing.T, method, route string, body io.Reader, res any) {
-	readAndUnmarshal(router, t, method, route, body, xml.Unmarshal, res)
-}
 
 func TestJson(t *testing.T) {
 	router := CreateRouter()
@@ -201,11 +186,6 @@ func testSampleUserRoutes(t *testing.T, route string, handler func(router *Route
 
 }
 
-func TestXml(t *testing.T) {
-	var res user
-	testSampleUserRoutes(t, "xml", readXmlBody, &res, &sampleUser)
-}
-
 func testErrorResponse(router *Router, t *testing.T, route string) {
 	var res map[string]any
 	readJsonBody(router, t, MethodGet, "/error/"+route, nil, &res)
@@ -221,6 +201,27 @@ func TestNotFoundError(t *testing.T) {
 	testErrorResponse(router, t, "not_existing_route")
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	router := NewRouter()
+	router.Handle("/user/{id}", handler, MethodGet, MethodPut)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodPost, "/user/1", nil))
+	if recorder.Code != status.MethodNotAllowed {
+		t.Fatalf("expected %v, got %v", status.MethodNotAllowed, recorder.Code)
+	}
+	if allow := recorder.Header().Get(header.ResponseAllow); allow != "GET, PUT" {
+		t.Errorf("unexpected allow header %v", allow)
+	}
+
+	router.Configuration.MethodNotAllowedHandler = nil
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodPost, "/user/1", nil))
+	if recorder.Code != status.NotFound {
+		t.Errorf("expected %v, got %v", status.NotFound, recorder.Code)
+	}
+}
+
 func TestJsonErrorResponse(t *testing.T) {
 	router := CreateRouter()
 	testErrorResponse(router, t, "json")
@@ -283,7 +284,7 @@ func TestBodyToStringError(t *testing.T) {
 	router.
 		Post("/test", func(request Request) Response {
 
-			tests := 3
+			tests := 2
 			did := 0
 
 			var s any
@@ -296,10 +297,6 @@ func TestBodyToStringError(t *testing.T) {
 			if err != nil {
 				did++
 			}
-			err = request.Body.BindXml(&s)
-			if err != nil {
-				did++
-			}
 
 			if tests != did {
 				return Error(status.InternalServerError, errors.New("not every bind threw an error: "+strconv.Itoa(did)+"/"+strconv.Itoa(tests)))
@@ -743,6 +740,35 @@ func TestRouteBuilder(t *testing.T) {
 			t.Fatalf("node did not have two middlewares")
 		}
 	})
+	t.Run("group middlewares", func(t *testing.T) {
+		var order []string
+		named := func(name string) Middleware {
+			return func(request Request, next Response) Response {
+				return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(rw, r)
+				})
+			}
+		}
+		router := NewRouter()
+		router.Use(named("global"))
+		admin := router.Group("/admin").With(named("group"))
+		reports := admin.Group("/reports").With(named("reports"))
+		admin.With(named("later"))
+		reports.Get("/", handler).With(named("route"), named("audit"))
+		router.Get("/public", handler)
+
+		order = nil
+		assertBodyResponse(t, router, MethodGet, "/admin/reports", "")
+		if strings.Join(order, ",") != "global,group,reports,route,audit" {
+			t.Errorf("unexpected order %v", order)
+		}
+		order = nil
+		assertBodyResponse(t, router, MethodGet, "/public", "")
+		if strings.Join(order, ",") != "global" {
+			t.Errorf("group middlewares must not run for other routes, got %v", order)
+		}
+	})
 	t.Run("group prefix", func(t *testing.T) {
 		router := NewRouter()
 		group := router.Group("/home")
@@ -850,3 +876,212 @@ func TestContentType(t *testing.T) {
 		})
 	}
 }
+
+// Tests for annotations.go
+
+func TestRouteDoc(t *testing.T) {
+	type input struct{ Name string }
+	type output struct{ Id int }
+
+	router := NewRouter()
+	h := router.Handle("/user", handler, MethodPost, MethodPut).
+		Doc("Create a user", "Creates or replaces a user").
+		Request(input{}).
+		Response(status.Created, output{}).
+		Response(status.BadRequest, nil)
+
+	for _, m := range []method{methodPost, methodPut} {
+		doc := h.muxHandler.methods[m].doc
+		if doc == nil {
+			t.Fatalf("%v has no documentation", methodToString(m))
+		}
+		if doc.Summary != "Create a user" || doc.Description != "Creates or replaces a user" {
+			t.Errorf("unexpected summary or description: %+v", doc)
+		}
+		if _, ok := doc.Request.(input); !ok {
+			t.Errorf("request was not documented: %+v", doc.Request)
+		}
+		if len(doc.Responses) != 2 {
+			t.Errorf("expected two documented responses, got %v", len(doc.Responses))
+		}
+	}
+}
+
+// Tests for mock.go
+
+func TestMockMode(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type output struct {
+		Name      string    `json:"name"`
+		Address   *address  `json:"address"`
+		Addresses []address `json:"addresses"`
+	}
+
+	router := NewRouter()
+	router.Get("/typed", nil).
+		Response(status.BadRequest, nil).
+		Response(status.Created, output{})
+	router.Get("/example", nil).
+		Response(status.OK, output{Name: "John"})
+	router.Get("/undocumented", nil)
+
+	assertBodyResponse(t, router, MethodGet, "/typed", "")
+
+	router.Configuration.MockMode = true
+	assertBodyResponse(t, router, MethodGet, "/typed", `{"name":"","address":{"city":""},"addresses":[{"city":""}]}`)
+	assertBodyResponse(t, router, MethodGet, "/example", `{"name":"John","address":null,"addresses":null}`)
+
+	request := httptest.NewRequest(MethodGet, "/undocumented", nil)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.NotImplemented {
+		t.Errorf("expected %v, got %v", status.NotImplemented, recorder.Code)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.MaxBodySize = 4
+	read := func(request Request) Response {
+		body, err := request.Body.ToString()
+		if errors.Is(err, ErrorBodyTooLarge) {
+			return Error(status.RequestEntityTooLarge, err)
+		}
+		return String(status.OK, body)
+	}
+	router.Post("/small", read)
+	router.Post("/upload", read).WithMaxBody(16)
+	router.Post("/unlimited", read).WithMaxBody(-1)
+
+	tests := []struct {
+		route   string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{route: "/small", body: "1234", want: status.OK},
+		{route: "/small", body: "12345", want: status.RequestEntityTooLarge},
+		{route: "/small", body: "12345", chunked: true, want: status.RequestEntityTooLarge},
+		{route: "/upload", body: "0123456789", want: status.OK},
+		{route: "/unlimited", body: "01234567890123456789", want: status.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route+" "+tt.body, func(t *testing.T) {
+			request := httptest.NewRequest(MethodPost, tt.route, strings.NewReader(tt.body))
+			if tt.chunked {
+				request.ContentLength = -1
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("expected %v, got %v: %v", tt.want, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestBodyTooLargeStatus(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.MaxBodySize = 4
+	router.Post("/", func(request Request) Response {
+		var body map[string]any
+		if err := request.Body.BindJson(&body); err != nil {
+			return BodyError(status.BadRequest, err)
+		}
+		return Status(status.OK)
+	})
+	serve := func(body string) *httptest.ResponseRecorder {
+		// the size of a chunked body is only known while reading it
+		request := httptest.NewRequest(MethodPost, "/", strings.NewReader(body))
+		request.ContentLength = -1
+		request.TransferEncoding = []string{"chunked"}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	if recorder := serve(`{"name":"John"}`); recorder.Code != status.RequestEntityTooLarge || !strings.Contains(recorder.Body.String(), "too large") {
+		t.Errorf("expected %v, got %v: %v", status.RequestEntityTooLarge, recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(`{`); recorder.Code != status.BadRequest {
+		t.Errorf("expected %v, got %v", status.BadRequest, recorder.Code)
+	}
+	// Error keeps the code of the caller
+	recorder := httptest.NewRecorder()
+	Error(status.BadRequest, bodyTooLargeError(4)).ServeHTTP(recorder, httptest.NewRequest(MethodPost, "/", nil))
+	if recorder.Code != status.BadRequest {
+		t.Errorf("expected %v, got %v", status.BadRequest, recorder.Code)
+	}
+}
+
+func TestClientDisconnect(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(request Request) Response {
+		<-request.Done()
+		if !request.Disconnected() {
+			return Status(status.InternalServerError)
+		}
+		return ETag(String(status.OK, "never sent"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/", nil).WithContext(ctx))
+
+	if recorder.Body.Len() != 0 {
+		t.Errorf("body was written to a disconnected client: %v", recorder.Body.String())
+	}
+	if disconnects := router.Stats().ClientDisconnects; disconnects != 1 {
+		t.Errorf("expected one disconnect, got %v", disconnects)
+	}
+}
+
+func TestAutoNegotiation(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	page := filepath.Join(t.TempDir(), "user.html")
+	if err := os.WriteFile(page, []byte("<b>{{.Name}}</b>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter()
+	router.Get("/auto", func(request Request) Response {
+		return Auto(status.OK, user{Name: "John"})
+	})
+	router.Get("/negotiate", func(request Request) Response {
+		return Negotiate(ContentTypeTextHtml, map[string]Response{
+			ContentTypeTextHtml:        Html(status.OK, page, user{Name: "John"}),
+			ContentTypeApplicationJson: Json(status.OK, user{Name: "John"}),
+		})
+	})
+
+	tests := []struct {
+		route, accept, contentType, body string
+	}{
+		{"/auto", "", ContentTypeApplicationJson, `{"name":"John"}`},
+		{"/auto", "*/*", ContentTypeApplicationJson, `{"name":"John"}`},
+		{"/auto", "text/plain, application/json;q=0.5", ContentTypeTextPlain, `{John}`},
+		{"/auto", "image/png", ContentTypeApplicationJson, `{"name":"John"}`},
+		{"/negotiate", "application/json", ContentTypeApplicationJson, `{"name":"John"}`},
+		{"/negotiate", "text/html", ContentTypeTextHtml, `<b>John</b>`},
+		{"/negotiate", "text/*", ContentTypeTextHtml, `<b>John</b>`},
+		{"/negotiate", "image/png", ContentTypeTextHtml, `<b>John</b>`},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(MethodGet, test.route, nil)
+		if test.accept != "" {
+			request.Header.Set(header.RequestAccept, test.accept)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if !strings.HasPrefix(recorder.Header().Get(header.ContentType), test.contentType) || strings.TrimSpace(recorder.Body.String()) != test.body {
+			t.Errorf("%v with %q: unexpected response %v %v", test.route, test.accept, recorder.Header().Get(header.ContentType), recorder.Body.String())
+		}
+		if recorder.Header().Get(header.ResponseVary) != header.RequestAccept {
+			t.Errorf("%v: vary header is missing", test.route)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package there
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+)
+
+// grpcTimeoutUnits maps the units of the Grpc-Timeout header to their duration
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// requestTimeout reads the timeout a caller sent in the X-Request-Timeout or the
+// Grpc-Timeout header. If max is greater than zero, the timeout is capped to it.
+// The second return value is false, if no valid timeout was sent.
+func requestTimeout(headers http.Header, max time.Duration) (time.Duration, bool) {
+	timeout, ok := parseRequestTimeout(headers.Get(header.RequestXRequestTimeout))
+	if !ok {
+		timeout, ok = parseGrpcTimeout(headers.Get(header.RequestGrpcTimeout))
+	}
+	if !ok || timeout <= 0 {
+		return 0, false
+	}
+	if max > 0 && timeout > max {
+		timeout = max
+	}
+	return timeout, true
+}
+
+// parseRequestTimeout parses either a duration like "1.5s" or the amount of seconds
+func parseRequestTimeout(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		if seconds*float64(time.Second) >= math.MaxInt64 {
+			return math.MaxInt64, true
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	timeout, err := time.ParseDuration(value)
+	return timeout, err == nil
+}
+
+// parseGrpcTimeout parses a timeout as defined by the gRPC over HTTP2 protocol
+func parseGrpcTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	amount, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	// 8 digits of hours exceed the range of a time.Duration, which is capped instead of overflowing
+	if amount > math.MaxInt64/uint64(unit) {
+		return math.MaxInt64, true
+	}
+	return time.Duration(amount) * unit, true
+}
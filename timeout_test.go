@@ -0,0 +1,61 @@
+package there
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers http.Header
+		max     time.Duration
+		want    time.Duration
+		wantOk  bool
+	}{
+		{name: "none", headers: http.Header{}},
+		{name: "seconds", headers: http.Header{header.RequestXRequestTimeout: {"2"}}, want: 2 * time.Second, wantOk: true},
+		{name: "duration", headers: http.Header{header.RequestXRequestTimeout: {"150ms"}}, want: 150 * time.Millisecond, wantOk: true},
+		{name: "grpc", headers: http.Header{header.RequestGrpcTimeout: {"500m"}}, want: 500 * time.Millisecond, wantOk: true},
+		{name: "invalid grpc unit", headers: http.Header{header.RequestGrpcTimeout: {"500x"}}},
+		{name: "negative", headers: http.Header{header.RequestXRequestTimeout: {"-1s"}}},
+		{name: "capped", headers: http.Header{header.RequestGrpcTimeout: {"1H"}}, max: time.Minute, want: time.Minute, wantOk: true},
+		{name: "grpc overflow", headers: http.Header{header.RequestGrpcTimeout: {"99999999H"}}, max: time.Minute, want: time.Minute, wantOk: true},
+		{name: "seconds overflow", headers: http.Header{header.RequestXRequestTimeout: {"1e300"}}, want: math.MaxInt64, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := requestTimeout(tt.headers, tt.max)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("requestTimeout() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRequestTimeoutDeadline(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.RequestTimeoutFromHeaders = true
+	router.Configuration.MaxRequestTimeout = time.Minute
+	router.Get("/", func(request Request) Response {
+		deadline, ok := request.Context().Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			return Status(status.BadRequest)
+		}
+		return Status(status.OK)
+	})
+
+	request := httptest.NewRequest(MethodGet, "/", nil)
+	request.Header.Set(header.RequestXRequestTimeout, "3600")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != status.OK {
+		t.Errorf("deadline was not applied or capped, got %v", recorder.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package there
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// ListenTLS serves HTTPS on the address, like ":443", with the certificate and
+// key files. Unless the Server of the router has a TLSConfig, TLS 1.2 is the
+// minimum version.
+func (router *Router) ListenTLS(addr, certFile, keyFile string) error {
+	if err := router.start(context.Background()); err != nil {
+		return err
+	}
+	router.Server.Addr = addr
+	router.applyTLSDefaults()
+	return router.Server.ListenAndServeTLS(certFile, keyFile)
+}
+
+func (router *Router) applyTLSDefaults() {
+	if router.Server.TLSConfig == nil {
+		router.Server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+}
+
+// CertificateManager obtains and renews certificates, like an
+// *autocert.Manager of golang.org/x/crypto/acme/autocert
+type CertificateManager interface {
+	// TLSConfig returns the configuration, that serves the certificates
+	TLSConfig() *tls.Config
+	// HTTPHandler answers the HTTP challenges and passes other requests on to fallback
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// ListenAutoTLS serves HTTPS on port 443 with the certificates of the manager,
+// like Let's Encrypt certificates from autocert. Port 80 answers the challenges
+// of the certificate authority and redirects all other requests to HTTPS. Both
+// are stopped by Shutdown.
+//
+//	manager := &autocert.Manager{
+//		Prompt:     autocert.AcceptTOS,
+//		HostPolicy: autocert.HostWhitelist("example.com", "www.example.com"),
+//		Cache:      autocert.DirCache("certs"),
+//	}
+//	err := router.ListenAutoTLS(manager)
+func (router *Router) ListenAutoTLS(manager CertificateManager) error {
+	if err := router.start(context.Background()); err != nil {
+		return err
+	}
+	httpsListener, err := net.Listen("tcp", ":443")
+	if err != nil {
+		return err
+	}
+	httpListener, err := net.Listen("tcp", ":80")
+	if err != nil {
+		httpsListener.Close()
+		return err
+	}
+	return router.serveAutoTLS(manager, httpsListener, httpListener)
+}
+
+func (router *Router) serveAutoTLS(manager CertificateManager, httpsListener, httpListener net.Listener) error {
+	router.Server.Addr = httpsListener.Addr().String()
+	router.Server.TLSConfig = manager.TLSConfig()
+	if router.Server.TLSConfig.MinVersion == 0 {
+		router.Server.TLSConfig.MinVersion = tls.VersionTLS12
+	}
+
+	redirect := &http.Server{
+		Handler:           manager.HTTPHandler(http.HandlerFunc(redirectToHttps)),
+		ReadHeaderTimeout: router.Server.ReadHeaderTimeout,
+		ErrorLog:          router.Server.ErrorLog,
+	}
+	router.Server.RegisterOnShutdown(func() {
+		redirect.Close()
+	})
+	redirected := make(chan error, 1)
+	go func() {
+		redirected <- redirect.Serve(httpListener)
+	}()
+
+	err := router.Server.ServeTLS(httpsListener, "", "")
+	if !errors.Is(err, http.ErrServerClosed) {
+		redirect.Close()
+	}
+	if redirectErr := <-redirected; !errors.Is(redirectErr, http.ErrServerClosed) && errors.Is(err, http.ErrServerClosed) {
+		return redirectErr
+	}
+	return err
+}
+
+// redirectToHttps redirects the request to the same URL with the https scheme
+func redirectToHttps(rw http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	http.Redirect(rw, r, "https://"+host+r.URL.RequestURI(), status.MovedPermanently)
+}
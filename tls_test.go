@@ -0,0 +1,117 @@
+package there
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// testCertificateManager serves a self-signed certificate and answers challenges
+type testCertificateManager struct {
+	certificate tls.Certificate
+}
+
+func (m testCertificateManager) TLSConfig() *tls.Config {
+	return &tls.Config{Certificates: []tls.Certificate{m.certificate}}
+}
+
+func (m testCertificateManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			io.WriteString(rw, "challenge")
+			return
+		}
+		fallback.ServeHTTP(rw, r)
+	})
+}
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestAutoTLS(t *testing.T) {
+	router := NewRouter()
+	router.Get("/secure", func(request Request) Response {
+		return String(status.OK, "secure")
+	})
+
+	httpsListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() {
+		served <- router.serveAutoTLS(testCertificateManager{selfSignedCertificate(t)}, httpsListener, httpListener)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(url string) (*http.Response, string) {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("https://" + httpsListener.Addr().String() + "/secure")
+	if body != "secure" || resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("unexpected response %v %v", body, resp.TLS)
+	}
+	resp, _ = get("http://" + httpListener.Addr().String() + "/secure?a=b")
+	if location := resp.Header.Get("Location"); resp.StatusCode != status.MovedPermanently || location != "https://127.0.0.1/secure?a=b" {
+		t.Errorf("unexpected redirect %v %v", resp.StatusCode, location)
+	}
+	if _, body := get("http://" + httpListener.Addr().String() + "/.well-known/acme-challenge/token"); body != "challenge" {
+		t.Errorf("expected the challenge to be answered, got %v", body)
+	}
+
+	if err := router.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("expected the server to be closed, got %v", err)
+	}
+	if _, err := client.Get("http://" + httpListener.Addr().String()); err == nil {
+		t.Errorf("expected the http server to be closed")
+	}
+}
@@ -0,0 +1,145 @@
+package there
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Tracer starts the spans of requests. Implement it with an adapter to the
+// tracing library, like OpenTelemetry:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, there.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+//		return ctx, otelSpan{span}
+//	}
+//
+// and set it as Tracer of the RouterConfiguration, so every request is traced
+// from the start of ServeHTTP, including the global middlewares.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the operation of serving a request
+type Span interface {
+	SetName(name string)
+	SetAttribute(key string, value any)
+	AddEvent(name string, attributes map[string]any)
+	RecordError(err error)
+	End()
+}
+
+// Span returns the span of the request, so endpoints can add events and
+// attributes. Without a Tracer a span is returned, that discards everything.
+func (r *Request) Span() Span {
+	return spanOf(r.Request)
+}
+
+type spanKey struct{}
+
+func spanOf(request *http.Request) Span {
+	if span, ok := request.Context().Value(spanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+func traced(request *http.Request) bool {
+	_, ok := request.Context().Value(spanKey{}).(Span)
+	return ok
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetName(string)                  {}
+func (noopSpan) SetAttribute(string, any)        {}
+func (noopSpan) AddEvent(string, map[string]any) {}
+func (noopSpan) RecordError(error)               {}
+func (noopSpan) End()                            {}
+
+// startSpan starts the span of the request, named after its method until the
+// route is matched. The returned request carries the span, and the returned
+// func ends it with the status code sent to the returned http.ResponseWriter.
+func startSpan(tracer Tracer, rw http.ResponseWriter, request *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	ctx, span := tracer.Start(request.Context(), request.Method)
+	span.SetAttribute("http.request.method", request.Method)
+	span.SetAttribute("url.path", request.URL.Path)
+	writer := NewCaptureWriter(rw)
+	return writer, request.WithContext(context.WithValue(ctx, spanKey{}, span)), func() {
+		span.SetAttribute("http.response.status_code", writer.Status())
+		span.End()
+	}
+}
+
+// nameSpan names the span after the matched route, like "GET /users/{id}"
+func nameSpan(span Span, method, pattern string) {
+	span.SetName(method + " " + pattern)
+	span.SetAttribute("http.route", pattern)
+}
+
+// Tracing traces the requests passing it with the Tracer. Use it for single
+// routes, otherwise set the Tracer of the RouterConfiguration, so
+// the span covers the global middlewares too and records the timing of every
+// middleware.
+//
+//	router.Post("/checkout", Checkout).With(there.Tracing(tracer))
+func Tracing(tracer Tracer) Middleware {
+	return func(request Request, next Response) Response {
+		return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw, r, end := startSpan(tracer, rw, r)
+			defer end()
+			nameSpan(spanOf(r), r.Method, request.Pattern())
+			// the endpoint reads the span from the Request it was created with
+			request.WithContext(r.Context())
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// applyMiddleware applies the middleware to next. If the request is traced,
+// the time spent in the middleware, without the middlewares and endpoint
+// after it, is added to the span as "middleware" event.
+func applyMiddleware(request Request, middleware Middleware, next Response) Response {
+	if !traced(request.Request) {
+		return middleware(request, next)
+	}
+	span := spanOf(request.Request)
+	var inner time.Duration
+	timed := ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(rw, r)
+		inner += time.Since(start)
+	})
+	start := time.Now()
+	response := middleware(request, timed)
+	applied := time.Since(start)
+	if response == nil {
+		return nil
+	}
+	return ResponseFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		response.ServeHTTP(rw, r)
+		span.AddEvent("middleware", map[string]any{
+			"name":     funcName(middleware),
+			"duration": applied + time.Since(start) - inner,
+		})
+	})
+}
+
+// traceResponse records the error of an Error response of the endpoint in the
+// span of the request
+func traceResponse(request *http.Request, response Response) {
+	if e, ok := response.(errorResponse); ok && traced(request) {
+		spanOf(request).RecordError(e.err)
+	}
+}
+
+// tracePanic records a recovered panic in the span of the request
+func tracePanic(request *http.Request, recovered any) {
+	if traced(request) {
+		spanOf(request).RecordError(fmt.Errorf("panic: %v", recovered))
+	}
+}
@@ -0,0 +1,136 @@
+package there
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gebes/there/v2/status"
+)
+
+type recordedSpan struct {
+	name       string
+	attributes map[string]any
+	events     []string
+	errors     []error
+	ended      bool
+}
+
+func (s *recordedSpan) SetName(name string) {
+	s.name = name
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) {
+	s.attributes[key] = value
+}
+
+func (s *recordedSpan) AddEvent(name string, attributes map[string]any) {
+	s.events = append(s.events, name+" "+attributes["name"].(string))
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := &recordedSpan{name: name, attributes: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func tracingGlobal(request Request, next Response) Response {
+	return next
+}
+
+func tracingRoute(request Request, next Response) Response {
+	return next
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	router := NewRouter()
+	router.Configuration.Tracer = tracer
+	router.Configuration.RecoverHandler = func(request Request, recovered any, stack []byte) Response {
+		return Status(status.InternalServerError)
+	}
+	router.Use(tracingGlobal)
+	router.Get("/users/{id}", func(request Request) Response {
+		request.Span().SetAttribute("user", request.RouteParams.Get("id"))
+		return Error(status.NotFound, errors.New("user not found"))
+	}).With(tracingRoute)
+	router.Get("/panic", func(request Request) Response {
+		panic("broken")
+	})
+
+	for _, path := range []string{"/users/1", "/missing", "/panic"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, path, nil))
+	}
+	if len(tracer.spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(tracer.spans))
+	}
+
+	user := tracer.spans[0]
+	if user.name != "GET /users/{id}" || user.attributes["http.route"] != "/users/{id}" ||
+		user.attributes["http.response.status_code"] != status.NotFound || user.attributes["user"] != "1" || !user.ended {
+		t.Errorf("unexpected span %+v", user)
+	}
+	if len(user.errors) != 1 || user.errors[0].Error() != "user not found" {
+		t.Errorf("expected the endpoint error to be recorded, got %v", user.errors)
+	}
+	if len(user.events) != 2 || !strings.HasSuffix(user.events[0], "tracingRoute") ||
+		!strings.HasSuffix(user.events[1], "tracingGlobal") {
+		t.Errorf("expected the timing of both middlewares, got %v", user.events)
+	}
+
+	missing := tracer.spans[1]
+	if missing.name != MethodGet || missing.attributes["http.response.status_code"] != status.NotFound ||
+		len(missing.events) != 1 || !missing.ended {
+		t.Errorf("unexpected span of an unmatched request %+v", missing)
+	}
+
+	panicked := tracer.spans[2]
+	if len(panicked.errors) != 1 || panicked.errors[0].Error() != "panic: broken" ||
+		panicked.attributes["http.response.status_code"] != status.InternalServerError || !panicked.ended {
+		t.Errorf("unexpected span of a panic %+v", panicked)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	router := NewRouter()
+	router.Group("/api").Get("/orders/{id}", func(request Request) Response {
+		request.Span().SetAttribute("order", request.RouteParams.Get("id"))
+		return Status(status.Accepted)
+	}).With(Tracing(tracer))
+	router.Get("/public", func(request Request) Response {
+		if _, ok := request.Span().(noopSpan); !ok {
+			t.Error("expected untraced requests to have a noop span")
+		}
+		return Status(status.OK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, "/api/orders/7", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodGet, "/public", nil))
+	if len(tracer.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "GET /api/orders/{id}" || span.attributes["order"] != "7" ||
+		span.attributes["http.response.status_code"] != status.Accepted || !span.ended {
+		t.Errorf("unexpected span %+v", span)
+	}
+}
@@ -0,0 +1,169 @@
+package there
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gebes/there/v2/status"
+)
+
+// TrailingSlash defines how requests are handled, whose path only differs from
+// a route in its trailing slash, like "/users/" for the route "/users"
+type TrailingSlash int
+
+const (
+	// TrailingSlashStrict does not find the route. This is the default.
+	TrailingSlashStrict TrailingSlash = iota
+	// TrailingSlashMovedPermanently redirects to the path of the route with
+	// StatusMovedPermanently. Clients may change the method to GET.
+	TrailingSlashMovedPermanently
+	// TrailingSlashPermanentRedirect redirects to the path of the route with
+	// StatusPermanentRedirect, which keeps the method and body of the request.
+	TrailingSlashPermanentRedirect
+	// TrailingSlashTolerant serves the route without redirecting
+	TrailingSlashTolerant
+)
+
+// redirectCode returns the status the path of the route is redirected to with,
+// or zero, if the route is served directly
+func (t TrailingSlash) redirectCode() int {
+	switch t {
+	case TrailingSlashMovedPermanently:
+		return status.MovedPermanently
+	case TrailingSlashPermanentRedirect:
+		return status.PermanentRedirect
+	}
+	return 0
+}
+
+// serveVariant serves the route, whose path only differs from the request
+// path in its trailing slash or case, as allowed by the TrailingSlash and
+// CaseInsensitivePaths of the RouterConfiguration. The pattern is the one the
+// request path matched. It reports whether the request was served.
+func (router *Router) serveVariant(rw http.ResponseWriter, request *http.Request, pattern string) bool {
+	trailingSlash, caseInsensitive := router.Configuration.TrailingSlash, router.Configuration.CaseInsensitivePaths
+	if trailingSlash == TrailingSlashStrict && !caseInsensitive {
+		return false
+	}
+	// a subtree pattern, like "/", matches any path below it, but a more specific variant is preferred
+	if pattern != "" && (!strings.HasSuffix(pattern, "/") || patternPath(pattern) == request.URL.Path) {
+		return false
+	}
+
+	escaped := request.URL.EscapedPath()
+	var candidates []string
+	if caseInsensitive {
+		candidates = append(candidates, router.caseVariants(escaped)...)
+	}
+	if trailingSlash != TrailingSlashStrict && escaped != "/" {
+		toggled := escaped + "/"
+		if strings.HasSuffix(escaped, "/") {
+			toggled = strings.TrimSuffix(escaped, "/")
+		}
+		candidates = append(candidates, toggled)
+		if caseInsensitive {
+			candidates = append(candidates, router.caseVariants(toggled)...)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate == escaped {
+			continue
+		}
+		routed, variantPattern := router.routeHost(withEscapedPath(request, candidate))
+		if variantPattern == "" || variantPattern == pattern {
+			continue
+		}
+		code := trailingSlash.redirectCode()
+		if code != 0 && strings.HasSuffix(candidate, "/") != strings.HasSuffix(escaped, "/") {
+			// browsers treat "//host" and "/\host" as the url of another host
+			location := "/" + strings.TrimLeft(candidate, "/\\")
+			if request.URL.RawQuery != "" {
+				location += "?" + request.URL.RawQuery
+			}
+			router.applyGlobalMiddlewares(Redirect(code, location)).ServeHTTP(rw, request)
+			return true
+		}
+		router.serveMux.ServeHTTP(rw, routed)
+		return true
+	}
+	return false
+}
+
+// caseVariants returns the escaped path with the case of the literal segments
+// of every route, that matches it case-insensitively
+func (router *Router) caseVariants(escaped string) []string {
+	router.mutex.Lock()
+	patterns := make([]string, 0, len(router.handlerKeeper))
+	for pattern := range router.handlerKeeper {
+		patterns = append(patterns, patternPath(pattern))
+	}
+	router.mutex.Unlock()
+	sort.Strings(patterns)
+
+	var variants []string
+	for _, pattern := range patterns {
+		if variant, ok := caseVariant(pattern, escaped); ok && !slices.Contains(variants, variant) {
+			variants = append(variants, variant)
+		}
+	}
+	return variants
+}
+
+// caseVariant returns the escaped path with the case of the literal segments
+// of the pattern, if they are equal to the ones of the path ignoring case
+func caseVariant(pattern, escaped string) (string, bool) {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(escaped, "/")
+	for i, segment := range patternSegments {
+		if i >= len(pathSegments) {
+			return "", false
+		}
+		if segment == "{$}" {
+			if i == len(pathSegments)-1 && pathSegments[i] == "" {
+				break
+			}
+			return "", false
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if strings.HasSuffix(segment, "...}") {
+				return strings.Join(pathSegments, "/"), true
+			}
+			if pathSegments[i] == "" {
+				return "", false
+			}
+			continue
+		}
+		literal, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", false
+		}
+		value, err := url.PathUnescape(pathSegments[i])
+		if err != nil || !strings.EqualFold(literal, value) {
+			return "", false
+		}
+		pathSegments[i] = url.PathEscape(literal)
+	}
+	if len(pathSegments) != len(patternSegments) {
+		return "", false
+	}
+	return strings.Join(pathSegments, "/"), true
+}
+
+// withEscapedPath returns a shallow copy of the request with the escaped path
+func withEscapedPath(request *http.Request, escaped string) *http.Request {
+	p, err := url.PathUnescape(escaped)
+	if err != nil {
+		p = escaped
+	}
+	variant := new(http.Request)
+	*variant = *request
+	variant.URL = new(url.URL)
+	*variant.URL = *request.URL
+	variant.URL.Path = p
+	variant.URL.RawPath = escaped
+	return variant
+}
@@ -0,0 +1,107 @@
+package there
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func TestTrailingSlash(t *testing.T) {
+	newRouter := func(trailingSlash TrailingSlash) *Router {
+		router := NewRouter()
+		router.Configuration.TrailingSlash = trailingSlash
+		router.Get("/users", func(request Request) Response {
+			return String(status.OK, "users")
+		})
+		router.Post("/users/{id}", func(request Request) Response {
+			return String(status.OK, "user "+request.RouteParams.Get("id"))
+		})
+		return router
+	}
+	serve := func(router *Router, method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+
+	strict := newRouter(TrailingSlashStrict)
+	for _, target := range []string{"/users/", "/users/42/"} {
+		if recorder := serve(strict, MethodGet, target); recorder.Code != status.NotFound {
+			t.Errorf("%v: expected %v, got %v", target, status.NotFound, recorder.Code)
+		}
+	}
+	assertBodyResponse(t, strict, MethodGet, "/users", "users")
+
+	tolerant := newRouter(TrailingSlashTolerant)
+	assertBodyResponse(t, tolerant, MethodGet, "/users/", "users")
+	assertBodyResponse(t, tolerant, MethodPost, "/users/42/", "user 42")
+
+	for trailingSlash, code := range map[TrailingSlash]int{
+		TrailingSlashMovedPermanently:  status.MovedPermanently,
+		TrailingSlashPermanentRedirect: status.PermanentRedirect,
+	} {
+		router := newRouter(trailingSlash)
+		recorder := serve(router, MethodGet, "/users/?page=2")
+		if recorder.Code != code || recorder.Header().Get(header.ResponseLocation) != "/users?page=2" {
+			t.Errorf("expected a %v redirect to /users?page=2, got %v %v", code, recorder.Code, recorder.Header().Get(header.ResponseLocation))
+		}
+		if recorder = serve(router, MethodGet, "/unknown/"); recorder.Code != status.NotFound {
+			t.Errorf("expected %v, got %v", status.NotFound, recorder.Code)
+		}
+
+		// leading slashes must not turn the redirect into one to another host
+		router.Get("/{slug}", handler)
+		for target, expected := range map[string]string{
+			"//evil.com/":  "/evil.com",
+			"///evil.com/": "/evil.com",
+			"/\\evil.com/": "/%5Cevil.com",
+		} {
+			recorder = serve(router, MethodGet, target)
+			if location := recorder.Header().Get(header.ResponseLocation); recorder.Code != code || location != expected {
+				t.Errorf("%v: expected a %v redirect to %v, got %v %v", target, code, expected, recorder.Code, location)
+			}
+		}
+	}
+
+	// a subtree route does not shadow the more specific route
+	tolerant.Get("/", func(request Request) Response {
+		return String(status.OK, "index")
+	})
+	assertBodyResponse(t, tolerant, MethodGet, "/users/", "users")
+	assertBodyResponse(t, tolerant, MethodGet, "/other/", "index")
+}
+
+func TestCaseInsensitivePaths(t *testing.T) {
+	router := NewRouter()
+	router.Configuration.CaseInsensitivePaths = true
+	router.Get("/users/{id}", func(request Request) Response {
+		return String(status.OK, request.Request.URL.Path+" "+request.RouteParams.Get("id"))
+	})
+	router.Get("/Files/*path", func(request Request) Response {
+		return String(status.OK, "file "+request.RouteParams.Get("path"))
+	})
+	router.Get("/users/me", func(request Request) Response {
+		return String(status.OK, "me")
+	})
+
+	assertBodyResponse(t, router, MethodGet, "/USERS/John", "/users/John John")
+	assertBodyResponse(t, router, MethodGet, "/Users/me", "me")
+	assertBodyResponse(t, router, MethodGet, "/users/Me", "/users/Me Me")
+	assertBodyResponse(t, router, MethodGet, "/files/A/b.TXT", "file A/b.TXT")
+	for _, target := range []string{"/users/John/", "/userz/john"} {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, target, nil))
+		if recorder.Code != status.NotFound {
+			t.Errorf("%v: expected %v, got %v", target, status.NotFound, recorder.Code)
+		}
+	}
+
+	router.Configuration.TrailingSlash = TrailingSlashPermanentRedirect
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/Users/John/", nil))
+	if recorder.Code != status.PermanentRedirect || recorder.Header().Get(header.ResponseLocation) != "/users/John" {
+		t.Errorf("expected a redirect to /users/John, got %v %v", recorder.Code, recorder.Header().Get(header.ResponseLocation))
+	}
+}
@@ -0,0 +1,465 @@
+package there
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// WsMessageType is the type of WebSocket message
+type WsMessageType int
+
+const (
+	WsMessageText   WsMessageType = 1
+	WsMessageBinary WsMessageType = 2
+)
+
+// WebSocket close codes, as defined in RFC 6455
+const (
+	WsCloseNormal          = 1000
+	WsCloseGoingAway       = 1001
+	WsCloseProtocolError   = 1002
+	WsCloseUnsupportedData = 1003
+	WsCloseNoStatus        = 1005
+	WsCloseInvalidPayload  = 1007
+	WsClosePolicyViolation = 1008
+	WsCloseMessageTooBig   = 1009
+	WsCloseInternalError   = 1011
+)
+
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+// wsShutdownTimeout limits how long the last messages and the close frame
+// are written, when the server shuts down
+const wsShutdownTimeout = 5 * time.Second
+
+// wsGuid is appended to the key of the client to compute the accept header
+const wsGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	// ErrorWsClosed is returned by writes, after the WebSocket was closed
+	ErrorWsClosed = errors.New("websocket closed")
+)
+
+// WsCloseError is returned by WsConn.ReadMessage, when the peer closed the WebSocket
+type WsCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WsCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket closed with code %d: %v", e.Code, e.Reason)
+}
+
+// WebSocketOptions configures the handshake and the connection of WebSocket
+type WebSocketOptions struct {
+	// Subprotocols the server speaks, in the order of preference. The first one
+	// requested by the client is selected, see WsConn.Subprotocol.
+	Subprotocols []string
+	// CheckOrigin decides, whether the Origin of the handshake is accepted.
+	// Defaults to accepting requests without Origin and the ones from the same host,
+	// so other websites cannot open WebSockets with the cookies of the user.
+	CheckOrigin func(request *http.Request) bool
+	// MaxMessageSize is the largest message read. Larger messages close the
+	// WebSocket with WsCloseMessageTooBig. Defaults to 1 MiB.
+	MaxMessageSize int64
+}
+
+// WebSocket upgrades the connection to a WebSocket and runs the handler with
+// it. The connection is closed, once the handler returns. Requests, that are
+// no valid WebSocket handshakes, are answered with StatusBadRequest or
+// StatusUpgradeRequired, and rejected origins with StatusForbidden.
+//
+// As WebSocket is a Response, the whole middleware chain runs before the
+// upgrade, like authentication. Writers of middlewares must implement Unwrap,
+// so the connection can be taken over. HTTP/2 connections cannot be upgraded.
+// When the server of the router shuts down, the WebSocket is closed with
+// WsCloseGoingAway. Use a Hub to broadcast to many WebSockets.
+//
+//	router.Get("/echo", func(request there.Request) there.Response {
+//		return there.WebSocket(func(conn *there.WsConn) {
+//			for {
+//				messageType, message, err := conn.ReadMessage()
+//				if err != nil {
+//					return
+//				}
+//				if err = conn.WriteMessage(messageType, message); err != nil {
+//					return
+//				}
+//			}
+//		})
+//	})
+func WebSocket(handler func(conn *WsConn), options ...WebSocketOptions) Response {
+	config := WebSocketOptions{}
+	if len(options) >= 1 {
+		config = options[0]
+	}
+	if config.CheckOrigin == nil {
+		config.CheckOrigin = sameOrigin
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = 1 << 20
+	}
+	return &webSocketResponse{handler: handler, options: config}
+}
+
+type webSocketResponse struct {
+	handler func(conn *WsConn)
+	options WebSocketOptions
+}
+
+func (ws webSocketResponse) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != MethodGet || !headerContainsToken(r.Header, header.Connection, "upgrade") ||
+		!headerContainsToken(r.Header, header.Upgrade, "websocket") {
+		Error(status.BadRequest, errors.New("websocket: not a websocket handshake")).ServeHTTP(rw, r)
+		return
+	}
+	if r.Header.Get(header.RequestSecWebSocketVersion) != "13" {
+		rw.Header().Set(header.RequestSecWebSocketVersion, "13")
+		Error(status.UpgradeRequired, errors.New("websocket: unsupported version")).ServeHTTP(rw, r)
+		return
+	}
+	key := r.Header.Get(header.RequestSecWebSocketKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		Error(status.BadRequest, errors.New("websocket: invalid key")).ServeHTTP(rw, r)
+		return
+	}
+	if !ws.options.CheckOrigin(r) {
+		Error(status.Forbidden, errors.New("websocket: origin not allowed")).ServeHTTP(rw, r)
+		return
+	}
+	subprotocol := selectSubprotocol(r.Header, ws.options.Subprotocols)
+
+	conn, readWriter, err := http.NewResponseController(rw).Hijack()
+	if err != nil {
+		Error(status.InternalServerError, ErrorHijackUnsupported).ServeHTTP(rw, r)
+		return
+	}
+	// the deadlines of the http server do not apply to the WebSocket
+	_ = conn.SetDeadline(time.Time{})
+
+	hijacked := &HijackedConn{Conn: conn, ReadWriter: readWriter}
+	wsConn := &WsConn{
+		conn:        hijacked,
+		reader:      readWriter.Reader,
+		writer:      readWriter.Writer,
+		request:     r,
+		subprotocol: subprotocol,
+		maxSize:     ws.options.MaxMessageSize,
+	}
+	hijacked.shutdown = wsConn.goingAway
+	if router := routerOf(r); router != nil {
+		hijacked.connections = &router.hijacked
+		router.hijacked.add(hijacked)
+	}
+	defer hijacked.Close()
+
+	accept := sha1.Sum([]byte(key + wsGuid))
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		header.ResponseSecWebSocketAccept + ": " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n"
+	if subprotocol != "" {
+		handshake += header.ResponseSecWebSocketProtocol + ": " + subprotocol + "\r\n"
+	}
+	if _, err = readWriter.WriteString(handshake + "\r\n"); err == nil {
+		err = readWriter.Flush()
+	}
+	if err != nil {
+		log.Printf("webSocketResponse: handshake failed: %v", err)
+		return
+	}
+
+	ws.handler(wsConn)
+	_ = wsConn.Close(WsCloseNormal, "")
+}
+
+// sameOrigin accepts requests without Origin and the ones from the host of the request
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(header.RequestOrigin)
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+func headerContainsToken(headers http.Header, name, token string) bool {
+	for _, value := range headers.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func selectSubprotocol(headers http.Header, supported []string) string {
+	for _, value := range headers.Values(header.RequestSecWebSocketProtocol) {
+		for _, requested := range strings.Split(value, ",") {
+			requested = strings.TrimSpace(requested)
+			for _, protocol := range supported {
+				if protocol == requested {
+					return protocol
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// WsConn is an upgraded WebSocket connection. Reads must not be called
+// concurrently, while writes may be.
+type WsConn struct {
+	conn        *HijackedConn
+	reader      *bufio.Reader
+	writer      *bufio.Writer
+	request     *http.Request
+	subprotocol string
+	maxSize     int64
+
+	writeMutex sync.Mutex
+	closeSent  bool
+	// shutdown replaces the close of goingAway, guarded by the writeMutex
+	shutdown func()
+}
+
+// Request returns the handshake request
+func (c *WsConn) Request() *http.Request {
+	return c.request
+}
+
+// Subprotocol returns the negotiated subprotocol, or an empty string if none was selected
+func (c *WsConn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// SetReadDeadline sets the deadline of the following reads
+func (c *WsConn) SetReadDeadline(deadline time.Time) error {
+	return c.conn.SetReadDeadline(deadline)
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered and
+// pongs ignored. If the peer closed the WebSocket, a *WsCloseError is returned.
+func (c *WsConn) ReadMessage() (WsMessageType, []byte, error) {
+	var messageType WsMessageType
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err = c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			closeError := &WsCloseError{Code: WsCloseNoStatus}
+			if len(payload) >= 2 {
+				closeError.Code = int(binary.BigEndian.Uint16(payload))
+				closeError.Reason = string(payload[2:])
+			}
+			_ = c.Close(WsCloseNormal, "")
+			return 0, nil, closeError
+		case wsOpText, wsOpBinary:
+			if messageType != 0 {
+				return 0, nil, c.fail(WsCloseProtocolError, "expected a continuation frame")
+			}
+			messageType, message = WsMessageType(opcode), payload
+		case wsOpContinuation:
+			if messageType == 0 {
+				return 0, nil, c.fail(WsCloseProtocolError, "unexpected continuation frame")
+			}
+			if int64(len(message)+len(payload)) > c.maxSize {
+				return 0, nil, c.fail(WsCloseMessageTooBig, "message too big")
+			}
+			message = append(message, payload...)
+		default:
+			return 0, nil, c.fail(WsCloseProtocolError, "unknown opcode")
+		}
+		if fin {
+			if messageType == WsMessageText && !utf8.Valid(message) {
+				return 0, nil, c.fail(WsCloseInvalidPayload, "invalid utf-8")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// ReadJson reads the next message and unmarshalls it into the dest
+func (c *WsConn) ReadJson(dest any) error {
+	_, message, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(message, dest)
+}
+
+// WriteMessage sends a text or binary message
+func (c *WsConn) WriteMessage(messageType WsMessageType, data []byte) error {
+	if messageType != WsMessageText && messageType != WsMessageBinary {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// WriteJson sends the marshalled data as text message
+func (c *WsConn) WriteJson(data any) error {
+	message, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(WsMessageText, message)
+}
+
+// Ping sends a ping, which the peer answers with a pong
+func (c *WsConn) Ping(data []byte) error {
+	return c.writeFrame(wsOpPing, data)
+}
+
+// Close sends a close frame with the code and reason, and closes the
+// connection. Further calls have no effect.
+func (c *WsConn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	err := c.writeFrame(wsOpClose, payload)
+	if errors.Is(err, ErrorWsClosed) {
+		return nil
+	}
+	_ = c.conn.Close()
+	return err
+}
+
+// goingAway closes the WebSocket with WsCloseGoingAway, when the server of
+// the router shuts down
+func (c *WsConn) goingAway() {
+	// unblocks writes to clients, that stopped reading
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsShutdownTimeout))
+	c.writeMutex.Lock()
+	shutdown := c.shutdown
+	c.writeMutex.Unlock()
+	if shutdown != nil {
+		shutdown()
+		return
+	}
+	_ = c.Close(WsCloseGoingAway, "server shutting down")
+}
+
+// fail closes the WebSocket because of a violation of the peer and returns the error
+func (c *WsConn) fail(code int, reason string) error {
+	_ = c.Close(code, reason)
+	return &WsCloseError{Code: code, Reason: reason}
+}
+
+func (c *WsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.reader, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(WsCloseProtocolError, "reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(WsCloseProtocolError, "client frames must be masked")
+	}
+
+	length := int64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]) & (1<<63 - 1))
+	}
+	if opcode >= wsOpClose && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(WsCloseProtocolError, "invalid control frame")
+	}
+	if length > c.maxSize {
+		return false, 0, nil, c.fail(WsCloseMessageTooBig, "message too big")
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *WsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.closeSent {
+		return ErrorWsClosed
+	}
+	if opcode == wsOpClose {
+		c.closeSent = true
+	}
+
+	head := []byte{0x80 | opcode, 0}
+	switch length := len(payload); {
+	case length <= 125:
+		head[1] = byte(length)
+	case length <= 0xffff:
+		head[1] = 126
+		head = binary.BigEndian.AppendUint16(head, uint16(length))
+	default:
+		head[1] = 127
+		head = binary.BigEndian.AppendUint64(head, uint64(length))
+	}
+	if _, err := c.writer.Write(head); err != nil {
+		return err
+	}
+	if _, err := c.writer.Write(payload); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
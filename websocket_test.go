@@ -0,0 +1,149 @@
+package there
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+// wsClient is a minimal WebSocket client for the tests
+type wsClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, headers map[string]string) (*wsClient, *http.Response) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := "GET " + path + " HTTP/1.1\r\nHost: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"
+	for name, value := range headers {
+		request += name + ": " + value + "\r\n"
+	}
+	if _, err = conn.Write([]byte(request + "\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{conn: conn, reader: reader}, response
+}
+
+func (c *wsClient) write(opcode byte, fin bool, payload []byte) {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, _ = c.conn.Write(frame)
+}
+
+func (c *wsClient) read() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+		return 0, nil, err
+	}
+	length := int(head[1] & 0x7f)
+	if length == 126 {
+		var extended [2]byte
+		_, _ = io.ReadFull(c.reader, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	}
+	payload := make([]byte, length)
+	_, err := io.ReadFull(c.reader, payload)
+	return head[0] & 0x0f, payload, err
+}
+
+func TestWebSocket(t *testing.T) {
+	router := NewRouter()
+	closed := make(chan error, 1)
+	router.Get("/echo", func(request Request) Response {
+		return WebSocket(func(conn *WsConn) {
+			for {
+				messageType, message, err := conn.ReadMessage()
+				if err != nil {
+					closed <- err
+					return
+				}
+				if err = conn.WriteMessage(messageType, append([]byte(conn.Subprotocol()+":"), message...)); err != nil {
+					closed <- err
+					return
+				}
+			}
+		}, WebSocketOptions{Subprotocols: []string{"chat"}})
+	}).With(func(request Request, next Response) Response {
+		if request.Request.Header.Get("X-Token") != "secret" {
+			return Status(status.Unauthorized)
+		}
+		return next
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	_, response := dialWebSocket(t, server, "/echo", nil)
+	if response.StatusCode != status.Unauthorized {
+		t.Errorf("middlewares must run before the upgrade, got %v", response.StatusCode)
+	}
+	_, response = dialWebSocket(t, server, "/echo", map[string]string{"X-Token": "secret", "Origin": "https://evil.example"})
+	if response.StatusCode != status.Forbidden {
+		t.Errorf("foreign origins must be rejected, got %v", response.StatusCode)
+	}
+
+	client, response := dialWebSocket(t, server, "/echo", map[string]string{"X-Token": "secret", "Sec-WebSocket-Protocol": "other, chat"})
+	defer client.conn.Close()
+	if response.StatusCode != status.SwitchingProtocols ||
+		response.Header.Get(header.ResponseSecWebSocketAccept) != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" ||
+		response.Header.Get(header.ResponseSecWebSocketProtocol) != "chat" {
+		t.Fatalf("unexpected handshake %v %v", response.StatusCode, response.Header)
+	}
+
+	client.write(wsOpText, false, []byte("hel"))
+	client.write(wsOpPing, true, []byte("ping"))
+	client.write(wsOpContinuation, true, []byte("lo"))
+	if opcode, payload, err := client.read(); err != nil || opcode != wsOpPong || string(payload) != "ping" {
+		t.Errorf("expected a pong, got %v %q %v", opcode, payload, err)
+	}
+	if opcode, payload, err := client.read(); err != nil || opcode != wsOpText || string(payload) != "chat:hello" {
+		t.Errorf("expected the echo, got %v %q %v", opcode, payload, err)
+	}
+
+	client.write(wsOpClose, true, []byte{0x03, 0xe8, 'b', 'y', 'e'})
+	var closeError *WsCloseError
+	if err := <-closed; !errors.As(err, &closeError) || closeError.Code != WsCloseNormal || closeError.Reason != "bye" {
+		t.Errorf("expected the close of the client, got %v", err)
+	}
+	if opcode, _, err := client.read(); err != nil || opcode != wsOpClose {
+		t.Errorf("expected the close to be answered, got %v %v", opcode, err)
+	}
+}
+
+func TestWebSocketInvalidHandshake(t *testing.T) {
+	router := NewRouter()
+	router.Get("/ws", func(request Request) Response {
+		return WebSocket(func(conn *WsConn) {})
+	})
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(MethodGet, "/ws", nil))
+	if recorder.Code != status.BadRequest {
+		t.Errorf("expected %v, got %v", status.BadRequest, recorder.Code)
+	}
+}
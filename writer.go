@@ -0,0 +1,69 @@
+package there
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+)
+
+// bufferedResponseWriter keeps the status code and the body in memory instead of
+// writing them, so a wrapping Response can inspect or modify them before sending.
+// Headers are set on the underlying http.ResponseWriter directly.
+// As soon as the client disconnects, writes fail and the buffer is released.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	ctx  context.Context
+	code int
+	body bytes.Buffer
+}
+
+func newBufferedResponseWriter(rw http.ResponseWriter, r *http.Request) *bufferedResponseWriter {
+	return &bufferedResponseWriter{ResponseWriter: rw, ctx: r.Context()}
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if err := w.disconnected(); err != nil {
+		return 0, err
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// disconnected releases the buffer and returns ErrorClientDisconnected, if the
+// client went away
+func (w *bufferedResponseWriter) disconnected() error {
+	if w.ctx.Err() == nil {
+		return nil
+	}
+	w.body = bytes.Buffer{}
+	return ErrorClientDisconnected
+}
+
+// statusCode returns the recorded status code or StatusOK, if none was written
+func (w *bufferedResponseWriter) statusCode() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
+
+// flush writes the recorded status code and body to the underlying http.ResponseWriter
+func (w *bufferedResponseWriter) flush() error {
+	if err := w.disconnected(); err != nil {
+		return err
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode())
+	if w.body.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	return err
+}
@@ -0,0 +1,97 @@
+//go:build !there_noxml
+
+package there
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gebes/there/v2/header"
+	"github.com/gebes/there/v2/status"
+)
+
+func readXmlBody(router *Router, t *testing.T, method, route string, body io.Reader, res any) {
+	readAndUnmarshal(router, t, method, route, body, xml.Unmarshal, res)
+}
+
+func TestXml(t *testing.T) {
+	router := CreateRouter()
+	router.Get("/data/xml", func(request Request) Response {
+		return Xml(status.OK, sampleUser)
+	})
+	var res user
+	readXmlBody(router, t, MethodGet, "/data/xml", nil, &res)
+	if res != sampleUser {
+		t.Fatal(res, "does not equal", sampleUser)
+	}
+
+	router.Post("/data/return/xml", func(request Request) Response {
+		var user simpleUser
+		if err := request.Body.BindXml(&user); err != nil {
+			return Error(status.BadRequest, err)
+		}
+		return String(status.OK, user.Name)
+	})
+	if name := readStringBody(router, t, MethodPost, "/data/return/xml", strings.NewReader("<simpleUser><Name>Hannes</Name></simpleUser>")); name != sampleSimpleUser.Name {
+		t.Errorf("unexpected name %v", name)
+	}
+}
+
+func TestBindXmlError(t *testing.T) {
+	type input struct {
+		Name string `xml:"name"`
+	}
+	var syntaxErr, readErr error
+	router := NewRouter()
+	router.Post("/syntax", func(request Request) Response {
+		var dest input
+		syntaxErr = request.Body.BindXml(&dest)
+		return Status(status.OK)
+	})
+	router.Post("/read", func(request Request) Response {
+		var dest input
+		readErr = request.Body.BindXml(&dest)
+		return Status(status.OK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodPost, "/syntax", strings.NewReader("<input>\n<name>John</nam>\n</input>")))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(MethodPost, "/read", errReader(0)))
+
+	var bindingError *BindingError
+	if !errors.As(syntaxErr, &bindingError) || bindingError.Line != 2 {
+		t.Errorf("expected a BindingError in line 2, got %T %v", syntaxErr, syntaxErr)
+	}
+	if readErr == nil {
+		t.Errorf("expected the read error")
+	}
+}
+
+func TestXmlNegotiation(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	router := NewRouter()
+	router.Configuration.ErrorFormats = ErrorFormats{ContentTypes: []string{ContentTypeApplicationXml}}
+	router.Get("/auto", func(request Request) Response {
+		return Auto(status.OK, user{Name: "John"})
+	})
+	router.Get("/error", func(request Request) Response {
+		return Error(status.NotFound, errors.New("user <1> not found"))
+	})
+
+	for route, expected := range map[string]string{
+		"/auto":  `<user><name>John</name></user>`,
+		"/error": `<Error><Message>user &lt;1&gt; not found</Message></Error>`,
+	} {
+		request := httptest.NewRequest(MethodGet, route, nil)
+		request.Header.Set(header.RequestAccept, "application/xml")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		if !strings.HasPrefix(recorder.Header().Get(header.ContentType), ContentTypeApplicationXml) || !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("%v: unexpected response %v %v", route, recorder.Header().Get(header.ContentType), recorder.Body.String())
+		}
+	}
+}